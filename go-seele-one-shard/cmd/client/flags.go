@@ -6,6 +6,9 @@
 package main
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/seeleteam/go-seele/common"
 	"github.com/urfave/cli"
 )
@@ -26,6 +29,23 @@ func (flag seeleAddressFlag) getValue() (interface{}, error) {
 	return common.EmptyAddress, nil
 }
 
+type chainNumberFlag struct {
+	cli.StringFlag
+}
+
+func (flag chainNumberFlag) getValue() (interface{}, error) {
+	if val := *flag.Destination; len(val) > 0 {
+		chainNum, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain number %s", val)
+		}
+
+		return &chainNum, nil
+	}
+
+	return (*uint64)(nil), nil
+}
+
 var (
 	addressValue string
 	addressFlag  = cli.StringFlag{
@@ -111,20 +131,76 @@ var (
 		Destination: &nonceValue,
 	}
 
+	gasPriceValue string
+	gasPriceFlag  = cli.StringFlag{
+		Name:        "gasprice",
+		Usage:       "gas price of gas metered transaction, required if gas limit is specified",
+		Destination: &gasPriceValue,
+	}
+
+	gasLimitValue uint64
+	gasLimitFlag  = cli.Uint64Flag{
+		Name:        "gaslimit",
+		Value:       0,
+		Usage:       "gas limit of gas metered transaction, legacy transaction is sent if not specified",
+		Destination: &gasLimitValue,
+	}
+
+	networkIDValue uint64
+	networkIDFlag  = cli.Uint64Flag{
+		Name:        "networkid",
+		Value:       0,
+		Usage:       "network id of replay protected transaction, which requires the gas limit",
+		Destination: &networkIDValue,
+	}
+
+	numOfChainsValue uint64
+	numOfChainsFlag  = cli.Uint64Flag{
+		Name:        "chains",
+		Value:       common.DefaultNumOfChains,
+		Usage:       "number of chains defined in genesis, used to sign the chain of replay protected transaction",
+		Destination: &numOfChainsValue,
+	}
+
 	contractValue string
 	contractFlag  = cli.StringFlag{
 		Name:        "contract",
-		Usage:       "contract code in hex",
+		Usage:       "contract addresses in hex, separated by comma",
 		Destination: &contractValue,
 	}
 
 	topicValue string
 	topicFlag  = cli.StringFlag{
 		Name:        "topic",
-		Usage:       "topic",
+		Usage:       "topics in hex, positions are separated by semicolon and alternatives are separated by comma",
 		Destination: &topicValue,
 	}
 
+	fromHeightValue int64
+	fromHeightFlag  = cli.Int64Flag{
+		Name:        "from",
+		Value:       -1,
+		Usage:       "start block height",
+		Destination: &fromHeightValue,
+	}
+
+	toHeightValue int64
+	toHeightFlag  = cli.Int64Flag{
+		Name:        "to",
+		Value:       -1,
+		Usage:       "end block height",
+		Destination: &toHeightValue,
+	}
+
+	chainNumValue string
+	chainNumFlag  = chainNumberFlag{
+		StringFlag: cli.StringFlag{
+			Name:        "chain",
+			Usage:       "chain number, the chain is decided by the hash or address if not specified, and required by height if there are multiple chains",
+			Destination: &chainNumValue,
+		},
+	}
+
 	threadsValue uint
 	threadsFlag  = cli.UintFlag{
 		Name:        "threads",
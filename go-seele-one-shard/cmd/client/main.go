@@ -129,7 +129,7 @@ func main() {
 		{
 			Name:   "sendtx",
 			Usage:  "send transaction to node",
-			Flags:  rpcFlags(fromFlag, toFlag, amountFlag, feeFlag, payloadFlag, nonceFlag),
+			Flags:  rpcFlags(fromFlag, toFlag, amountFlag, feeFlag, payloadFlag, nonceFlag, gasPriceFlag, gasLimitFlag, networkIDFlag, numOfChainsFlag),
 			Action: rpcActionEx("seele", "addTx", makeTransaction, onTxAdded),
 		},
 		{
@@ -144,76 +144,100 @@ func main() {
 			Flags:  rpcFlags(toFlag, payloadFlag, heightFlag),
 			Action: rpcAction("seele", "call"),
 		},
+		{
+			Name:   "estimategas",
+			Usage:  "estimate the gas and fee of transaction",
+			Flags:  rpcFlags(accountFlag, toFlag, payloadFlag, amountFlag, heightFlag),
+			Action: rpcActionEx("seele", "estimateGas", makeEstimateGasArgs, handleCallResult),
+		},
 		{
 			Name:   "getblockheight",
 			Usage:  "get block height",
-			Flags:  rpcFlags(),
+			Flags:  rpcFlags(chainNumFlag),
 			Action: rpcAction("seele", "getBlockHeight"),
 		},
 		{
 			Name:   "getblock",
 			Usage:  "get block by height or hash",
-			Flags:  rpcFlags(hashFlag, heightFlag, fulltxFlag),
+			Flags:  rpcFlags(hashFlag, heightFlag, fulltxFlag, chainNumFlag),
 			Action: rpcAction("seele", "getBlock"),
 		},
 		{
 			Name:   "getlogs",
 			Usage:  "get logs",
-			Flags:  rpcFlags(heightFlag, contractFlag, topicFlag),
-			Action: rpcAction("seele", "getLogs"),
+			Flags:  rpcFlags(fromHeightFlag, toHeightFlag, chainNumFlag, contractFlag, topicFlag),
+			Action: rpcActionEx("seele", "getLogs", makeLogFilter, handleCallResult),
 		},
 		{
 			Name:   "gettxpoolcontent",
 			Usage:  "get transaction pool contents",
-			Flags:  rpcFlags(),
+			Flags:  rpcFlags(chainNumFlag),
 			Action: rpcAction("debug", "getTxPoolContent"),
 		},
 		{
 			Name:   "gettxpoolcount",
 			Usage:  "get transaction pool transaction count",
-			Flags:  rpcFlags(),
+			Flags:  rpcFlags(chainNumFlag),
 			Action: rpcAction("debug", "getTxPoolTxCount"),
 		},
+		{
+			Name:   "txpoolcontent",
+			Usage:  "get pending and queued transactions of transaction pool grouped by account",
+			Flags:  rpcFlags(chainNumFlag),
+			Action: rpcAction("txpool", "content"),
+		},
+		{
+			Name:   "txpoolinspect",
+			Usage:  "get summary of pending and queued transactions of transaction pool grouped by account",
+			Flags:  rpcFlags(chainNumFlag),
+			Action: rpcAction("txpool", "inspect"),
+		},
+		{
+			Name:   "gettxpoolevictions",
+			Usage:  "get transactions evicted from transaction pool",
+			Flags:  rpcFlags(chainNumFlag),
+			Action: rpcAction("debug", "getTxPoolEvictions"),
+		},
 		{
 			Name:   "getblocktxcount",
 			Usage:  "get block transaction count by block height or block hash",
-			Flags:  rpcFlags(hashFlag, heightFlag),
+			Flags:  rpcFlags(hashFlag, heightFlag, chainNumFlag),
 			Action: rpcAction("txpool", "getBlockTransactionCount"),
 		},
 		{
 			Name:   "gettxinblock",
 			Usage:  "get transaction by block height or block hash with index of the transaction in the block",
-			Flags:  rpcFlags(hashFlag, heightFlag, indexFlag),
+			Flags:  rpcFlags(hashFlag, heightFlag, indexFlag, chainNumFlag),
 			Action: rpcAction("txpool", "getTransactionByBlockIndex"),
 		},
 		{
 			Name:   "gettxbyhash",
 			Usage:  "get transaction by transaction hash",
-			Flags:  rpcFlags(hashFlag),
+			Flags:  rpcFlags(hashFlag, chainNumFlag),
 			Action: rpcAction("txpool", "getTransactionByHash"),
 		},
 		{
 			Name:   "getdebtbyhash",
 			Usage:  "get debt by debt hash",
-			Flags:  rpcFlags(hashFlag),
+			Flags:  rpcFlags(hashFlag, chainNumFlag),
 			Action: rpcAction("txpool", "getDebtByHash"),
 		},
 		{
 			Name:   "getdebts",
 			Usage:  "get pending debts",
-			Flags:  rpcFlags(),
+			Flags:  rpcFlags(chainNumFlag),
 			Action: rpcAction("debug", "getPendingDebts"),
 		},
 		{
 			Name:   "getreceipt",
 			Usage:  "get receipt by transaction hash",
-			Flags:  rpcFlags(hashFlag),
+			Flags:  rpcFlags(hashFlag, chainNumFlag),
 			Action: rpcAction("txpool", "getReceiptByTxHash"),
 		},
 		{
 			Name:   "getpendingtxs",
 			Usage:  "get pending transactions",
-			Flags:  rpcFlags(),
+			Flags:  rpcFlags(chainNumFlag),
 			Action: rpcAction("debug", "getPendingTransactions"),
 		},
 		{
@@ -256,6 +280,12 @@ func main() {
 			},
 			Action: GenerateKeyAction,
 		},
+		{
+			Name:   "sethead",
+			Usage:  "rewind the specified chain to the block of height or hash, the later blocks are deleted",
+			Flags:  rpcFlags(hashFlag, heightFlag, chainNumFlag),
+			Action: rpcAction("admin", "setHead"),
+		},
 		{
 			Name:   "dumpheap",
 			Usage:  "dump heap for profiling, return the file path",
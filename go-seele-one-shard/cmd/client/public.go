@@ -9,12 +9,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
+	"strings"
 
 	"github.com/seeleteam/go-seele/cmd/util"
 	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/common/keystore"
 	"github.com/seeleteam/go-seele/core/types"
 	"github.com/seeleteam/go-seele/rpc2"
+	"github.com/seeleteam/go-seele/seele"
 	"github.com/urfave/cli"
 )
 
@@ -110,7 +113,13 @@ func makeTransaction(context *cli.Context, client *rpc.Client) ([]interface{}, e
 		return nil, err
 	}
 
-	tx, err := util.GenerateTx(key.PrivateKey, txd.To, txd.Amount, txd.Fee, txd.AccountNonce, txd.Payload)
+	var tx *types.Transaction
+	if txd.Version >= types.TxVersionGasMetered {
+		tx, err = util.GenerateGasMeteredTx(key.PrivateKey, txd.To, txd.Amount, txd.Fee, txd.GasPrice, txd.GasLimit, txd.AccountNonce, txd.Payload, txd.NetworkID, txd.ChainNum)
+	} else {
+		tx, err = util.GenerateTx(key.PrivateKey, txd.To, txd.Amount, txd.Fee, txd.AccountNonce, txd.Payload)
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +127,76 @@ func makeTransaction(context *cli.Context, client *rpc.Client) ([]interface{}, e
 	return []interface{}{*tx}, nil
 }
 
+func makeEstimateGasArgs(context *cli.Context, client *rpc.Client) ([]interface{}, error) {
+	from, err := accountFlag.getValue()
+	if err != nil {
+		return nil, fmt.Errorf("invalid account address: %s", err)
+	}
+
+	amount := big.NewInt(0)
+	if len(amountValue) > 0 {
+		var ok bool
+		if amount, ok = amount.SetString(amountValue, 10); !ok {
+			return nil, fmt.Errorf("invalid amount value")
+		}
+	}
+
+	return []interface{}{from, toValue, payloadValue, amount, heightValue}, nil
+}
+
+func makeLogFilter(context *cli.Context, client *rpc.Client) ([]interface{}, error) {
+	filter := seele.LogFilter{
+		FromHeight: fromHeightValue,
+		ToHeight:   toHeightValue,
+	}
+
+	chainNum, err := chainNumFlag.getValue()
+	if err != nil {
+		return nil, err
+	}
+
+	filter.ChainNum = chainNum.(*uint64)
+
+	for _, contract := range splitNonEmpty(contractValue, ",") {
+		addr, err := common.HexToAddress(contract)
+		if err != nil {
+			return nil, fmt.Errorf("invalid contract address %s, %s", contract, err)
+		}
+
+		filter.Addresses = append(filter.Addresses, addr)
+	}
+
+	if len(topicValue) > 0 {
+		for _, position := range strings.Split(topicValue, ";") {
+			alternatives := make([]common.Hash, 0)
+			for _, topic := range splitNonEmpty(position, ",") {
+				hash, err := common.HexToHash(topic)
+				if err != nil {
+					return nil, fmt.Errorf("invalid topic %s, %s", topic, err)
+				}
+
+				alternatives = append(alternatives, hash)
+			}
+
+			filter.Topics = append(filter.Topics, alternatives)
+		}
+	}
+
+	return []interface{}{filter}, nil
+}
+
+// splitNonEmpty splits the string by the separator and drops the empty items.
+func splitNonEmpty(str, sep string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(str, sep) {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
 func onTxAdded(inputs []interface{}, result interface{}) error {
 	if !result.(bool) {
 		fmt.Println("failed to send transaction")
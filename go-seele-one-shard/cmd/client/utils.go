@@ -46,9 +46,37 @@ func checkParameter(publicKey *ecdsa.PublicKey, client *rpc.Client) (*types.Tran
 	}
 	info.Fee = fee
 
+	if gasLimitValue > 0 {
+		gasPrice, ok := big.NewInt(0).SetString(gasPriceValue, 10)
+		if !ok {
+			return info, fmt.Errorf("invalid gas price value")
+		}
+
+		info.Version = types.TxVersionGasMetered
+		info.GasPrice = gasPrice
+		info.GasLimit = gasLimitValue
+	}
+
+	if networkIDValue > 0 {
+		if info.Version != types.TxVersionGasMetered {
+			return info, fmt.Errorf("gas limit is required for replay protected transaction")
+		}
+
+		info.Version = types.TxVersionReplayProtected
+		info.NetworkID = networkIDValue
+	}
+
 	fromAddr := crypto.GetAddress(publicKey)
 	info.From = *fromAddr
 
+	if info.Version >= types.TxVersionReplayProtected {
+		if numOfChainsValue == 0 {
+			return info, fmt.Errorf("number of chains should be greater than 0")
+		}
+
+		info.ChainNum = fromAddr.GetChainNum(numOfChainsValue)
+	}
+
 	if client != nil {
 		nonce, err := util.GetAccountNonce(client, *fromAddr)
 		if err != nil {
@@ -6,6 +6,7 @@
 package cmd
 
 import (
+	"crypto/ecdsa"
 	"fmt"
 	"net"
 	"sync"
@@ -20,6 +21,7 @@ var (
 	addr          *string //node address
 	bootstrapNode *string //bootstrap node id
 	shard         *uint
+	privateKey    *string //private key of the specified node id
 )
 
 // startCmd represents the start command
@@ -29,8 +31,8 @@ var startCmd = &cobra.Command{
 	Long: `usage example:
     discovery start 
         start a server which will generate a node id randomly. The default address is 127.0.0.1:9000
-    discovery start -i snode://2aa34f83208861645c9f1b26e4314ced1540788f190564e2bd9594c5da4b68d1e46a8054a590b4a923beaac6c007c120571597586ff099d06e109d7f4769f021@127.0.0.1:9000[0]
-        start a server with the specified node id.
+    discovery start -i snode://2aa34f83208861645c9f1b26e4314ced1540788f190564e2bd9594c5da4b68d1e46a8054a590b4a923beaac6c007c120571597586ff099d06e109d7f4769f021@127.0.0.1:9000[0] -k 0x<private key>
+        start a server with the specified node id and its private key to sign the packets.
     discovery start -b snode://2aa34f83208861645c9f1b26e4314ced1540788f190564e2bd9594c5da4b68d1e46a8054a590b4a923beaac6c007c120571597586ff099d06e109d7f4769f021@127.0.0.1:9000[0] -a "127.0.0.1:9001"
         start a server with a bootstrap node and specify its binding address.`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -45,6 +47,7 @@ var startCmd = &cobra.Command{
 		}
 
 		var mynode *discovery.Node
+		var myKey *ecdsa.PrivateKey
 		if *id == "" { // ignore the address if node id is specified
 			myAddr, err := net.ResolveUDPAddr("udp", *addr)
 			if err != nil {
@@ -52,13 +55,14 @@ var startCmd = &cobra.Command{
 				return
 			}
 
-			myId, err := crypto.GenerateRandomAddress()
+			myId, key, err := crypto.GenerateKeyPair()
 			if err != nil {
 				fmt.Println(err.Error())
 				return
 			}
 
 			mynode = discovery.NewNodeWithAddr(*myId, myAddr, *shard)
+			myKey = key
 			fmt.Println(mynode.String())
 		} else {
 			n, err := discovery.NewNodeFromString(*id)
@@ -67,10 +71,23 @@ var startCmd = &cobra.Command{
 				return
 			}
 
+			key, err := crypto.LoadECDSAFromString(*privateKey)
+			if err != nil {
+				fmt.Printf("invalid private key: %s\n", err.Error())
+				return
+			}
+
+			if !crypto.GetAddress(&key.PublicKey).Equal(n.ID) {
+				fmt.Println("the private key doesn't match the node id")
+				return
+			}
+
 			mynode = n
+			myKey = key
 		}
 
-		discovery.StartService(common.GetTempFolder(), mynode.ID, mynode.GetUDPAddr(), bootstrap, *shard)
+		mynode.Shard = *shard
+		discovery.StartService(common.GetTempFolder(), myKey, mynode, bootstrap)
 
 		wg := sync.WaitGroup{}
 		wg.Add(1)
@@ -84,4 +101,5 @@ func init() {
 	addr = startCmd.Flags().StringP("addr", "a", "127.0.0.1:9000", "node addr")
 	bootstrapNode = startCmd.Flags().StringP("bootstrapNode", "b", "", "bootstrap node id")
 	shard = startCmd.Flags().UintP("shard", "s", 1, "shard number")
+	privateKey = startCmd.Flags().StringP("key", "k", "", "private key of the specified node id")
 }
@@ -0,0 +1,88 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/seeleteam/go-seele/core"
+	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/seeleteam/go-seele/seele"
+	"github.com/spf13/cobra"
+)
+
+var exportConfigFile string
+var exportChainNum uint64
+var exportFrom uint64
+var exportTo uint64
+var exportFile string
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export the blocks of a chain in the data folder into a file",
+	Long: `For example:
+			node.exe export -c cmd\node.json -n 0 -f 1 -t 1000 -o chain0.blocks
+			the node must be stopped before exporting.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		nCfg, err := LoadConfigFromFile(exportConfigFile, "")
+		if err != nil {
+			fmt.Printf("failed to reading the config file: %s\n", err.Error())
+			return
+		}
+
+		if numOfChains := core.GetGenesis(nCfg.SeeleConfig.GenesisConfig).GetNumOfChains(); exportChainNum >= numOfChains {
+			fmt.Printf("invalid chain number %d, number of chains is %d\n", exportChainNum, numOfChains)
+			return
+		}
+
+		count, err := exportBlocks(nCfg.BasicConfig.DataDir, exportChainNum, exportFrom, exportTo, exportFile)
+		if err != nil {
+			fmt.Printf("failed to export blocks: %s\n", err.Error())
+			return
+		}
+
+		fmt.Printf("blocks file: %s, exported blocks: %d\n", exportFile, count)
+	},
+}
+
+// exportBlocks writes the blocks in the height range [from, to] of the specified chain into file.
+func exportBlocks(dataDir string, chainNum uint64, from, to uint64, file string) (int, error) {
+	chainDB, err := leveldb.NewLevelDB(filepath.Join(dataDir, seele.BlockChainDir, strconv.FormatUint(chainNum, 10)))
+	if err != nil {
+		return 0, err
+	}
+	defer chainDB.Close()
+
+	f, err := os.Create(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return core.ExportBlocks(store.NewBlockchainDatabase(chainDB), f, from, to, func(processed int, height uint64) {
+		fmt.Printf("exported blocks: %d, height: %d\n", processed, height)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVarP(&exportConfigFile, "config", "c", "", "seele node config file (required)")
+	exportCmd.MarkFlagRequired("config")
+
+	exportCmd.Flags().Uint64VarP(&exportChainNum, "chain", "n", 0, "chain number to export")
+	exportCmd.Flags().Uint64VarP(&exportFrom, "from", "f", 0, "height of the first block to export")
+	exportCmd.Flags().Uint64VarP(&exportTo, "to", "t", math.MaxUint64, "height of the last block to export, HEAD block by default")
+
+	exportCmd.Flags().StringVarP(&exportFile, "output", "o", "", "blocks file to export (required)")
+	exportCmd.MarkFlagRequired("output")
+}
@@ -0,0 +1,118 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/seeleteam/go-seele/core"
+	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/database"
+	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/seeleteam/go-seele/seele"
+	"github.com/spf13/cobra"
+)
+
+var importConfigFile string
+var importChainNum uint64
+var importFile string
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import the blocks file of a chain into the data folder",
+	Long: `For example:
+			node.exe import -c cmd\node.json -n 0 -i chain0.blocks
+			the node must be stopped before importing, and the existing blocks are skipped,
+			so an interrupted import could be resumed by running the same command again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		nCfg, err := LoadConfigFromFile(importConfigFile, "")
+		if err != nil {
+			fmt.Printf("failed to reading the config file: %s\n", err.Error())
+			return
+		}
+
+		genesis := core.GetGenesis(nCfg.SeeleConfig.GenesisConfig)
+		if importChainNum >= genesis.GetNumOfChains() {
+			fmt.Printf("invalid chain number %d, number of chains is %d\n", importChainNum, genesis.GetNumOfChains())
+			return
+		}
+
+		imported, skipped, err := importBlocks(nCfg.BasicConfig.DataDir, genesis, nCfg.P2PConfig.NetworkID, importChainNum, importFile)
+		if err != nil {
+			fmt.Printf("failed to import blocks: %s\n", err.Error())
+		}
+
+		fmt.Printf("data folder: %s, imported blocks: %d, skipped blocks: %d\n", nCfg.BasicConfig.DataDir, imported, skipped)
+	},
+}
+
+// importBlocks writes the blocks in file into the specified chain with full validation.
+func importBlocks(dataDir string, genesis *core.Genesis, networkID, chainNum uint64, file string) (int, int, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	accountStateDB, err := leveldb.NewLevelDB(filepath.Join(dataDir, seele.AccountStateDir))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer accountStateDB.Close()
+
+	// the mining data of the imported blocks is validated against all the local chains
+	var chains []*core.Blockchain
+	for i := uint64(0); i < genesis.GetNumOfChains(); i++ {
+		chainDB, err := leveldb.NewLevelDB(filepath.Join(dataDir, seele.BlockChainDir, strconv.FormatUint(i, 10)))
+		if err != nil {
+			return 0, 0, err
+		}
+		defer chainDB.Close()
+
+		chain, err := openBlockchain(dataDir, chainDB, accountStateDB, genesis, networkID, i)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to load chain %d, %s", i, err)
+		}
+
+		chains = append(chains, chain)
+	}
+
+	for _, chain := range chains {
+		chain.SetChains(chains)
+	}
+
+	return core.ImportBlocks(chains[chainNum], f, func(processed int, height uint64) {
+		fmt.Printf("processed blocks: %d, height: %d\n", processed, height)
+	})
+}
+
+// openBlockchain returns the blockchain of the specified chain number in the data folder,
+// and the network id is used to validate the replay protected transactions.
+func openBlockchain(dataDir string, chainDB, accountStateDB database.Database, genesis *core.Genesis, networkID, chainNum uint64) (*core.Blockchain, error) {
+	bcStore := store.NewCachedStore(store.NewBlockchainDatabase(chainDB))
+	if err := genesis.InitializeAndValidate(bcStore, accountStateDB); err != nil {
+		return nil, err
+	}
+
+	recoveryPointFile := filepath.Join(dataDir, strconv.FormatUint(chainNum, 10), seele.BlockChainRecoveryPointFile)
+	return core.NewBlockchain(bcStore, accountStateDB, recoveryPointFile, chainNum, genesis.GetNumOfChains(), networkID)
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().StringVarP(&importConfigFile, "config", "c", "", "seele node config file (required)")
+	importCmd.MarkFlagRequired("config")
+
+	importCmd.Flags().Uint64VarP(&importChainNum, "chain", "n", 0, "chain number to import")
+
+	importCmd.Flags().StringVarP(&importFile, "input", "i", "", "blocks file to import (required)")
+	importCmd.MarkFlagRequired("input")
+}
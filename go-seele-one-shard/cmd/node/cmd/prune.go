@@ -0,0 +1,93 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/seeleteam/go-seele/core"
+	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/seeleteam/go-seele/seele"
+	"github.com/spf13/cobra"
+)
+
+var pruneConfigFile string
+var pruneRetention uint64
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "prune the account state of old blocks in the data folder",
+	Long: `For example:
+			node.exe prune -c cmd\node.json
+			the node must be stopped before pruning.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		nCfg, err := LoadConfigFromFile(pruneConfigFile, "")
+		if err != nil {
+			fmt.Printf("failed to reading the config file: %s\n", err.Error())
+			return
+		}
+
+		retention := pruneRetention
+		if retention == 0 {
+			retention = nCfg.BasicConfig.StateRetention
+		}
+
+		if retention == 0 {
+			retention = core.DefaultStateRetention
+		}
+
+		fmt.Printf("the state of blocks older than %d blocks behind the HEAD block of each chain will be removed\n", retention)
+		genesis := core.GetGenesis(nCfg.SeeleConfig.GenesisConfig)
+		removed, err := pruneState(nCfg.BasicConfig.DataDir, genesis, nCfg.P2PConfig.NetworkID, retention)
+		if err != nil {
+			fmt.Printf("failed to prune the account state: %s\n", err.Error())
+			return
+		}
+
+		fmt.Printf("data folder: %s, removed trie nodes: %d\n", nCfg.BasicConfig.DataDir, removed)
+	},
+}
+
+// pruneState removes the account state of the blocks that older than the number of retention
+// blocks behind the HEAD block of each chain in the specified data folder. The state of the block
+// leaves and the state being synchronized are kept as the running node does.
+func pruneState(dataDir string, genesis *core.Genesis, networkID uint64, retention uint64) (int, error) {
+	accountStateDB, err := leveldb.NewLevelDB(filepath.Join(dataDir, seele.AccountStateDir))
+	if err != nil {
+		return 0, err
+	}
+	defer accountStateDB.Close()
+
+	var chains []*core.Blockchain
+	for i := uint64(0); i < genesis.GetNumOfChains(); i++ {
+		chainDB, err := leveldb.NewLevelDB(filepath.Join(dataDir, seele.BlockChainDir, strconv.FormatUint(i, 10)))
+		if err != nil {
+			return 0, err
+		}
+		defer chainDB.Close()
+
+		chain, err := openBlockchain(dataDir, chainDB, accountStateDB, genesis, networkID, i)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load chain %d, %s", i, err)
+		}
+
+		chains = append(chains, chain)
+	}
+
+	return core.NewStatePruner(accountStateDB, chains, retention).Prune()
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().StringVarP(&pruneConfigFile, "config", "c", "", "seele node config file (required)")
+	pruneCmd.MarkFlagRequired("config")
+
+	pruneCmd.Flags().Uint64VarP(&pruneRetention, "retention", "r", 0, "number of recent blocks whose state is kept for each chain")
+}
@@ -0,0 +1,156 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/seeleteam/go-seele/core"
+	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/seeleteam/go-seele/seele"
+	"github.com/spf13/cobra"
+)
+
+var snapshotConfigFile string
+var snapshotFile string
+
+// exportStateCmd represents the export-state command
+var exportStateCmd = &cobra.Command{
+	Use:   "export-state",
+	Short: "export the HEAD blocks and their account state of all chains into a state snapshot file",
+	Long: `For example:
+			node.exe export-state -c cmd\node.json -o state.snapshot
+			the node must be stopped before exporting.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		nCfg, err := LoadConfigFromFile(snapshotConfigFile, "")
+		if err != nil {
+			fmt.Printf("failed to reading the config file: %s\n", err.Error())
+			return
+		}
+
+		numOfChains := core.GetGenesis(nCfg.SeeleConfig.GenesisConfig).GetNumOfChains()
+		count, err := exportState(nCfg.BasicConfig.DataDir, numOfChains, snapshotFile)
+		if err != nil {
+			fmt.Printf("failed to export the state snapshot: %s\n", err.Error())
+			return
+		}
+
+		fmt.Printf("state snapshot: %s, exported trie nodes: %d\n", snapshotFile, count)
+	},
+}
+
+// importStateCmd represents the import-state command
+var importStateCmd = &cobra.Command{
+	Use:   "import-state",
+	Short: "import the state snapshot file into the data folder which only has the genesis blocks",
+	Long: `For example:
+			node.exe import-state -c cmd\node.json -i state.snapshot
+			the node must be stopped before importing, and the HEAD blocks in the state snapshot
+			must match with the snapshotCheckpoints in the config file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		nCfg, err := LoadConfigFromFile(snapshotConfigFile, "")
+		if err != nil {
+			fmt.Printf("failed to reading the config file: %s\n", err.Error())
+			return
+		}
+
+		genesis := core.GetGenesis(nCfg.SeeleConfig.GenesisConfig)
+		count, err := importState(nCfg.BasicConfig.DataDir, genesis, nCfg.P2PConfig.NetworkID, nCfg.BasicConfig.SnapshotCheckpoints, snapshotFile)
+		if err != nil {
+			fmt.Printf("failed to import the state snapshot: %s\n", err.Error())
+			return
+		}
+
+		fmt.Printf("data folder: %s, imported trie nodes: %d\n", nCfg.BasicConfig.DataDir, count)
+	},
+}
+
+// exportState writes the state snapshot of the chains in the specified data folder into file.
+func exportState(dataDir string, numOfChains uint64, file string) (int, error) {
+	accountStateDB, err := leveldb.NewLevelDB(filepath.Join(dataDir, seele.AccountStateDir))
+	if err != nil {
+		return 0, err
+	}
+	defer accountStateDB.Close()
+
+	var bcStores []store.BlockchainStore
+	for i := uint64(0); i < numOfChains; i++ {
+		chainDB, err := leveldb.NewLevelDB(filepath.Join(dataDir, seele.BlockChainDir, strconv.FormatUint(i, 10)))
+		if err != nil {
+			return 0, err
+		}
+		defer chainDB.Close()
+
+		bcStores = append(bcStores, store.NewBlockchainDatabase(chainDB))
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return core.ExportStateSnapshot(accountStateDB, bcStores, f)
+}
+
+// importState reads the state snapshot from file into the specified data folder, and the HEAD
+// block of each chain must match with the specified trusted checkpoints.
+func importState(dataDir string, genesis *core.Genesis, networkID uint64, checkpoints []core.Checkpoint, file string) (int, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	accountStateDB, err := leveldb.NewLevelDB(filepath.Join(dataDir, seele.AccountStateDir))
+	if err != nil {
+		return 0, err
+	}
+	defer accountStateDB.Close()
+
+	var chains []*core.Blockchain
+	for i := uint64(0); i < genesis.GetNumOfChains(); i++ {
+		chainDB, err := leveldb.NewLevelDB(filepath.Join(dataDir, seele.BlockChainDir, strconv.FormatUint(i, 10)))
+		if err != nil {
+			return 0, err
+		}
+		defer chainDB.Close()
+
+		chain, err := openBlockchain(dataDir, chainDB, accountStateDB, genesis, networkID, i)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load chain %d, %s", i, err)
+		}
+
+		chains = append(chains, chain)
+	}
+
+	if err = core.SetCheckpoints(chains, checkpoints); err != nil {
+		return 0, err
+	}
+
+	return core.ImportStateSnapshot(accountStateDB, chains, f)
+}
+
+func init() {
+	rootCmd.AddCommand(exportStateCmd)
+	rootCmd.AddCommand(importStateCmd)
+
+	exportStateCmd.Flags().StringVarP(&snapshotConfigFile, "config", "c", "", "seele node config file (required)")
+	exportStateCmd.MarkFlagRequired("config")
+
+	exportStateCmd.Flags().StringVarP(&snapshotFile, "output", "o", "", "state snapshot file to export (required)")
+	exportStateCmd.MarkFlagRequired("output")
+
+	importStateCmd.Flags().StringVarP(&snapshotConfigFile, "config", "c", "", "seele node config file (required)")
+	importStateCmd.MarkFlagRequired("config")
+
+	importStateCmd.Flags().StringVarP(&snapshotFile, "input", "i", "", "state snapshot file to import (required)")
+	importStateCmd.MarkFlagRequired("input")
+}
@@ -32,7 +32,7 @@ var gettps = &cobra.Command{
 				}
 
 				shard := getShard(client)
-				for i := 0; i < seele.NumOfChains; i++ {
+				for i := range tps.Tps {
 					if tps.Duration[i] > 0 {
 						t := tps.Tps[i]
 						fmt.Printf("shard:%d, chainNum:%d, tx count:%d, interval:%d, tps:%.2f\n", shard, i,
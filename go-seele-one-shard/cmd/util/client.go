@@ -61,6 +61,27 @@ func GenerateTx(from *ecdsa.PrivateKey, to common.Address, amount *big.Int, fee
 	return tx, nil
 }
 
+// GenerateGasMeteredTx generates a gas metered transaction with the specified gas price and gas limit.
+// The transaction is replay protected on the specified chain if the network id is not 0.
+func GenerateGasMeteredTx(from *ecdsa.PrivateKey, to common.Address, amount, fee, gasPrice *big.Int, gasLimit, nonce uint64, payload []byte, networkID, chainNum uint64) (*types.Transaction, error) {
+	fromAddr := crypto.GetAddress(&from.PublicKey)
+
+	var tx *types.Transaction
+	var err error
+	if networkID == 0 {
+		tx, err = types.NewGasMeteredTransaction(*fromAddr, to, amount, fee, gasPrice, gasLimit, nonce, payload)
+	} else {
+		tx, err = types.NewReplayProtectedTransaction(*fromAddr, to, amount, fee, gasPrice, gasLimit, nonce, payload, networkID, chainNum)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("create transaction err %s", err)
+	}
+	tx.Sign(from)
+
+	return tx, nil
+}
+
 func GetTransactionByHash(client *rpc.Client, hash string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	err := client.Call(&result, "txpool_getTransactionByHash", hash)
@@ -162,6 +162,7 @@ func processContract(statedb *state.Statedb, bcStore store.BlockchainStore, tx *
 		Statedb:     statedb,
 		BlockHeader: header,
 		BcStore:     bcStore,
+		NumOfChains: common.DefaultNumOfChains,
 	}
 	return svm.Process(ctx)
 }
@@ -16,10 +16,6 @@ import (
 	"github.com/seeleteam/go-seele/common/hexutil"
 )
 
-const (
-	numOfChains = 3
-)
-
 //////////////////////////////////////////////////////////////////////////////
 // Address format:
 // - External account: pubKeyHash[12:32] and set last 4 bits to addressTypeExternal(1)
@@ -234,14 +230,12 @@ func (id *Address) CreateContractAddress(nonce uint64, hashFunc func(interface{}
 }
 
 
-//  returns the chain number of this address.
-func (id *Address) GetChainNum() uint64 {
+// GetChainNum returns the chain number of this address with the specified number of chains.
+func (id *Address) GetChainNum(numOfChains uint64) uint64 {
 	AddressInt := new(big.Int)
-
-	//miner.log.Info("Got Mining key: %s", miningKeyHash.ToHex())
 	AddressInt.SetBytes(id.Bytes())
 	result := new(big.Int)
-	result = result.Mod(AddressInt, big.NewInt(numOfChains))
+	result = result.Mod(AddressInt, new(big.Int).SetUint64(numOfChains))
 	chainNum := result.Uint64()
 	return chainNum
 }
\ No newline at end of file
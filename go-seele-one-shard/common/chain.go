@@ -0,0 +1,14 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package common
+
+const (
+	// DefaultNumOfChains is the number of chains if not specified in genesis info.
+	DefaultNumOfChains = uint64(3)
+
+	// MaxNumOfChains is the max number of chains supported.
+	MaxNumOfChains = uint64(64)
+)
@@ -0,0 +1,20 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Address_GetChainNum(t *testing.T) {
+	addr := BytesToAddress([]byte{10})
+
+	assert.Equal(t, addr.GetChainNum(1), uint64(0))
+	assert.Equal(t, addr.GetChainNum(3), uint64(1))
+	assert.Equal(t, addr.GetChainNum(8), uint64(2))
+}
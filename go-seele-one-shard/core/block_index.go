@@ -10,18 +10,21 @@ import (
 
 	"github.com/orcaman/concurrent-map"
 	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/state"
 	"github.com/seeleteam/go-seele/core/types"
 )
 
 // BlockIndex is the index of the block chain
 type BlockIndex struct {
+	state           *state.Statedb
 	currentBlock    *types.Block
 	totalDifficulty *big.Int
 }
 
 // NewBlockIndex constructs and returns a BlockIndex instance
-func NewBlockIndex(block *types.Block, td *big.Int) *BlockIndex {
+func NewBlockIndex(state *state.Statedb, block *types.Block, td *big.Int) *BlockIndex {
 	return &BlockIndex{
+		state:           state,
 		currentBlock:    block,
 		totalDifficulty: td,
 	}
@@ -69,9 +72,9 @@ func (bf *BlockLeaves) GetBestBlock() *types.Block {
 }
 
 // GetBestStateDB gets the state DB of the best block index in the block leaves
-//func (bf *BlockLeaves) GetBestStateDB() *state.Statedb {
-//	return bf.GetBestBlockIndex().state
-//}
+func (bf *BlockLeaves) GetBestStateDB() *state.Statedb {
+	return bf.GetBestBlockIndex().state
+}
 
 // GetBlockIndexByHash gets the block index with the specified hash in the block leaves
 func (bf *BlockLeaves) GetBlockIndexByHash(hash common.Hash) *BlockIndex {
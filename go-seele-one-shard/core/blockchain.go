@@ -67,6 +67,10 @@ var (
 	// does not match the receipts root hash in block header.
 	ErrBlockReceiptHashMismatch = errors.New("block receipts hash mismatch")
 
+	// ErrBlockLogBloomMismatch is returned when the calculated log bloom of block
+	// does not match the log bloom in block header.
+	ErrBlockLogBloomMismatch = errors.New("block log bloom mismatch")
+
 	// ErrBlockDebtHashMismatch is returned when the calculated debts hash of block
 	// does not match the debts root hash in block header.
 	ErrBlockDebtHashMismatch = errors.New("block debts hash mismatch")
@@ -75,6 +79,9 @@ var (
 	// does not match the debts root hash in block header.
 	ErrBlockTxDebtHashMismatch = errors.New("block transaction debts hash mismatch")
 
+	// ErrBlockDebtChainNumMismatch is returned when the debt packed in block is for another chain.
+	ErrBlockDebtChainNumMismatch = errors.New("block debt chain number mismatch")
+
 	// ErrBlockEmptyTxs is returned when writing a block with empty transactions.
 	ErrBlockEmptyTxs = errors.New("empty transactions in block")
 
@@ -103,28 +110,29 @@ var (
 	// ErrBlockExtraDataNotEmpty is returned when the block extra data is not empty.
 	ErrBlockExtraDataNotEmpty = errors.New("block extra data is not empty")
 
+	// ErrBlockTxChainNumMismatch is returned when the sender of a tx in block does not belong to the blockchain.
+	ErrBlockTxChainNumMismatch = errors.New("block transaction chain number mismatch")
+
 	ErrNotSupported = errors.New("not supported function")
 )
 
+// IsBlockPending returns true if the block could not be validated for now, since the history
+// it refers to is not known locally yet, e.g. the other chains lag behind. Such a block is not
+// invalid, and could be written again after the history synchronized.
+func IsBlockPending(err error) bool {
+	return err == pow.ErrMiningDataHistoryUnknown || err == ErrDebtSourceNotFound || err == ErrDebtNotConfirmed
+}
+
 type consensusEngine interface {
 	// ValidateHeader validates the specified header and return error if validation failed.
-	// Generally, need to validate the block nonce.
-	ValidateHeader(blockHeader *types.BlockHeader) error
+	// Generally, need to validate the block nonce and the mining key of the chain.
+	ValidateHeader(blockHeader *types.BlockHeader, chainNum uint64) error
 
 	// ValidateRewardAmount validates the specified amount and returns error if validation failed.
 	// The amount of miner reward will change over time.
 	ValidateRewardAmount(blockHeight uint64, amount *big.Int) error
 }
 
-type SeeleBackendForBlockchain interface {
-	GetCurrentState() (*state.Statedb, error)
-	AccountStateDB() database.Database
-	UpdateDB(db database.Database) error 
-	UpdateDBRootHash(dbRootHash common.Hash) error
-	Lock() error
-	Unlock() error
-}
-
 // Blockchain represents the blockchain with a genesis block. The Blockchain manages
 // blocks insertion, deletion, reorganizations and persistence with a given database.
 // This is a thread safe structure. we must keep all of its parameters are thread safe too.
@@ -140,18 +148,34 @@ type Blockchain struct {
 
 	rp *recoveryPoint // used to recover blockchain in case of program crashed when write a block
 
-	seele		  SeeleBackendForBlockchain
-	chainNum      uint64
+	chainNum    uint64
+	numOfChains uint64 // number of chains defined in genesis
+
+	networkID              uint64 // network that the replay protected transactions are signed for
+	replayProtectionHeight uint64 // height since which only replay protected transactions are allowed, defined in genesis
+
+	executor *ParallelExecutor // executes txs concurrently if not nil
+
+	debtVerifier DebtVerifier // verifies the packed debts against the source chains if not nil
+
+	syncRoot   common.Hash // root hash of the state being synchronized, see StartStateSync
+	checkpoint *Checkpoint // trusted block to set as the HEAD block from state snapshot, see SetCheckpoint
 }
 
-// NewBlockchain returns an initialized blockchain with the given store and account state DB.
-func NewBlockchain(bcStore store.BlockchainStore, recoveryPointFile string, chainNum uint64, seele SeeleBackendForBlockchain) (*Blockchain, error) {
+// NewBlockchain returns an initialized blockchain of the specified chain number with the given
+// store and account state DB, and numOfChains is the number of chains defined in genesis.
+// The networkID is used to validate the replay protected transactions.
+// The account state DB could be shared by all chains, since every chain tracks its own state
+// root in the block header and trie nodes are addressed by content.
+func NewBlockchain(bcStore store.BlockchainStore, accountStateDB database.Database, recoveryPointFile string, chainNum, numOfChains, networkID uint64) (*Blockchain, error) {
 	bc := &Blockchain{
 		bcStore:        bcStore,
-		engine:         &pow.Engine{},
+		accountStateDB: accountStateDB,
+		engine:         pow.NewEngine(numOfChains, nil),
 		log:            log.GetLogger("blockchain"),
 		chainNum:       chainNum,
-		seele:			seele,
+		numOfChains:    numOfChains,
+		networkID:      networkID,
 	}
 
 	var err error
@@ -181,6 +205,14 @@ func NewBlockchain(bcStore store.BlockchainStore, recoveryPointFile string, chai
 		return nil, err
 	}
 
+	genesisData, err := getGenesisExtraData(bc.genesisBlock)
+	if err != nil {
+		bc.log.Error("Failed to get genesis extra data, %v", err.Error())
+		return nil, err
+	}
+
+	bc.replayProtectionHeight = genesisData.ReplayProtectionHeight
+
 	// Get the HEAD block from store
 	currentHeaderHash, err := bcStore.GetHeadBlockHash()
 	if err != nil {
@@ -200,10 +232,23 @@ func NewBlockchain(bcStore store.BlockchainStore, recoveryPointFile string, chai
 		return nil, err
 	}
 
-	blockIndex := NewBlockIndex(currentBlock, td)
+	// Get the state DB of current block
+	currentState, err := state.NewStatedb(currentBlock.Header.StateHash, accountStateDB)
+	if err != nil {
+		bc.log.Error("Failed to create statedb, root hash = %v, error = %v", currentBlock.Header.StateHash.ToHex(), err.Error())
+		return nil, err
+	}
+
+	blockIndex := NewBlockIndex(currentState, currentBlock, td)
 	bc.blockLeaves = NewBlockLeaves()
 	bc.blockLeaves.Add(blockIndex)
 
+	// the state being synchronized before restart is kept from pruning
+	if bc.syncRoot, err = bcStore.GetSyncRoot(); err != nil {
+		bc.log.Error("Failed to get sync root, %v", err.Error())
+		return nil, err
+	}
+
 	return bc, nil
 }
 
@@ -220,16 +265,43 @@ func (bc *Blockchain) CurrentBlock() *types.Block {
 	return index.currentBlock
 }
 
+// ChainNum returns the chain number of the blockchain.
+func (bc *Blockchain) ChainNum() uint64 {
+	return bc.chainNum
+}
+
+// NumOfChains returns the number of chains defined in genesis.
+func (bc *Blockchain) NumOfChains() uint64 {
+	return bc.numOfChains
+}
+
 // GetCurrentState returns the state DB of the current block.
-//func (bc *Blockchain) GetCurrentState() (*state.Statedb, error) {
-//	block := bc.CurrentBlock()
-//	return state.NewStatedb(block.Header.StateHash, bc.accountStateDB)
-//}
+func (bc *Blockchain) GetCurrentState() (*state.Statedb, error) {
+	block := bc.CurrentBlock()
+	return state.NewStatedb(block.Header.StateHash, bc.accountStateDB)
+}
 
 // GetCurrentInfo return the current block and current state info
-func (bc *Blockchain) GetCurrentInfo() (*types.Block, error) {
+func (bc *Blockchain) GetCurrentInfo() (*types.Block, *state.Statedb, error) {
 	block := bc.CurrentBlock()
-	return block, nil
+	statedb, err := state.NewStatedb(block.Header.StateHash, bc.accountStateDB)
+	return block, statedb, err
+}
+
+// GetStateByRootHash returns the statedb by root hash
+func (bc *Blockchain) GetStateByRootHash(root common.Hash) (*state.Statedb, error) {
+	return state.NewStatedb(root, bc.accountStateDB)
+}
+
+// GetStateByBlockHash returns the statedb after the specified block is applied,
+// the block could be in the canonical chain or in a forked branch.
+func (bc *Blockchain) GetStateByBlockHash(hash common.Hash) (*state.Statedb, error) {
+	header, err := bc.bcStore.GetBlockHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return state.NewStatedb(header.StateHash, bc.accountStateDB)
 }
 
 // WriteBlock writes the specified block to the blockchain store.
@@ -263,10 +335,8 @@ func (bc *Blockchain) doWriteBlock(block *types.Block) error {
 		return ErrBlockAlreadyExists
 	}
 
-	//bc.lock.Lock()
-	//defer bc.lock.Unlock()
-	bc.seele.Lock()
-	defer bc.seele.Unlock()
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
 
 	var preBlock *types.Block
 	if preBlock, err = bc.bcStore.GetBlock(block.Header.PreviousBlockHash); err != nil {
@@ -278,11 +348,12 @@ func (bc *Blockchain) doWriteBlock(block *types.Block) error {
 		return err
 	}
 
-	statedb, err := bc.seele.GetCurrentState()
+	// Always process the block on top of the state of its parent block,
+	// so that a fork rebuilds the state from the fork point.
+	statedb, err := state.NewStatedb(preBlock.Header.StateHash, bc.accountStateDB)
 	if err != nil {
 		return err
 	}
-	accountStateDB := bc.seele.AccountStateDB()
 
 	// Process the txs in the block and check the state root hash.
 	var blockStatedb *state.Statedb
@@ -292,13 +363,17 @@ func (bc *Blockchain) doWriteBlock(block *types.Block) error {
 	}
 
 	// Validate receipts root hash.
-	//if receiptsRootHash := types.ReceiptMerkleRootHash(receipts); !receiptsRootHash.Equal(block.Header.ReceiptHash) {
-	//	return ErrBlockReceiptHashMismatch
-	//}
+	if receiptsRootHash := types.ReceiptMerkleRootHash(receipts); !receiptsRootHash.Equal(block.Header.ReceiptHash) {
+		return ErrBlockReceiptHashMismatch
+	}
 
+	// Validate log bloom.
+	if types.CreateBloom(receipts) != block.Header.LogBloom {
+		return ErrBlockLogBloomMismatch
+	}
 
 	// Validate state root hash.
-	batch := accountStateDB.NewBatch()
+	batch := bc.accountStateDB.NewBatch()
 	committed := false
 	defer func() {
 		if !committed {
@@ -306,10 +381,15 @@ func (bc *Blockchain) doWriteBlock(block *types.Block) error {
 		}
 	}()
 
-	if _, err = blockStatedb.Commit(batch); err != nil {
+	var stateRootHash common.Hash
+	if stateRootHash, err = blockStatedb.Commit(batch); err != nil {
 		return err
 	}
 
+	if !stateRootHash.Equal(block.Header.StateHash) {
+		return ErrBlockStateHashMismatch
+	}
+
 	// Update block leaves and write the block into store.
 	currentBlock := &types.Block{
 		HeaderHash:   block.HeaderHash,
@@ -329,7 +409,7 @@ func (bc *Blockchain) doWriteBlock(block *types.Block) error {
 	}
 
 	currentTd := new(big.Int).Add(previousTd, block.Header.Difficulty)
-	blockIndex := NewBlockIndex(currentBlock, currentTd)
+	blockIndex := NewBlockIndex(blockStatedb, currentBlock, currentTd)
 	isHead := bc.blockLeaves.IsBestBlockIndex(blockIndex)
 	/////////////////////////////////////////////////////////////////
 	// PAY ATTENTION TO THE ORDER OF WRITING DATA INTO DB.
@@ -380,25 +460,13 @@ func (bc *Blockchain) doWriteBlock(block *types.Block) error {
 
 	var HeaderChangedMsg event.ChainHeaderChangedMsg
 	HeaderChangedMsg.HeaderHash = block.HeaderHash
-	HeaderChangedMsg.ChainNum = block.ChainNum
+	HeaderChangedMsg.ChainNum = bc.chainNum
 
 	committed = true
 	if isHead {
 		event.ChainHeaderChangedEventMananger.Fire(HeaderChangedMsg)
 	}
 
-	// update accountstate database and the root Hash
-	if err = bc.seele.UpdateDB(accountStateDB); err != nil {
-		return err
-	} 
-	
-	stateRootHash, err := blockStatedb.Hash()
-	if err != nil {
-		return err
-	}
-	if err = bc.seele.UpdateDBRootHash(stateRootHash); err != nil {
-		return err
-	} 
 	return nil
 }
 
@@ -412,6 +480,13 @@ func (bc *Blockchain) validateBlock(block *types.Block) error {
 		return ErrBlockHashMismatch
 	}
 
+	// Accounts are only spendable on their own chain, cross chain transfer is done via debt.
+	for _, tx := range block.GetExcludeRewardTransactions() {
+		if tx.ValidateChain(bc.chainNum, bc.numOfChains) != nil {
+			return ErrBlockTxChainNumMismatch
+		}
+	}
+
 	if types.GetTransactionsSize(block.Transactions) > BlockByteLimit {
 		return ErrBlockTooManyTxs
 	}
@@ -439,26 +514,43 @@ func (bc *Blockchain) validateBlock(block *types.Block) error {
 	}
 
 	// Validate tx merkle root hash
-	txsHash := types.MerkleRootHash(block.Transactions)
-	if !txsHash.Equal(block.Header.TxHash) {
+	if h := types.MerkleRootHash(block.Transactions); !h.Equal(block.Header.TxHash) {
 		return ErrBlockTxsHashMismatch
 	}
 
-	return bc.engine.ValidateHeader(block.Header)
+	// Validate debt merkle root hashes
+	if h := types.DebtMerkleRootHash(types.NewDebts(block.Transactions, bc.numOfChains)); !h.Equal(block.Header.TxDebtHash) {
+		return ErrBlockTxDebtHashMismatch
+	}
+
+	if h := types.DebtMerkleRootHash(block.Debts); !h.Equal(block.Header.DebtHash) {
+		return ErrBlockDebtHashMismatch
+	}
+
+	return bc.engine.ValidateHeader(block.Header, bc.chainNum)
 }
 
 // validateBlockInChain validates the specified block against with the previous block.
 func (bc *Blockchain) validateBlockInChain(block, preBlock *types.Block) error {
-	if block.Header.Height != preBlock.Header.Height+1 {
+	return validateHeaderInChain(block.Header, preBlock.Header)
+}
+
+// validateHeaderInChain validates the specified header against its parent header.
+func validateHeaderInChain(header, preHeader *types.BlockHeader) error {
+	if header.Height != preHeader.Height+1 {
 		return ErrBlockInvalidHeight
 	}
 
-	if block.Header.CreateTimestamp.Cmp(preBlock.Header.CreateTimestamp) < 0 {
+	if header.CreateTimestamp == nil {
+		return ErrBlockCreateTimeNull
+	}
+
+	if header.CreateTimestamp.Cmp(preHeader.CreateTimestamp) < 0 {
 		return ErrBlockCreateTimeOld
 	}
 
-	difficult := pow.GetDifficult(block.Header.CreateTimestamp.Uint64(), preBlock.Header)
-	if difficult == nil || difficult.Cmp(block.Header.Difficulty) != 0 {
+	difficult := pow.GetDifficult(header.CreateTimestamp.Uint64(), preHeader)
+	if difficult == nil || difficult.Cmp(header.Difficulty) != 0 {
 		return ErrBlockDifficultInvalid
 	}
 
@@ -480,6 +572,10 @@ func (bc *Blockchain) applyTxs(block, preBlock *types.Block, statedb *state.Stat
 
 	// update debts
 	for _, d := range block.Debts {
+		if err = bc.validateDebt(d); err != nil {
+			return nil, nil, err
+		}
+
 		err = ApplyDebt(statedb, d, block.Header.Creator)
 		if err != nil {
 			return nil, nil, err
@@ -545,22 +641,149 @@ func (bc *Blockchain) updateStateDB(statedb *state.Statedb, minerRewardTx *types
 	}
 
 	// process other txs
-	for i, tx := range txs {
-		if err := tx.ValidateState(statedb); err != nil {
-			return nil, err
+	validate := func(tx *types.Transaction, statedb *state.Statedb) error {
+		if err := bc.ValidateTxReplay(tx, blockHeader.Height); err != nil {
+			return err
 		}
 
-		receipt, err := bc.ApplyTransaction(tx, i+1, minerRewardTx.Data.To, statedb, blockHeader)
+		return tx.ValidateState(statedb)
+	}
+
+	i := 1
+	handle := func(tx *types.Transaction, receipt *types.Receipt, err error) error {
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		receipts[i+1] = receipt
+		receipts[i] = receipt
+		i++
+
+		return nil
+	}
+
+	if err := bc.ApplyTransactions(statedb, txs, 1, blockHeader, validate, handle); err != nil {
+		return nil, err
 	}
 
 	return receipts, nil
 }
 
+// validateDebt validates the debt packed in block is for this chain, and is generated by
+// a confirmed transaction on its source chain.
+func (bc *Blockchain) validateDebt(debt *types.Debt) error {
+	if debt.Data.ChainNum != bc.chainNum {
+		return ErrBlockDebtChainNumMismatch
+	}
+
+	if bc.debtVerifier == nil {
+		return nil
+	}
+
+	return bc.debtVerifier.ValidateDebt(debt)
+}
+
+// SetChains sets all the local chains indexed by chain number, so that the mining data pack
+// of block is validated against the history of each chain, and the debts of block are
+// validated against their source chains.
+func (bc *Blockchain) SetChains(chains []*Blockchain) {
+	bc.engine = pow.NewEngine(bc.numOfChains, chainReader(chains))
+	bc.debtVerifier = NewDebtVerifier(chains)
+}
+
+// chainReader reads the history of the local chains, which are indexed by chain number.
+type chainReader []*Blockchain
+
+func (chains chainReader) getStore(chainNum uint64) (store.BlockchainStore, error) {
+	if chainNum >= uint64(len(chains)) {
+		return nil, fmt.Errorf("invalid chain number %d", chainNum)
+	}
+
+	return chains[chainNum].bcStore, nil
+}
+
+// GetHeadHeader implements the pow.ChainReader interface.
+func (chains chainReader) GetHeadHeader(chainNum uint64) (*types.BlockHeader, error) {
+	bcStore, err := chains.getStore(chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := bcStore.GetHeadBlockHash()
+	if err != nil {
+		return nil, err
+	}
+
+	return bcStore.GetBlockHeader(hash)
+}
+
+// GetCanonicalHash implements the pow.ChainReader interface.
+func (chains chainReader) GetCanonicalHash(chainNum uint64, height uint64) (common.Hash, error) {
+	bcStore, err := chains.getStore(chainNum)
+	if err != nil {
+		return common.EmptyHash, err
+	}
+
+	return bcStore.GetBlockHash(height)
+}
+
+// GetBlockHeader implements the pow.ChainReader interface.
+func (chains chainReader) GetBlockHeader(chainNum uint64, hash common.Hash) (*types.BlockHeader, error) {
+	bcStore, err := chains.getStore(chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	return bcStore.GetBlockHeader(hash)
+}
+
+// GetBlock implements the pow.ChainReader interface.
+func (chains chainReader) GetBlock(chainNum uint64, hash common.Hash) (*types.Block, error) {
+	bcStore, err := chains.getStore(chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	return bcStore.GetBlock(hash)
+}
+
+// SetParallelExecution sets the number of workers to execute txs of block concurrently,
+// and txs are executed sequentially if the specified number is less than 2.
+func (bc *Blockchain) SetParallelExecution(workers int) {
+	if workers < 2 {
+		bc.executor = nil
+	} else {
+		bc.executor = NewParallelExecutor(workers, bc.bcStore, bc.numOfChains)
+	}
+}
+
+// ApplyTransactions applies the specified txs in order, and the first tx takes the specified tx index.
+// The txs are executed concurrently if parallel execution is enabled, which produces the same receipts
+// and state as sequential execution.
+func (bc *Blockchain) ApplyTransactions(statedb *state.Statedb, txs []*types.Transaction, txIndex int, blockHeader *types.BlockHeader,
+	validate TxValidator, handle TxResultHandler) error {
+	if bc.executor != nil {
+		return bc.executor.Execute(statedb, txs, txIndex, blockHeader, validate, handle)
+	}
+
+	for _, tx := range txs {
+		var receipt *types.Receipt
+		err := validate(tx, statedb)
+		if err == nil {
+			receipt, err = bc.ApplyTransaction(tx, txIndex, blockHeader.Creator, statedb, blockHeader)
+		}
+
+		if handleErr := handle(tx, receipt, err); handleErr != nil {
+			return handleErr
+		}
+
+		if err == nil {
+			txIndex++
+		}
+	}
+
+	return nil
+}
+
 // ApplyRewardTx applies a reward transaction, changes corresponding statedb and generates a receipt.
 func ApplyRewardTx(rewardTx *types.Transaction, statedb *state.Statedb) (*types.Receipt, error) {
 	statedb.CreateAccount(rewardTx.Data.To)
@@ -586,6 +809,7 @@ func (bc *Blockchain) ApplyTransaction(tx *types.Transaction, txIndex int, coinb
 		Statedb:     statedb,
 		BlockHeader: blockHeader,
 		BcStore:     bc.bcStore,
+		NumOfChains: bc.numOfChains,
 	}
 	receipt, err := svm.Process(ctx)
 	if err != nil {
@@ -644,7 +868,7 @@ func deleteLargerHeightBlocks(bcStore store.BlockchainStore, largerHeight uint64
 
 // overwriteStaleBlocks overwrites the stale canonical height-to-hash mappings.
 func overwriteStaleBlocks(bcStore store.BlockchainStore, staleHash common.Hash, rp *recoveryPoint) error {
-	var overwritten bool
+	var overwritten, exist bool
 	var err error
 
 	// When recover the blockchain, the stale block hash my be already overwritten before program crash.
@@ -653,6 +877,15 @@ func overwriteStaleBlocks(bcStore store.BlockchainStore, staleHash common.Hash,
 	}
 
 	for !staleHash.Equal(common.EmptyHash) {
+		// the blocks before the HEAD block of state snapshot are not stored.
+		if exist, err = bcStore.HasBlock(staleHash); err != nil {
+			return err
+		}
+
+		if !exist {
+			break
+		}
+
 		if rp != nil {
 			rp.onOverwriteStaleBlocks(staleHash)
 		}
@@ -695,6 +928,16 @@ func overwriteSingleStaleBlock(bcStore store.BlockchainStore, hash common.Hash)
 	return true, header.PreviousBlockHash, nil
 }
 
+// ValidateTxReplay validates the network id and version of the specified tx to be packed
+// in the block of specified height, so that txs of other networks could not be replayed.
+func (bc *Blockchain) ValidateTxReplay(tx *types.Transaction, height uint64) error {
+	if err := tx.ValidateNetwork(bc.networkID); err != nil {
+		return err
+	}
+
+	return tx.ValidateVersion(height, bc.replayProtectionHeight)
+}
+
 // GetShardNumber returns the shard number of blockchian.
 func (bc *Blockchain) GetShardNumber() (uint, error) {
 	data, err := getGenesisExtraData(bc.genesisBlock)
@@ -0,0 +1,99 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/core/types"
+)
+
+// blockProgressInterval is the number of processed blocks between two progress reports.
+const blockProgressInterval = 1000
+
+// BlockProgressFunc is called periodically when exporting or importing blocks, with the
+// number of processed blocks and the height of the last processed block.
+type BlockProgressFunc func(processed int, height uint64)
+
+// ExportBlocks writes the canonical blocks in the height range [from, to] of the specified
+// blockchain store into w as an RLP stream. The range is truncated by the HEAD block.
+// Returns the number of exported blocks.
+func ExportBlocks(bcStore store.BlockchainStore, w io.Writer, from, to uint64, progress BlockProgressFunc) (int, error) {
+	headHash, err := bcStore.GetHeadBlockHash()
+	if err != nil {
+		return 0, err
+	}
+
+	head, err := bcStore.GetBlockHeader(headHash)
+	if err != nil {
+		return 0, err
+	}
+
+	if to > head.Height {
+		to = head.Height
+	}
+
+	writer := bufio.NewWriter(w)
+	count := 0
+
+	for height := from; height <= to; height++ {
+		block, err := bcStore.GetBlockByHeight(height)
+		if err != nil {
+			return count, err
+		}
+
+		if err = rlp.Encode(writer, block); err != nil {
+			return count, err
+		}
+
+		count++
+		if progress != nil && count%blockProgressInterval == 0 {
+			progress(count, height)
+		}
+	}
+
+	return count, writer.Flush()
+}
+
+// ImportBlocks reads the blocks from the RLP stream r, and writes them into the specified
+// blockchain with full validation. The blocks that already exist are skipped, so that an
+// interrupted import could be resumed with the same stream. Returns the number of imported
+// and skipped blocks.
+func ImportBlocks(bc *Blockchain, r io.Reader, progress BlockProgressFunc) (imported int, skipped int, err error) {
+	stream := rlp.NewStream(bufio.NewReader(r), 0)
+
+	for {
+		block := new(types.Block)
+		if err = stream.Decode(block); err == io.EOF {
+			return imported, skipped, nil
+		} else if err != nil {
+			return imported, skipped, err
+		}
+
+		// skip the existing blocks without validation, e.g. genesis block.
+		var exist bool
+		if exist, err = bc.bcStore.HasBlock(block.HeaderHash); err != nil {
+			return imported, skipped, err
+		}
+
+		if exist {
+			skipped++
+		} else if err = bc.WriteBlock(block); err == nil {
+			imported++
+		} else if err == ErrBlockAlreadyExists {
+			skipped++
+		} else {
+			return imported, skipped, err
+		}
+
+		if progress != nil && (imported+skipped)%blockProgressInterval == 0 {
+			progress(imported+skipped, block.Header.Height)
+		}
+	}
+}
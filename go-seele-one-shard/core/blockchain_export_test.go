@@ -0,0 +1,73 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/seeleteam/go-seele/database/leveldb"
+)
+
+func Test_Blockchain_ExportImportBlocks(t *testing.T) {
+	srcDB, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	srcBC := newTestBlockchain(srcDB)
+	parentHash := srcBC.genesisBlock.HeaderHash
+	for height := uint64(1); height <= 3; height++ {
+		block := newTestBlock(srcBC, parentHash, height, 3*height, 0)
+		assert.Equal(t, srcBC.WriteBlock(block), error(nil))
+		parentHash = block.HeaderHash
+	}
+
+	// the range is truncated by HEAD block
+	var buff bytes.Buffer
+	count, err := ExportBlocks(srcBC.bcStore, &buff, 0, 100, nil)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, count, 4)
+
+	db, dispose2 := leveldb.NewTestDatabase()
+	defer dispose2()
+
+	bc := newTestBlockchain(db)
+	imported, skipped, err := ImportBlocks(bc, bytes.NewReader(buff.Bytes()), nil)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, imported, 3)
+	assert.Equal(t, skipped, 1)
+	assert.Equal(t, bc.CurrentBlock().HeaderHash, srcBC.CurrentBlock().HeaderHash)
+
+	// resume with the same stream
+	imported, skipped, err = ImportBlocks(bc, bytes.NewReader(buff.Bytes()), nil)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, imported, 0)
+	assert.Equal(t, skipped, 4)
+}
+
+func Test_Blockchain_ImportBlocks_MissingParent(t *testing.T) {
+	srcDB, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	srcBC := newTestBlockchain(srcDB)
+	block1 := newTestBlock(srcBC, srcBC.genesisBlock.HeaderHash, 1, 0, 0)
+	assert.Equal(t, srcBC.WriteBlock(block1), error(nil))
+	block2 := newTestBlock(srcBC, block1.HeaderHash, 2, 0, 0)
+	assert.Equal(t, srcBC.WriteBlock(block2), error(nil))
+
+	var buff bytes.Buffer
+	count, err := ExportBlocks(srcBC.bcStore, &buff, 2, 2, nil)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, count, 1)
+
+	db, dispose2 := leveldb.NewTestDatabase()
+	defer dispose2()
+
+	bc := newTestBlockchain(db)
+	imported, _, err := ImportBlocks(bc, bytes.NewReader(buff.Bytes()), nil)
+	assert.Equal(t, err, ErrBlockInvalidParentHash)
+	assert.Equal(t, imported, 0)
+}
@@ -37,7 +37,7 @@ func newTestRecoverableBlockchain(bcStore store.BlockchainStore, stateDB databas
 		panic(err)
 	}
 
-	bc, err := NewBlockchain(bcStore, stateDB, rpFile)
+	bc, err := NewBlockchain(bcStore, stateDB, rpFile, 0, common.DefaultNumOfChains, testNetworkID)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,163 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"errors"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/state"
+	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/event"
+)
+
+var (
+	// ErrRewindBlockNotFound is returned when the block to rewind to is not found.
+	ErrRewindBlockNotFound = errors.New("block to rewind to not found")
+
+	// ErrRewindStateUnavailable is returned when the state of the block to rewind to is unavailable, e.g. pruned.
+	ErrRewindStateUnavailable = errors.New("state of the block to rewind to is unavailable")
+)
+
+// Rewind sets the block of the specified hash as the HEAD block of the blockchain, which
+// could be in the canonical chain or in a forked branch. All blocks that are not ancestors
+// of the block are deleted, including their receipts, tx indices and debt indices.
+//
+// Returns the blocks removed from the canonical chain in descending order, and the blocks
+// added to the canonical chain in ascending order, which are collected before deleted, so
+// that the txs and debts of the removed blocks could be reinjected into pools.
+func (bc *Blockchain) Rewind(hash common.Hash) ([]*types.Block, []*types.Block, error) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	exist, err := bc.bcStore.HasBlock(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !exist {
+		return nil, nil, ErrRewindBlockNotFound
+	}
+
+	block, err := bc.bcStore.GetBlock(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	td, err := bc.bcStore.GetBlockTotalDifficulty(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	statedb, err := state.NewStatedb(block.Header.StateHash, bc.accountStateDB)
+	if err != nil {
+		bc.log.Error("Failed to create statedb of rewind block, root hash = %v, error = %v", block.Header.StateHash.ToHex(), err.Error())
+		return nil, nil, ErrRewindStateUnavailable
+	}
+
+	headHash, err := bc.bcStore.GetHeadBlockHash()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	removed, added, err := GetChangedBlocks(bc.bcStore, hash, headHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deleted, err := bc.getRewindDeletedBlocks(block, added)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the blocks in the canonical chain after rewound are written along with deletion in batch.
+	canonical := added
+	if len(canonical) == 0 {
+		canonical = []*types.Block{block}
+	}
+
+	if err = bc.bcStore.RewindBlocks(canonical, deleted); err != nil {
+		return nil, nil, err
+	}
+
+	bc.blockLeaves = NewBlockLeaves()
+	bc.blockLeaves.Add(NewBlockIndex(statedb, block, td))
+
+	bc.log.Info("blockchain rewound, height = %d, hash = %s, deleted blocks = %d", block.Header.Height, hash.ToHex(), len(deleted))
+	event.ChainHeaderChangedEventMananger.Fire(event.ChainHeaderChangedMsg{
+		HeaderHash: hash,
+		ChainNum:   bc.chainNum,
+	})
+
+	return removed, added, nil
+}
+
+// getRewindDeletedBlocks returns the hashes of the blocks that are not ancestors of the specified
+// HEAD block to rewind to, where the added blocks are the ones not in the current canonical chain.
+// The blocks are collected from the leaves to their ancestors until a block in the canonical chain
+// after rewound.
+func (bc *Blockchain) getRewindDeletedBlocks(head *types.Block, added []*types.Block) ([]common.Hash, error) {
+	ancestorHeight := head.Header.Height
+	if len(added) > 0 {
+		ancestorHeight = added[0].Header.Height - 1
+	}
+
+	addedHashes := make(map[common.Hash]struct{})
+	for _, block := range added {
+		addedHashes[block.HeaderHash] = struct{}{}
+	}
+
+	isCanonical := func(hash common.Hash, height uint64) bool {
+		if _, found := addedHashes[hash]; found {
+			return true
+		}
+
+		if height > ancestorHeight {
+			return false
+		}
+
+		canonicalHash, err := bc.bcStore.GetBlockHash(height)
+		return err == nil && canonicalHash.Equal(hash)
+	}
+
+	var deleted []common.Hash
+	visited := make(map[common.Hash]struct{})
+
+	for item := range bc.blockLeaves.blockIndexMap.IterBuffered() {
+		hash := item.Val.(*BlockIndex).currentBlock.HeaderHash
+
+		for {
+			if _, found := visited[hash]; found {
+				break
+			}
+
+			// the ancestors may be not stored before the HEAD block of state snapshot.
+			exist, err := bc.bcStore.HasBlock(hash)
+			if err != nil {
+				return nil, err
+			}
+
+			if !exist {
+				break
+			}
+
+			header, err := bc.bcStore.GetBlockHeader(hash)
+			if err != nil {
+				return nil, err
+			}
+
+			if isCanonical(hash, header.Height) {
+				break
+			}
+
+			visited[hash] = struct{}{}
+			deleted = append(deleted, hash)
+			hash = header.PreviousBlockHash
+		}
+	}
+
+	return deleted, nil
+}
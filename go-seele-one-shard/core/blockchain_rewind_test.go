@@ -0,0 +1,167 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/database/leveldb"
+)
+
+func assertBlockExists(t *testing.T, bc *Blockchain, hash common.Hash, expected bool) {
+	exist, err := bc.bcStore.HasBlock(hash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, exist, expected)
+}
+
+func Test_Blockchain_Rewind(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	// genesis <- block1 <- block2 <- block3 (canonical)
+	//                   <- block4 (forked)
+	block1 := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block1), error(nil))
+
+	block2 := newTestBlock(bc, block1.HeaderHash, 2, 0, 1000)
+	assert.Equal(t, len(block2.Transactions) > 1, true)
+	assert.Equal(t, bc.WriteBlock(block2), error(nil))
+
+	block3 := newTestBlock(bc, block2.HeaderHash, 3, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block3), error(nil))
+
+	block4 := newTestBlock(bc, block1.HeaderHash, 2, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block4), error(nil))
+	assert.Equal(t, bc.CurrentBlock().HeaderHash, block3.HeaderHash)
+
+	removed, added, err := bc.Rewind(block1.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, removed, []*types.Block{block3, block2})
+	assert.Equal(t, len(added), 0)
+	assert.Equal(t, bc.CurrentBlock().HeaderHash, block1.HeaderHash)
+
+	headHash, err := bc.bcStore.GetHeadBlockHash()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, headHash, block1.HeaderHash)
+
+	hash, err := bc.bcStore.GetBlockHash(1)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, hash, block1.HeaderHash)
+
+	_, err = bc.bcStore.GetBlockHash(2)
+	assert.Equal(t, err != nil, true)
+
+	assertBlockExists(t, bc, block1.HeaderHash, true)
+	assertBlockExists(t, bc, block2.HeaderHash, false)
+	assertBlockExists(t, bc, block3.HeaderHash, false)
+	assertBlockExists(t, bc, block4.HeaderHash, false)
+
+	// tx indices of deleted blocks are cleaned
+	_, err = bc.bcStore.GetTxIndex(block2.Transactions[1].Hash)
+	assert.Equal(t, err != nil, true)
+
+	// blocks could be written after rewound
+	block5 := newTestBlock(bc, block1.HeaderHash, 2, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block5), error(nil))
+	assert.Equal(t, bc.CurrentBlock().HeaderHash, block5.HeaderHash)
+}
+
+func Test_Blockchain_Rewind_ForkedBlock(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	// genesis <- block1 <- block2 <- block3 (canonical)
+	//                   <- block4 (forked)
+	block1 := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block1), error(nil))
+
+	block2 := newTestBlock(bc, block1.HeaderHash, 2, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block2), error(nil))
+
+	block3 := newTestBlock(bc, block2.HeaderHash, 3, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block3), error(nil))
+
+	block4 := newTestBlock(bc, block1.HeaderHash, 2, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block4), error(nil))
+
+	removed, added, err := bc.Rewind(block4.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, removed, []*types.Block{block3, block2})
+	assert.Equal(t, added, []*types.Block{block4})
+	assert.Equal(t, bc.CurrentBlock().HeaderHash, block4.HeaderHash)
+
+	hash, err := bc.bcStore.GetBlockHash(2)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, hash, block4.HeaderHash)
+
+	_, err = bc.bcStore.GetBlockHash(3)
+	assert.Equal(t, err != nil, true)
+
+	assertBlockExists(t, bc, block1.HeaderHash, true)
+	assertBlockExists(t, bc, block2.HeaderHash, false)
+	assertBlockExists(t, bc, block3.HeaderHash, false)
+	assertBlockExists(t, bc, block4.HeaderHash, true)
+}
+
+func Test_Blockchain_Rewind_Errors(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	block1 := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 0)
+	_, _, err := bc.Rewind(block1.HeaderHash)
+	assert.Equal(t, err, ErrRewindBlockNotFound)
+
+	assert.Equal(t, bc.WriteBlock(block1), error(nil))
+
+	block2 := newTestBlock(bc, block1.HeaderHash, 2, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block2), error(nil))
+
+	// state of genesis and block1 are pruned
+	pruner := NewStatePruner(db, []*Blockchain{bc}, 1)
+	_, err = pruner.Prune()
+	assert.Equal(t, err, error(nil))
+
+	_, _, err = bc.Rewind(block1.HeaderHash)
+	assert.Equal(t, err, ErrRewindStateUnavailable)
+	assert.Equal(t, bc.CurrentBlock().HeaderHash, block2.HeaderHash)
+}
+
+func Test_Blockchain_Rewind_ReinjectTransactions(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+	config := DefaultTxPoolConfig()
+	config.JournalFile = ""
+	pool := NewTransactionPool(*config, bc, 0, common.DefaultNumOfChains)
+
+	block1 := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block1), error(nil))
+
+	block2 := newTestBlock(bc, block1.HeaderHash, 2, 0, 1000)
+	assert.Equal(t, len(block2.Transactions) > 1, true)
+	assert.Equal(t, bc.WriteBlock(block2), error(nil))
+
+	removed, added, err := bc.Rewind(block1.HeaderHash)
+	assert.Equal(t, err, error(nil))
+
+	// the txs of removed blocks are reinjected though the blocks are already deleted
+	assertBlockExists(t, bc, block2.HeaderHash, false)
+	pool.HandleChainRewound(removed, added)
+
+	for _, tx := range block2.GetExcludeRewardTransactions() {
+		assert.Equal(t, pool.GetTransaction(tx.Hash) != nil, true)
+	}
+}
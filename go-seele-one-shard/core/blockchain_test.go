@@ -8,6 +8,7 @@ package core
 import (
 	"crypto/ecdsa"
 	"math/big"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,6 +20,7 @@ import (
 	"github.com/seeleteam/go-seele/database"
 	"github.com/seeleteam/go-seele/database/leveldb"
 	"github.com/seeleteam/go-seele/miner/pow"
+	"github.com/seeleteam/go-seele/miner/pow/powtest"
 )
 
 type testAccount struct {
@@ -34,12 +36,19 @@ var testGenesisAccounts = []*testAccount{
 	newTestAccount(big.NewInt(100000), 0),
 }
 
+// newTestAccount returns a new account that belongs to chain 0, which is the chain of test blockchain.
 func newTestAccount(amount *big.Int, nonce uint64) *testAccount {
 	addr, privKey, err := crypto.GenerateKeyPair()
 	if err != nil {
 		panic(err)
 	}
 
+	for addr.GetChainNum(common.DefaultNumOfChains) != 0 {
+		if addr, privKey, err = crypto.GenerateKeyPair(); err != nil {
+			panic(err)
+		}
+	}
+
 	return &testAccount{
 		addr:    *addr,
 		privKey: privKey,
@@ -48,13 +57,16 @@ func newTestAccount(amount *big.Int, nonce uint64) *testAccount {
 	}
 }
 
+// testNetworkID is the network id of test blockchain.
+const testNetworkID = uint64(1)
+
 func newTestGenesis() *Genesis {
 	accounts := make(map[common.Address]*big.Int)
 	for _, account := range testGenesisAccounts {
 		accounts[account.addr] = account.amount
 	}
 
-	return GetGenesis(GenesisInfo{accounts, 1, 0})
+	return GetGenesis(GenesisInfo{Accounts: accounts, Difficult: 1})
 }
 
 func newTestBlockchain(db database.Database) *Blockchain {
@@ -65,7 +77,7 @@ func newTestBlockchain(db database.Database) *Blockchain {
 		panic(err)
 	}
 
-	bc, err := NewBlockchain(bcStore, db, "")
+	bc, err := NewBlockchain(bcStore, db, "", 0, common.DefaultNumOfChains, testNetworkID)
 	if err != nil {
 		panic(err)
 	}
@@ -84,8 +96,7 @@ func newTestBlockTx(genesisAccountIndex int, amount, fee, nonce uint64) *types.T
 }
 
 func newTestBlock(bc *Blockchain, parentHash common.Hash, blockHeight, startNonce uint64, size int) *types.Block {
-	minerAccount := newTestAccount(pow.GetReward(blockHeight), 0)
-	rewardTx, _ := types.NewRewardTransaction(minerAccount.addr, minerAccount.amount, uint64(1))
+	rewardTx, _ := types.NewRewardTransaction(powtest.Creator, pow.GetReward(blockHeight), uint64(1))
 
 	txs := []*types.Transaction{rewardTx}
 	totalSize := rewardTx.Size()
@@ -103,14 +114,17 @@ func newTestBlock(bc *Blockchain, parentHash common.Hash, blockHeight, startNonc
 
 	header := &types.BlockHeader{
 		PreviousBlockHash: parentHash,
-		Creator:           minerAccount.addr,
+		Creator:           powtest.Creator,
 		StateHash:         common.EmptyHash,
 		TxHash:            types.MerkleRootHash(txs),
+		TxDebtHash:        types.DebtMerkleRootHash(types.NewDebts(txs, common.DefaultNumOfChains)),
+		DebtHash:          types.DebtMerkleRootHash(nil),
 		Height:            blockHeight,
 		Difficulty:        big.NewInt(1),
 		CreateTimestamp:   big.NewInt(1),
-		Nonce:             10,
+		Nonce:             rand.Uint64(), // differs the blocks of the same parent and creator
 		ExtraData:         make([]byte, 0),
+		MiningData:        powtest.NewMiningData(bc.chainNum),
 	}
 
 	stateRootHash := common.EmptyHash
@@ -132,6 +146,7 @@ func newTestBlock(bc *Blockchain, parentHash common.Hash, blockHeight, startNonc
 		}
 
 		receiptsRootHash = types.ReceiptMerkleRootHash(receipts)
+		header.LogBloom = types.CreateBloom(receipts)
 	}
 
 	header.StateHash = stateRootHash
@@ -169,6 +184,107 @@ func Test_Blockchain_WriteBlock_TxRootHashChanged(t *testing.T) {
 	assert.Equal(t, bc.WriteBlock(newBlock), ErrBlockTxsHashMismatch)
 }
 
+func Test_Blockchain_WriteBlock_TxDebtRootHashChanged(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	newBlock := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 3, 0)
+	newBlock.Header.TxDebtHash = common.StringToHash("invalid tx debt root")
+	newBlock.HeaderHash = newBlock.Header.Hash()
+
+	assert.Equal(t, bc.WriteBlock(newBlock), ErrBlockTxDebtHashMismatch)
+}
+
+func Test_Blockchain_WriteBlock_DebtRootHashChanged(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	newBlock := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 3, 0)
+	newBlock.Header.DebtHash = common.StringToHash("invalid debt root")
+	newBlock.HeaderHash = newBlock.Header.Hash()
+
+	assert.Equal(t, bc.WriteBlock(newBlock), ErrBlockDebtHashMismatch)
+}
+
+func Test_Blockchain_WriteBlock_InvalidDebt(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+	bc.debtVerifier = &mockDebtVerifier{ErrDebtSourceNotFound}
+
+	// debt whose source block is not known yet
+	newBlock := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 3, 0)
+	newBlock.Debts = []*types.Debt{newTestPoolDebt()}
+	newBlock.Header.DebtHash = types.DebtMerkleRootHash(newBlock.Debts)
+	newBlock.HeaderHash = newBlock.Header.Hash()
+
+	err := bc.WriteBlock(newBlock)
+	assert.Equal(t, err, ErrDebtSourceNotFound)
+	assert.Equal(t, IsBlockPending(err), true)
+
+	// invalid debt proof
+	bc.debtVerifier = &mockDebtVerifier{types.ErrDebtProofMismatch}
+	err = bc.WriteBlock(newBlock)
+	assert.Equal(t, err, types.ErrDebtProofMismatch)
+	assert.Equal(t, IsBlockPending(err), false)
+
+	// debt for another chain
+	newBlock.Debts[0].Data.ChainNum = bc.chainNum + 1
+	newBlock.Header.DebtHash = types.DebtMerkleRootHash(newBlock.Debts)
+	newBlock.HeaderHash = newBlock.Header.Hash()
+
+	assert.Equal(t, bc.WriteBlock(newBlock), ErrBlockDebtChainNumMismatch)
+}
+
+func Test_Blockchain_WriteBlock_ReceiptRootHashChanged(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	newBlock := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 3, 0)
+	newBlock.Header.ReceiptHash = common.StringToHash("invalid receipt root")
+	newBlock.HeaderHash = newBlock.Header.Hash()
+
+	assert.Equal(t, bc.WriteBlock(newBlock), ErrBlockReceiptHashMismatch)
+}
+
+func Test_Blockchain_WriteBlock_LogBloomChanged(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	newBlock := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 3, 0)
+	newBlock.Header.LogBloom.Add([]byte("invalid log bloom"))
+	newBlock.HeaderHash = newBlock.Header.Hash()
+
+	assert.Equal(t, bc.WriteBlock(newBlock), ErrBlockLogBloomMismatch)
+}
+
+func Test_Blockchain_WriteBlock_StateRootHashChanged(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	newBlock := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 3, 0)
+	newBlock.Header.StateHash = common.StringToHash("invalid state root")
+	newBlock.HeaderHash = newBlock.Header.Hash()
+
+	assert.Equal(t, bc.WriteBlock(newBlock), ErrBlockStateHashMismatch)
+
+	// the block should not be written into store
+	exist, err := bc.bcStore.HasBlock(newBlock.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, exist, false)
+}
+
 func Test_Blockchain_WriteBlock_InvalidHeight(t *testing.T) {
 	db, dispose := leveldb.NewTestDatabase()
 	defer dispose()
@@ -275,6 +391,86 @@ func Test_Blockchain_BlockFork(t *testing.T) {
 	assert.Equal(t, bc.blockLeaves.Count(), 2)
 }
 
+func Test_Blockchain_BlockFork_State(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+	from := testGenesisAccounts[0].addr
+
+	// genesis <- block1 (canonical, transfer from account 0)
+	//         <- block2 (no transfer)
+	block1 := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 1024)
+	assert.Equal(t, len(block1.Transactions) > 1, true)
+	assert.Equal(t, bc.WriteBlock(block1), error(nil))
+
+	block2 := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block2), error(nil))
+
+	statedb1, err := bc.GetStateByBlockHash(block1.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, statedb1.GetNonce(from), uint64(len(block1.Transactions)-1))
+
+	statedb2, err := bc.GetStateByBlockHash(block2.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, statedb2.GetNonce(from), uint64(0))
+	assert.Equal(t, statedb2.GetBalance(from), testGenesisAccounts[0].amount)
+
+	// block on the forked branch is applied on top of the state of its parent
+	block3 := newTestBlock(bc, block2.HeaderHash, 2, 0, 1024)
+	assert.Equal(t, bc.WriteBlock(block3), error(nil))
+	assert.Equal(t, bc.CurrentBlock().HeaderHash, block3.HeaderHash)
+
+	current, err := bc.GetCurrentState()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, current.GetNonce(from), uint64(len(block3.Transactions)-1))
+}
+
+func Test_Blockchain_WriteBlock_TxChainNumMismatch(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bcStore := store.NewCachedStore(store.NewBlockchainDatabase(db))
+	if err := newTestGenesis().InitializeAndValidate(bcStore, db); err != nil {
+		panic(err)
+	}
+
+	// the test txs are sent from accounts of chain 0
+	bc, err := NewBlockchain(bcStore, db, "", 1, common.DefaultNumOfChains, testNetworkID)
+	assert.Equal(t, err, error(nil))
+
+	block := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 1024)
+	assert.Equal(t, bc.WriteBlock(block), ErrBlockTxChainNumMismatch)
+}
+
+func Test_Blockchain_ValidateTxReplay(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bcStore := store.NewCachedStore(store.NewBlockchainDatabase(db))
+	if err := GetGenesis(GenesisInfo{Difficult: 1, ReplayProtectionHeight: 10}).InitializeAndValidate(bcStore, db); err != nil {
+		panic(err)
+	}
+
+	bc, err := NewBlockchain(bcStore, db, "", 0, common.DefaultNumOfChains, testNetworkID)
+	assert.Equal(t, err, error(nil))
+
+	from := testGenesisAccounts[0].addr
+	to := *crypto.MustGenerateRandomAddress()
+	legacyTx := newTestBlockTx(0, 1, 1, 0)
+	tx, err := types.NewReplayProtectedTransaction(from, to, big.NewInt(1), big.NewInt(1), big.NewInt(1), 21000, 0, nil, testNetworkID, 0)
+	assert.Equal(t, err, error(nil))
+
+	// replay protection height is loaded from genesis
+	assert.Equal(t, bc.ValidateTxReplay(legacyTx, 9), error(nil))
+	assert.Equal(t, bc.ValidateTxReplay(legacyTx, 10), types.ErrReplayUnprotected)
+	assert.Equal(t, bc.ValidateTxReplay(tx, 10), error(nil))
+
+	// tx of other network
+	tx.Data.NetworkID = testNetworkID + 1
+	assert.Equal(t, bc.ValidateTxReplay(tx, 1), types.ErrNetworkMismatch)
+}
+
 func Test_BlockChain_InvalidParent(t *testing.T) {
 	db, dispose := leveldb.NewTestDatabase()
 	defer dispose()
@@ -346,12 +542,12 @@ func Test_Blockchain_Shard(t *testing.T) {
 	defer dispose()
 
 	bcStore := store.NewBlockchainDatabase(db)
-	genesis := GetGenesis(GenesisInfo{nil, 1, 8})
+	genesis := GetGenesis(GenesisInfo{Difficult: 1, ShardNumber: 8})
 	if err := genesis.InitializeAndValidate(bcStore, db); err != nil {
 		panic(err)
 	}
 
-	bc, err := NewBlockchain(bcStore, db, "")
+	bc, err := NewBlockchain(bcStore, db, "", 0, common.DefaultNumOfChains, testNetworkID)
 	if err != nil {
 		panic(err)
 	}
@@ -367,8 +563,12 @@ func Test_Blockchain_ApplyTransaction(t *testing.T) {
 
 	bc := newTestBlockchain(db)
 
-	// prepare tx to apply, amount is 10 and fee is 2
+	// prepare tx to apply, amount is 10 and fee is 2.
+	// The receiver must be in the same chain, otherwise the amount is transferred via debt.
 	tx := newTestBlockTx(0, 10, 2, 0)
+	for tx.Data.To.GetChainNum(common.DefaultNumOfChains) != tx.Data.From.GetChainNum(common.DefaultNumOfChains) {
+		tx = newTestBlockTx(0, 10, 2, 0)
+	}
 	block := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 1, 0)
 	coinbase := block.Header.Creator
 	statedb, err := bc.GetCurrentState()
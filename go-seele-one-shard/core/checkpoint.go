@@ -0,0 +1,160 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/types"
+)
+
+var (
+	// ErrCheckpointRequired is returned when setting the HEAD block of state snapshot
+	// to a blockchain that has no trusted checkpoint.
+	ErrCheckpointRequired = errors.New("trusted checkpoint is required for state snapshot")
+
+	// ErrCheckpointMismatch is returned when the HEAD block of state snapshot mismatch
+	// with the trusted checkpoint.
+	ErrCheckpointMismatch = errors.New("block mismatch with the trusted checkpoint")
+
+	// ErrHeaderChainIncomplete is returned when setting the HEAD block of state snapshot
+	// before its header chain is verified back to the genesis block.
+	ErrHeaderChainIncomplete = errors.New("header chain is not verified to the genesis block")
+
+	errHeaderChainVerified = errors.New("header chain is already verified to the genesis block")
+)
+
+// Checkpoint is a trusted block of a chain, whose state could be retrieved from peers or
+// imported from a state snapshot instead of replaying all the blocks before it.
+type Checkpoint struct {
+	ChainNum uint64      `json:"chainNum"`
+	Height   uint64      `json:"height"`
+	Hash     common.Hash `json:"hash"`
+}
+
+// SetCheckpoints sets the trusted checkpoints to the chains indexed by chain number.
+func SetCheckpoints(chains []*Blockchain, checkpoints []Checkpoint) error {
+	for i := range checkpoints {
+		cp := checkpoints[i]
+		if cp.ChainNum >= uint64(len(chains)) {
+			return fmt.Errorf("invalid chain number %d of checkpoint", cp.ChainNum)
+		}
+
+		chains[cp.ChainNum].SetCheckpoint(&cp)
+	}
+
+	return nil
+}
+
+// SetCheckpoint sets the trusted checkpoint, which is the only block allowed to be set
+// as the HEAD block from state snapshot.
+func (bc *Blockchain) SetCheckpoint(cp *Checkpoint) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	bc.checkpoint = cp
+}
+
+// GetCheckpoint returns the trusted checkpoint, or nil if not set.
+func (bc *Blockchain) GetCheckpoint() *Checkpoint {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+
+	return bc.checkpoint
+}
+
+// validateCheckpoint validates the specified header matches with the trusted checkpoint.
+func (bc *Blockchain) validateCheckpoint(header *types.BlockHeader) error {
+	if bc.checkpoint == nil {
+		return ErrCheckpointRequired
+	}
+
+	if hash := header.Hash(); header.Height != bc.checkpoint.Height || !hash.Equal(bc.checkpoint.Hash) {
+		return ErrCheckpointMismatch
+	}
+
+	return nil
+}
+
+// HeaderChainVerifier verifies the header chain from the HEAD block of state snapshot back to
+// the genesis block, and computes the total difficulty of the HEAD block locally.
+type HeaderChainVerifier struct {
+	genesisHash common.Hash
+	head        common.Hash
+	next        *types.BlockHeader // the last verified header, whose parent is verified next
+	td          *big.Int
+}
+
+// NewHeaderChainVerifier returns a verifier of the header chain of the specified HEAD block,
+// which must match with the trusted checkpoint.
+func (bc *Blockchain) NewHeaderChainVerifier(head *types.BlockHeader) (*HeaderChainVerifier, error) {
+	bc.lock.RLock()
+	err := bc.validateCheckpoint(head)
+	bc.lock.RUnlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if head.Height == genesisBlockHeight || head.Difficulty == nil {
+		return nil, ErrBlockInvalidHeight
+	}
+
+	td, err := bc.bcStore.GetBlockTotalDifficulty(bc.genesisBlock.HeaderHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HeaderChainVerifier{
+		genesisHash: bc.genesisBlock.HeaderHash,
+		head:        head.Hash(),
+		next:        head,
+		td:          new(big.Int).Add(td, head.Difficulty),
+	}, nil
+}
+
+// Verify verifies the specified header is the parent of the last verified header.
+// The headers should be verified in descending order until the genesis block.
+func (v *HeaderChainVerifier) Verify(header *types.BlockHeader) error {
+	if v.Done() {
+		return errHeaderChainVerified
+	}
+
+	hash := header.Hash()
+	if !hash.Equal(v.next.PreviousBlockHash) {
+		return ErrBlockInvalidParentHash
+	}
+
+	if err := validateHeaderInChain(v.next, header); err != nil {
+		return err
+	}
+
+	// the total difficulty of genesis block is already counted
+	if header.Height == genesisBlockHeight {
+		if !hash.Equal(v.genesisHash) {
+			return ErrSnapshotGenesisMismatch
+		}
+	} else {
+		v.td.Add(v.td, header.Difficulty)
+	}
+
+	v.next = header
+
+	return nil
+}
+
+// Next returns the height of the header to verify next, which is only valid if not done.
+func (v *HeaderChainVerifier) Next() uint64 {
+	return v.next.Height - 1
+}
+
+// Done returns true if the header chain is verified back to the genesis block.
+func (v *HeaderChainVerifier) Done() bool {
+	return v.next.Height == genesisBlockHeight
+}
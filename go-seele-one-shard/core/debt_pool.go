@@ -8,202 +8,300 @@
  import (
  	"bytes"
  	"sync"
+	"time"
 
  	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/core/types"
-	"github.com/seeleteam/go-seele/core/state"
+	"github.com/seeleteam/go-seele/event"
  	"github.com/seeleteam/go-seele/log"
  )
 
  var DebtDataFlag = []byte{0x01}
 
- // DebtPool debt pool
+// debtTimeout is the max duration that a debt could stay in pool without its source block
+// known or confirmed, e.g. the source block is reorganized out of the canonical chain.
+const debtTimeout = 3 * time.Hour
+
+// DebtPool debt pool. Debts are persisted in the chain store, and only packable
+// after the source transaction is confirmed and proved on its source chain.
  type DebtPool struct {
- 	hashMap map[common.Hash]*types.Debt
+	hashMap map[common.Hash]*types.DebtRecord
  	mutex   sync.RWMutex
 
- 	chain blockchain
-	log   *log.SeeleLog
-	
-	seele SeeleBackendForDebtPool
-
+	chain    blockchain
+	verifier DebtVerifier
+	log      *log.SeeleLog
  }
 
- type SeeleBackendForDebtPool interface {
-	GetCurrentState() (*state.Statedb, error)
- }
+// NewDebtPool creates a debt pool and loads the persisted debts from the chain store.
+// If the verifier is nil, debts are packable without verification.
+func NewDebtPool(chain blockchain, verifier DebtVerifier) *DebtPool {
+	dp := &DebtPool{
+		hashMap:  make(map[common.Hash]*types.DebtRecord, 0),
+		mutex:    sync.RWMutex{},
+		chain:    chain,
+		verifier: verifier,
+		log:      log.GetLogger("debtpool"),
+	}
 
- func NewDebtPool(chain blockchain, seele SeeleBackendForDebtPool) *DebtPool {
- 	return &DebtPool{
- 		hashMap: make(map[common.Hash]*types.Debt, 0),
- 		mutex:   sync.RWMutex{},
- 		chain:   chain,
-		log:     log.GetLogger("debtpool"),
-		seele:   seele, 
- 	}
- }
+	records, err := chain.GetStore().GetDebtRecords()
+	if err != nil {
+		dp.log.Warn("failed to load debt records, %s", err)
+	}
+
+	now := uint64(time.Now().Unix())
+	for _, r := range records {
+		if r.Timestamp == 0 {
+			r.Timestamp = now
+		}
 
- func (dp *DebtPool) HandleChainHeaderChanged(newHeader, lastHeader common.Hash) {
+		dp.hashMap[r.Debt.Hash] = r
+	}
+
+	return dp
+}
+
+func (dp *DebtPool) HandleChainHeaderChanged(newHeader, lastHeader common.Hash) {
 	reinject := dp.getReinjectDebts(newHeader, lastHeader)
-	
+	dp.reinjectDebts(reinject)
+}
+
+// HandleChainRewound reverts the debts of the blocks removed from the canonical chain when the
+// chain rewound, since the removed blocks are already deleted from the chain store.
+func (dp *DebtPool) HandleChainRewound(removed, added []*types.Block) {
+	reinject := getRemovedDebts(removed, added)
+	dp.reinjectDebts(reinject)
+}
+
+// reinjectDebts adds the debts back with the reverted status and persists their records,
+// then removes the debts that applied in the current canonical chain, and validates the
+// debts that are not packable yet.
+func (dp *DebtPool) reinjectDebts(reinject []*types.Debt) {
 	if len(reinject) > 0 {
 		dp.log.Info("reinject %d debts", len(reinject))
 	}
-	for _, d := range(reinject) {
-		dp.Add(d)
-	} 
 
- 	dp.removeDebts()
- }
+	dp.mutex.Lock()
+	defer dp.mutex.Unlock()
 
- func (dp *DebtPool) getReinjectDebts(newHeader, lastHeader common.Hash) []*types.Debt {
- 	chainStore := dp.chain.GetStore()
- 	log := dp.log
-
- 	newBlock, err := chainStore.GetBlock(newHeader)
- 	if err != nil {
- 		log.Error("got block failed, %s", err)
- 		return nil
- 	}
-
- 	if newBlock.Header.PreviousBlockHash != lastHeader {
- 		lastBlock, err := chainStore.GetBlock(lastHeader)
- 		if err != nil {
- 			log.Error("got block failed, %s", err)
- 			return nil
- 		}
-
- 		log.Debug("handle chain header forked, last height %d, new height %d", lastBlock.Header.Height, newBlock.Header.Height)
- 		// add committed debts back in current branch.
- 		toDeleted := make(map[common.Hash]*types.Debt)
- 		toAdded := make(map[common.Hash]*types.Debt)
- 		for newBlock.Header.Height > lastBlock.Header.Height {
- 			for _, d := range newBlock.Debts {
- 				toDeleted[d.Hash] = d
- 			}
-
- 			if newBlock, err = chainStore.GetBlock(newBlock.Header.PreviousBlockHash); err != nil {
- 				log.Error("got block failed, %s", err)
- 				return nil
- 			}
- 		}
+	for _, d := range reinject {
+		dp.addWithStatus(d, types.DebtStatusReverted)
+	}
 
- 		for lastBlock.Header.Height > newBlock.Header.Height {
- 			for _, d := range lastBlock.Debts {
- 				toAdded[d.Hash] = d
- 			}
+	dp.removeDebts()
+	dp.promoteDebts()
+}
 
- 			if lastBlock, err = chainStore.GetBlock(lastBlock.Header.PreviousBlockHash); err != nil {
- 				log.Error("got block failed, %s", err)
- 				return nil
- 			}
- 		}
+func (dp *DebtPool) getReinjectDebts(newHeader, lastHeader common.Hash) []*types.Debt {
+	removed, added, err := GetChangedBlocks(dp.chain.GetStore(), newHeader, lastHeader)
+	if err != nil {
+		dp.log.Error("failed to get changed blocks, %s", err)
+		return nil
+	}
 
- 		for lastBlock.HeaderHash != newBlock.HeaderHash {
- 			for _, d := range lastBlock.Debts {
- 				toAdded[d.Hash] = d
- 			}
+	reinject := getRemovedDebts(removed, added)
+	dp.log.Debug("removed blocks %d, added blocks %d, to reinject debt length %d", len(removed), len(added), len(reinject))
 
- 			for _, d := range newBlock.Debts {
- 				toDeleted[d.Hash] = d
- 			}
+	return reinject
+}
 
- 			if lastBlock, err = chainStore.GetBlock(lastBlock.Header.PreviousBlockHash); err != nil {
- 				log.Error("got block failed, %s", err)
- 				return nil
- 			}
+// getRemovedDebts returns the debts in the removed blocks but not in the added blocks.
+func getRemovedDebts(removed, added []*types.Block) []*types.Debt {
+	if len(removed) == 0 {
+		return nil
+	}
 
- 			if newBlock, err = chainStore.GetBlock(newBlock.Header.PreviousBlockHash); err != nil {
- 				log.Error("got block failed, %s", err)
- 				return nil
- 			}
- 		}
+	// add committed debts back in current branch.
+	toDeleted := make(map[common.Hash]*types.Debt)
+	for _, block := range added {
+		for _, d := range block.Debts {
+			toDeleted[d.Hash] = d
+		}
+	}
 
- 		reinject := make([]*types.Debt, 0)
- 		for key, d := range toAdded {
- 			if _, ok := toDeleted[key]; !ok {
- 				reinject = append(reinject, d)
- 			}
- 		}
+	toAdded := make(map[common.Hash]*types.Debt)
+	for _, block := range removed {
+		for _, d := range block.Debts {
+			toAdded[d.Hash] = d
+		}
+	}
 
- 		log.Debug("to added debt length %d, to deleted debt length %d, to reinject debt length %d",
- 			len(toAdded), len(toDeleted), len(reinject))
- 		return reinject
- 	}
+	reinject := make([]*types.Debt, 0)
+	for key, d := range toAdded {
+		if _, ok := toDeleted[key]; !ok {
+			reinject = append(reinject, d)
+		}
+	}
 
- 	return nil
+	return reinject
  }
 
- func (dp *DebtPool) removeDebts() {
- 	dp.mutex.Lock()
- 	defer dp.mutex.Unlock()
-
- 	state, err := dp.seele.GetCurrentState()
+// removeDebts removes the debts that applied in the current canonical chain.
+func (dp *DebtPool) removeDebts() {
+ 	state, err := dp.chain.GetCurrentState()
  	if err != nil {
  		dp.log.Warn("failed to get current state, err: %s", err)
  		return
  	}
 
- 	for _, d := range dp.hashMap {
- 		if !state.Exist(d.Data.Account) {
+	for hash, r := range dp.hashMap {
+		if !state.Exist(r.Debt.Data.Account) {
  			continue
  		}
 
- 		data := state.GetData(d.Data.Account, d.Hash)
+		data := state.GetData(r.Debt.Data.Account, hash)
  		if bytes.Equal(data, DebtDataFlag) {
- 			delete(dp.hashMap, d.Hash)
+			dp.removeRecord(hash)
  		}
  	}
  }
 
+// putRecord writes the debt record into the chain store, must be called with the lock held.
+func (dp *DebtPool) putRecord(r *types.DebtRecord) {
+	if err := dp.chain.GetStore().PutDebtRecord(r); err != nil {
+		dp.log.Warn("failed to persist debt record %s, %s", r.Debt.Hash.ToHex(), err)
+	}
+}
+
+// removeRecord removes the debt record from pool and chain store, must be called with the lock held.
+func (dp *DebtPool) removeRecord(hash common.Hash) {
+	delete(dp.hashMap, hash)
+
+	if err := dp.chain.GetStore().DeleteDebtRecord(hash); err != nil {
+		dp.log.Warn("failed to delete debt record %s, %s", hash.ToHex(), err)
+	}
+}
+
+// addWithStatus adds the debt with the specified status if not exists, must be called with the lock held.
+func (dp *DebtPool) addWithStatus(debt *types.Debt, status types.DebtStatus) bool {
+	if debt.Data.Shard != common.LocalShardNumber {
+		return false
+	}
+
+	if _, ok := dp.hashMap[debt.Hash]; ok {
+		return false
+	}
+
+	r := &types.DebtRecord{Debt: debt, Status: status, Timestamp: uint64(time.Now().Unix())}
+	dp.hashMap[debt.Hash] = r
+	dp.putRecord(r)
+
+	return true
+}
+
+// Add adds the debt into pool, the debt is not packable until its source transaction is confirmed.
  func (dp *DebtPool) Add(debt *types.Debt) {
  	dp.mutex.Lock()
  	defer dp.mutex.Unlock()
 
-	if debt.Data.Shard == common.LocalShardNumber {
-		dp.hashMap[debt.Hash] = debt
+	if dp.addWithStatus(debt, types.DebtStatusCreated) {
+		// fire event
+		var NewDebtMsg event.HandleNewDebtMsg
+		NewDebtMsg.Debt = debt
+		NewDebtMsg.ChainNum = debt.Data.ChainNum
+		event.DebtInsertedEventManager.Fire(NewDebtMsg)
 	}
- 	
  }
 
  func (dp *DebtPool) Remove(hash common.Hash) {
  	dp.mutex.Lock()
  	defer dp.mutex.Unlock()
 
- 	delete(dp.hashMap, hash)
+	if _, ok := dp.hashMap[hash]; ok {
+		dp.removeRecord(hash)
+	}
  }
 
+// Get returns the packable debts within the specified size, and the remain size.
  func (dp *DebtPool) Get(size int) ([]*types.Debt, int) {
- 	dp.mutex.RLock()
- 	defer dp.mutex.RUnlock()
+	dp.mutex.RLock()
+	defer dp.mutex.RUnlock()
 
  	remainSize := size
  	results := make([]*types.Debt, 0)
- 	for _, d := range dp.hashMap {
- 		tmp := remainSize - d.Size()
+	for _, r := range dp.hashMap {
+		// the created debts are not packable until promoted
+		if dp.verifier != nil && r.Status == types.DebtStatusCreated {
+			continue
+		}
+
+		tmp := remainSize - r.Debt.Size()
  		if tmp > 0 {
  			remainSize = tmp
- 			results = append(results, d)
+			results = append(results, r.Debt)
  		}
  	}
 
  	return results, remainSize
  }
 
+// promoteDebts validates the debts that are not pending against their source chains, must be called
+// with the lock held. The verified debts become packable, and the debts that could not be verified
+// for now are not packable until verified later. The invalid debts and the debts that could not be
+// verified for too long are removed.
+func (dp *DebtPool) promoteDebts() {
+	if dp.verifier == nil {
+		return
+	}
+
+	now := uint64(time.Now().Unix())
+	for hash, r := range dp.hashMap {
+		if r.Status == types.DebtStatusPending {
+			continue
+		}
+
+		err := dp.verifier.ValidateDebt(r.Debt)
+		switch {
+		case err == nil:
+			if r.Status == types.DebtStatusCreated {
+				r.Status = types.DebtStatusPending
+				dp.putRecord(r)
+			}
+		case err == ErrDebtSourceNotFound || err == ErrDebtNotConfirmed:
+			if now > r.Timestamp+uint64(debtTimeout/time.Second) {
+				dp.log.Debug("remove debt %s because not verified for more than three hours, %s", hash.ToHex(), err)
+				dp.removeRecord(hash)
+			} else if r.Status != types.DebtStatusCreated {
+				r.Status = types.DebtStatusCreated
+				dp.putRecord(r)
+			}
+		default:
+			dp.log.Warn("remove invalid debt %s, %s", hash.ToHex(), err)
+			dp.removeRecord(hash)
+		}
+	}
+}
+
  func (dp *DebtPool) GetDebtByHash(debt common.Hash) *types.Debt {
  	dp.mutex.RLock()
  	defer dp.mutex.RUnlock()
 
- 	return dp.hashMap[debt]
+	if r := dp.hashMap[debt]; r != nil {
+		return r.Debt
+	}
+
+	return nil
  }
 
+// GetDebtRecord returns the debt with its status in pool by the specified debt hash.
+func (dp *DebtPool) GetDebtRecord(debt common.Hash) *types.DebtRecord {
+	dp.mutex.RLock()
+	defer dp.mutex.RUnlock()
+
+	if r := dp.hashMap[debt]; r != nil {
+		return &types.DebtRecord{Debt: r.Debt, Status: r.Status}
+	}
+
+	return nil
+}
+
  func (dp *DebtPool) GetAll() []*types.Debt {
  	dp.mutex.RLock()
  	defer dp.mutex.RUnlock()
 
  	results := make([]*types.Debt, 0)
  	for _, v := range dp.hashMap {
- 		results = append(results, v)
+		results = append(results, v.Debt)
  	}
 
  	return results
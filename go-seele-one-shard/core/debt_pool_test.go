@@ -0,0 +1,222 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDebtVerifier struct {
+	err error
+}
+
+func (v *mockDebtVerifier) ValidateDebt(debt *types.Debt) error {
+	return v.err
+}
+
+func newTestPoolDebt() *types.Debt {
+	data := types.DebtData{
+		TxHash:  crypto.MustHash(crypto.MustGenerateRandomAddress()),
+		Shard:   common.LocalShardNumber,
+		Account: *crypto.MustGenerateRandomAddress(),
+		Amount:  big.NewInt(10),
+		Fee:     big.NewInt(1),
+	}
+
+	return &types.Debt{
+		Hash: crypto.MustHash(data),
+		Data: data,
+	}
+}
+
+func Test_DebtPool_Lifecycle(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+	verifier := &mockDebtVerifier{ErrDebtNotConfirmed}
+	pool := NewDebtPool(bc, verifier)
+
+	debt := newTestPoolDebt()
+	pool.Add(debt)
+	assert.Equal(t, pool.GetDebtRecord(debt.Hash).Status, types.DebtStatusCreated)
+
+	// not packable until confirmed
+	debts, _ := pool.Get(BlockByteLimit)
+	assert.Equal(t, len(debts), 0)
+
+	// promoted once confirmed
+	verifier.err = nil
+	debts, _ = pool.Get(BlockByteLimit)
+	assert.Equal(t, len(debts), 0)
+	assert.Equal(t, pool.GetDebtRecord(debt.Hash).Status, types.DebtStatusCreated)
+
+	pool.HandleChainRewound(nil, nil)
+	debts, _ = pool.Get(BlockByteLimit)
+	assert.Equal(t, len(debts), 1)
+	assert.Equal(t, pool.GetDebtRecord(debt.Hash).Status, types.DebtStatusPending)
+
+	// debts are loaded from store after restart
+	pool = NewDebtPool(bc, verifier)
+	assert.Equal(t, pool.GetDebtByHash(debt.Hash).Hash, debt.Hash)
+	assert.Equal(t, pool.GetDebtRecord(debt.Hash).Status, types.DebtStatusPending)
+
+	pool.Remove(debt.Hash)
+	pool = NewDebtPool(bc, verifier)
+	assert.Equal(t, len(pool.GetAll()), 0)
+}
+
+func Test_DebtPool_InvalidDebt(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+	pool := NewDebtPool(bc, &mockDebtVerifier{types.ErrDebtProofMismatch})
+
+	debt := newTestPoolDebt()
+	pool.Add(debt)
+
+	debts, _ := pool.Get(BlockByteLimit)
+	assert.Equal(t, len(debts), 0)
+
+	pool.HandleChainRewound(nil, nil)
+	assert.Equal(t, pool.GetDebtByHash(debt.Hash), (*types.Debt)(nil))
+}
+
+func Test_DebtPool_Timeout(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+	verifier := &mockDebtVerifier{ErrDebtSourceNotFound}
+	pool := NewDebtPool(bc, verifier)
+
+	debt := newTestPoolDebt()
+	pool.Add(debt)
+
+	// source not found for now
+	pool.HandleChainRewound(nil, nil)
+	assert.Equal(t, pool.GetDebtRecord(debt.Hash).Status, types.DebtStatusCreated)
+
+	// source not found for too long
+	pool.hashMap[debt.Hash].Timestamp -= uint64(debtTimeout/time.Second) + 1
+	pool.HandleChainRewound(nil, nil)
+	assert.Equal(t, pool.GetDebtRecord(debt.Hash), (*types.DebtRecord)(nil))
+
+	pool = NewDebtPool(bc, verifier)
+	assert.Equal(t, len(pool.GetAll()), 0)
+}
+
+func Test_DebtPool_HandleChainRewound(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+	pool := NewDebtPool(bc, nil)
+
+	debt1, debt2 := newTestPoolDebt(), newTestPoolDebt()
+	removed := []*types.Block{{Debts: []*types.Debt{debt1, debt2}}}
+	added := []*types.Block{{Debts: []*types.Debt{debt2}}}
+
+	// only the debts not packed in the added blocks are reverted
+	pool.HandleChainRewound(removed, added)
+	assert.Equal(t, pool.GetDebtRecord(debt1.Hash).Status, types.DebtStatusReverted)
+	assert.Equal(t, pool.GetDebtRecord(debt2.Hash), (*types.DebtRecord)(nil))
+
+	// the reverted debt records are persisted
+	pool = NewDebtPool(bc, nil)
+	assert.Equal(t, pool.GetDebtRecord(debt1.Hash).Status, types.DebtStatusReverted)
+
+	// the reverted debt is not packable if its source block is not confirmed anymore
+	pool = NewDebtPool(bc, &mockDebtVerifier{ErrDebtNotConfirmed})
+	debts, _ := pool.Get(BlockByteLimit)
+	assert.Equal(t, len(debts), 1)
+
+	pool.HandleChainRewound(nil, nil)
+	assert.Equal(t, pool.GetDebtRecord(debt1.Hash).Status, types.DebtStatusCreated)
+	debts, _ = pool.Get(BlockByteLimit)
+	assert.Equal(t, len(debts), 0)
+}
+
+func Test_DebtVerifier(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+	chains := make([]*Blockchain, common.DefaultNumOfChains)
+	for i := range chains {
+		chains[i] = bc
+	}
+	verifier := NewDebtVerifier(chains)
+
+	block := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 1000)
+	assert.Equal(t, bc.WriteBlock(block), error(nil))
+
+	debtMap, err := types.NewDebtMap(block, common.DefaultNumOfChains)
+	assert.Equal(t, err, error(nil))
+	debts := make([]*types.Debt, 0)
+	for _, shardDebts := range debtMap {
+		debts = append(debts, shardDebts...)
+	}
+	assert.Equal(t, len(debts) > 0, true)
+	debt := debts[0]
+
+	for height := uint64(2); height <= common.ConfirmedBlockNumber; height++ {
+		assert.Equal(t, verifier.ValidateDebt(debt), ErrDebtNotConfirmed)
+
+		block = newTestBlock(bc, block.HeaderHash, height, 0, 0)
+		assert.Equal(t, bc.WriteBlock(block), error(nil))
+	}
+
+	assert.Equal(t, verifier.ValidateDebt(debt), ErrDebtNotConfirmed)
+
+	block = newTestBlock(bc, block.HeaderHash, common.ConfirmedBlockNumber+1, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block), error(nil))
+	assert.Equal(t, verifier.ValidateDebt(debt), error(nil))
+
+	// tampered debt
+	tampered := *debt
+	tampered.Data.Amount = big.NewInt(0).Add(debt.Data.Amount, big.NewInt(1))
+	assert.Equal(t, verifier.ValidateDebt(&tampered), types.ErrDebtProofMismatch)
+
+	// forged debt of the source tx
+	tampered.Hash = crypto.MustHash(tampered.Data)
+	assert.Equal(t, verifier.ValidateDebt(&tampered), types.ErrDebtProofMismatch)
+
+	// debt without source
+	tampered = *debt
+	tampered.Source = nil
+	assert.Equal(t, verifier.ValidateDebt(&tampered), types.ErrDebtSourceInvalid)
+
+	// source height mismatch
+	source := *debt.Source
+	source.Height++
+	tampered.Source = &source
+	assert.Equal(t, verifier.ValidateDebt(&tampered), types.ErrDebtSourceInvalid)
+
+	// source block not known locally yet
+	source = *debt.Source
+	source.BlockHash = common.StringToHash("unknown")
+	tampered.Source = &source
+	assert.Equal(t, verifier.ValidateDebt(&tampered), ErrDebtSourceNotFound)
+
+	source.Height = common.ConfirmedBlockNumber + 2
+	assert.Equal(t, verifier.ValidateDebt(&tampered), ErrDebtSourceNotFound)
+
+	// source block is not stored before the HEAD block of state snapshot
+	_, err = bc.GetStore().DeleteBlockHash(debt.Source.Height)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, bc.GetStore().DeleteBlock(debt.Source.BlockHash), error(nil))
+	assert.Equal(t, verifier.ValidateDebt(debt), error(nil))
+}
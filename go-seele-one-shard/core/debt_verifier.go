@@ -0,0 +1,108 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"errors"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/types"
+)
+
+var (
+	// ErrDebtSourceNotFound is returned when the source block of debt is not known locally yet.
+	ErrDebtSourceNotFound = errors.New("debt source block not found")
+
+	// ErrDebtNotConfirmed is returned when the source block of debt is not confirmed in the canonical chain yet.
+	ErrDebtNotConfirmed = errors.New("debt source block not confirmed")
+)
+
+// DebtVerifier is the interface to verify a debt against its source chain.
+type DebtVerifier interface {
+	// ValidateDebt validates the debt is generated in a confirmed block on its source chain.
+	// Returns ErrDebtSourceNotFound or ErrDebtNotConfirmed if the debt could not be validated
+	// for now, which should be retried later rather than treated as invalid.
+	ValidateDebt(debt *types.Debt) error
+}
+
+// chainDebtVerifier verifies debts with the blockchains of all local chains.
+type chainDebtVerifier struct {
+	chains      []blockchain
+	numOfChains uint64 // number of chains defined in genesis
+}
+
+// NewDebtVerifier returns a debt verifier with the specified blockchains, indexed by chain number.
+func NewDebtVerifier(chains []*Blockchain) DebtVerifier {
+	verifier := &chainDebtVerifier{
+		chains: make([]blockchain, len(chains)),
+	}
+
+	for i, bc := range chains {
+		verifier.chains[i] = bc
+		verifier.numOfChains = bc.NumOfChains()
+	}
+
+	return verifier
+}
+
+// ValidateDebt implements the DebtVerifier interface. The debt is proved by the merkle proof against
+// the source block header, which is committed in the debt source. The source block is not validated
+// if it is before the HEAD block of state snapshot, since the history is not stored locally.
+func (v *chainDebtVerifier) ValidateDebt(debt *types.Debt) error {
+	if debt.Source == nil {
+		return types.ErrDebtSourceInvalid
+	}
+
+	// the source chain is determined by the sender of source transaction
+	sourceChainNum := debt.Data.From.GetChainNum(v.numOfChains)
+	if sourceChainNum >= uint64(len(v.chains)) {
+		return types.ErrDebtSourceInvalid
+	}
+
+	bcStore := v.chains[sourceChainNum].GetStore()
+
+	headHash, err := bcStore.GetHeadBlockHash()
+	if err != nil {
+		return ErrDebtSourceNotFound
+	}
+
+	head, err := bcStore.GetBlockHeader(headHash)
+	if err != nil {
+		return ErrDebtSourceNotFound
+	}
+
+	source := debt.Source
+	header, err := bcStore.GetBlockHeader(source.BlockHash)
+	if err != nil {
+		// the canonical blocks are not stored before the HEAD block of state snapshot
+		if source.Height+common.ConfirmedBlockNumber <= head.Height {
+			if _, err = bcStore.GetBlockHash(source.Height); err != nil {
+				return nil
+			}
+		}
+
+		return ErrDebtSourceNotFound
+	}
+
+	if header.Height != source.Height {
+		return types.ErrDebtSourceInvalid
+	}
+
+	if err = types.VerifyDebtProof(header.TxDebtHash, debt); err != nil {
+		return err
+	}
+
+	canonicalHash, err := bcStore.GetBlockHash(source.Height)
+	if err != nil || !canonicalHash.Equal(source.BlockHash) {
+		return ErrDebtNotConfirmed
+	}
+
+	if source.Height+common.ConfirmedBlockNumber > head.Height {
+		return ErrDebtNotConfirmed
+	}
+
+	return nil
+}
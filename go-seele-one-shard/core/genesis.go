@@ -6,13 +6,17 @@
 package core
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/core/state"
 	"github.com/seeleteam/go-seele/core/store"
 	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/database"
 	"github.com/syndtr/goleveldb/leveldb/errors"
 )
 
@@ -22,6 +26,9 @@ var (
 
 	// ErrGenesisNotFound is returned when genesis block not found in the store.
 	ErrGenesisNotFound = errors.New("genesis block not found")
+
+	// ErrGenesisNumOfChainsInvalid is returned when the number of chains in genesis info is out of range.
+	ErrGenesisNumOfChainsInvalid = fmt.Errorf("invalid number of chains, it must be in range [1, %d]", common.MaxNumOfChains)
 )
 
 const genesisBlockHeight = uint64(0)
@@ -43,11 +50,52 @@ type GenesisInfo struct {
 
 	// ShardNumber is the shard number of genesis block.
 	ShardNumber uint `json:"shard"`
+
+	// NumOfChains is the number of parallel chains, use common.DefaultNumOfChains if not specified.
+	NumOfChains uint64 `json:"chains"`
+
+	// ReplayProtectionHeight is the block height since which only replay protected transactions are allowed.
+	// Both legacy and replay protected transactions are allowed before this height, and 0 means no limit.
+	ReplayProtectionHeight uint64 `json:"replayProtectionHeight"`
+}
+
+// genesisAccount is the account of genesis info in a deterministic order for hashing.
+type genesisAccount struct {
+	Address common.Address
+	Balance *big.Int
+}
+
+// Hash returns the hash of genesis info that is shared by all shards of the network, i.e. the
+// shard number is excluded, so that the nodes of different shards could verify they belong to
+// the same network.
+func (info GenesisInfo) Hash() common.Hash {
+	accounts := make([]genesisAccount, 0, len(info.Accounts))
+	for addr, balance := range info.Accounts {
+		accounts = append(accounts, genesisAccount{addr, balance})
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i].Address.Bytes(), accounts[j].Address.Bytes()) < 0
+	})
+
+	// use the same default values of genesis block
+	difficult, numOfChains := info.Difficult, info.NumOfChains
+	if difficult <= 0 {
+		difficult = 1
+	}
+
+	if numOfChains == 0 {
+		numOfChains = common.DefaultNumOfChains
+	}
+
+	return crypto.MustHash([]interface{}{accounts, uint64(difficult), numOfChains, info.ReplayProtectionHeight})
 }
 
 // genesisExtraData represents the extra data that saved in the genesis block in the blockchain.
 type genesisExtraData struct {
-	ShardNumber uint
+	ShardNumber            uint
+	NumOfChains            uint64
+	ReplayProtectionHeight uint64
 }
 
 // GetGenesis gets the genesis block according to accounts' balance
@@ -55,13 +103,28 @@ func GetGenesis(info GenesisInfo) *Genesis {
 	if info.Difficult <= 0 {
 		info.Difficult = 1
 	}
+
+	if info.NumOfChains == 0 {
+		info.NumOfChains = common.DefaultNumOfChains
+	}
 	
-	extraData := genesisExtraData{info.ShardNumber}
+	extraData := genesisExtraData{info.ShardNumber, info.NumOfChains, info.ReplayProtectionHeight}
+
+	statedb, err := GetStateDB(info)
+	if err != nil {
+		panic(err)
+	}
+
+	stateRootHash, err := statedb.Hash()
+	if err != nil {
+		panic(err)
+	}
 
 	return &Genesis{
 		header: &types.BlockHeader{
 			PreviousBlockHash: common.EmptyHash,
 			Creator:           common.EmptyAddress,
+			StateHash:         stateRootHash,
 			TxHash:            types.MerkleRootHash(nil),
 			Difficulty:        big.NewInt(info.Difficult),
 			Height:            genesisBlockHeight,
@@ -78,14 +141,23 @@ func (genesis *Genesis) GetShardNumber() uint {
 	return genesis.Info.ShardNumber
 }
 
+// GetNumOfChains gets the number of chains of genesis
+func (genesis *Genesis) GetNumOfChains() uint64 {
+	return genesis.Info.NumOfChains
+}
+
 // InitializeAndValidate writes the genesis block in the blockchain store if unavailable.
 // Otherwise, check if the existing genesis block is valid in the blockchain store.
-func (genesis *Genesis) InitializeAndValidate(bcStore store.BlockchainStore) error {
+func (genesis *Genesis) InitializeAndValidate(bcStore store.BlockchainStore, accountStateDB database.Database) error {
+	if genesis.Info.NumOfChains == 0 || genesis.Info.NumOfChains > common.MaxNumOfChains {
+		return ErrGenesisNumOfChainsInvalid
+	}
+
 	storedGenesisHash, err := bcStore.GetBlockHash(genesisBlockHeight)
 
 	// FIXME use seele-defined common error instead of concrete levelDB error.
 	if err == errors.ErrNotFound {
-		return genesis.store(bcStore)
+		return genesis.store(bcStore, accountStateDB)
 	}
 
 	if err != nil {
@@ -106,6 +178,14 @@ func (genesis *Genesis) InitializeAndValidate(bcStore store.BlockchainStore) err
 		return errors.New("specific shard number does not match with the shard number in genesis info")
 	}
 
+	if data.NumOfChains != genesis.Info.NumOfChains {
+		return errors.New("specific number of chains does not match with the number of chains in genesis info")
+	}
+
+	if data.ReplayProtectionHeight != genesis.Info.ReplayProtectionHeight {
+		return errors.New("specific replay protection height does not match with the replay protection height in genesis info")
+	}
+
 	headerHash := genesis.header.Hash()
 	if !headerHash.Equal(storedGenesisHash) {
 		return ErrGenesisHashMismatch
@@ -115,11 +195,26 @@ func (genesis *Genesis) InitializeAndValidate(bcStore store.BlockchainStore) err
 }
 
 // store atomically stores the genesis block in the blockchain store.
-func (genesis *Genesis) store(bcStore store.BlockchainStore) error {
+func (genesis *Genesis) store(bcStore store.BlockchainStore, accountStateDB database.Database) error {
+	statedb, err := GetStateDB(genesis.Info)
+	if err != nil {
+		return err
+	}
+
+	batch := accountStateDB.NewBatch()
+	if _, err = statedb.Commit(batch); err != nil {
+		return err
+	}
+
+	if err = batch.Commit(); err != nil {
+		return err
+	}
 
 	return bcStore.PutBlockHeader(genesis.header.Hash(), genesis.header, genesis.header.Difficulty, true)
 }
 
+// GetStateDB returns the state DB of genesis accounts. Genesis state is the same on all chains,
+// but an account is only spendable on the chain it belongs to.
 func GetStateDB(info GenesisInfo) (*state.Statedb, error) {
 	statedb, err := state.NewStatedb(common.EmptyHash, nil)
 	if err != nil {
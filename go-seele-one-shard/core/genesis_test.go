@@ -23,8 +23,8 @@ func Test_Genesis_GetGenesis(t *testing.T) {
 	genesis1 := GetGenesis(GenesisInfo{})
 	genesis2 := GetGenesis(GenesisInfo{})
 	assert.Equal(t, genesis1.header, genesis2.header)
-	assert.Equal(t, genesis1.info, GenesisInfo{nil, 1, 0})
-	assert.Equal(t, genesis2.info, GenesisInfo{nil, 1, 0})
+	assert.Equal(t, genesis1.Info, GenesisInfo{Difficult: 1, NumOfChains: common.DefaultNumOfChains})
+	assert.Equal(t, genesis2.Info, GenesisInfo{Difficult: 1, NumOfChains: common.DefaultNumOfChains})
 	validateGenesisDefaultMembers(t, genesis1)
 	validateGenesisDefaultMembers(t, genesis2)
 
@@ -32,7 +32,7 @@ func Test_Genesis_GetGenesis(t *testing.T) {
 	addr := crypto.MustGenerateRandomAddress()
 	accounts := make(map[common.Address]*big.Int)
 	accounts[*addr] = big.NewInt(10)
-	genesis3 := GetGenesis(GenesisInfo{accounts, 1, 0})
+	genesis3 := GetGenesis(GenesisInfo{Accounts: accounts, Difficult: 1})
 	if genesis3.header.StateHash == common.EmptyHash {
 		panic("genesis3 state hash should not equal to empty hash")
 	}
@@ -41,41 +41,123 @@ func Test_Genesis_GetGenesis(t *testing.T) {
 		panic("genesis3 should not equal to genesis2")
 	}
 
-	assert.Equal(t, genesis3.info, GenesisInfo{accounts, 1, 0})
+	assert.Equal(t, genesis3.Info, GenesisInfo{Accounts: accounts, Difficult: 1, NumOfChains: common.DefaultNumOfChains})
 	validateGenesisDefaultMembers(t, genesis3)
 
 	// case 3
 	var difficult int64
-	genesis4 := GetGenesis(GenesisInfo{nil, difficult, 0})
+	genesis4 := GetGenesis(GenesisInfo{Difficult: difficult})
 	assert.Equal(t, genesis4.header.Difficulty, big.NewInt(1))
-	assert.Equal(t, genesis4.header.ExtraData, common.SerializePanic(genesisExtraData{0}))
-	assert.Equal(t, genesis4.info, GenesisInfo{nil, 1, 0})
+	assert.Equal(t, genesis4.header.ExtraData, common.SerializePanic(genesisExtraData{0, common.DefaultNumOfChains, 0}))
+	assert.Equal(t, genesis4.Info, GenesisInfo{Difficult: 1, NumOfChains: common.DefaultNumOfChains})
 	validateGenesisDefaultMembers(t, genesis4)
 
 	difficult = 10
-	genesis4 = GetGenesis(GenesisInfo{nil, difficult, 0})
+	genesis4 = GetGenesis(GenesisInfo{Difficult: difficult})
 	assert.Equal(t, genesis4.header.Difficulty, big.NewInt(difficult))
-	assert.Equal(t, genesis4.header.ExtraData, common.SerializePanic(genesisExtraData{0}))
-	assert.Equal(t, genesis4.info, GenesisInfo{nil, difficult, 0})
+	assert.Equal(t, genesis4.header.ExtraData, common.SerializePanic(genesisExtraData{0, common.DefaultNumOfChains, 0}))
+	assert.Equal(t, genesis4.Info, GenesisInfo{Difficult: difficult, NumOfChains: common.DefaultNumOfChains})
 	validateGenesisDefaultMembers(t, genesis4)
 
 	// case 4
 	var shardNumber uint = 1
-	genesis5 := GetGenesis(GenesisInfo{nil, difficult, shardNumber})
+	genesis5 := GetGenesis(GenesisInfo{Difficult: difficult, ShardNumber: shardNumber})
 	assert.Equal(t, genesis5.header.Difficulty, big.NewInt(difficult))
-	assert.Equal(t, genesis5.header.ExtraData, common.SerializePanic(genesisExtraData{shardNumber}))
-	assert.Equal(t, genesis5.info, GenesisInfo{nil, difficult, shardNumber})
+	assert.Equal(t, genesis5.header.ExtraData, common.SerializePanic(genesisExtraData{shardNumber, common.DefaultNumOfChains, 0}))
+	assert.Equal(t, genesis5.Info, GenesisInfo{Difficult: difficult, ShardNumber: shardNumber, NumOfChains: common.DefaultNumOfChains})
 	validateGenesisDefaultMembers(t, genesis5)
 }
 
+func Test_GenesisInfo_Hash(t *testing.T) {
+	addr1 := crypto.MustGenerateRandomAddress()
+	addr2 := crypto.MustGenerateRandomAddress()
+	accounts := map[common.Address]*big.Int{
+		*addr1: big.NewInt(10),
+		*addr2: big.NewInt(20),
+	}
+
+	// shard number is excluded, and default values are used
+	info := GenesisInfo{Accounts: accounts, Difficult: 1, ShardNumber: 1, NumOfChains: common.DefaultNumOfChains}
+	assert.Equal(t, GenesisInfo{Accounts: accounts, ShardNumber: 2}.Hash(), info.Hash())
+
+	// different accounts
+	accounts2 := map[common.Address]*big.Int{
+		*addr1: big.NewInt(10),
+		*addr2: big.NewInt(21),
+	}
+	assert.Equal(t, GenesisInfo{Accounts: accounts2, Difficult: 1, ShardNumber: 1, NumOfChains: common.DefaultNumOfChains}.Hash() == info.Hash(), false)
+
+	// different difficult
+	assert.Equal(t, GenesisInfo{Accounts: accounts, Difficult: 2, ShardNumber: 1, NumOfChains: common.DefaultNumOfChains}.Hash() == info.Hash(), false)
+
+	// different replay protection height
+	assert.Equal(t, GenesisInfo{Accounts: accounts, Difficult: 1, ShardNumber: 1, NumOfChains: common.DefaultNumOfChains, ReplayProtectionHeight: 10}.Hash() == info.Hash(), false)
+}
+
 func Test_Genesis_GetShardNumber(t *testing.T) {
 	genesis := GetGenesis(GenesisInfo{})
 	assert.Equal(t, genesis.GetShardNumber(), uint(0))
 
-	genesis = GetGenesis(GenesisInfo{nil, 0, 10})
+	genesis = GetGenesis(GenesisInfo{ShardNumber: 10})
 	assert.Equal(t, genesis.GetShardNumber(), uint(10))
 }
 
+func Test_Genesis_GetNumOfChains(t *testing.T) {
+	genesis := GetGenesis(GenesisInfo{})
+	assert.Equal(t, genesis.GetNumOfChains(), common.DefaultNumOfChains)
+
+	genesis = GetGenesis(GenesisInfo{NumOfChains: 8})
+	assert.Equal(t, genesis.GetNumOfChains(), uint64(8))
+	assert.Equal(t, genesis.header.ExtraData, common.SerializePanic(genesisExtraData{0, 8, 0}))
+}
+
+func Test_Genesis_Init_NumOfChainsInvalid(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bcStore := store.NewBlockchainDatabase(db)
+
+	genesis := GetGenesis(GenesisInfo{NumOfChains: common.MaxNumOfChains + 1})
+	err := genesis.InitializeAndValidate(bcStore, db)
+	assert.Equal(t, err, ErrGenesisNumOfChainsInvalid)
+}
+
+func Test_Genesis_Init_NumOfChainsMismatch(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bcStore := store.NewBlockchainDatabase(db)
+
+	genesis := GetGenesis(GenesisInfo{NumOfChains: 1})
+	err := genesis.InitializeAndValidate(bcStore, db)
+	assert.Equal(t, err, error(nil))
+
+	// the number of chains could not be changed once the genesis block is stored
+	genesis = GetGenesis(GenesisInfo{NumOfChains: 8})
+	err = genesis.InitializeAndValidate(bcStore, db)
+	assert.Equal(t, err != nil, true)
+}
+
+func Test_Genesis_Init_ReplayProtectionHeightMismatch(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bcStore := store.NewBlockchainDatabase(db)
+
+	genesis := GetGenesis(GenesisInfo{ReplayProtectionHeight: 10})
+	extra, err := getGenesisExtraData(&types.Block{Header: genesis.header})
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, extra.ReplayProtectionHeight, uint64(10))
+
+	err = genesis.InitializeAndValidate(bcStore, db)
+	assert.Equal(t, err, error(nil))
+
+	// the replay protection height could not be changed once the genesis block is stored
+	genesis = GetGenesis(GenesisInfo{ReplayProtectionHeight: 20})
+	err = genesis.InitializeAndValidate(bcStore, db)
+	assert.Equal(t, err != nil, true)
+}
+
 func Test_Genesis_Init_DefaultGenesis(t *testing.T) {
 	db, dispose := leveldb.NewTestDatabase()
 	defer dispose()
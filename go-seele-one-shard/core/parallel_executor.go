@@ -0,0 +1,219 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/seeleteam/go-seele/core/state"
+	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/core/svm"
+	"github.com/seeleteam/go-seele/core/types"
+)
+
+// TxValidator validates the specified tx against the statedb before it is applied.
+type TxValidator func(tx *types.Transaction, statedb *state.Statedb) error
+
+// TxResultHandler handles the result of applied tx in order. If the tx failed, the statedb is not changed
+// and the tx does not take a tx index. Returns error to abort applying the remaining txs.
+type TxResultHandler func(tx *types.Transaction, receipt *types.Receipt, err error) error
+
+// ParallelExecutor executes txs concurrently against the origin state of statedb, and then commits
+// the results in order. The tx that reads any account or storage changed by the previous txs will be
+// re-executed sequentially, so that the receipts and state are exactly the same as sequential execution.
+// Each result is committed as soon as it is ready, so that the post state hashing of the committed txs
+// overlaps with the execution of the remaining txs.
+type ParallelExecutor struct {
+	workers     int
+	bcStore     store.BlockchainStore
+	numOfChains uint64
+}
+
+type txExecution struct {
+	statedb *state.Statedb // tracked statedb that the tx executed against
+	receipt *types.Receipt
+	err     error
+}
+
+// NewParallelExecutor returns a parallel executor with the specified number of workers,
+// and numOfChains is the number of chains defined in genesis. Note, the workers are limited
+// to GOMAXPROCS when executing txs, and txs are executed sequentially if only 1 CPU is usable.
+func NewParallelExecutor(workers int, bcStore store.BlockchainStore, numOfChains uint64) *ParallelExecutor {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &ParallelExecutor{
+		workers:     workers,
+		bcStore:     bcStore,
+		numOfChains: numOfChains,
+	}
+}
+
+// Execute applies the specified txs to statedb, and the first tx takes the specified tx index.
+func (e *ParallelExecutor) Execute(statedb *state.Statedb, txs []*types.Transaction, txIndex int, header *types.BlockHeader,
+	validate TxValidator, handle TxResultHandler) error {
+	// The concurrent execution only adds overhead if the workers could not run in parallel.
+	workers := e.concurrency(len(txs))
+	if workers < 2 {
+		return e.executeSequentially(statedb, txs, txIndex, header, validate, handle)
+	}
+
+	// The workers executed against the origin state, so the accounts already changed are conflicted.
+	written := state.NewAccessSet()
+	for _, addr := range statedb.GetTouchedAccounts() {
+		written.AddWrittenAccount(addr)
+	}
+
+	executions, stop, err := e.executeConcurrently(statedb, txs, txIndex, header, workers, validate)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	for i, tx := range txs {
+		execution := <-executions[i]
+		receipt, err := execution.receipt, execution.err
+
+		if execution.statedb.GetAccessSet().Conflicts(written) {
+			set := state.NewAccessSet()
+			statedb.TrackAccess(set)
+			receipt, err = e.apply(statedb, tx, txIndex, header, validate)
+			statedb.TrackAccess(nil)
+			written.Merge(set)
+		} else if err == nil {
+			statedb.ApplyChanges(execution.statedb)
+			written.Merge(execution.statedb.GetAccessSet())
+
+			if receipt.PostState, err = statedb.Hash(); err != nil {
+				return err
+			}
+
+			// The tx index only affects the logs, and it changes if any previous tx failed.
+			for _, log := range receipt.Logs {
+				log.TxIndex = uint(txIndex)
+			}
+		}
+
+		if handleErr := handle(tx, receipt, err); handleErr != nil {
+			return handleErr
+		}
+
+		if err == nil {
+			txIndex++
+		}
+	}
+
+	return nil
+}
+
+// concurrency returns the number of workers to execute the specified number of txs,
+// which is limited to the number of txs and GOMAXPROCS.
+func (e *ParallelExecutor) concurrency(numOfTxs int) int {
+	workers := e.workers
+	if procs := runtime.GOMAXPROCS(0); workers > procs {
+		workers = procs
+	}
+
+	if workers > numOfTxs {
+		workers = numOfTxs
+	}
+
+	return workers
+}
+
+// executeSequentially applies the txs one by one to statedb.
+func (e *ParallelExecutor) executeSequentially(statedb *state.Statedb, txs []*types.Transaction, txIndex int,
+	header *types.BlockHeader, validate TxValidator, handle TxResultHandler) error {
+	for _, tx := range txs {
+		receipt, err := e.apply(statedb, tx, txIndex, header, validate)
+		if handleErr := handle(tx, receipt, err); handleErr != nil {
+			return handleErr
+		}
+
+		if err == nil {
+			txIndex++
+		}
+	}
+
+	return nil
+}
+
+// executeConcurrently executes each tx against a tracked statedb of the origin state with the specified
+// number of workers in background, and the execution of each tx is sent to its own channel once it is
+// done. The returned stop function aborts the remaining txs and waits for the workers to exit.
+func (e *ParallelExecutor) executeConcurrently(statedb *state.Statedb, txs []*types.Transaction, txIndex int,
+	header *types.BlockHeader, workers int, validate TxValidator) ([]chan *txExecution, func(), error) {
+	// The origin statedb caches the loaded accounts, so each worker has its own one.
+	origins := make([]*state.Statedb, workers)
+	for i := range origins {
+		origin, err := statedb.NewOriginStatedb()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		origins[i] = origin
+	}
+
+	// The jobs are taken in order, so that the txs to commit first are executed first.
+	jobs := make(chan int, len(txs))
+	executions := make([]chan *txExecution, len(txs))
+	for i := range txs {
+		jobs <- i
+		executions[i] = make(chan *txExecution, 1)
+	}
+	close(jobs)
+
+	quit := make(chan struct{})
+	wg := sync.WaitGroup{}
+
+	for _, origin := range origins {
+		wg.Add(1)
+		go func(origin *state.Statedb) {
+			defer wg.Done()
+
+			for i := range jobs {
+				select {
+				case <-quit:
+					return
+				default:
+				}
+
+				tracked := state.NewTrackedStatedb(origin)
+				receipt, err := e.apply(tracked, txs[i], txIndex+i, header, validate)
+				executions[i] <- &txExecution{tracked, receipt, err}
+			}
+		}(origin)
+	}
+
+	stop := func() {
+		close(quit)
+		wg.Wait()
+	}
+
+	return executions, stop, nil
+}
+
+func (e *ParallelExecutor) apply(statedb *state.Statedb, tx *types.Transaction, txIndex int, header *types.BlockHeader,
+	validate TxValidator) (*types.Receipt, error) {
+	if validate != nil {
+		if err := validate(tx, statedb); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := &svm.Context{
+		Tx:          tx,
+		TxIndex:     txIndex,
+		Statedb:     statedb,
+		BlockHeader: header,
+		BcStore:     e.bcStore,
+		NumOfChains: e.numOfChains,
+	}
+
+	return svm.Process(ctx)
+}
@@ -0,0 +1,318 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"crypto/rand"
+	"math/big"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/common/hexutil"
+	"github.com/seeleteam/go-seele/core/state"
+	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/database"
+	"github.com/seeleteam/go-seele/database/leveldb"
+)
+
+// simple storage contract, please refer to the code content in contract/solidity/simple_storage.sol
+const testSimpleStorageCode = "0x608060405234801561001057600080fd5b50600560008190555060df806100276000396000f3006080604052600436106049576000357c0100000000000000000000000000000000000000000000000000000000900463ffffffff16806360fe47b114604e5780636d4ce63c146078575b600080fd5b348015605957600080fd5b5060766004803603810190808035906020019092919050505060a0565b005b348015608357600080fd5b50608a60aa565b6040518082815260200191505060405180910390f35b8060008190555050565b600080549050905600a165627a7a723058207f6dc43a0d648e9f5a0cad5071cde46657de72eb87ab4cded53a7f1090f51e6d0029"
+
+// newTestParallelAccount returns a new account of shard 1 and chain 0, so that txs between
+// such accounts are neither cross shard nor cross chain.
+func newTestParallelAccount(amount *big.Int) *testAccount {
+	addr, privKey := crypto.MustGenerateShardKeyPair(1)
+	for addr.GetChainNum(common.DefaultNumOfChains) != 0 {
+		addr, privKey = crypto.MustGenerateShardKeyPair(1)
+	}
+
+	return &testAccount{
+		addr:    *addr,
+		privKey: privKey,
+		amount:  new(big.Int).Set(amount),
+	}
+}
+
+func newTestParallelAccounts(num int) []*testAccount {
+	accounts := make([]*testAccount, num)
+	for i := range accounts {
+		accounts[i] = newTestParallelAccount(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+	}
+
+	return accounts
+}
+
+// newTestParallelRecipient returns a random address in the same shard and chain of the specified address,
+// which is much faster than generating key pairs.
+func newTestParallelRecipient(from common.Address) common.Address {
+	for {
+		var addr common.Address
+		if _, err := rand.Read(addr[:]); err != nil {
+			panic(err)
+		}
+
+		// keep the same address type
+		addr[len(addr)-1] = addr[len(addr)-1]&0xF0 | from[len(from)-1]&0x0F
+		if addr.Shard() == from.Shard() && addr.GetChainNum(common.DefaultNumOfChains) == from.GetChainNum(common.DefaultNumOfChains) {
+			return addr
+		}
+	}
+}
+
+func newTestParallelBlockchain(db database.Database, accounts []*testAccount) *Blockchain {
+	balances := make(map[common.Address]*big.Int)
+	for _, account := range accounts {
+		balances[account.addr] = account.amount
+	}
+
+	bcStore := store.NewCachedStore(store.NewBlockchainDatabase(db))
+	genesis := GetGenesis(GenesisInfo{Accounts: balances, Difficult: 1})
+	if err := genesis.InitializeAndValidate(bcStore, db); err != nil {
+		panic(err)
+	}
+
+	bc, err := NewBlockchain(bcStore, db, "", 0, common.DefaultNumOfChains, testNetworkID)
+	if err != nil {
+		panic(err)
+	}
+
+	return bc
+}
+
+func newTestParallelTx(from *testAccount, to common.Address, amount int64, payload []byte) *types.Transaction {
+	var tx *types.Transaction
+	var err error
+	if to.IsEmpty() {
+		tx, err = types.NewContractTransaction(from.addr, big.NewInt(amount), big.NewInt(1), from.nonce, payload)
+	} else if len(payload) > 0 {
+		tx, err = types.NewMessageTransaction(from.addr, to, big.NewInt(amount), big.NewInt(1), from.nonce, payload)
+	} else {
+		tx, err = types.NewTransaction(from.addr, to, big.NewInt(amount), big.NewInt(1), from.nonce)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	tx.Sign(from.privKey)
+	from.nonce++
+
+	return tx
+}
+
+func newTestParallelHeader(bc *Blockchain) (*types.BlockHeader, *types.Transaction) {
+	coinbase := newTestParallelAccount(big.NewInt(0))
+	rewardTx, err := types.NewRewardTransaction(coinbase.addr, big.NewInt(100), 1)
+	if err != nil {
+		panic(err)
+	}
+
+	header := &types.BlockHeader{
+		PreviousBlockHash: bc.genesisBlock.HeaderHash,
+		Creator:           coinbase.addr,
+		Height:            1,
+		Difficulty:        big.NewInt(1),
+		CreateTimestamp:   big.NewInt(1),
+	}
+
+	return header, rewardTx
+}
+
+// applyTestBlockTxs applies the txs against the genesis state, and returns the receipts and state root.
+func applyTestBlockTxs(bc *Blockchain, header *types.BlockHeader, rewardTx *types.Transaction, txs []*types.Transaction) ([]*types.Receipt, common.Hash, error) {
+	statedb, err := state.NewStatedb(bc.genesisBlock.Header.StateHash, bc.accountStateDB)
+	if err != nil {
+		panic(err)
+	}
+
+	receipts, err := bc.updateStateDB(statedb, rewardTx, txs, header)
+	if err != nil {
+		return nil, common.EmptyHash, err
+	}
+
+	root, err := statedb.Hash()
+	if err != nil {
+		panic(err)
+	}
+
+	return receipts, root, nil
+}
+
+func Test_ParallelExecutor_SameAsSequential(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	accounts := newTestParallelAccounts(16)
+	bc := newTestParallelBlockchain(db, accounts)
+	header, rewardTx := newTestParallelHeader(bc)
+
+	code, err := hexutil.HexToBytes(testSimpleStorageCode)
+	assert.Equal(t, err, nil)
+	contractAddr := crypto.CreateAddress(accounts[12].addr, 0)
+	setValue := append(common.CopyBytes([]byte{0x60, 0xfe, 0x47, 0xb1}), common.BigToHash(big.NewInt(38)).Bytes()...)
+
+	var txs []*types.Transaction
+
+	// independent transfers
+	for i := 0; i < 8; i++ {
+		txs = append(txs, newTestParallelTx(accounts[i], newTestParallelRecipient(accounts[i].addr), 10, nil))
+	}
+
+	// successive txs of the same account
+	for i := 0; i < 3; i++ {
+		txs = append(txs, newTestParallelTx(accounts[8], newTestParallelRecipient(accounts[8].addr), 10, nil))
+	}
+
+	// spend the balance received in the same block
+	txs = append(txs, newTestParallelTx(accounts[9], accounts[10].addr, 1000, nil))
+	txs = append(txs, newTestParallelTx(accounts[10], accounts[11].addr, accounts[10].amount.Int64()+500, nil))
+
+	// transfer to the coinbase, and create and call a contract
+	txs = append(txs, newTestParallelTx(accounts[11], header.Creator, 10, nil))
+	txs = append(txs, newTestParallelTx(accounts[12], common.EmptyAddress, 0, code))
+	txs = append(txs, newTestParallelTx(accounts[13], contractAddr, 0, setValue))
+	txs = append(txs, newTestParallelTx(accounts[14], contractAddr, 0, setValue))
+
+	expectedReceipts, expectedRoot, err := applyTestBlockTxs(bc, header, rewardTx, txs)
+	assert.Equal(t, err, nil)
+
+	// execute concurrently even if only 1 CPU is available
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	bc.SetParallelExecution(4)
+	assert.Equal(t, bc.executor != nil, true)
+
+	receipts, root, err := applyTestBlockTxs(bc, header, rewardTx, txs)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, root, expectedRoot)
+	assert.Equal(t, types.ReceiptMerkleRootHash(receipts), types.ReceiptMerkleRootHash(expectedReceipts))
+	assert.Equal(t, receipts, expectedReceipts)
+}
+
+func Test_ParallelExecutor_InvalidTx(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	accounts := newTestParallelAccounts(2)
+	bc := newTestParallelBlockchain(db, accounts)
+	header, rewardTx := newTestParallelHeader(bc)
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	bc.SetParallelExecution(4)
+
+	// the tx is valid only if the previous tx is applied
+	txs := []*types.Transaction{
+		newTestParallelTx(accounts[0], newTestParallelRecipient(accounts[0].addr), 10, nil),
+		newTestParallelTx(accounts[0], newTestParallelRecipient(accounts[0].addr), 10, nil),
+	}
+
+	_, _, err := applyTestBlockTxs(bc, header, rewardTx, txs)
+	assert.Equal(t, err, nil)
+
+	// nonce too small
+	txs = append(txs, newTestParallelTx(accounts[1], newTestParallelRecipient(accounts[1].addr), 10, nil))
+	accounts[1].nonce = 0
+	txs = append(txs, newTestParallelTx(accounts[1], newTestParallelRecipient(accounts[1].addr), 10, nil))
+
+	_, _, err = applyTestBlockTxs(bc, header, rewardTx, txs)
+	assert.Equal(t, err != nil, true)
+}
+
+func Test_Blockchain_SetParallelExecution(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	bc.SetParallelExecution(1)
+	assert.Equal(t, bc.executor == nil, true)
+
+	bc.SetParallelExecution(8)
+	assert.Equal(t, bc.executor.workers, 8)
+
+	bc.SetParallelExecution(0)
+	assert.Equal(t, bc.executor == nil, true)
+}
+
+func Test_ParallelExecutor_Concurrency(t *testing.T) {
+	executor := NewParallelExecutor(8, nil, common.DefaultNumOfChains)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	assert.Equal(t, executor.concurrency(100), 4)
+	assert.Equal(t, executor.concurrency(3), 3)
+
+	runtime.GOMAXPROCS(16)
+	assert.Equal(t, executor.concurrency(100), 8)
+
+	// fall back to sequential execution
+	runtime.GOMAXPROCS(1)
+	assert.Equal(t, executor.concurrency(100), 1)
+}
+
+// benchmarkApplyTransfers applies a block of 5000 independent transfers. The signatures are
+// not verified, which are already verified concurrently in batch for both ways. Note, the parallel
+// execution falls back to sequential if GOMAXPROCS is 1, so run with -cpu to compare them.
+func benchmarkApplyTransfers(b *testing.B, workers int) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	// the senders could be in any shard or chain, since the txs are applied directly.
+	accounts := make([]*testAccount, 5000)
+	for i := range accounts {
+		addr, privKey, err := crypto.GenerateKeyPair()
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		accounts[i] = &testAccount{*addr, privKey, big.NewInt(1000000), 0}
+	}
+
+	bc := newTestParallelBlockchain(db, accounts)
+	header, rewardTx := newTestParallelHeader(bc)
+	bc.SetParallelExecution(workers)
+
+	txs := make([]*types.Transaction, len(accounts))
+	for i, account := range accounts {
+		txs[i] = newTestParallelTx(account, newTestParallelRecipient(account.addr), 10, nil)
+	}
+
+	validate := func(tx *types.Transaction, statedb *state.Statedb) error {
+		return tx.ValidateState(statedb)
+	}
+
+	handle := func(tx *types.Transaction, receipt *types.Receipt, err error) error {
+		return err
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		statedb, err := state.NewStatedb(bc.genesisBlock.Header.StateHash, bc.accountStateDB)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err = ApplyRewardTx(rewardTx, statedb); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		if err = bc.ApplyTransactions(statedb, txs, 1, header, validate, handle); err != nil {
+			b.Fatalf("failed to apply txs, %v", err)
+		}
+	}
+}
+
+func Benchmark_Blockchain_ApplyTransfers_Sequential(b *testing.B) {
+	benchmarkApplyTransfers(b, 0)
+}
+
+func Benchmark_Blockchain_ApplyTransfers_Parallel(b *testing.B) {
+	benchmarkApplyTransfers(b, 8)
+}
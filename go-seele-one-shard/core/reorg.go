@@ -0,0 +1,66 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/core/types"
+)
+
+// GetChangedBlocks returns the blocks removed from the canonical chain in descending order,
+// and the blocks added to the canonical chain in ascending order when the HEAD block changed
+// from lastHeader to newHeader. If lastHeader is empty, only the new HEAD block is added.
+func GetChangedBlocks(bcStore store.BlockchainStore, newHeader, lastHeader common.Hash) ([]*types.Block, []*types.Block, error) {
+	newBlock, err := bcStore.GetBlock(newHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if lastHeader.IsEmpty() || newBlock.Header.PreviousBlockHash.Equal(lastHeader) {
+		return nil, []*types.Block{newBlock}, nil
+	}
+
+	lastBlock, err := bcStore.GetBlock(lastHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var removed, added []*types.Block
+	for newBlock.Header.Height > lastBlock.Header.Height {
+		added = append(added, newBlock)
+		if newBlock, err = bcStore.GetBlock(newBlock.Header.PreviousBlockHash); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for lastBlock.Header.Height > newBlock.Header.Height {
+		removed = append(removed, lastBlock)
+		if lastBlock, err = bcStore.GetBlock(lastBlock.Header.PreviousBlockHash); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for !lastBlock.HeaderHash.Equal(newBlock.HeaderHash) {
+		removed = append(removed, lastBlock)
+		added = append(added, newBlock)
+
+		if lastBlock, err = bcStore.GetBlock(lastBlock.Header.PreviousBlockHash); err != nil {
+			return nil, nil, err
+		}
+
+		if newBlock, err = bcStore.GetBlock(newBlock.Header.PreviousBlockHash); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// reverse the added blocks in ascending order
+	for i, j := 0, len(added)-1; i < j; i, j = i+1, j-1 {
+		added[i], added[j] = added[j], added[i]
+	}
+
+	return removed, added, nil
+}
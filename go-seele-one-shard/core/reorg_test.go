@@ -0,0 +1,81 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"testing"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/stretchr/testify/assert"
+)
+
+func blockHashes(blocks []*types.Block) []common.Hash {
+	hashes := make([]common.Hash, 0)
+	for _, b := range blocks {
+		hashes = append(hashes, b.HeaderHash)
+	}
+
+	return hashes
+}
+
+func Test_GetChangedBlocks(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+	bcStore := bc.GetStore()
+	genesis := bc.genesisBlock
+
+	// genesis <- a1 <- a2
+	//         <- b1 <- b2 <- b3
+	a1 := newTestBlock(bc, genesis.HeaderHash, 1, 0, 0)
+	assert.Equal(t, bc.WriteBlock(a1), error(nil))
+	a2 := newTestBlock(bc, a1.HeaderHash, 2, 0, 0)
+	assert.Equal(t, bc.WriteBlock(a2), error(nil))
+
+	b1 := newTestBlock(bc, genesis.HeaderHash, 1, 0, 0)
+	assert.Equal(t, bc.WriteBlock(b1), error(nil))
+	b2 := newTestBlock(bc, b1.HeaderHash, 2, 0, 0)
+	assert.Equal(t, bc.WriteBlock(b2), error(nil))
+	b3 := newTestBlock(bc, b2.HeaderHash, 3, 0, 0)
+	assert.Equal(t, bc.WriteBlock(b3), error(nil))
+
+	// no last header
+	removed, added, err := GetChangedBlocks(bcStore, a1.HeaderHash, common.EmptyHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, len(removed), 0)
+	assert.Equal(t, blockHashes(added), []common.Hash{a1.HeaderHash})
+
+	// chain extended by one block
+	removed, added, err = GetChangedBlocks(bcStore, a2.HeaderHash, a1.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, len(removed), 0)
+	assert.Equal(t, blockHashes(added), []common.Hash{a2.HeaderHash})
+
+	// chain extended by several blocks
+	removed, added, err = GetChangedBlocks(bcStore, b3.HeaderHash, b1.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, len(removed), 0)
+	assert.Equal(t, blockHashes(added), []common.Hash{b2.HeaderHash, b3.HeaderHash})
+
+	// reorg to the longer branch
+	removed, added, err = GetChangedBlocks(bcStore, b3.HeaderHash, a2.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, blockHashes(removed), []common.Hash{a2.HeaderHash, a1.HeaderHash})
+	assert.Equal(t, blockHashes(added), []common.Hash{b1.HeaderHash, b2.HeaderHash, b3.HeaderHash})
+
+	// reorg to the shorter branch
+	removed, added, err = GetChangedBlocks(bcStore, a1.HeaderHash, b3.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, blockHashes(removed), []common.Hash{b3.HeaderHash, b2.HeaderHash, b1.HeaderHash})
+	assert.Equal(t, blockHashes(added), []common.Hash{a1.HeaderHash})
+
+	// unknown block
+	_, _, err = GetChangedBlocks(bcStore, common.StringToHash("unknown"), a1.HeaderHash)
+	assert.Equal(t, err != nil, true)
+}
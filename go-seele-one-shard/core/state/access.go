@@ -0,0 +1,131 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package state
+
+import (
+	"math/big"
+
+	"github.com/seeleteam/go-seele/common"
+)
+
+type storageKey struct {
+	addr common.Address
+	key  common.Hash
+}
+
+// AccessSet records the accounts and storage keys that read or written in statedb,
+// which is used to detect the conflicts of txs that executed concurrently.
+type AccessSet struct {
+	accountReads  map[common.Address]struct{}
+	accountWrites map[common.Address]struct{}
+	storageReads  map[storageKey]struct{}
+	storageWrites map[storageKey]struct{}
+
+	// balance added to accounts without reading them, e.g. the tx fee of coinbase.
+	// Such changes are commutative, so they will not conflict with each other.
+	balanceDeltas map[common.Address]*big.Int
+}
+
+// NewAccessSet returns an empty access set.
+func NewAccessSet() *AccessSet {
+	return &AccessSet{
+		accountReads:  make(map[common.Address]struct{}),
+		accountWrites: make(map[common.Address]struct{}),
+		storageReads:  make(map[storageKey]struct{}),
+		storageWrites: make(map[storageKey]struct{}),
+		balanceDeltas: make(map[common.Address]*big.Int),
+	}
+}
+
+func (set *AccessSet) readAccount(addr common.Address) {
+	set.accountReads[addr] = struct{}{}
+
+	// the read value contains the added balance, so the account should be written as a whole.
+	if _, found := set.balanceDeltas[addr]; found {
+		delete(set.balanceDeltas, addr)
+		set.accountWrites[addr] = struct{}{}
+	}
+}
+
+func (set *AccessSet) writeAccount(addr common.Address) {
+	set.readAccount(addr)
+	set.accountWrites[addr] = struct{}{}
+}
+
+// addBalance records the balance added to the specified account, and returns true
+// if it is recorded as a balance delta.
+func (set *AccessSet) addBalance(addr common.Address, amount *big.Int) bool {
+	if _, found := set.accountReads[addr]; found || amount.Sign() < 0 {
+		set.writeAccount(addr)
+		return false
+	}
+
+	if delta, found := set.balanceDeltas[addr]; found {
+		delta.Add(delta, amount)
+	} else {
+		set.balanceDeltas[addr] = new(big.Int).Set(amount)
+	}
+
+	return true
+}
+
+func (set *AccessSet) subBalanceDelta(addr common.Address, amount *big.Int) {
+	if delta, found := set.balanceDeltas[addr]; found {
+		delta.Sub(delta, amount)
+	}
+}
+
+func (set *AccessSet) readStorage(addr common.Address, key common.Hash) {
+	set.storageReads[storageKey{addr, key}] = struct{}{}
+}
+
+func (set *AccessSet) writeStorage(addr common.Address, key common.Hash) {
+	set.readStorage(addr, key)
+	set.storageWrites[storageKey{addr, key}] = struct{}{}
+}
+
+// AddWrittenAccount marks the specified account as written.
+func (set *AccessSet) AddWrittenAccount(addr common.Address) {
+	set.accountWrites[addr] = struct{}{}
+}
+
+// Merge merges the written accounts and storage keys of the specified access set.
+// Note, the balance delta is merged as a written account.
+func (set *AccessSet) Merge(other *AccessSet) {
+	for addr := range other.accountWrites {
+		set.accountWrites[addr] = struct{}{}
+	}
+
+	for addr := range other.balanceDeltas {
+		set.accountWrites[addr] = struct{}{}
+	}
+
+	for key := range other.storageWrites {
+		set.storageWrites[key] = struct{}{}
+	}
+}
+
+// Conflicts returns true if any account or storage key read in set is written in the specified access set.
+// Note, the storage keys are also conflicted if the account is written, since the account may be suicided.
+func (set *AccessSet) Conflicts(written *AccessSet) bool {
+	for addr := range set.accountReads {
+		if _, found := written.accountWrites[addr]; found {
+			return true
+		}
+	}
+
+	for key := range set.storageReads {
+		if _, found := written.accountWrites[key.addr]; found {
+			return true
+		}
+
+		if _, found := written.storageWrites[key]; found {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,139 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/database"
+	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOriginStatedb(db database.Database, addrs ...common.Address) *Statedb {
+	statedb, err := NewStatedb(common.EmptyHash, db)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, addr := range addrs {
+		statedb.CreateAccount(addr)
+		statedb.SetBalance(addr, big.NewInt(100))
+	}
+
+	batch := db.NewBatch()
+	root, err := statedb.Commit(batch)
+	if err != nil {
+		panic(err)
+	}
+
+	if err = batch.Commit(); err != nil {
+		panic(err)
+	}
+
+	if statedb, err = NewStatedb(root, db); err != nil {
+		panic(err)
+	}
+
+	return statedb
+}
+
+func Test_AccessSet_BalanceDelta(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	from, to, coinbase := common.BytesToAddress([]byte{1}), common.BytesToAddress([]byte{2}), common.BytesToAddress([]byte{3})
+	statedb := newTestOriginStatedb(db, from, to, coinbase)
+
+	tracked := NewTrackedStatedb(statedb)
+	tracked.SubBalance(from, big.NewInt(10))
+	tracked.AddBalance(to, big.NewInt(9))
+	tracked.AddBalance(coinbase, big.NewInt(1))
+
+	set := tracked.GetAccessSet()
+	assert.Equal(t, len(set.accountWrites), 1)
+	assert.Equal(t, set.balanceDeltas[to], big.NewInt(9))
+	assert.Equal(t, set.balanceDeltas[coinbase], big.NewInt(1))
+
+	// balance delta is commutative
+	written := NewAccessSet()
+	written.balanceDeltas[coinbase] = big.NewInt(1)
+	assert.Equal(t, set.Conflicts(written), false)
+
+	written.AddWrittenAccount(to)
+	assert.Equal(t, set.Conflicts(written), false)
+
+	written.Merge(set)
+	assert.Equal(t, set.Conflicts(written), true)
+
+	// read after balance added
+	tracked.GetBalance(coinbase)
+	assert.Equal(t, len(set.balanceDeltas), 1)
+	assert.Equal(t, len(set.accountWrites), 2)
+}
+
+func Test_AccessSet_RevertBalanceDelta(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	coinbase := common.BytesToAddress([]byte{3})
+	statedb := newTestOriginStatedb(db, coinbase)
+
+	tracked := NewTrackedStatedb(statedb)
+	snapshot := tracked.Snapshot()
+	tracked.AddBalance(coinbase, big.NewInt(1))
+	tracked.RevertToSnapshot(snapshot)
+
+	assert.Equal(t, tracked.GetAccessSet().balanceDeltas[coinbase].Sign(), 0)
+}
+
+func Test_Statedb_ApplyChanges(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	from, to, coinbase := common.BytesToAddress([]byte{1}), common.BytesToAddress([]byte{2}), common.BytesToAddress([]byte{3})
+	newAccount, key := common.BytesToAddress([]byte{4}), common.StringToHash("key")
+	statedb := newTestOriginStatedb(db, from, to, coinbase)
+	expected, err := statedb.NewOriginStatedb()
+	assert.Equal(t, err, nil)
+
+	change := func(s *Statedb) {
+		s.SetNonce(from, 1)
+		s.SubBalance(from, big.NewInt(10))
+		s.AddBalance(to, big.NewInt(9))
+		s.AddBalance(coinbase, big.NewInt(1))
+		s.CreateAccount(newAccount)
+		s.SetData(newAccount, key, []byte("value"))
+	}
+
+	// coinbase is changed by other tx
+	statedb.AddBalance(coinbase, big.NewInt(5))
+	expected.AddBalance(coinbase, big.NewInt(5))
+
+	origin, err := statedb.NewOriginStatedb()
+	assert.Equal(t, err, nil)
+
+	tracked := NewTrackedStatedb(origin)
+	change(tracked)
+	statedb.ApplyChanges(tracked)
+
+	change(expected)
+
+	hash, err := tracked.Hash()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, hash, common.EmptyHash)
+
+	hash, err = statedb.Hash()
+	assert.Equal(t, err, nil)
+
+	expectedHash, err := expected.Hash()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, hash, expectedHash)
+	assert.Equal(t, statedb.GetBalance(coinbase), big.NewInt(106))
+	assert.Equal(t, statedb.GetData(newAccount, key), []byte("value"))
+}
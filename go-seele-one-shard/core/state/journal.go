@@ -87,6 +87,10 @@ type (
 	createObjectChange struct {
 		account *common.Address
 	}
+	balanceDeltaChange struct {
+		account *common.Address
+		amount  *big.Int
+	}
 )
 
 func (ch refundChange) revert(s *Statedb) {
@@ -148,3 +152,13 @@ func (ch createObjectChange) revert(s *Statedb) {
 func (ch createObjectChange) dirtyAccount() *common.Address {
 	return ch.account
 }
+
+func (ch balanceDeltaChange) revert(s *Statedb) {
+	if s.access != nil {
+		s.access.subBalanceDelta(*ch.account, ch.amount)
+	}
+}
+
+func (ch balanceDeltaChange) dirtyAccount() *common.Address {
+	return nil
+}
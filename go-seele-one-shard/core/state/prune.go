@@ -0,0 +1,137 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package state
+
+import (
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/database"
+	"github.com/seeleteam/go-seele/trie"
+)
+
+// pruneBatchSize is the max number of trie nodes deleted in a batch.
+const pruneBatchSize = 10000
+
+// Pruner removes the trie nodes in db that not reachable from any of the marked state roots.
+// The nodes are swept on the snapshot of db when the pruner is created, so the nodes written
+// later are always kept. Since the nodes are addressed by content, a removable node may be
+// written again by a new block, so the roots of the blocks written after the pruner created
+// should be marked again before sweeping, and no block should be written during sweeping.
+type Pruner struct {
+	db     database.Database
+	it     database.Iterator // iterator on the snapshot of db when the pruner created
+	marked map[common.Hash]struct{}
+}
+
+// NewPruner returns a pruner of db, which should be released after used.
+func NewPruner(db database.Database) *Pruner {
+	return &Pruner{
+		db:     db,
+		it:     db.NewIterator(trieDbPrefix),
+		marked: make(map[common.Hash]struct{}),
+	}
+}
+
+// Mark marks the trie nodes of the specified state roots in use. The partial roots are the
+// roots of the state being synchronized, whose missing trie nodes are skipped.
+func (p *Pruner) Mark(roots []common.Hash, partialRoots []common.Hash) error {
+	for _, root := range roots {
+		if err := trie.MarkNodes(root, trieDbPrefix, p.db, p.marked); err != nil {
+			return err
+		}
+	}
+
+	for _, root := range partialRoots {
+		if err := trie.MarkExistingNodes(root, trieDbPrefix, p.db, p.marked); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Candidates returns the keys of the trie nodes in the snapshot of db that not marked yet.
+func (p *Pruner) Candidates() ([][]byte, error) {
+	var keys [][]byte
+
+	for p.it.Next() {
+		key := p.it.Key()
+		if len(key) != len(trieDbPrefix)+common.HashLength {
+			continue
+		}
+
+		if _, found := p.marked[common.BytesToHash(key[len(trieDbPrefix):])]; found {
+			continue
+		}
+
+		keys = append(keys, common.CopyBytes(key))
+	}
+
+	if err := p.it.Error(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Sweep removes the trie nodes of the specified candidates that still not marked,
+// and returns the number of removed nodes.
+func (p *Pruner) Sweep(candidates [][]byte) (int, error) {
+	batch := p.db.NewBatch()
+	removed, pending := 0, 0
+
+	for _, key := range candidates {
+		if _, found := p.marked[common.BytesToHash(key[len(trieDbPrefix):])]; found {
+			continue
+		}
+
+		batch.Delete(key)
+		pending++
+
+		if pending == pruneBatchSize {
+			if err := batch.Commit(); err != nil {
+				return removed, err
+			}
+
+			removed += pending
+			batch, pending = p.db.NewBatch(), 0
+		}
+	}
+
+	if pending > 0 {
+		if err := batch.Commit(); err != nil {
+			return removed, err
+		}
+
+		removed += pending
+	}
+
+	return removed, nil
+}
+
+// Release releases the snapshot of db.
+func (p *Pruner) Release() {
+	p.it.Release()
+}
+
+// Prune removes the trie nodes in db that not reachable from any of the specified state
+// root hashes, and returns the number of removed nodes. The partial roots are the roots of
+// the state being synchronized, whose missing trie nodes are skipped. Note, no block should
+// be written concurrently, which may reference the removed nodes.
+func Prune(db database.Database, roots []common.Hash, partialRoots []common.Hash) (int, error) {
+	pruner := NewPruner(db)
+	defer pruner.Release()
+
+	if err := pruner.Mark(roots, partialRoots); err != nil {
+		return 0, err
+	}
+
+	candidates, err := pruner.Candidates()
+	if err != nil {
+		return 0, err
+	}
+
+	return pruner.Sweep(candidates)
+}
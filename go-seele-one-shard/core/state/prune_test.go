@@ -0,0 +1,91 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Prune(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	var addrs []common.Address
+	for i := 1; i <= 20; i++ {
+		addrs = append(addrs, common.BytesToAddress([]byte{byte(i)}))
+	}
+
+	statedb := newTestOriginStatedb(db, addrs...)
+	root1 := statedb.root
+
+	statedb.SetBalance(addrs[0], big.NewInt(200))
+	batch := db.NewBatch()
+	root2, err := statedb.Commit(batch)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, batch.Commit(), nil)
+
+	removed, err := Prune(db, []common.Hash{root2}, nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, removed > 0, true)
+
+	// the state of root1 is removed
+	_, err = NewStatedb(root1, db)
+	assert.Equal(t, err != nil, true)
+
+	// the state of root2 is kept
+	statedb, err = NewStatedb(root2, db)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statedb.GetBalance(addrs[0]), big.NewInt(200))
+	for _, addr := range addrs[1:] {
+		assert.Equal(t, statedb.GetBalance(addr), big.NewInt(100))
+	}
+
+	removed, err = Prune(db, []common.Hash{root2}, nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, removed, 0)
+
+	// missing root
+	_, err = Prune(db, []common.Hash{root1}, nil)
+	assert.Equal(t, err != nil, true)
+}
+
+func Test_Pruner_MarkBeforeSweep(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	addr := common.BytesToAddress([]byte{1})
+	statedb := newTestOriginStatedb(db, addr)
+	root1 := statedb.root
+
+	statedb.SetBalance(addr, big.NewInt(200))
+	batch := db.NewBatch()
+	root2, err := statedb.Commit(batch)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, batch.Commit(), nil)
+
+	pruner := NewPruner(db)
+	defer pruner.Release()
+
+	assert.Equal(t, pruner.Mark([]common.Hash{root2}, nil), nil)
+	candidates, err := pruner.Candidates()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(candidates) > 0, true)
+
+	// the state of root1 is in use again before sweeping
+	assert.Equal(t, pruner.Mark([]common.Hash{root1}, nil), nil)
+	removed, err := pruner.Sweep(candidates)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, removed, 0)
+
+	statedb, err = NewStatedb(root1, db)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, statedb.GetBalance(addr), big.NewInt(100))
+}
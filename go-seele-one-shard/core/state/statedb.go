@@ -33,6 +33,16 @@ type Statedb struct {
 
 	// State modifications for current processed tx.
 	curJournal *journal
+
+	// The root hash and database that statedb is constructed from.
+	root common.Hash
+	db   database.Database
+
+	// Accessed state for parallel execution, nil if not tracked.
+	access *AccessSet
+
+	// The statedb to load accounts from for tracked statedb, see NewTrackedStatedb.
+	origin *Statedb
 }
 
 // NewStatedb constructs and returns a statedb instance
@@ -46,9 +56,32 @@ func NewStatedb(root common.Hash, db database.Database) (*Statedb, error) {
 		trie:         trie,
 		stateObjects: make(map[common.Address]*stateObject),
 		curJournal:   newJournal(),
+		root:         root,
+		db:           db,
 	}, nil
 }
 
+// NewTrackedStatedb returns a statedb that loads accounts from the specified origin statedb and
+// records the accessed state. The origin statedb should not be changed and used in other goroutines.
+// Changes of the tracked statedb are never flushed into the trie, but could be applied to another
+// statedb via ApplyChanges.
+func NewTrackedStatedb(origin *Statedb) *Statedb {
+	return &Statedb{
+		trie:         origin.trie,
+		stateObjects: make(map[common.Address]*stateObject),
+		curJournal:   newJournal(),
+		root:         origin.root,
+		db:           origin.db,
+		access:       NewAccessSet(),
+		origin:       origin,
+	}
+}
+
+// NewOriginStatedb returns a new statedb of the root hash that statedb is constructed from.
+func (s *Statedb) NewOriginStatedb() (*Statedb, error) {
+	return NewStatedb(s.root, s.db)
+}
+
 // GetCopy is a memory copy of state db.
 func (s *Statedb) GetCopy() (*Statedb, error) {
 	copyObjecsFunc := func(src map[common.Address]*stateObject) map[common.Address]*stateObject {
@@ -70,6 +103,9 @@ func (s *Statedb) GetCopy() (*Statedb, error) {
 
 		dbErr:  s.dbErr,
 		refund: s.refund,
+
+		root: s.root,
+		db:   s.db,
 	}, nil
 }
 
@@ -83,6 +119,8 @@ func (s *Statedb) setError(err error) {
 // GetBalance returns the balance of the specified account if exists.
 // Otherwise, returns zero.
 func (s *Statedb) GetBalance(addr common.Address) *big.Int {
+	s.readAccount(addr)
+
 	if object := s.getStateObject(addr); object != nil {
 		return object.getAmount()
 	}
@@ -92,6 +130,8 @@ func (s *Statedb) GetBalance(addr common.Address) *big.Int {
 
 // SetBalance sets the balance of the specified account if exists.
 func (s *Statedb) SetBalance(addr common.Address, balance *big.Int) {
+	s.writeAccount(addr)
+
 	if object := s.getStateObject(addr); object != nil {
 		s.curJournal.append(balanceChange{&addr, object.getAmount()})
 		object.setAmount(balance)
@@ -100,6 +140,10 @@ func (s *Statedb) SetBalance(addr common.Address, balance *big.Int) {
 
 // AddBalance adds the specified amount to the balance for the specified account if exists.
 func (s *Statedb) AddBalance(addr common.Address, amount *big.Int) {
+	if s.access != nil && s.access.addBalance(addr, amount) {
+		s.curJournal.append(balanceDeltaChange{&addr, new(big.Int).Set(amount)})
+	}
+
 	if object := s.getStateObject(addr); object != nil {
 		s.curJournal.append(balanceChange{&addr, object.getAmount()})
 		object.addAmount(amount)
@@ -108,6 +152,8 @@ func (s *Statedb) AddBalance(addr common.Address, amount *big.Int) {
 
 // SubBalance substracts the specified amount from the balance for the specified account if exists.
 func (s *Statedb) SubBalance(addr common.Address, amount *big.Int) {
+	s.writeAccount(addr)
+
 	if object := s.getStateObject(addr); object != nil {
 		s.curJournal.append(balanceChange{&addr, object.getAmount()})
 		object.subAmount(amount)
@@ -117,6 +163,8 @@ func (s *Statedb) SubBalance(addr common.Address, amount *big.Int) {
 // GetNonce gets the nonce of the specified account if exists.
 // Otherwise, return 0.
 func (s *Statedb) GetNonce(addr common.Address) uint64 {
+	s.readAccount(addr)
+
 	if object := s.getStateObject(addr); object != nil {
 		return object.getNonce()
 	}
@@ -126,6 +174,8 @@ func (s *Statedb) GetNonce(addr common.Address) uint64 {
 
 // SetNonce sets the nonce of the specified account if exists.
 func (s *Statedb) SetNonce(addr common.Address, nonce uint64) {
+	s.writeAccount(addr)
+
 	if object := s.getStateObject(addr); object != nil {
 		s.curJournal.append(nonceChange{&addr, object.getNonce()})
 		object.setNonce(nonce)
@@ -135,6 +185,10 @@ func (s *Statedb) SetNonce(addr common.Address, nonce uint64) {
 // GetData returns the account data of the specified key if exists.
 // Otherwise, return nil.
 func (s *Statedb) GetData(addr common.Address, key common.Hash) []byte {
+	if s.access != nil {
+		s.access.readStorage(addr, key)
+	}
+
 	if object := s.getStateObject(addr); object != nil {
 		return object.getState(s.trie, key)
 	}
@@ -144,6 +198,10 @@ func (s *Statedb) GetData(addr common.Address, key common.Hash) []byte {
 
 // SetData sets the key value pair for the specified account if exists.
 func (s *Statedb) SetData(addr common.Address, key common.Hash, value []byte) {
+	if s.access != nil {
+		s.access.writeStorage(addr, key)
+	}
+
 	if object := s.getStateObject(addr); object != nil {
 		prevValue := object.getState(s.trie, key)
 		s.curJournal.append(storageChange{&addr, key, prevValue})
@@ -157,6 +215,12 @@ func (s *Statedb) Hash() (common.Hash, error) {
 		return common.EmptyHash, s.dbErr
 	}
 
+	// changes of tracked statedb are never flushed into the trie of origin statedb.
+	if s.origin != nil {
+		s.clearJournalAndRefund()
+		return common.EmptyHash, nil
+	}
+
 	for addr := range s.curJournal.dirties {
 		if object, found := s.stateObjects[addr]; found {
 			if err := object.flush(s.trie); err != nil {
@@ -180,6 +244,10 @@ func (s *Statedb) Commit(batch database.Batch) (common.Hash, error) {
 		return common.EmptyHash, s.dbErr
 	}
 
+	if s.origin != nil {
+		panic("tracked statedb could not be committed")
+	}
+
 	for _, object := range s.stateObjects {
 		if err := object.flush(s.trie); err != nil {
 			return common.EmptyHash, err
@@ -200,6 +268,19 @@ func (s *Statedb) getStateObject(addr common.Address) *stateObject {
 		return nil
 	}
 
+	// load from origin statedb
+	if s.origin != nil {
+		object := s.origin.getStateObject(addr)
+		if object == nil {
+			return nil
+		}
+
+		object = object.clone()
+		s.stateObjects[addr] = object
+
+		return object
+	}
+
 	// load from trie
 	object := newStateObject(addr)
 	if ok, err := object.loadAccount(s.trie); !ok || err != nil {
@@ -234,6 +315,8 @@ func (s *Statedb) GetCurrentLogs() []*types.Log {
 
 // CreateAccount creates a new account in statedb.
 func (s *Statedb) CreateAccount(address common.Address) {
+	s.writeAccount(address)
+
 	if object := s.getStateObject(address); object == nil {
 		object = newStateObject(address)
 		s.curJournal.append(createObjectChange{&address})
@@ -244,6 +327,8 @@ func (s *Statedb) CreateAccount(address common.Address) {
 // GetCodeHash returns the hash of the contract code associated with the specified address if any.
 // Otherwise, return an empty hash.
 func (s *Statedb) GetCodeHash(address common.Address) common.Hash {
+	s.readAccount(address)
+
 	if object := s.getStateObject(address); object != nil {
 		return common.BytesToHash(object.account.CodeHash)
 	}
@@ -254,6 +339,8 @@ func (s *Statedb) GetCodeHash(address common.Address) common.Hash {
 // GetCode returns the contract code associated with the specified address if any.
 // Otherwise, return nil.
 func (s *Statedb) GetCode(address common.Address) []byte {
+	s.readAccount(address)
+
 	if object := s.getStateObject(address); object != nil {
 		return object.loadCode(s.trie)
 	}
@@ -263,6 +350,8 @@ func (s *Statedb) GetCode(address common.Address) []byte {
 
 // SetCode sets the contract code of the specified address if exists.
 func (s *Statedb) SetCode(address common.Address, code []byte) {
+	s.writeAccount(address)
+
 	// EVM call SetCode after CreateAccount during contract creation.
 	// So, here the retrieved stateObj should not be nil.
 	if object := s.getStateObject(address); object != nil {
@@ -282,11 +371,15 @@ func (s *Statedb) GetCodeSize(address common.Address) int {
 // Exist indicates whether the given account exists in statedb.
 // Note that it should also return true for suicided accounts.
 func (s *Statedb) Exist(address common.Address) bool {
+	s.readAccount(address)
+
 	return s.getStateObject(address) != nil
 }
 
 // Empty indicates whether the given account satisfies (balance = nonce = code = 0).
 func (s *Statedb) Empty(address common.Address) bool {
+	s.readAccount(address)
+
 	stateObj := s.getStateObject(address)
 	return stateObj == nil || stateObj.empty()
 }
@@ -295,6 +388,8 @@ func (s *Statedb) Empty(address common.Address) bool {
 // Note the account's state object is still available until the state is committed.
 // Return true if the specified account exists, otherwise false.
 func (s *Statedb) Suicide(address common.Address) bool {
+	s.writeAccount(address)
+
 	stateObj := s.getStateObject(address)
 	if stateObj == nil {
 		return false
@@ -310,6 +405,8 @@ func (s *Statedb) Suicide(address common.Address) bool {
 
 // HasSuicided returns true if the specified account exists and suicided, otherwise false.
 func (s *Statedb) HasSuicided(address common.Address) bool {
+	s.readAccount(address)
+
 	stateObj := s.getStateObject(address)
 	if stateObj == nil {
 		return false
@@ -345,3 +442,83 @@ func (s *Statedb) AddRefund(gas uint64) {
 func (s *Statedb) GetRefund() uint64 {
 	return s.refund
 }
+
+// TrackAccess starts to record the accessed state with the specified access set,
+// or stops recording if the access set is nil.
+func (s *Statedb) TrackAccess(set *AccessSet) {
+	s.access = set
+}
+
+// GetAccessSet returns the recorded access set, or nil if not tracked.
+func (s *Statedb) GetAccessSet() *AccessSet {
+	return s.access
+}
+
+func (s *Statedb) readAccount(addr common.Address) {
+	if s.access != nil {
+		s.access.readAccount(addr)
+	}
+}
+
+func (s *Statedb) writeAccount(addr common.Address) {
+	if s.access != nil {
+		s.access.writeAccount(addr)
+	}
+}
+
+// GetTouchedAccounts returns the accounts that loaded or changed in statedb.
+func (s *Statedb) GetTouchedAccounts() []common.Address {
+	addrs := make([]common.Address, 0, len(s.stateObjects))
+	for addr := range s.stateObjects {
+		addrs = append(addrs, addr)
+	}
+
+	return addrs
+}
+
+// ApplyChanges applies the changes of the specified tracked statedb, which supposes
+// that the accounts and storage keys read in the tracked statedb are not changed in s
+// since the origin state. Note, the intermediate state should be flushed via Hash.
+func (s *Statedb) ApplyChanges(tracked *Statedb) {
+	set := tracked.access
+
+	for addr := range set.accountWrites {
+		if object, found := tracked.stateObjects[addr]; found {
+			s.applyStateObject(object)
+		}
+	}
+
+	for key := range set.storageWrites {
+		if object, found := tracked.stateObjects[key.addr]; found {
+			if value, ok := object.cachedStorage[key.key]; ok {
+				s.SetData(key.addr, key.key, value)
+			}
+		}
+	}
+
+	for addr, delta := range set.balanceDeltas {
+		s.AddBalance(addr, delta)
+	}
+}
+
+func (s *Statedb) applyStateObject(object *stateObject) {
+	addr := object.address
+	s.CreateAccount(addr)
+
+	current := s.getStateObject(addr)
+	if current.getNonce() != object.getNonce() {
+		s.SetNonce(addr, object.getNonce())
+	}
+
+	if current.account.Amount.Cmp(object.account.Amount) != 0 {
+		s.SetBalance(addr, object.account.Amount)
+	}
+
+	if object.dirtyCode {
+		s.SetCode(addr, object.code)
+	}
+
+	if object.suicided && !current.suicided {
+		s.Suicide(addr)
+	}
+}
@@ -0,0 +1,145 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package state
+
+import (
+	"errors"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/database"
+	"github.com/seeleteam/go-seele/trie"
+)
+
+var errTrieNodeNotRequested = errors.New("trie node not requested")
+
+func trieNodeKey(hash common.Hash) []byte {
+	key := make([]byte, 0, len(trieDbPrefix)+common.HashLength)
+	return append(append(key, trieDbPrefix...), hash.Bytes()...)
+}
+
+// GetTrieNode returns the encoded trie node of the specified hash in db.
+func GetTrieNode(db database.Database, hash common.Hash) ([]byte, error) {
+	return db.Get(trieNodeKey(hash))
+}
+
+// PutTrieNode verifies the encoded trie node against the specified hash, and then puts it
+// into batch. Returns the hashes of the child nodes.
+func PutTrieNode(batch database.Batch, hash common.Hash, value []byte) ([]common.Hash, error) {
+	children, err := trie.DecodeNodeChildren(hash, value)
+	if err != nil {
+		return nil, err
+	}
+
+	batch.Put(trieNodeKey(hash), value)
+
+	return children, nil
+}
+
+// WalkTrieNodes calls fn with every trie node of the state of the specified root hashes,
+// and the trie nodes shared by the states are only called once.
+func WalkTrieNodes(db database.Database, roots []common.Hash, fn func(hash common.Hash, value []byte) error) error {
+	visited := make(map[common.Hash]struct{})
+	for _, root := range roots {
+		if err := trie.WalkNodes(root, trieDbPrefix, db, visited, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TrieSync schedules the retrieval of the trie nodes that missing in db for the state of
+// the specified root hash. Every retrieved node is verified against the hash referenced by
+// its parent node, so the whole state is verified against the root hash.
+type TrieSync struct {
+	db        database.Database
+	queue     []common.Hash            // nodes to check whether in db
+	requested map[common.Hash]struct{} // nodes being retrieved
+	visited   map[common.Hash]struct{}
+}
+
+// NewTrieSync returns a trie sync for the state of the specified root hash.
+func NewTrieSync(root common.Hash, db database.Database) *TrieSync {
+	s := &TrieSync{
+		db:        db,
+		requested: make(map[common.Hash]struct{}),
+		visited:   make(map[common.Hash]struct{}),
+	}
+
+	if root != common.EmptyHash {
+		s.queue = append(s.queue, root)
+	}
+
+	return s
+}
+
+// Missing returns the hashes of at most max trie nodes to retrieve. The nodes already in db
+// are walked locally, so that an interrupted sync could be resumed.
+func (s *TrieSync) Missing(max int) ([]common.Hash, error) {
+	var hashes []common.Hash
+
+	for len(s.queue) > 0 && len(hashes) < max {
+		hash := s.queue[len(s.queue)-1]
+		s.queue = s.queue[:len(s.queue)-1]
+
+		if _, found := s.visited[hash]; found {
+			continue
+		}
+
+		s.visited[hash] = struct{}{}
+
+		if value, err := GetTrieNode(s.db, hash); err == nil && len(value) > 0 {
+			children, err := trie.DecodeNodeChildren(hash, value)
+			if err != nil {
+				return nil, err
+			}
+
+			s.queue = append(s.queue, children...)
+			continue
+		}
+
+		s.requested[hash] = struct{}{}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
+
+// Process verifies the retrieved trie nodes and writes them into db. The requested nodes
+// that not retrieved will be returned by Missing again.
+func (s *TrieSync) Process(nodes [][]byte) error {
+	batch := s.db.NewBatch()
+
+	for _, value := range nodes {
+		hash := crypto.HashBytes(value)
+		if _, found := s.requested[hash]; !found {
+			return errTrieNodeNotRequested
+		}
+
+		children, err := PutTrieNode(batch, hash, value)
+		if err != nil {
+			return err
+		}
+
+		delete(s.requested, hash)
+		s.queue = append(s.queue, children...)
+	}
+
+	for hash := range s.requested {
+		delete(s.visited, hash)
+		s.queue = append(s.queue, hash)
+	}
+
+	s.requested = make(map[common.Hash]struct{})
+
+	return batch.Commit()
+}
+
+// Done returns true if all trie nodes of the state are in db.
+func (s *TrieSync) Done() bool {
+	return len(s.queue) == 0 && len(s.requested) == 0
+}
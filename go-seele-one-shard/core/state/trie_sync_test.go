@@ -0,0 +1,121 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/database"
+	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSyncAccounts(num int) []common.Address {
+	var addrs []common.Address
+	for i := 1; i <= num; i++ {
+		addrs = append(addrs, common.BytesToAddress([]byte{byte(i)}))
+	}
+
+	return addrs
+}
+
+// retrieveTestTrieNodes returns the first num trie nodes of the specified hashes from db.
+func retrieveTestTrieNodes(db database.Database, hashes []common.Hash, num int) [][]byte {
+	var nodes [][]byte
+	for i := 0; i < len(hashes) && i < num; i++ {
+		value, err := GetTrieNode(db, hashes[i])
+		if err != nil {
+			panic(err)
+		}
+
+		nodes = append(nodes, value)
+	}
+
+	return nodes
+}
+
+func Test_TrieSync(t *testing.T) {
+	srcDB, srcDispose := leveldb.NewTestDatabase()
+	defer srcDispose()
+
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	addrs := newTestSyncAccounts(20)
+	root := newTestOriginStatedb(srcDB, addrs...).root
+
+	sync := NewTrieSync(root, db)
+	for !sync.Done() {
+		hashes, err := sync.Missing(4)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(hashes) > 0, true)
+
+		// the nodes not delivered will be requested again
+		assert.Equal(t, sync.Process(retrieveTestTrieNodes(srcDB, hashes, 3)), nil)
+	}
+
+	statedb, err := NewStatedb(root, db)
+	assert.Equal(t, err, nil)
+	for _, addr := range addrs {
+		assert.Equal(t, statedb.GetBalance(addr), big.NewInt(100))
+	}
+
+	assert.Equal(t, WalkTrieNodes(db, []common.Hash{root}, nil), nil)
+
+	// all nodes are in db
+	sync = NewTrieSync(root, db)
+	hashes, err := sync.Missing(4)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(hashes), 0)
+	assert.Equal(t, sync.Done(), true)
+}
+
+func Test_TrieSync_InvalidNode(t *testing.T) {
+	srcDB, srcDispose := leveldb.NewTestDatabase()
+	defer srcDispose()
+
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	root := newTestOriginStatedb(srcDB, newTestSyncAccounts(20)...).root
+
+	sync := NewTrieSync(root, db)
+	hashes, err := sync.Missing(4)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, hashes, []common.Hash{root})
+
+	assert.Equal(t, sync.Process([][]byte{[]byte("invalid")}), errTrieNodeNotRequested)
+}
+
+func Test_Prune_PartialRoot(t *testing.T) {
+	srcDB, srcDispose := leveldb.NewTestDatabase()
+	defer srcDispose()
+
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	root := newTestOriginStatedb(srcDB, newTestSyncAccounts(20)...).root
+
+	// retrieve the trie nodes partially
+	sync := NewTrieSync(root, db)
+	for i := 0; i < 2; i++ {
+		hashes, err := sync.Missing(4)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, sync.Process(retrieveTestTrieNodes(srcDB, hashes, len(hashes))), nil)
+	}
+
+	assert.Equal(t, sync.Done(), false)
+
+	removed, err := Prune(db, nil, []common.Hash{root})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, removed, 0)
+
+	removed, err = Prune(db, nil, nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, removed > 0, true)
+}
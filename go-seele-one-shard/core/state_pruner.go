@@ -0,0 +1,239 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/state"
+	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/database"
+	"github.com/seeleteam/go-seele/log"
+	"github.com/syndtr/goleveldb/leveldb/errors"
+)
+
+const (
+	// DefaultStateRetention is the default number of recent blocks whose state is kept for each chain.
+	DefaultStateRetention uint64 = 1024
+
+	// pruneCheckInterval is the interval to check whether the account state DB should be pruned.
+	pruneCheckInterval = 10 * time.Minute
+)
+
+// StatePruner prunes the account state DB shared by all chains with mark and sweep, so that
+// only the state of the recent blocks of each chain is kept.
+type StatePruner struct {
+	accountStateDB database.Database
+	chains         []*Blockchain
+	retention      uint64
+
+	lastHeights []uint64      // HEAD block heights of chains when last pruned
+	lastRoots   []common.Hash // state roots kept by the last pruning
+	quit        chan struct{}
+	wg          sync.WaitGroup
+	log         *log.SeeleLog
+}
+
+// NewStatePruner returns a state pruner that keeps the state of the specified number of
+// recent blocks for each chain, and DefaultStateRetention is used if retention is 0.
+func NewStatePruner(accountStateDB database.Database, chains []*Blockchain, retention uint64) *StatePruner {
+	if retention == 0 {
+		retention = DefaultStateRetention
+	}
+
+	return &StatePruner{
+		accountStateDB: accountStateDB,
+		chains:         chains,
+		retention:      retention,
+		lastHeights:    make([]uint64, len(chains)),
+		quit:           make(chan struct{}),
+		log:            log.GetLogger("pruner"),
+	}
+}
+
+// Start starts to prune the account state DB periodically.
+func (p *StatePruner) Start() {
+	p.wg.Add(1)
+	go p.loop()
+}
+
+// Stop stops pruning and waits for the running pruning to finish.
+func (p *StatePruner) Stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+func (p *StatePruner) loop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(pruneCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !p.needPrune() {
+				continue
+			}
+
+			start := time.Now()
+			removed, err := p.Prune()
+			if err != nil {
+				p.log.Error("failed to prune account state DB, %s", err)
+				continue
+			}
+
+			p.log.Info("account state DB pruned, removed nodes = %d, elapsed = %s", removed, time.Since(start))
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// needPrune returns true if any chain has grown by the number of retention blocks since last pruned.
+func (p *StatePruner) needPrune() bool {
+	result := false
+	for i, bc := range p.chains {
+		height := bc.CurrentBlock().Header.Height
+		if height >= p.lastHeights[i]+p.retention {
+			result = true
+		}
+	}
+
+	if result {
+		for i, bc := range p.chains {
+			p.lastHeights[i] = bc.CurrentBlock().Header.Height
+		}
+	}
+
+	return result
+}
+
+// Prune removes the state of the blocks that older than the number of retention blocks behind
+// the HEAD block of each chain, and returns the number of removed trie nodes. The state kept by
+// the last pruning is kept again, so that the readers of an old state have a whole pruning interval
+// to finish reading. Since the account state DB is shared by all chains, writing blocks to any chain
+// is only blocked when the state of the blocks written during marking is marked and nodes are swept.
+func (p *StatePruner) Prune() (int, error) {
+	p.lockChains()
+	roots, partialRoots, err := p.stateRoots()
+	pruner := state.NewPruner(p.accountStateDB)
+	p.unlockChains()
+
+	defer pruner.Release()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err = pruner.Mark(append(roots, p.lastRoots...), partialRoots); err != nil {
+		return 0, err
+	}
+
+	candidates, err := pruner.Candidates()
+	if err != nil {
+		return 0, err
+	}
+
+	p.lockChains()
+	defer p.unlockChains()
+
+	// the nodes of the blocks written during marking may be the candidates
+	newRoots, newPartialRoots, err := p.stateRoots()
+	if err != nil {
+		return 0, err
+	}
+
+	if err = pruner.Mark(newRoots, newPartialRoots); err != nil {
+		return 0, err
+	}
+
+	removed, err := pruner.Sweep(candidates)
+	if err != nil {
+		return removed, err
+	}
+
+	p.lastRoots = append(roots, newRoots...)
+
+	return removed, nil
+}
+
+// stateRoots returns the state roots of the recent blocks and forked blocks of all chains,
+// and the roots of the state being synchronized. The chains should be locked.
+func (p *StatePruner) stateRoots() ([]common.Hash, []common.Hash, error) {
+	var roots, partialRoots []common.Hash
+	for _, bc := range p.chains {
+		chainRoots, err := RecentStateRoots(bc.bcStore, p.retention)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		roots = append(roots, chainRoots...)
+
+		// forked blocks may be extended later
+		for item := range bc.blockLeaves.blockIndexMap.IterBuffered() {
+			roots = append(roots, item.Val.(*BlockIndex).currentBlock.Header.StateHash)
+		}
+
+		if !bc.syncRoot.IsEmpty() {
+			partialRoots = append(partialRoots, bc.syncRoot)
+		}
+	}
+
+	return roots, partialRoots, nil
+}
+
+func (p *StatePruner) lockChains() {
+	for _, bc := range p.chains {
+		bc.lock.Lock()
+	}
+}
+
+func (p *StatePruner) unlockChains() {
+	for i := len(p.chains) - 1; i >= 0; i-- {
+		p.chains[i].lock.Unlock()
+	}
+}
+
+// RecentStateRoots returns the state root hashes of the specified number of recent blocks
+// in the canonical chain of the specified blockchain store.
+func RecentStateRoots(bcStore store.BlockchainStore, retention uint64) ([]common.Hash, error) {
+	headHash, err := bcStore.GetHeadBlockHash()
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := bcStore.GetBlockHeader(headHash)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := []common.Hash{head.StateHash}
+	for height := head.Height; height > 0 && uint64(len(roots)) < retention; {
+		height--
+
+		// the blocks before the HEAD block of state snapshot are not stored.
+		hash, err := bcStore.GetBlockHash(height)
+		if err == errors.ErrNotFound {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		header, err := bcStore.GetBlockHeader(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		roots = append(roots, header.StateHash)
+	}
+
+	return roots, nil
+}
@@ -0,0 +1,168 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/state"
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/database/leveldb"
+)
+
+func Test_StatePruner_Prune(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	// genesis <- block1 <- block2 <- block3 (canonical)
+	//                   <- block4 (forked)
+	block1 := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block1), error(nil))
+
+	block2 := newTestBlock(bc, block1.HeaderHash, 2, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block2), error(nil))
+
+	block3 := newTestBlock(bc, block2.HeaderHash, 3, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block3), error(nil))
+
+	block4 := newTestBlock(bc, block1.HeaderHash, 2, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block4), error(nil))
+	assert.Equal(t, bc.CurrentBlock().HeaderHash, block3.HeaderHash)
+
+	pruner := NewStatePruner(db, []*Blockchain{bc}, 2)
+	removed, err := pruner.Prune()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, removed > 0, true)
+
+	_, err = bc.GetStateByBlockHash(bc.genesisBlock.HeaderHash)
+	assert.Equal(t, err != nil, true)
+
+	_, err = bc.GetStateByBlockHash(block1.HeaderHash)
+	assert.Equal(t, err != nil, true)
+
+	_, err = bc.GetStateByBlockHash(block2.HeaderHash)
+	assert.Equal(t, err, error(nil))
+
+	_, err = bc.GetStateByBlockHash(block3.HeaderHash)
+	assert.Equal(t, err, error(nil))
+
+	_, err = bc.GetStateByBlockHash(block4.HeaderHash)
+	assert.Equal(t, err, error(nil))
+
+	// the HEAD block could be extended after pruned
+	block5 := newTestBlock(bc, block3.HeaderHash, 4, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block5), error(nil))
+}
+
+func Test_StatePruner_KeepLastRoots(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	block1 := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block1), error(nil))
+
+	pruner := NewStatePruner(db, []*Blockchain{bc}, 1)
+	_, err := pruner.Prune()
+	assert.Equal(t, err, error(nil))
+
+	block2 := newTestBlock(bc, block1.HeaderHash, 2, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block2), error(nil))
+
+	block3 := newTestBlock(bc, block2.HeaderHash, 3, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block3), error(nil))
+
+	// the state kept by the last pruning is kept again
+	_, err = pruner.Prune()
+	assert.Equal(t, err, error(nil))
+
+	_, err = bc.GetStateByBlockHash(block1.HeaderHash)
+	assert.Equal(t, err, error(nil))
+
+	_, err = bc.GetStateByBlockHash(block2.HeaderHash)
+	assert.Equal(t, err != nil, true)
+
+	// removed by the next pruning
+	_, err = pruner.Prune()
+	assert.Equal(t, err, error(nil))
+
+	_, err = bc.GetStateByBlockHash(block1.HeaderHash)
+	assert.Equal(t, err != nil, true)
+
+	_, err = bc.GetStateByBlockHash(block3.HeaderHash)
+	assert.Equal(t, err, error(nil))
+}
+
+func Test_StatePruner_SyncRoot(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	// the state being synchronized from peers
+	statedb, err := state.NewStatedb(bc.genesisBlock.Header.StateHash, db)
+	assert.Equal(t, err, error(nil))
+	addr := *crypto.MustGenerateRandomAddress()
+	statedb.CreateAccount(addr)
+	statedb.SetBalance(addr, big.NewInt(100))
+
+	batch := db.NewBatch()
+	root, err := statedb.Commit(batch)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, batch.Commit(), error(nil))
+
+	_, err = bc.StartStateSync(root)
+	assert.Equal(t, err, error(nil))
+
+	// the sync root is kept after restart
+	bc, err = NewBlockchain(bc.bcStore, db, "", 0, common.DefaultNumOfChains, testNetworkID)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, bc.syncRoot, root)
+
+	pruner := NewStatePruner(db, []*Blockchain{bc}, 1)
+	removed, err := pruner.Prune()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, removed, 0)
+	assert.Equal(t, state.WalkTrieNodes(db, []common.Hash{root}, nil), error(nil))
+
+	// removed once the sync is finished
+	bc.syncRoot = common.EmptyHash
+	removed, err = pruner.Prune()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, removed > 0, true)
+}
+
+func Test_StatePruner_DefaultRetention(t *testing.T) {
+	pruner := NewStatePruner(nil, nil, 0)
+	assert.Equal(t, pruner.retention, DefaultStateRetention)
+}
+
+func Test_RecentStateRoots(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+
+	bc := newTestBlockchain(db)
+
+	block1 := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block1), error(nil))
+
+	roots, err := RecentStateRoots(bc.bcStore, 1)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, len(roots), 1)
+	assert.Equal(t, roots[0], block1.Header.StateHash)
+
+	// genesis block is the first block
+	roots, err = RecentStateRoots(bc.bcStore, 10)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, len(roots), 2)
+	assert.Equal(t, roots[1], bc.genesisBlock.Header.StateHash)
+}
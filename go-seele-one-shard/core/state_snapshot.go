@@ -0,0 +1,289 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"bufio"
+	"errors"
+	"io"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/state"
+	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/database"
+	"github.com/seeleteam/go-seele/event"
+	"github.com/seeleteam/go-seele/miner/pow"
+)
+
+const (
+	stateSnapshotVersion uint = 2
+
+	// snapshotBatchSize is the max number of trie nodes written in a batch when importing state snapshot.
+	snapshotBatchSize = 10000
+)
+
+var (
+	// ErrBlockchainNotEmpty is returned when setting the HEAD block of state snapshot to
+	// a blockchain that has blocks other than the genesis block.
+	ErrBlockchainNotEmpty = errors.New("blockchain has blocks other than genesis block")
+
+	// ErrSnapshotVersionUnsupported is returned when the state snapshot version is not supported.
+	ErrSnapshotVersionUnsupported = errors.New("unsupported state snapshot version")
+
+	// ErrSnapshotGenesisMismatch is returned when the genesis block of state snapshot mismatch.
+	ErrSnapshotGenesisMismatch = errors.New("state snapshot genesis block mismatch")
+
+	// ErrSnapshotChainsMismatch is returned when the number of chains in state snapshot mismatch.
+	ErrSnapshotChainsMismatch = errors.New("state snapshot number of chains mismatch")
+)
+
+// stateSnapshotHeader is the first item in the RLP stream of state snapshot, and followed by
+// the ancestor headers of the HEAD block of each chain in descending order until the genesis
+// block, and then the trie nodes of the state of the HEAD blocks.
+type stateSnapshotHeader struct {
+	Version     uint
+	GenesisHash common.Hash
+	Blocks      []*types.Block // HEAD block of each chain
+}
+
+type stateSnapshotNode struct {
+	Hash  common.Hash
+	Value []byte
+}
+
+// ExportStateSnapshot writes the HEAD blocks of the specified chains with their header chains and
+// account state into w, and returns the number of exported trie nodes. Note, the chains synchronized
+// from state snapshot could not be exported, since the blocks before the HEAD block are not stored.
+func ExportStateSnapshot(accountStateDB database.Database, bcStores []store.BlockchainStore, w io.Writer) (int, error) {
+	header := stateSnapshotHeader{Version: stateSnapshotVersion}
+	var roots []common.Hash
+
+	for _, bcStore := range bcStores {
+		genesisHash, err := bcStore.GetBlockHash(genesisBlockHeight)
+		if err != nil {
+			return 0, err
+		}
+
+		headHash, err := bcStore.GetHeadBlockHash()
+		if err != nil {
+			return 0, err
+		}
+
+		block, err := bcStore.GetBlock(headHash)
+		if err != nil {
+			return 0, err
+		}
+
+		header.GenesisHash = genesisHash
+		header.Blocks = append(header.Blocks, block)
+		roots = append(roots, block.Header.StateHash)
+	}
+
+	writer := bufio.NewWriter(w)
+	if err := rlp.Encode(writer, &header); err != nil {
+		return 0, err
+	}
+
+	for i, bcStore := range bcStores {
+		for parent := header.Blocks[i].Header; parent.Height > genesisBlockHeight; {
+			var err error
+			if parent, err = bcStore.GetBlockHeader(parent.PreviousBlockHash); err != nil {
+				return 0, err
+			}
+
+			if err = rlp.Encode(writer, parent); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	count := 0
+	err := state.WalkTrieNodes(accountStateDB, roots, func(hash common.Hash, value []byte) error {
+		count++
+		return rlp.Encode(writer, &stateSnapshotNode{hash, value})
+	})
+
+	if err != nil {
+		return count, err
+	}
+
+	return count, writer.Flush()
+}
+
+// ImportStateSnapshot reads the state snapshot from r, and then sets the HEAD block of each
+// chain, which should only have the genesis block. The HEAD block of each chain must match with
+// its trusted checkpoint, and the header chain is verified back to the genesis block to compute
+// the total difficulty. Every trie node is verified against its hash, and the state of every HEAD
+// block is validated to be complete. Returns the number of imported trie nodes.
+func ImportStateSnapshot(accountStateDB database.Database, chains []*Blockchain, r io.Reader) (int, error) {
+	stream := rlp.NewStream(bufio.NewReader(r), 0)
+
+	var header stateSnapshotHeader
+	if err := stream.Decode(&header); err != nil {
+		return 0, err
+	}
+
+	if header.Version != stateSnapshotVersion {
+		return 0, ErrSnapshotVersionUnsupported
+	}
+
+	if len(header.Blocks) != len(chains) {
+		return 0, ErrSnapshotChainsMismatch
+	}
+
+	for _, bc := range chains {
+		if bc.CurrentBlock().Header.Height != genesisBlockHeight {
+			return 0, ErrBlockchainNotEmpty
+		}
+
+		if !bc.genesisBlock.HeaderHash.Equal(header.GenesisHash) {
+			return 0, ErrSnapshotGenesisMismatch
+		}
+	}
+
+	verifiers := make([]*HeaderChainVerifier, len(chains))
+	for i, bc := range chains {
+		verifier, err := bc.NewHeaderChainVerifier(header.Blocks[i].Header)
+		if err != nil {
+			return 0, err
+		}
+
+		for !verifier.Done() {
+			var parent types.BlockHeader
+			if err = stream.Decode(&parent); err != nil {
+				return 0, err
+			}
+
+			if err = verifier.Verify(&parent); err != nil {
+				return 0, err
+			}
+		}
+
+		verifiers[i] = verifier
+	}
+
+	count, pending := 0, 0
+	batch := accountStateDB.NewBatch()
+
+	for {
+		var node stateSnapshotNode
+		if err := stream.Decode(&node); err == io.EOF {
+			break
+		} else if err != nil {
+			return count, err
+		}
+
+		if _, err := state.PutTrieNode(batch, node.Hash, node.Value); err != nil {
+			return count, err
+		}
+
+		count++
+		pending++
+
+		if pending == snapshotBatchSize {
+			if err := batch.Commit(); err != nil {
+				return count, err
+			}
+
+			batch, pending = accountStateDB.NewBatch(), 0
+		}
+	}
+
+	if err := batch.Commit(); err != nil {
+		return count, err
+	}
+
+	for i, bc := range chains {
+		if err := bc.SetSnapshotHead(header.Blocks[i], verifiers[i]); err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+// StartStateSync returns a trie sync to retrieve the state of the specified root hash from
+// peers, which is only allowed if the blockchain only has the genesis block. The retrieved
+// trie nodes will not be pruned before the sync is finished by SetSnapshotHead.
+func (bc *Blockchain) StartStateSync(root common.Hash) (*state.TrieSync, error) {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	if bc.blockLeaves.GetBestBlock().Header.Height != genesisBlockHeight {
+		return nil, ErrBlockchainNotEmpty
+	}
+
+	// the sync root is recorded for the offline pruner and restart
+	if err := bc.bcStore.PutSyncRoot(root); err != nil {
+		return nil, err
+	}
+
+	bc.syncRoot = root
+
+	return state.NewTrieSync(root, bc.accountStateDB), nil
+}
+
+// SetSnapshotHead sets the specified block as the HEAD block, whose state is imported from a
+// state snapshot, so that only the blocks after it need to be synchronized. It's only allowed
+// if the blockchain only has the genesis block, and the block matches with the trusted checkpoint.
+// The verifier should have verified the header chain of the block back to the genesis block.
+// Note, the blocks before the HEAD block are not stored, so the history they contain, e.g. the
+// historical txs in mining data packs and the source blocks of debts, is skipped by the validators.
+func (bc *Blockchain) SetSnapshotHead(block *types.Block, verifier *HeaderChainVerifier) error {
+	// the history of the HEAD block is trusted along with the checkpoint
+	if err := bc.validateBlock(block); err != nil && err != pow.ErrMiningDataHistoryUnknown {
+		return err
+	}
+
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	if bc.blockLeaves.GetBestBlock().Header.Height != genesisBlockHeight {
+		return ErrBlockchainNotEmpty
+	}
+
+	if err := bc.validateCheckpoint(block.Header); err != nil {
+		return err
+	}
+
+	if !verifier.Done() || !verifier.head.Equal(block.HeaderHash) {
+		return ErrHeaderChainIncomplete
+	}
+
+	td := verifier.td
+
+	// the state must be complete
+	if err := state.WalkTrieNodes(bc.accountStateDB, []common.Hash{block.Header.StateHash}, nil); err != nil {
+		return err
+	}
+
+	statedb, err := state.NewStatedb(block.Header.StateHash, bc.accountStateDB)
+	if err != nil {
+		return err
+	}
+
+	if err = bc.bcStore.PutBlock(block, td, true); err != nil {
+		return err
+	}
+
+	if err = bc.bcStore.PutSyncRoot(common.EmptyHash); err != nil {
+		return err
+	}
+
+	bc.blockLeaves = NewBlockLeaves()
+	bc.blockLeaves.Add(NewBlockIndex(statedb, block, td))
+	bc.syncRoot = common.EmptyHash
+
+	bc.log.Info("HEAD block set from state snapshot, height = %d, hash = %s", block.Header.Height, block.HeaderHash.ToHex())
+	event.ChainHeaderChangedEventMananger.Fire(event.ChainHeaderChangedMsg{
+		HeaderHash: block.HeaderHash,
+		ChainNum:   bc.chainNum,
+	})
+
+	return nil
+}
@@ -0,0 +1,242 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/seeleteam/go-seele/miner/pow"
+)
+
+func newTestSnapshot(t *testing.T) ([]byte, *Blockchain, func()) {
+	db, dispose := leveldb.NewTestDatabase()
+
+	bc := newTestBlockchain(db)
+	parentHash := bc.genesisBlock.HeaderHash
+	for height := uint64(1); height <= 3; height++ {
+		block := newTestBlock(bc, parentHash, height, 3*height, 0)
+		assert.Equal(t, bc.WriteBlock(block), error(nil))
+		parentHash = block.HeaderHash
+	}
+
+	var buff bytes.Buffer
+	count, err := ExportStateSnapshot(db, []store.BlockchainStore{bc.bcStore}, &buff)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, count > 0, true)
+
+	return buff.Bytes(), bc, dispose
+}
+
+func newTestCheckpoint(bc *Blockchain) *Checkpoint {
+	head := bc.CurrentBlock()
+	return &Checkpoint{Height: head.Header.Height, Hash: head.HeaderHash}
+}
+
+func Test_StateSnapshot_ExportImport(t *testing.T) {
+	snapshot, srcBC, dispose := newTestSnapshot(t)
+	defer dispose()
+
+	db, dispose2 := leveldb.NewTestDatabase()
+	defer dispose2()
+
+	bc := newTestBlockchain(db)
+	bc.SetCheckpoint(newTestCheckpoint(srcBC))
+	count, err := ImportStateSnapshot(db, []*Blockchain{bc}, bytes.NewReader(snapshot))
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, count > 0, true)
+
+	head := bc.CurrentBlock()
+	assert.Equal(t, head.HeaderHash, srcBC.CurrentBlock().HeaderHash)
+
+	td, err := bc.bcStore.GetBlockTotalDifficulty(head.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	srcTD, _ := srcBC.bcStore.GetBlockTotalDifficulty(head.HeaderHash)
+	assert.Equal(t, td, srcTD)
+
+	_, err = bc.GetStateByBlockHash(head.HeaderHash)
+	assert.Equal(t, err, error(nil))
+
+	// the blocks before the HEAD block are not stored
+	roots, err := RecentStateRoots(bc.bcStore, 10)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, roots, []common.Hash{head.Header.StateHash})
+
+	// only blocks after the HEAD block need to be synchronized
+	block := newTestBlock(bc, head.HeaderHash, head.Header.Height+1, 0, 0)
+	assert.Equal(t, bc.WriteBlock(block), error(nil))
+	assert.Equal(t, bc.CurrentBlock().HeaderHash, block.HeaderHash)
+
+	// not allowed once the chain has blocks
+	_, err = ImportStateSnapshot(db, []*Blockchain{bc}, bytes.NewReader(snapshot))
+	assert.Equal(t, err, ErrBlockchainNotEmpty)
+}
+
+func Test_StateSnapshot_History(t *testing.T) {
+	snapshot, srcBC, dispose := newTestSnapshot(t)
+	defer dispose()
+
+	db, dispose2 := leveldb.NewTestDatabase()
+	defer dispose2()
+
+	bc := newTestBlockchain(db)
+	bc.SetChains([]*Blockchain{bc, bc, bc})
+	bc.SetCheckpoint(newTestCheckpoint(srcBC))
+	_, err := ImportStateSnapshot(db, []*Blockchain{bc}, bytes.NewReader(snapshot))
+	assert.Equal(t, err, error(nil))
+
+	// mining data pack refers to a tx before the HEAD block of state snapshot
+	defer func(difficulty *big.Int) { pow.MiningKeyDifficulty = difficulty }(pow.MiningKeyDifficulty)
+	pow.MiningKeyDifficulty = big.NewInt(1)
+
+	head := bc.CurrentBlock()
+	block := newTestBlock(bc, head.HeaderHash, head.Header.Height+1, 0, 0)
+	block.Header.MiningData.Heights[bc.chainNum] = 1
+	block.Header.MiningData.TxHashes[bc.chainNum] = common.StringToHash("tx")
+	for pow.ValidateMiningData(block.Header.Creator, &block.Header.MiningData, bc.chainNum, bc.numOfChains) != nil {
+		block.Header.MiningData.Nonce++
+	}
+	block.HeaderHash = block.Header.Hash()
+
+	// the history is validated if stored
+	srcBC.SetChains([]*Blockchain{srcBC, srcBC, srcBC})
+	assert.Equal(t, srcBC.WriteBlock(block) != nil, true)
+
+	// the history not stored is skipped
+	assert.Equal(t, bc.WriteBlock(block), error(nil))
+	assert.Equal(t, bc.CurrentBlock().HeaderHash, block.HeaderHash)
+}
+
+func Test_StateSnapshot_Checkpoint(t *testing.T) {
+	snapshot, srcBC, dispose := newTestSnapshot(t)
+	defer dispose()
+
+	db, dispose2 := leveldb.NewTestDatabase()
+	defer dispose2()
+
+	bc := newTestBlockchain(db)
+
+	_, err := ImportStateSnapshot(db, []*Blockchain{bc}, bytes.NewReader(snapshot))
+	assert.Equal(t, err, ErrCheckpointRequired)
+
+	cp := newTestCheckpoint(srcBC)
+	cp.Height--
+	bc.SetCheckpoint(cp)
+	_, err = ImportStateSnapshot(db, []*Blockchain{bc}, bytes.NewReader(snapshot))
+	assert.Equal(t, err, ErrCheckpointMismatch)
+
+	cp = newTestCheckpoint(srcBC)
+	cp.Hash = srcBC.genesisBlock.HeaderHash
+	bc.SetCheckpoint(cp)
+	_, err = ImportStateSnapshot(db, []*Blockchain{bc}, bytes.NewReader(snapshot))
+	assert.Equal(t, err, ErrCheckpointMismatch)
+	assert.Equal(t, bc.CurrentBlock().Header.Height, uint64(0))
+}
+
+func Test_HeaderChainVerifier(t *testing.T) {
+	_, srcBC, dispose := newTestSnapshot(t)
+	defer dispose()
+
+	db, dispose2 := leveldb.NewTestDatabase()
+	defer dispose2()
+
+	bc := newTestBlockchain(db)
+	bc.SetCheckpoint(newTestCheckpoint(srcBC))
+
+	head := srcBC.CurrentBlock()
+	verifier, err := bc.NewHeaderChainVerifier(head.Header)
+	assert.Equal(t, err, error(nil))
+
+	// the head block is not verified yet
+	assert.Equal(t, bc.SetSnapshotHead(head, verifier), ErrHeaderChainIncomplete)
+
+	block2, err := srcBC.bcStore.GetBlockByHeight(2)
+	assert.Equal(t, err, error(nil))
+	block1, err := srcBC.bcStore.GetBlockByHeight(1)
+	assert.Equal(t, err, error(nil))
+
+	// not the parent
+	assert.Equal(t, verifier.Verify(block1.Header), ErrBlockInvalidParentHash)
+
+	// tampered difficulty
+	tampered := block2.Header.Clone()
+	tampered.Difficulty = new(big.Int).Add(block2.Header.Difficulty, big.NewInt(1))
+	assert.Equal(t, verifier.Verify(tampered), ErrBlockInvalidParentHash)
+
+	assert.Equal(t, verifier.Next(), uint64(2))
+	assert.Equal(t, verifier.Verify(block2.Header), error(nil))
+	assert.Equal(t, verifier.Verify(block1.Header), error(nil))
+	assert.Equal(t, verifier.Done(), false)
+	assert.Equal(t, verifier.Verify(srcBC.genesisBlock.Header), error(nil))
+	assert.Equal(t, verifier.Done(), true)
+	assert.Equal(t, verifier.Verify(srcBC.genesisBlock.Header), errHeaderChainVerified)
+
+	srcTD, _ := srcBC.bcStore.GetBlockTotalDifficulty(head.HeaderHash)
+	assert.Equal(t, verifier.td, srcTD)
+}
+
+func Test_overwriteStaleBlocks_MissingParent(t *testing.T) {
+	snapshot, srcBC, dispose := newTestSnapshot(t)
+	defer dispose()
+
+	db, dispose2 := leveldb.NewTestDatabase()
+	defer dispose2()
+
+	bc := newTestBlockchain(db)
+	bc.SetCheckpoint(newTestCheckpoint(srcBC))
+	_, err := ImportStateSnapshot(db, []*Blockchain{bc}, bytes.NewReader(snapshot))
+	assert.Equal(t, err, error(nil))
+
+	// forked block of the HEAD block, whose parent is not stored
+	block2, err := srcBC.bcStore.GetBlockByHeight(2)
+	assert.Equal(t, err, error(nil))
+	forked := newTestBlock(srcBC, block2.HeaderHash, 3, 100, 0)
+	assert.Equal(t, bc.bcStore.PutBlock(forked, big.NewInt(1), false), error(nil))
+
+	assert.Equal(t, overwriteStaleBlocks(bc.bcStore, forked.HeaderHash, nil), error(nil))
+
+	hash, err := bc.bcStore.GetBlockHash(3)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, hash, forked.HeaderHash)
+}
+
+func Test_StateSnapshot_Mismatch(t *testing.T) {
+	snapshot, _, dispose := newTestSnapshot(t)
+	defer dispose()
+
+	db, dispose2 := leveldb.NewTestDatabase()
+	defer dispose2()
+
+	bc := newTestBlockchain(db)
+
+	_, err := ImportStateSnapshot(db, []*Blockchain{bc, bc}, bytes.NewReader(snapshot))
+	assert.Equal(t, err, ErrSnapshotChainsMismatch)
+
+	bc.genesisBlock = newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 0)
+	_, err = ImportStateSnapshot(db, []*Blockchain{bc}, bytes.NewReader(snapshot))
+	assert.Equal(t, err, ErrSnapshotGenesisMismatch)
+}
+
+func Test_StateSnapshot_IncompleteState(t *testing.T) {
+	snapshot, srcBC, dispose := newTestSnapshot(t)
+	defer dispose()
+
+	db, dispose2 := leveldb.NewTestDatabase()
+	defer dispose2()
+
+	bc := newTestBlockchain(db)
+	bc.SetCheckpoint(newTestCheckpoint(srcBC))
+
+	// truncate the trie nodes of state
+	_, err := ImportStateSnapshot(db, []*Blockchain{bc}, bytes.NewReader(snapshot[:len(snapshot)/2]))
+	assert.Equal(t, err != nil, true)
+	assert.Equal(t, bc.CurrentBlock().Header.Height, uint64(0))
+}
@@ -173,12 +173,8 @@ func (store *cachedStore) DeleteBlock(hash common.Hash) error {
 		return err
 	}
 
-	canonicalHash, err := store.GetBlockHash(header.Height)
-	if err != nil {
-		return err
-	}
-
-	if canonicalHash.Equal(hash) {
+	// the height-to-hash mapping may be already deleted, e.g. when rewinding the blockchain.
+	if canonicalHash, err := store.GetBlockHash(header.Height); err == nil && canonicalHash.Equal(hash) {
 		store.hashCache.Remove(header.Height)
 	}
 
@@ -190,6 +186,22 @@ func (store *cachedStore) DeleteBlock(hash common.Hash) error {
 	return store.raw.DeleteBlock(hash)
 }
 
+// RewindBlocks writes the specified blocks in ascending order into the canonical chain with the
+// last one as the HEAD block, deletes the canonical height-to-hash mappings of larger heights,
+// and deletes the blocks of the specified hashes along with their receipts and indices in a batch.
+func (store *cachedStore) RewindBlocks(canonical []*types.Block, deleted []common.Hash) error {
+	// the canonical height-to-hash mappings of any height may be changed.
+	store.hashCache.Purge()
+
+	for _, hash := range deleted {
+		store.headerCache.Remove(hash)
+		store.tdCache.Remove(hash)
+		store.blockCache.Remove(hash)
+	}
+
+	return store.raw.RewindBlocks(canonical, deleted)
+}
+
 // GetBlockByHeight retrieves the block for the specified block height.
 func (store *cachedStore) GetBlockByHeight(height uint64) (*types.Block, error) {
 	return store.raw.GetBlockByHeight(height)
@@ -220,3 +232,28 @@ func (store *cachedStore) GetDebtIndex(txHash common.Hash) (*types.DebtIndex, er
 	return store.raw.GetDebtIndex(txHash)
 }
 
+
+// PutDebtRecord writes the debt record of debt pool into the store.
+func (store *cachedStore) PutDebtRecord(record *types.DebtRecord) error {
+	return store.raw.PutDebtRecord(record)
+}
+
+// DeleteDebtRecord deletes the debt record of the specified debt hash in the store.
+func (store *cachedStore) DeleteDebtRecord(debtHash common.Hash) error {
+	return store.raw.DeleteDebtRecord(debtHash)
+}
+
+// GetDebtRecords retrieves the debt records of debt pool.
+func (store *cachedStore) GetDebtRecords() ([]*types.DebtRecord, error) {
+	return store.raw.GetDebtRecords()
+}
+
+// PutSyncRoot writes the root hash of the state being synchronized, or deletes it if the root is empty.
+func (store *cachedStore) PutSyncRoot(root common.Hash) error {
+	return store.raw.PutSyncRoot(root)
+}
+
+// GetSyncRoot retrieves the root hash of the state being synchronized.
+func (store *cachedStore) GetSyncRoot() (common.Hash, error) {
+	return store.raw.GetSyncRoot()
+}
@@ -131,7 +131,7 @@ func Test_cachedStore_PutBlock(t *testing.T) {
 	store := NewMemStore()
 	cachedStore := NewCachedStore(store)
 
-	block := types.NewBlock(newTestBlockHeader(), []*types.Transaction{newTestTx()}, []*types.Receipt{&types.Receipt{}}, nil)
+	block := types.NewBlock(newTestBlockHeader(), []*types.Transaction{newTestTx()}, []*types.Receipt{&types.Receipt{}}, nil, 0, common.DefaultNumOfChains)
 	err := cachedStore.PutBlock(block, big.NewInt(38), true)
 	assert.Equal(t, err, nil)
 
@@ -152,7 +152,7 @@ func Test_cachedStore_PutBlock(t *testing.T) {
 
 func Test_cachedStore_GutBlock(t *testing.T) {
 	store := NewMemStore()
-	block := types.NewBlock(newTestBlockHeader(), []*types.Transaction{newTestTx()}, []*types.Receipt{&types.Receipt{}}, nil)
+	block := types.NewBlock(newTestBlockHeader(), []*types.Transaction{newTestTx()}, []*types.Receipt{&types.Receipt{}}, nil, 0, common.DefaultNumOfChains)
 	store.PutBlock(block, big.NewInt(38), true)
 	cachedStore := NewCachedStore(store)
 
@@ -169,7 +169,7 @@ func Test_cachedStore_DeleteBlock(t *testing.T) {
 	store := NewMemStore()
 	cachedStore := NewCachedStore(store)
 
-	block := types.NewBlock(newTestBlockHeader(), []*types.Transaction{newTestTx()}, []*types.Receipt{&types.Receipt{}}, nil)
+	block := types.NewBlock(newTestBlockHeader(), []*types.Transaction{newTestTx()}, []*types.Receipt{&types.Receipt{}}, nil, 0, common.DefaultNumOfChains)
 	cachedStore.PutBlock(block, big.NewInt(38), true)
 
 	assert.Equal(t, cachedStore.DeleteBlock(block.HeaderHash), nil)
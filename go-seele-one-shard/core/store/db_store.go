@@ -17,20 +17,22 @@ import (
 
 var (
 	keyHeadBlockHash = []byte("HeadBlockHash")
-
-	keyPrefixHash      = []byte("H")
-	keyPrefixHeader    = []byte("h")
-	keyPrefixTD        = []byte("t")
-	keyPrefixBody      = []byte("b")
-	keyPrefixReceipts  = []byte("r")
-	keyPrefixTxIndex   = []byte("i")
-	keyPrefixDebtIndex = []byte("d")
+	keySyncRoot      = []byte("SyncRoot")
+
+	keyPrefixHash       = []byte("H")
+	keyPrefixHeader     = []byte("h")
+	keyPrefixTD         = []byte("t")
+	keyPrefixBody       = []byte("b")
+	keyPrefixReceipts   = []byte("r")
+	keyPrefixTxIndex    = []byte("i")
+	keyPrefixDebtIndex  = []byte("d")
+	keyPrefixDebtRecord = []byte("p")
 )
 
 // blockBody represents the payload of a block
 type blockBody struct {
 	Txs   []*types.Transaction // Txs is a transaction collection
-	debts []*types.Debt        // debts is a debt collection
+	Debts []*types.Debt        // Debts is a debt collection
 }
 
 // blockchainDatabase wraps a database used for the blockchain
@@ -47,6 +49,9 @@ type blockchainDatabase struct {
 //   5) keyPrefixBody + hash => block body (transactions)
 //   6) keyPrefixReceipts + hash => block receipts
 //   7) keyPrefixTxIndex + txHash => txIndex
+//   8) keyPrefixDebtIndex + debtHash => debtIndex
+//   9) keyPrefixDebtRecord + debtHash => debt record of debt pool
+//  10) keySyncRoot => root hash of the state being synchronized
 func NewBlockchainDatabase(db database.Database) BlockchainStore {
 	return &blockchainDatabase{db}
 }
@@ -58,6 +63,7 @@ func hashToBodyKey(hash []byte) []byte          { return append(keyPrefixBody, h
 func hashToReceiptsKey(hash []byte) []byte      { return append(keyPrefixReceipts, hash...) }
 func txHashToIndexKey(txHash []byte) []byte     { return append(keyPrefixTxIndex, txHash...) }
 func debtHashToIndexKey(debtHash []byte) []byte { return append(keyPrefixDebtIndex, debtHash...) }
+func debtHashToRecordKey(debtHash []byte) []byte { return append(keyPrefixDebtRecord, debtHash...) }
 
 // GetBlockHash gets the hash of the block with the specified height in the blockchain database
 func (store *blockchainDatabase) GetBlockHash(height uint64) (common.Hash, error) {
@@ -174,7 +180,7 @@ func (store *blockchainDatabase) putBlockInternal(hash common.Hash, header *type
 			batch.Put(txHashToIndexKey(tx.Hash.Bytes()), encodedTxIndex)
 		}
 
-		for i, d := range body.debts {
+		for i, d := range body.Debts {
 			idx := types.DebtIndex{BlockHash: hash, Index: uint(i)}
 			encodedDebtIndex := common.SerializePanic(idx)
 			batch.Put(debtHashToIndexKey(d.Hash.Bytes()), encodedDebtIndex)
@@ -249,15 +255,78 @@ func (store *blockchainDatabase) GetBlock(hash common.Hash) (*types.Block, error
 		HeaderHash:   hash,
 		Header:       header,
 		Transactions: body.Txs,
-		Debts:        body.debts,
+		Debts:        body.Debts,
 	}, nil
 }
 
 // DeleteBlock deletes the block of the specified block hash.
 func (store *blockchainDatabase) DeleteBlock(hash common.Hash) error {
-	hashBytes := hash.Bytes()
 	batch := store.db.NewBatch()
 
+	if err := store.deleteBlock(batch, hash); err != nil {
+		return err
+	}
+
+	return batch.Commit()
+}
+
+// RewindBlocks writes the specified blocks in ascending order into the canonical chain with the
+// last one as the HEAD block, deletes the canonical height-to-hash mappings of larger heights,
+// and deletes the blocks of the specified hashes along with their receipts and indices in a batch.
+func (store *blockchainDatabase) RewindBlocks(canonical []*types.Block, deleted []common.Hash) error {
+	if len(canonical) == 0 {
+		panic("canonical blocks is empty")
+	}
+
+	batch := store.db.NewBatch()
+
+	for _, hash := range deleted {
+		if err := store.deleteBlock(batch, hash); err != nil {
+			return err
+		}
+	}
+
+	// the tx and debt indices may be deleted along with the same txs and debts in deleted blocks.
+	for _, block := range canonical {
+		hashBytes := block.HeaderHash.Bytes()
+		batch.Put(heightToHashKey(block.Header.Height), hashBytes)
+
+		for i, tx := range block.Transactions {
+			idx := types.TxIndex{BlockHash: block.HeaderHash, Index: uint(i)}
+			batch.Put(txHashToIndexKey(tx.Hash.Bytes()), common.SerializePanic(idx))
+		}
+
+		for i, d := range block.Debts {
+			idx := types.DebtIndex{BlockHash: block.HeaderHash, Index: uint(i)}
+			batch.Put(debtHashToIndexKey(d.Hash.Bytes()), common.SerializePanic(idx))
+		}
+	}
+
+	head := canonical[len(canonical)-1]
+	batch.Put(keyHeadBlockHash, head.HeaderHash.Bytes())
+
+	for height := head.Header.Height + 1; ; height++ {
+		key := heightToHashKey(height)
+
+		found, err := store.db.Has(key)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			break
+		}
+
+		batch.Delete(key)
+	}
+
+	return batch.Commit()
+}
+
+// deleteBlock deletes the block of the specified block hash in the specified batch.
+func (store *blockchainDatabase) deleteBlock(batch database.Batch, hash common.Hash) error {
+	hashBytes := hash.Bytes()
+
 	// delete header, TD and receipts if any.
 	headerKey := hashToHeaderKey(hashBytes)
 	tdKey := hashToTDKey(hashBytes)
@@ -274,7 +343,7 @@ func (store *blockchainDatabase) DeleteBlock(hash common.Hash) error {
 	}
 
 	if !found {
-		return batch.Commit()
+		return nil
 	}
 
 	encodedBody, err := store.db.Get(bodyKey)
@@ -294,7 +363,7 @@ func (store *blockchainDatabase) DeleteBlock(hash common.Hash) error {
 		}
 	}
 
-	for _, d := range body.debts {
+	for _, d := range body.Debts {
 		if err = store.delete(batch, debtHashToIndexKey(d.Hash.Bytes())); err != nil {
 			return err
 		}
@@ -303,7 +372,7 @@ func (store *blockchainDatabase) DeleteBlock(hash common.Hash) error {
 	// delete body
 	batch.Delete(bodyKey)
 
-	return batch.Commit()
+	return nil
 }
 
 func (store *blockchainDatabase) delete(batch database.Batch, keys ...[]byte) error {
@@ -410,3 +479,62 @@ func (store *blockchainDatabase) GetTxIndex(txHash common.Hash) (*types.TxIndex,
 
  	return index, nil
  }
+
+// PutDebtRecord writes the debt record of debt pool into the blockchain database.
+func (store *blockchainDatabase) PutDebtRecord(record *types.DebtRecord) error {
+	encodedBytes, err := common.Serialize(record)
+	if err != nil {
+		return err
+	}
+
+	return store.db.Put(debtHashToRecordKey(record.Debt.Hash.Bytes()), encodedBytes)
+}
+
+// DeleteDebtRecord deletes the debt record of the specified debt hash in the blockchain database.
+func (store *blockchainDatabase) DeleteDebtRecord(debtHash common.Hash) error {
+	return store.db.Delete(debtHashToRecordKey(debtHash.Bytes()))
+}
+
+// GetDebtRecords retrieves the debt records of debt pool in the blockchain database.
+func (store *blockchainDatabase) GetDebtRecords() ([]*types.DebtRecord, error) {
+	records := make([]*types.DebtRecord, 0)
+
+	it := store.db.NewIterator(keyPrefixDebtRecord)
+	defer it.Release()
+
+	for it.Next() {
+		record := &types.DebtRecord{}
+		if err := common.Deserialize(it.Value(), record); err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, it.Error()
+}
+
+// PutSyncRoot writes the root hash of the state being synchronized into the blockchain database,
+// or deletes it if the root is empty.
+func (store *blockchainDatabase) PutSyncRoot(root common.Hash) error {
+	if root.IsEmpty() {
+		return store.db.Delete(keySyncRoot)
+	}
+
+	return store.db.Put(keySyncRoot, root.Bytes())
+}
+
+// GetSyncRoot retrieves the root hash of the state being synchronized in the blockchain database.
+func (store *blockchainDatabase) GetSyncRoot() (common.Hash, error) {
+	found, err := store.db.Has(keySyncRoot)
+	if err != nil || !found {
+		return common.EmptyHash, err
+	}
+
+	rootBytes, err := store.db.Get(keySyncRoot)
+	if err != nil {
+		return common.EmptyHash, err
+	}
+
+	return common.BytesToHash(rootBytes), nil
+}
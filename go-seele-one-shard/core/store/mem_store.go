@@ -28,8 +28,10 @@ type MemStore struct {
 	CanonicalBlocks map[uint64]common.Hash // height to block hash map in canonical chain
 	HeadBlockHash   common.Hash            // HEAD block hash
 	Blocks          map[common.Hash]*memBlock
-	TxLookups       map[common.Hash]types.TxIndex   // tx hash to index mapping
-	DebtLookups     map[common.Hash]types.DebtIndex // debt hash to index mapping
+	TxLookups       map[common.Hash]types.TxIndex     // tx hash to index mapping
+	DebtLookups     map[common.Hash]types.DebtIndex   // debt hash to index mapping
+	DebtRecords     map[common.Hash]*types.DebtRecord // debt records of debt pool
+	SyncRoot        common.Hash                       // root hash of the state being synchronized
 
 	CorruptOnPutBlock bool // used to test blockchain recovery if program crashed
 }
@@ -40,6 +42,7 @@ func NewMemStore() *MemStore {
 		Blocks:          make(map[common.Hash]*memBlock),
 		TxLookups:       make(map[common.Hash]types.TxIndex),
 		DebtLookups:     make(map[common.Hash]types.DebtIndex),
+		DebtRecords:     make(map[common.Hash]*types.DebtRecord),
 	}
 }
 
@@ -175,6 +178,37 @@ func (store *MemStore) DeleteBlock(hash common.Hash) error {
 	return nil
 }
 
+func (store *MemStore) RewindBlocks(canonical []*types.Block, deleted []common.Hash) error {
+	for _, hash := range deleted {
+		store.DeleteBlock(hash)
+	}
+
+	for _, block := range canonical {
+		store.CanonicalBlocks[block.Header.Height] = block.HeaderHash
+
+		for i, tx := range block.Transactions {
+			store.TxLookups[tx.Hash] = types.TxIndex{BlockHash: block.HeaderHash, Index: uint(i)}
+		}
+
+		for i, d := range block.Debts {
+			store.DebtLookups[d.Hash] = types.DebtIndex{BlockHash: block.HeaderHash, Index: uint(i)}
+		}
+	}
+
+	head := canonical[len(canonical)-1]
+	store.HeadBlockHash = head.HeaderHash
+
+	for height := head.Header.Height + 1; ; height++ {
+		if _, found := store.CanonicalBlocks[height]; !found {
+			break
+		}
+
+		delete(store.CanonicalBlocks, height)
+	}
+
+	return nil
+}
+
 func (store *MemStore) GetBlockByHeight(height uint64) (*types.Block, error) {
 	hash, err := store.GetBlockHash(height)
 	if err != nil {
@@ -240,3 +274,31 @@ func (store *MemStore) GetTxIndex(txHash common.Hash) (*types.TxIndex, error) {
 
  	return &debtIndex, nil
  }
+
+func (store *MemStore) PutDebtRecord(record *types.DebtRecord) error {
+	store.DebtRecords[record.Debt.Hash] = record
+	return nil
+}
+
+func (store *MemStore) DeleteDebtRecord(debtHash common.Hash) error {
+	delete(store.DebtRecords, debtHash)
+	return nil
+}
+
+func (store *MemStore) GetDebtRecords() ([]*types.DebtRecord, error) {
+	records := make([]*types.DebtRecord, 0, len(store.DebtRecords))
+	for _, r := range store.DebtRecords {
+		records = append(records, r)
+	}
+
+	return records, nil
+}
+
+func (store *MemStore) PutSyncRoot(root common.Hash) error {
+	store.SyncRoot = root
+	return nil
+}
+
+func (store *MemStore) GetSyncRoot() (common.Hash, error) {
+	return store.SyncRoot, nil
+}
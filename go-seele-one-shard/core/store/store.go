@@ -55,6 +55,11 @@ type BlockchainStore interface {
 	// GetBlockByHeight retrieves the block for the specified block height.
 	GetBlockByHeight(height uint64) (*types.Block, error)
 
+	// RewindBlocks writes the specified blocks in ascending order into the canonical chain with the
+	// last one as the HEAD block, deletes the canonical height-to-hash mappings of larger heights,
+	// and deletes the blocks of the specified hashes along with their receipts and indices in a batch.
+	RewindBlocks(canonical []*types.Block, deleted []common.Hash) error
+
 	// PutReceipts serializes given receipts for the specified block hash.
 	PutReceipts(hash common.Hash, receipts []*types.Receipt) error
 
@@ -69,4 +74,19 @@ type BlockchainStore interface {
 
 	// GetDebtIndex retrieves the debt index for the specified debt hash
 	GetDebtIndex(debtHash common.Hash) (*types.DebtIndex, error)
+
+	// PutDebtRecord writes the debt record of debt pool into the store, keyed by the debt hash.
+	PutDebtRecord(record *types.DebtRecord) error
+
+	// DeleteDebtRecord deletes the debt record of the specified debt hash in the store.
+	DeleteDebtRecord(debtHash common.Hash) error
+
+	// GetDebtRecords retrieves the debt records of debt pool. Returns empty records if not found.
+	GetDebtRecords() ([]*types.DebtRecord, error)
+
+	// PutSyncRoot writes the root hash of the state being synchronized, or deletes it if the root is empty.
+	PutSyncRoot(root common.Hash) error
+
+	// GetSyncRoot retrieves the root hash of the state being synchronized. Returns empty hash if not found.
+	GetSyncRoot() (common.Hash, error)
 }
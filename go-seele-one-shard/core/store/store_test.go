@@ -32,6 +32,10 @@ func newTestBlockHeader() *types.BlockHeader {
 		CreateTimestamp:   big.NewInt(1),
 		Nonce:             1,
 		ExtraData:         make([]byte, 0),
+		MiningData: types.MiningDataPack{
+			Heights:  make([]uint64, 0),
+			TxHashes: make([]common.Hash, 0),
+		},
 	}
 }
 
@@ -143,7 +147,12 @@ func newTestTx() *types.Transaction {
 }
 
 func newTestDebt() *types.Debt {
-	return types.NewDebt(newTestTx())
+	for {
+		// the random tx may be neither cross chain nor cross shard
+		if debt := types.NewDebt(newTestTx(), common.DefaultNumOfChains); debt != nil {
+			return debt
+		}
+	}
 }
 
 func Test_blockchainDatabase_Block(t *testing.T) {
@@ -152,6 +161,7 @@ func Test_blockchainDatabase_Block(t *testing.T) {
 		HeaderHash:   header.Hash(),
 		Header:       header,
 		Transactions: []*types.Transaction{newTestTx(), newTestTx(), newTestTx()},
+		Debts:        make([]*types.Debt, 0),
 	}
 
 	bcStore, dispose := newTestBlockchainDatabase()
@@ -281,3 +291,136 @@ func GetDebtIndexTest(t *testing.T, bcStore BlockchainStore) {
 	_, err = bcStore.GetTxIndex(debtNoExist.Hash)
 	assert.Equal(t, err != nil, true)
 }
+
+func Test_blockchainDatabase_BlockDebts(t *testing.T) {
+	header := newTestBlockHeader()
+	block := &types.Block{
+		HeaderHash:   header.Hash(),
+		Header:       header,
+		Transactions: []*types.Transaction{newTestTx()},
+		Debts:        []*types.Debt{newTestDebt(), newTestDebt()},
+	}
+
+	bcStore, dispose := newTestBlockchainDatabase()
+	defer dispose()
+
+	err := bcStore.PutBlock(block, header.Difficulty, true)
+	assert.Equal(t, err, error(nil))
+
+	storedBlock, err := bcStore.GetBlock(block.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, len(storedBlock.Debts), 2)
+	assert.Equal(t, storedBlock.Debts[0].Hash, block.Debts[0].Hash)
+	assert.Equal(t, storedBlock.Debts[1].Hash, block.Debts[1].Hash)
+}
+
+func Test_blockchainDatabase_DebtRecords(t *testing.T) {
+	bcStore, dispose := newTestBlockchainDatabase()
+	defer dispose()
+
+	records, err := bcStore.GetDebtRecords()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, len(records), 0)
+
+	created := &types.DebtRecord{Debt: newTestDebt(), Status: types.DebtStatusCreated}
+	reverted := &types.DebtRecord{Debt: newTestDebt(), Status: types.DebtStatusReverted}
+
+	assert.Equal(t, bcStore.PutDebtRecord(created), error(nil))
+	assert.Equal(t, bcStore.PutDebtRecord(reverted), error(nil))
+
+	storedRecords, err := bcStore.GetDebtRecords()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, len(storedRecords), 2)
+
+	statuses := make(map[common.Hash]types.DebtStatus)
+	for _, r := range storedRecords {
+		statuses[r.Debt.Hash] = r.Status
+	}
+	assert.Equal(t, statuses[created.Debt.Hash], types.DebtStatusCreated)
+	assert.Equal(t, statuses[reverted.Debt.Hash], types.DebtStatusReverted)
+
+	// update the status of existing record
+	created.Status = types.DebtStatusPending
+	assert.Equal(t, bcStore.PutDebtRecord(created), error(nil))
+
+	// delete record
+	assert.Equal(t, bcStore.DeleteDebtRecord(reverted.Debt.Hash), error(nil))
+
+	storedRecords, err = bcStore.GetDebtRecords()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, len(storedRecords), 1)
+	assert.Equal(t, storedRecords[0].Debt.Hash, created.Debt.Hash)
+	assert.Equal(t, storedRecords[0].Status, types.DebtStatusPending)
+}
+
+func Test_blockchainDatabase_SyncRoot(t *testing.T) {
+	bcStore, dispose := newTestBlockchainDatabase()
+	defer dispose()
+
+	root, err := bcStore.GetSyncRoot()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, root, common.EmptyHash)
+
+	assert.Equal(t, bcStore.PutSyncRoot(common.StringToHash("root")), error(nil))
+	root, err = bcStore.GetSyncRoot()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, root, common.StringToHash("root"))
+
+	// empty root deletes the sync root
+	assert.Equal(t, bcStore.PutSyncRoot(common.EmptyHash), error(nil))
+	root, err = bcStore.GetSyncRoot()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, root, common.EmptyHash)
+}
+
+func Test_blockchainDatabase_RewindBlocks(t *testing.T) {
+	bcStore, dispose := newTestBlockchainDatabase()
+	defer dispose()
+
+	newBlock := func(height uint64, txs ...*types.Transaction) *types.Block {
+		header := newTestBlockHeader()
+		header.Height = height
+		return &types.Block{
+			HeaderHash:   header.Hash(),
+			Header:       header,
+			Transactions: txs,
+			Debts:        make([]*types.Debt, 0),
+		}
+	}
+
+	// block2 and block3 are in canonical chain, and forked has the same tx with block2.
+	tx := newTestTx()
+	block1, block2, block3 := newBlock(1), newBlock(2, tx), newBlock(3, newTestTx())
+	forked := newBlock(2, tx)
+
+	assert.Equal(t, bcStore.PutBlock(block1, big.NewInt(1), true), error(nil))
+	assert.Equal(t, bcStore.PutBlock(forked, big.NewInt(2), false), error(nil))
+	assert.Equal(t, bcStore.PutBlock(block2, big.NewInt(2), true), error(nil))
+	assert.Equal(t, bcStore.PutBlock(block3, big.NewInt(3), true), error(nil))
+
+	err := bcStore.RewindBlocks([]*types.Block{forked}, []common.Hash{block2.HeaderHash, block3.HeaderHash})
+	assert.Equal(t, err, error(nil))
+
+	head, err := bcStore.GetHeadBlockHash()
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, head, forked.HeaderHash)
+
+	hash, err := bcStore.GetBlockHash(2)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, hash, forked.HeaderHash)
+
+	_, err = bcStore.GetBlockHash(3)
+	assert.Equal(t, err != nil, true)
+
+	exist, err := bcStore.HasBlock(block2.HeaderHash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, exist, false)
+
+	_, err = bcStore.GetTxIndex(block3.Transactions[0].Hash)
+	assert.Equal(t, err != nil, true)
+
+	// the tx index is kept for the same tx in the canonical block.
+	index, err := bcStore.GetTxIndex(tx.Hash)
+	assert.Equal(t, err, error(nil))
+	assert.Equal(t, index.BlockHash, forked.HeaderHash)
+}
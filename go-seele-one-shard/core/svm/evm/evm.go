@@ -69,6 +69,11 @@ func newEVMContext(tx *types.Transaction, header *types.BlockHeader, minerAddres
 		}
 	}
 
+	gasPrice := big.NewInt(0)
+	if tx.IsGasMetered() {
+		gasPrice.Set(tx.Data.GasPrice)
+	}
+
 	return &vm.Context{
 		CanTransfer: canTransferFunc,
 		Transfer:    transferFunc,
@@ -78,5 +83,6 @@ func newEVMContext(tx *types.Transaction, header *types.BlockHeader, minerAddres
 		BlockNumber: new(big.Int).SetUint64(header.Height),
 		Time:        new(big.Int).Set(header.CreateTimestamp),
 		Difficulty:  new(big.Int).Set(header.Difficulty),
+		GasPrice:    gasPrice,
 	}
 }
@@ -9,12 +9,13 @@ import (
 	"math/big"
 
 	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/types"
 )
 
 ///////////////////////////////////////////////////////////////////////////////////////
-// Gas fee model for test net
+// Gas fee model of legacy tx for test net. Gas metered tx pays used gas * gas price instead.
 ///////////////////////////////////////////////////////////////////////////////////////
-const maxTxGas = uint64(10000000)
+const maxTxGas = types.MaxTxGasLimit
 
 var (
 	contractFeeComplex       = new(big.Int).Div(common.SeeleToFan, big.NewInt(100))
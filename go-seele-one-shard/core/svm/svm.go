@@ -6,6 +6,7 @@
 package svm
 
 import (
+	"bytes"
 	"math/big"
 
 	"github.com/seeleteam/go-seele/common"
@@ -17,6 +18,9 @@ import (
 	"github.com/seeleteam/go-seele/core/vm"
 )
 
+// revertSelector is the function selector of Error(string), which is used to encode the revert reason.
+var revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
 // Context for other vm constructs
 type Context struct {
 	Tx          *types.Transaction
@@ -24,33 +28,71 @@ type Context struct {
 	Statedb     *state.Statedb
 	BlockHeader *types.BlockHeader
 	BcStore     store.BlockchainStore
+	NumOfChains uint64 // NumOfChains is the number of chains defined in genesis, used to identify cross chain tx
 }
 
 // Process the tx
 func Process(ctx *Context) (*types.Receipt, error) {
+	receipt, _, err := process(ctx)
+	return receipt, err
+}
+
+// Simulate processes the tx like Process, and also returns the raw output of contract execution,
+// which contains the ABI encoded revert reason if the execution is reverted. It is used to preview
+// a tx, so the statedb in context should be a copy that will not be committed.
+func Simulate(ctx *Context) (*types.Receipt, []byte, error) {
+	return process(ctx)
+}
+
+func process(ctx *Context) (*types.Receipt, []byte, error) {
 	var err error
 	var receipt *types.Receipt
 	snapshot := ctx.Statedb.Prepare(ctx.TxIndex)
 
 	if contract := system.GetContractByAddress(ctx.Tx.Data.To); contract != nil { // system contract
 		receipt, err = processSystemContract(ctx, contract, snapshot)
-	} else if (ctx.Tx.IsCrossShardTx() || ctx.Tx.IsCrossChainTx()) && !ctx.Tx.Data.To.IsEVMContract() {
-		return processCrossShardTransaction(ctx, snapshot)
+	} else if (ctx.Tx.IsCrossShardTx() || ctx.Tx.IsCrossChainTx(ctx.NumOfChains)) && !ctx.Tx.Data.To.IsEVMContract() {
+		receipt, err = processCrossShardTransaction(ctx, snapshot)
+		return receipt, nil, err
 	} else { // evm
-		receipt, err = processEvmContract(ctx)
+		receipt, err = processEvmContract(ctx, snapshot)
 	}
 
 	// Gas is not enough
 	if err == vm.ErrInsufficientBalance {
-		return nil, err
+		return nil, nil, revertStatedb(ctx.Statedb, snapshot, err)
 	}
 
+	output := receipt.Result
 	if err != nil {
 		receipt.Failed = true
 		receipt.Result = []byte(err.Error())
 	}
 
-	return handleFee(ctx, receipt, snapshot)
+	receipt, err = handleFee(ctx, receipt, snapshot)
+	return receipt, output, err
+}
+
+// UnpackRevertReason unpacks the revert reason from the ABI encoded output of
+// reverted contract execution, which is encoded as Error(string).
+func UnpackRevertReason(output []byte) (string, bool) {
+	if len(output) < 4+64 || !bytes.Equal(output[:4], revertSelector) {
+		return "", false
+	}
+
+	data := output[4:]
+	offset := new(big.Int).SetBytes(data[:32])
+	if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(data)) {
+		return "", false
+	}
+
+	start := offset.Uint64() + 32
+	length := new(big.Int).SetBytes(data[offset.Uint64():start])
+	if !length.IsUint64() || start+length.Uint64() > uint64(len(data)) {
+		return "", false
+	}
+
+	return string(data[start : start+length.Uint64()]), true
 }
 
 func processCrossShardTransaction(ctx *Context, snapshot int) (*types.Receipt, error) {
@@ -88,9 +130,23 @@ func processSystemContract(ctx *Context, contract system.Contract, snapshot int)
 		TxHash: ctx.Tx.Hash,
 	}
 
+	if err = buyGas(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
 	// Add from nonce
 	ctx.Statedb.SetNonce(ctx.Tx.Data.From, ctx.Tx.Data.AccountNonce+1)
 
+	// Check gas limit before any state change of execution
+	receipt.UsedGas = contract.RequiredGas(ctx.Tx.Data.Payload)
+	if ctx.Tx.IsGasMetered() {
+		receipt.UsedGas += types.IntrinsicGas(ctx.Tx.Data.Payload, false)
+		if receipt.UsedGas > ctx.Tx.Data.GasLimit {
+			receipt.UsedGas = ctx.Tx.Data.GasLimit
+			return receipt, vm.ErrOutOfGas
+		}
+	}
+
 	// Transfer amount
 	amount, sender, recipient := ctx.Tx.Data.Amount, ctx.Tx.Data.From, ctx.Tx.Data.To
 	if ctx.Statedb.GetBalance(sender).Cmp(amount) < 0 {
@@ -101,13 +157,12 @@ func processSystemContract(ctx *Context, contract system.Contract, snapshot int)
 	ctx.Statedb.AddBalance(recipient, amount)
 
 	// Run
-	receipt.UsedGas = contract.RequiredGas(ctx.Tx.Data.Payload)
 	receipt.Result, err = contract.Run(ctx.Tx.Data.Payload, system.NewContext(ctx.Tx, ctx.Statedb, ctx.BlockHeader))
 
 	return receipt, err
 }
 
-func processEvmContract(ctx *Context) (*types.Receipt, error) {
+func processEvmContract(ctx *Context, snapshot int) (*types.Receipt, error) {
 	var err error
 	receipt := &types.Receipt{
 		TxHash: ctx.Tx.Hash,
@@ -116,9 +171,19 @@ func processEvmContract(ctx *Context) (*types.Receipt, error) {
 	statedb := &evm.StateDB{Statedb: ctx.Statedb}
 	e := evm.NewEVMByDefaultConfig(ctx.Tx, statedb, ctx.BlockHeader, ctx.BcStore)
 	caller := vm.AccountRef(ctx.Tx.Data.From)
-	gas, leftOverGas := maxTxGas, uint64(0)
+	gas, leftOverGas, intrinsicGas := maxTxGas, uint64(0), uint64(0)
+
+	// For legacy tx, use maxTxGas gas to bypass ErrInsufficientBalance error and avoid overly complex contract creation or calculation.
+	// For gas metered tx, the execution is bounded by the gas limit, which is validated to cover the intrinsic gas.
+	if ctx.Tx.IsGasMetered() {
+		if err = buyGas(ctx, snapshot); err != nil {
+			return nil, err
+		}
+
+		intrinsicGas = types.IntrinsicGas(ctx.Tx.Data.Payload, ctx.Tx.Data.To.IsEmpty())
+		gas = ctx.Tx.Data.GasLimit - intrinsicGas
+	}
 
-	// Currently, use maxTxGas gas to bypass ErrInsufficientBalance error and avoid overly complex contract creation or calculation.
 	if ctx.Tx.Data.To.IsEmpty() {
 		var createdContractAddr common.Address
 		receipt.Result, createdContractAddr, leftOverGas, err = e.Create(caller, ctx.Tx.Data.Payload, gas, ctx.Tx.Data.Amount)
@@ -131,26 +196,65 @@ func processEvmContract(ctx *Context) (*types.Receipt, error) {
 	}
 	receipt.UsedGas = gas - leftOverGas
 
+	if ctx.Tx.IsGasMetered() {
+		receipt.UsedGas += intrinsicGas
+
+		// refund the gas of cleared storage, which is capped by half of the used gas.
+		refund := ctx.Statedb.GetRefund()
+		if refund > receipt.UsedGas/2 {
+			refund = receipt.UsedGas / 2
+		}
+
+		receipt.UsedGas -= refund
+	}
+
 	return receipt, err
 }
 
+// buyGas prepays the gas fee of gas limit for gas metered tx,
+// and the unused gas will be refunded when handling fee.
+func buyGas(ctx *Context, snapshot int) error {
+	if !ctx.Tx.IsGasMetered() {
+		return nil
+	}
+
+	gasFee := ctx.Tx.MaxGasFee()
+	if ctx.Statedb.GetBalance(ctx.Tx.Data.From).Cmp(gasFee) < 0 {
+		return revertStatedb(ctx.Statedb, snapshot, vm.ErrInsufficientBalance)
+	}
+
+	ctx.Statedb.SubBalance(ctx.Tx.Data.From, gasFee)
+
+	return nil
+}
+
 func handleFee(ctx *Context, receipt *types.Receipt, snapshot int) (*types.Receipt, error) {
-	// Calculating the total fee
+	// Calculating the gas fee
 	gasFee := big.NewInt(0)
-	if ctx.Tx.Data.To.IsEmpty() {
+	if ctx.Tx.IsGasMetered() {
+		// the gas fee of gas limit is prepaid, so refund the unused gas
+		gasFee.Mul(ctx.Tx.Data.GasPrice, new(big.Int).SetUint64(receipt.UsedGas))
+		ctx.Statedb.AddBalance(ctx.Tx.Data.From, new(big.Int).Sub(ctx.Tx.MaxGasFee(), gasFee))
+	} else if ctx.Tx.Data.To.IsEmpty() {
 		gasFee = contractCreationFee(ctx.Tx.Data.Payload)
 	} else {
 		gasFee = usedGasFee(receipt.UsedGas)
 	}
-	totalFee := big.NewInt(0).Add(gasFee, ctx.Tx.Data.Fee)
+
+	// The gas fee of gas metered tx is already paid
+	toPay := new(big.Int).Set(ctx.Tx.Data.Fee)
+	if !ctx.Tx.IsGasMetered() {
+		toPay.Add(toPay, gasFee)
+	}
 
 	// Calculating the From account balance is enough
-	if balance := ctx.Statedb.GetBalance(ctx.Tx.Data.From); balance.Cmp(totalFee) < 0 {
+	if balance := ctx.Statedb.GetBalance(ctx.Tx.Data.From); balance.Cmp(toPay) < 0 {
 		return nil, revertStatedb(ctx.Statedb, snapshot, vm.ErrInsufficientBalance)
 	}
 
 	// Transfer fee to coinbase
-	ctx.Statedb.SubBalance(ctx.Tx.Data.From, totalFee)
+	totalFee := big.NewInt(0).Add(gasFee, ctx.Tx.Data.Fee)
+	ctx.Statedb.SubBalance(ctx.Tx.Data.From, toPay)
 	ctx.Statedb.AddBalance(ctx.BlockHeader.Creator, totalFee)
 	receipt.TotalFee = totalFee.Uint64()
 
@@ -122,6 +122,100 @@ func Test_Process_ErrInsufficientBalance(t *testing.T) {
 	assert.Equal(t, balanceF2Now, balanceF2)
 }
 
+func Test_Simulate(t *testing.T) {
+	ctx, _ := newTestContext(t, big.NewInt(0))
+	receipt, err := Process(ctx)
+	assert.Equal(t, err, nil)
+	contractAddr := common.BytesToAddress(receipt.ContractAddress)
+
+	// Call contract tx: SimpleStorage.get(), it returns 5 as initialized in constructor.
+	input := mustHexToBytes("0x6d4ce63c")
+	ctx.Tx, _ = types.NewMessageTransaction(ctx.Tx.Data.From, contractAddr, big.NewInt(0), big.NewInt(1), 39, input)
+	receipt, output, err := Simulate(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, receipt.Failed, false)
+	assert.Equal(t, output, receipt.Result)
+	assert.Equal(t, new(big.Int).SetBytes(output), big.NewInt(5))
+
+	// Call a function that does not exist, the execution is reverted without reason.
+	ctx.Tx, _ = types.NewMessageTransaction(ctx.Tx.Data.From, contractAddr, big.NewInt(0), big.NewInt(1), 40, mustHexToBytes("0x12345678"))
+	receipt, output, err = Simulate(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, receipt.Failed, true)
+	_, ok := UnpackRevertReason(output)
+	assert.Equal(t, ok, false)
+}
+
+func Test_Process_GasMetered(t *testing.T) {
+	ctx, err := newTestContext(t, big.NewInt(0))
+	assert.Equal(t, err, nil)
+
+	gasPrice := big.NewInt(10)
+	ctx.Tx, err = types.NewGasMeteredTransaction(ctx.Tx.Data.From, common.EmptyAddress, big.NewInt(0), big.NewInt(1), gasPrice, 500000, 38, ctx.Tx.Data.Payload)
+	assert.Equal(t, err, nil)
+
+	receipt, err := Process(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, receipt.Failed, false)
+	assert.Equal(t, receipt.UsedGas > types.IntrinsicGas(ctx.Tx.Data.Payload, true), true)
+	assert.Equal(t, receipt.UsedGas < ctx.Tx.Data.GasLimit, true)
+
+	// pays used gas * gas price + fee, and the unused gas is refunded
+	totalFee := new(big.Int).Add(new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(receipt.UsedGas)), ctx.Tx.Data.Fee)
+	assert.Equal(t, new(big.Int).SetUint64(receipt.TotalFee), totalFee)
+	assert.Equal(t, ctx.Statedb.GetBalance(ctx.BlockHeader.Creator), totalFee)
+	assert.Equal(t, ctx.Statedb.GetBalance(ctx.Tx.Data.From), new(big.Int).Sub(new(big.Int).SetUint64(fromBalance), totalFee))
+}
+
+func Test_Process_GasMetered_OutOfGas(t *testing.T) {
+	ctx, err := newTestContext(t, big.NewInt(0))
+	assert.Equal(t, err, nil)
+
+	gasPrice := big.NewInt(10)
+	gasLimit := types.IntrinsicGas(ctx.Tx.Data.Payload, true) + 1000
+	ctx.Tx, err = types.NewGasMeteredTransaction(ctx.Tx.Data.From, common.EmptyAddress, big.NewInt(0), big.NewInt(1), gasPrice, gasLimit, 38, ctx.Tx.Data.Payload)
+	assert.Equal(t, err, nil)
+
+	receipt, err := Process(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, receipt.Failed, true)
+	assert.Equal(t, receipt.UsedGas, gasLimit)
+
+	// contract is not created, but all gas is charged
+	contractAddr := crypto.CreateAddress(ctx.Tx.Data.From, ctx.Tx.Data.AccountNonce)
+	assert.Equal(t, len(ctx.Statedb.GetCode(contractAddr)), 0)
+
+	totalFee := new(big.Int).Add(ctx.Tx.MaxGasFee(), ctx.Tx.Data.Fee)
+	assert.Equal(t, new(big.Int).SetUint64(receipt.TotalFee), totalFee)
+	assert.Equal(t, ctx.Statedb.GetBalance(ctx.Tx.Data.From), new(big.Int).Sub(new(big.Int).SetUint64(fromBalance), totalFee))
+
+	// not enough balance to buy gas
+	ctx.Statedb.SetBalance(ctx.Tx.Data.From, big.NewInt(1))
+	ctx.Tx, _ = types.NewGasMeteredTransaction(ctx.Tx.Data.From, common.EmptyAddress, big.NewInt(0), big.NewInt(1), gasPrice, gasLimit, 39, ctx.Tx.Data.Payload)
+	_, err = Process(ctx)
+	assert.Equal(t, err, vm.ErrInsufficientBalance)
+	assert.Equal(t, ctx.Statedb.GetBalance(ctx.Tx.Data.From), big.NewInt(1))
+}
+
+func Test_UnpackRevertReason(t *testing.T) {
+	// Error("not enough")
+	output := mustHexToBytes("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000000a" +
+		"6e6f7420656e6f75676800000000000000000000000000000000000000000000")
+	reason, ok := UnpackRevertReason(output)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, reason, "not enough")
+
+	// invalid length
+	_, ok = UnpackRevertReason(output[:4+64])
+	assert.Equal(t, ok, false)
+
+	// invalid selector
+	_, ok = UnpackRevertReason(append([]byte{0, 0, 0, 0}, output[4:]...))
+	assert.Equal(t, ok, false)
+}
+
 func mustHexToBytes(hex string) []byte {
 	code, err := hexutil.HexToBytes(hex)
 	if err != nil {
@@ -187,6 +281,7 @@ func newTestContext(t *testing.T, amount *big.Int) (*Context, error) {
 		Statedb:     statedb,
 		BlockHeader: header,
 		BcStore:     bcStore,
+		NumOfChains: common.DefaultNumOfChains,
 	}, err
 }
 
@@ -49,8 +49,10 @@ func (h *txHeapByNonce) Pop() interface{} {
 // txCollection represents the nonce sorted transactions of an account.
 type txCollection struct {
 	heapItem
-	txs       map[uint64]*pooledTx
-	nonceHeap txHeapByNonce
+	tailHeapIndex int // used to randomly remove an item from tail heap.
+	txs           map[uint64]*pooledTx
+	nonceHeap     txHeapByNonce
+	tail          *pooledTx // transaction with the highest nonce
 }
 
 func newTxCollection() *txCollection {
@@ -69,6 +71,10 @@ func (collection *txCollection) add(tx *pooledTx) bool {
 	heap.Push(&collection.nonceHeap, tx)
 	collection.txs[tx.Data.AccountNonce] = tx
 
+	if collection.tail == nil || tx.Data.AccountNonce > collection.tail.Data.AccountNonce {
+		collection.tail = tx
+	}
+
 	return true
 }
 
@@ -80,6 +86,11 @@ func (collection *txCollection) remove(nonce uint64) bool {
 	if tx := collection.txs[nonce]; tx != nil {
 		heap.Remove(&collection.nonceHeap, tx.heapIndex)
 		delete(collection.txs, nonce)
+
+		if tx == collection.tail {
+			collection.resetTail()
+		}
+
 		return true
 	}
 
@@ -94,9 +105,30 @@ func (collection *txCollection) peek() *pooledTx {
 	return collection.nonceHeap[0]
 }
 
+// last returns the transaction with the highest nonce, which is removable without nonce gap.
+func (collection *txCollection) last() *pooledTx {
+	return collection.tail
+}
+
+// resetTail finds the transaction with the highest nonce after the tail is removed,
+// which walks through at most the account slots of transactions.
+func (collection *txCollection) resetTail() {
+	collection.tail = nil
+	for _, tx := range collection.nonceHeap {
+		if collection.tail == nil || tx.Data.AccountNonce > collection.tail.Data.AccountNonce {
+			collection.tail = tx
+		}
+	}
+}
+
 func (collection *txCollection) pop() *pooledTx {
 	tx := heap.Pop(&collection.nonceHeap).(*pooledTx)
 	delete(collection.txs, tx.Data.AccountNonce)
+
+	if tx == collection.tail {
+		collection.resetTail()
+	}
+
 	return tx
 }
 
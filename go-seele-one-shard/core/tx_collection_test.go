@@ -118,3 +118,26 @@ func Test_txCollection_pop(t *testing.T) {
 	assert.Equal(t, collection.pop(), tx2)
 	assert.Equal(t, collection.len(), 0)
 }
+
+func Test_txCollection_last(t *testing.T) {
+	collection := newTxCollection()
+	assert.Equal(t, collection.last() == nil, true)
+
+	tx1, tx2, tx3 := newTestPoolTx(t, 1, 3), newTestPoolTx(t, 1, 5), newTestPoolTx(t, 1, 4)
+	collection.add(tx1)
+	assert.Equal(t, collection.last(), tx1)
+
+	collection.add(tx2)
+	collection.add(tx3)
+	assert.Equal(t, collection.last(), tx2)
+
+	// tail is updated when removed
+	collection.remove(5)
+	assert.Equal(t, collection.last(), tx3)
+
+	collection.pop()
+	assert.Equal(t, collection.last(), tx3)
+
+	collection.pop()
+	assert.Equal(t, collection.last() == nil, true)
+}
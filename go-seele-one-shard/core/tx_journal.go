@@ -0,0 +1,142 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package core
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/log"
+)
+
+var errNoActiveJournal = errors.New("no active journal")
+
+// txJournal is an append only log of the RLP encoded transactions in pool,
+// so that the transactions survive the node restart. The journal is compacted
+// with the transactions that still in pool periodically.
+type txJournal struct {
+	path   string
+	writer io.WriteCloser
+	log    *log.SeeleLog
+}
+
+// journalEntry is a journaled transaction along with whether it is submitted locally,
+// so that the remote transactions are not replayed as local ones.
+type journalEntry struct {
+	Tx    *types.Transaction
+	Local bool
+}
+
+func newTxJournal(path string, log *log.SeeleLog) *txJournal {
+	return &txJournal{
+		path: path,
+		log:  log,
+	}
+}
+
+// load reads the transactions from the journal file and injects them into pool via the add function
+// along with whether they are local. Transactions that failed to add, e.g. nonce already used, are dropped.
+func (journal *txJournal) load(add func(tx *types.Transaction, local bool) error) error {
+	if !common.FileOrFolderExists(journal.path) {
+		return nil
+	}
+
+	input, err := os.Open(journal.path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	stream := rlp.NewStream(input, 0)
+	total, dropped := 0, 0
+	for {
+		entry := new(journalEntry)
+		if err = stream.Decode(entry); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+
+			break
+		}
+
+		total++
+		if addErr := add(entry.Tx, entry.Local); addErr != nil {
+			journal.log.Debug("failed to add journaled transaction %s, %s", entry.Tx.Hash.ToHex(), addErr)
+			dropped++
+		}
+	}
+
+	journal.log.Info("loaded transaction journal %s, transactions: %d, dropped: %d", journal.path, total, dropped)
+
+	return err
+}
+
+// insert appends the transaction to the journal file.
+func (journal *txJournal) insert(tx *types.Transaction, local bool) error {
+	if journal.writer == nil {
+		return errNoActiveJournal
+	}
+
+	return rlp.Encode(journal.writer, &journalEntry{tx, local})
+}
+
+// rotate regenerates the journal file with the specified entries, and reopens it for appending.
+func (journal *txJournal) rotate(entries []*journalEntry) error {
+	if journal.writer != nil {
+		if err := journal.writer.Close(); err != nil {
+			return err
+		}
+
+		journal.writer = nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(journal.path), os.ModePerm); err != nil {
+		return err
+	}
+
+	replacement, err := os.OpenFile(journal.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err = rlp.Encode(replacement, entry); err != nil {
+			replacement.Close()
+			return err
+		}
+	}
+	replacement.Close()
+
+	if err = os.Rename(journal.path+".new", journal.path); err != nil {
+		return err
+	}
+
+	sink, err := os.OpenFile(journal.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	journal.writer = sink
+	journal.log.Debug("regenerated transaction journal %s, transactions: %d", journal.path, len(entries))
+
+	return nil
+}
+
+// close flushes the journal file and closes it.
+func (journal *txJournal) close() error {
+	var err error
+	if journal.writer != nil {
+		err = journal.writer.Close()
+		journal.writer = nil
+	}
+
+	return err
+}
@@ -8,6 +8,7 @@ package core
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -17,7 +18,7 @@ import (
 	"github.com/seeleteam/go-seele/core/types"
 	"github.com/seeleteam/go-seele/event"
 	"github.com/seeleteam/go-seele/log"
-
+	"github.com/seeleteam/go-seele/metrics"
 )
 
 var (
@@ -25,61 +26,160 @@ var (
 	errTxPoolFull   = errors.New("transaction pool is full")
 	errTxFeeNil     = errors.New("fee can't be nil")
 	errTxNonceUsed  = errors.New("transaction nonce already been used")
+	errTxChainNum   = errors.New("transaction sender does not belong to this chain")
+	errTxFeeTooLow  = errors.New("transaction fee is lower than the minimum fee of pool")
+
+	errTxAccountSlotsFull = errors.New("too many transactions of the account in pool")
 )
 
-const overTimeInterval = 3 * time.Hour
+const (
+	overTimeInterval = 3 * time.Hour
 
-type blockchain interface {
-	GetStore() store.BlockchainStore
-}
+	// maxRecentEvictions is the maximum number of recently evicted transactions kept for debugging.
+	maxRecentEvictions = 100
+)
 
-type SeeleBackendForTxPool interface {
+type blockchain interface {
 	GetCurrentState() (*state.Statedb, error)
+	GetStore() store.BlockchainStore
+	CurrentBlock() *types.Block
+	ValidateTxReplay(tx *types.Transaction, height uint64) error
 }
 
 type pooledTx struct {
 	*types.Transaction
 	heapItem
 	timestamp time.Time
+	local     bool // submitted locally via RPC
 }
 
 func newPooledTx(tx *types.Transaction) *pooledTx {
-	return &pooledTx{tx, heapItem{0}, time.Now()}
+	return &pooledTx{tx, heapItem{0}, time.Now(), false}
+}
+
+// EvictedTx is the information of a transaction evicted from the pool
+// to make room for a better paying transaction.
+type EvictedTx struct {
+	Hash       common.Hash
+	From       common.Address
+	Nonce      uint64
+	Fee        *big.Int
+	ReplacedBy common.Hash
+	Time       time.Time
 }
 
 // TransactionPool is a thread-safe container for transactions received
 // from the network or submitted locally. A transaction will be removed from
 // the pool once included in a blockchain or pending time too long (> overTimeInterval).
+// Transactions with contiguous nonces from the account nonce are pending and could be
+// processed by miner, while others are queued until the nonce gap is filled.
 type TransactionPool struct {
 	mutex         sync.RWMutex
 	config        TransactionPoolConfig
 	chain         blockchain
 	hashToTxMap   map[common.Hash]*pooledTx
 	pendingQueue  *pendingQueue
+	queuedTxs     *queuedSet
 	processingTxs map[common.Hash]struct{}
 	log           *log.SeeleLog
-	seele		  SeeleBackendForTxPool
 	chainNum      uint64
+	numOfChains   uint64
+	journal       *txJournal
+	quit          chan struct{}
+
+	// the highest nonce of transactions being processed by miner for each account
+	processingNonces map[common.Address]uint64
+
+	evictedCount    uint64
+	recentEvictions []*EvictedTx
 }
 
-// NewTransactionPool creates and returns a transaction pool.
-func NewTransactionPool(config TransactionPoolConfig, chain blockchain, chainNum uint64, seele SeeleBackendForTxPool) *TransactionPool {
+// NewTransactionPool creates and returns a transaction pool of the specified chain number,
+// and numOfChains is the number of chains defined in genesis.
+func NewTransactionPool(config TransactionPoolConfig, chain blockchain, chainNum, numOfChains uint64) *TransactionPool {
 	pool := &TransactionPool{
 		config:        config,
 		chain:         chain,
 		hashToTxMap:   make(map[common.Hash]*pooledTx),
 		pendingQueue:  newPendingQueue(),
+		queuedTxs:     newQueuedSet(),
 		processingTxs: make(map[common.Hash]struct{}),
 		log:           log.GetLogger("txpool"),
 		chainNum:      chainNum,
-		seele:    	   seele,
+		numOfChains:   numOfChains,
+		quit:          make(chan struct{}),
+
+		processingNonces: make(map[common.Address]uint64),
+	}
+
+	// Replay the journaled transactions with validation against the current state.
+	if len(config.JournalFile) > 0 {
+		pool.journal = newTxJournal(config.JournalFile, pool.log)
+
+		if err := pool.journal.load(pool.add); err != nil {
+			pool.log.Warn("failed to load transaction journal, %s", err)
+		}
+
+		if err := pool.rotateJournal(); err != nil {
+			pool.log.Warn("failed to rotate transaction journal, %s", err)
+		}
+
+		go pool.journalLoop()
 	}
 
 	return pool
 }
 
+// journalLoop compacts the journal file periodically until the pool is stopped.
+func (pool *TransactionPool) journalLoop() {
+	interval := pool.config.RejournalInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := pool.rotateJournal(); err != nil {
+				pool.log.Warn("failed to rotate transaction journal, %s", err)
+			}
+		case <-pool.quit:
+			return
+		}
+	}
+}
+
+// rotateJournal regenerates the journal file with the transactions to journal in pool.
+func (pool *TransactionPool) rotateJournal() error {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	var entries []*journalEntry
+	for _, poolTx := range pool.hashToTxMap {
+		if poolTx.local || pool.config.JournalRemotes {
+			entries = append(entries, &journalEntry{poolTx.Transaction, poolTx.local})
+		}
+	}
+
+	return pool.journal.rotate(entries)
+}
+
 func (pool *TransactionPool) HandleChainHeaderChanged(newHeader, lastHeader common.Hash) {
 	reinject := pool.getReinjectTransaction(newHeader, lastHeader)
+	pool.reinjectTransactions(reinject)
+}
+
+// HandleChainRewound reinjects the txs of the blocks removed from the canonical chain when the
+// chain rewound, since the removed blocks are already deleted from the chain store.
+func (pool *TransactionPool) HandleChainRewound(removed, added []*types.Block) {
+	reinject := getRemovedTransactions(removed, added)
+	pool.reinjectTransactions(reinject)
+}
+
+func (pool *TransactionPool) reinjectTransactions(reinject []*types.Transaction) {
 	count := pool.addTransactions(reinject)
 	if count > 0 {
 		pool.log.Info("add %d reinject transactions", count)
@@ -89,81 +189,47 @@ func (pool *TransactionPool) HandleChainHeaderChanged(newHeader, lastHeader comm
 }
 
 func (pool *TransactionPool) getReinjectTransaction(newHeader, lastHeader common.Hash) []*types.Transaction {
-	chainStore := pool.chain.GetStore()
-	log := pool.log
-
-	newBlock, err := chainStore.GetBlock(newHeader)
+	removed, added, err := GetChangedBlocks(pool.chain.GetStore(), newHeader, lastHeader)
 	if err != nil {
-		log.Error("got block failed, %s", err)
+		pool.log.Error("failed to get changed blocks, %s", err)
 		return nil
 	}
 
-	if newBlock.Header.PreviousBlockHash != lastHeader {
-		lastBlock, err := chainStore.GetBlock(lastHeader)
-		if err != nil {
-			log.Error("got block failed, %s", err)
-			return nil
-		}
-
-		log.Debug("handle chain header forked, last height %d, new height %d", lastBlock.Header.Height, newBlock.Header.Height)
-		// add committed txs back in current branch.
-		toDeleted := make(map[common.Hash]*types.Transaction)
-		toAdded := make(map[common.Hash]*types.Transaction)
-		for newBlock.Header.Height > lastBlock.Header.Height {
-			for _, t := range newBlock.GetExcludeRewardTransactions() {
-				toDeleted[t.Hash] = t
-			}
+	reinject := getRemovedTransactions(removed, added)
+	pool.log.Debug("removed blocks %d, added blocks %d, to reinject tx length %d", len(removed), len(added), len(reinject))
 
-			if newBlock, err = chainStore.GetBlock(newBlock.Header.PreviousBlockHash); err != nil {
-				log.Error("got block failed, %s", err)
-				return nil
-			}
-		}
+	return reinject
+}
 
-		for lastBlock.Header.Height > newBlock.Header.Height {
-			for _, t := range lastBlock.GetExcludeRewardTransactions() {
-				toAdded[t.Hash] = t
-			}
+// getRemovedTransactions returns the txs in the removed blocks but not in the added blocks.
+func getRemovedTransactions(removed, added []*types.Block) []*types.Transaction {
+	if len(removed) == 0 {
+		return nil
+	}
 
-			if lastBlock, err = chainStore.GetBlock(lastBlock.Header.PreviousBlockHash); err != nil {
-				log.Error("got block failed, %s", err)
-				return nil
-			}
+	// add committed txs back in current branch.
+	toDeleted := make(map[common.Hash]*types.Transaction)
+	for _, block := range added {
+		for _, t := range block.GetExcludeRewardTransactions() {
+			toDeleted[t.Hash] = t
 		}
+	}
 
-		for lastBlock.HeaderHash != newBlock.HeaderHash {
-			for _, t := range lastBlock.GetExcludeRewardTransactions() {
-				toAdded[t.Hash] = t
-			}
-
-			for _, t := range newBlock.GetExcludeRewardTransactions() {
-				toDeleted[t.Hash] = t
-			}
-
-			if lastBlock, err = chainStore.GetBlock(lastBlock.Header.PreviousBlockHash); err != nil {
-				log.Error("got block failed, %s", err)
-				return nil
-			}
-
-			if newBlock, err = chainStore.GetBlock(newBlock.Header.PreviousBlockHash); err != nil {
-				log.Error("got block failed, %s", err)
-				return nil
-			}
+	toAdded := make(map[common.Hash]*types.Transaction)
+	for _, block := range removed {
+		for _, t := range block.GetExcludeRewardTransactions() {
+			toAdded[t.Hash] = t
 		}
+	}
 
-		reinject := make([]*types.Transaction, 0)
-		for key, t := range toAdded {
-			if _, ok := toDeleted[key]; !ok {
-				reinject = append(reinject, t)
-			}
+	reinject := make([]*types.Transaction, 0)
+	for key, t := range toAdded {
+		if _, ok := toDeleted[key]; !ok {
+			reinject = append(reinject, t)
 		}
-
-		log.Debug("to added tx length %d, to deleted tx length %d, to reinject tx length %d",
-			len(toAdded), len(toDeleted), len(reinject))
-		return reinject
 	}
 
-	return nil
+	return reinject
 }
 
 func (pool *TransactionPool) addTransactions(txs []*types.Transaction) int {
@@ -179,22 +245,41 @@ func (pool *TransactionPool) addTransactions(txs []*types.Transaction) int {
 	return count
 }
 
-// AddTransaction adds a single transaction into the pool if it is valid and returns nil.
+// AddTransaction adds a single transaction received from network into the pool if it is valid and returns nil.
 // Otherwise, return the concrete error.
 func (pool *TransactionPool) AddTransaction(tx *types.Transaction) error {
+	return pool.add(tx, false)
+}
+
+// AddLocalTransaction adds a single transaction submitted locally into the pool if it is valid and returns nil.
+// Local transactions are always persisted in the journal if the journal is enabled.
+func (pool *TransactionPool) AddLocalTransaction(tx *types.Transaction) error {
+	return pool.add(tx, true)
+}
+
+func (pool *TransactionPool) add(tx *types.Transaction, local bool) error {
 	if tx == nil {
 		return nil
 	}
-	
-	statedb, err := pool.seele.GetCurrentState()
+
+	statedb, err := pool.chain.GetCurrentState()
 	if err != nil {
 		return fmt.Errorf("get current state db failed, error %s", err)
 	}
 
-	return pool.addTransactionWithStateInfo(tx, statedb)
+	return pool.addTransactionWithStateInfo(tx, statedb, local)
 }
 
-func (pool *TransactionPool) addTransactionWithStateInfo(tx *types.Transaction, statedb *state.Statedb) error {
+func (pool *TransactionPool) addTransactionWithStateInfo(tx *types.Transaction, statedb *state.Statedb, local bool) error {
+	if tx.ValidateChain(pool.chainNum, pool.numOfChains) != nil {
+		return errTxChainNum
+	}
+
+	// the tx will be packed in the next block at least
+	if err := pool.chain.ValidateTxReplay(tx, pool.chain.CurrentBlock().Header.Height+1); err != nil {
+		return err
+	}
+
 	if err := tx.Validate(statedb); err != nil {
 		return err
 	}
@@ -206,11 +291,18 @@ func (pool *TransactionPool) addTransactionWithStateInfo(tx *types.Transaction,
 		return errTxHashExists
 	}
 
-	if uint(len(pool.hashToTxMap)) >= pool.config.Capacity {
-		return errTxPoolFull
+	if pool.config.MinFee != nil && tx.Data.Fee.Cmp(pool.config.MinFee) < 0 {
+		metrics.MetricsTxPoolRejectedMeter.Mark(1)
+		return errTxFeeTooLow
+	}
+
+	existTx := pool.pendingQueue.get(tx.Data.From, tx.Data.AccountNonce)
+	if existTx == nil {
+		existTx = pool.queuedTxs.get(tx.Data.From, tx.Data.AccountNonce)
 	}
 
-	if existTx := pool.pendingQueue.get(tx.Data.From, tx.Data.AccountNonce); existTx != nil {
+	if existTx != nil {
+		// replacement takes the slot of the existing transaction, so no quota check is required.
 		if tx.Data.Fee.Cmp(existTx.Data.Fee) > 0 {
 			pool.log.Debug("got a transaction have more fees than before. remove old one. new: %s, old: %s",
 				tx.Hash.ToHex(), existTx.Hash.ToHex())
@@ -218,9 +310,20 @@ func (pool *TransactionPool) addTransactionWithStateInfo(tx *types.Transaction,
 		} else {
 			return errTxNonceUsed
 		}
+	} else {
+		accountCount := pool.pendingQueue.accountCount(tx.Data.From) + pool.queuedTxs.accountCount(tx.Data.From)
+		if pool.config.AccountSlots > 0 && uint(accountCount) >= pool.config.AccountSlots {
+			metrics.MetricsTxPoolRejectedMeter.Mark(1)
+			return errTxAccountSlotsFull
+		}
+
+		if uint(len(pool.hashToTxMap)) >= pool.config.Capacity && !pool.evict(tx) {
+			metrics.MetricsTxPoolRejectedMeter.Mark(1)
+			return errTxPoolFull
+		}
 	}
 
-	pool.addTransaction(tx)
+	pool.addTransaction(tx, statedb, local)
 
 	// fire event
 	var NewTxMsg event.HandleNewTxMsg
@@ -231,10 +334,126 @@ func (pool *TransactionPool) addTransactionWithStateInfo(tx *types.Transaction,
 	return nil
 }
 
-func (pool *TransactionPool) addTransaction(tx *types.Transaction) {
+// evict removes the transaction with the lowest fee to make room for the specified transaction.
+// Queued transactions are evicted prior to pending ones. Only the highest nonce transaction of
+// an account could be evicted to avoid nonce gap, and local transactions are never evicted.
+// Returns false if no transaction pays less fee than the specified one.
+func (pool *TransactionPool) evict(tx *types.Transaction) bool {
+	victim := pool.queuedTxs.evictable()
+	if victim == nil || victim.Data.Fee.Cmp(tx.Data.Fee) >= 0 {
+		victim = pool.pendingQueue.evictable()
+	}
+
+	if victim == nil || victim.Data.Fee.Cmp(tx.Data.Fee) >= 0 {
+		return false
+	}
+
+	pool.log.Debug("evict transaction %s with fee %s for transaction %s with fee %s",
+		victim.Hash.ToHex(), victim.Data.Fee, tx.Hash.ToHex(), tx.Data.Fee)
+	pool.RemoveTransaction(victim.Hash)
+
+	pool.evictedCount++
+	pool.recentEvictions = append(pool.recentEvictions, &EvictedTx{
+		Hash:       victim.Hash,
+		From:       victim.Data.From,
+		Nonce:      victim.Data.AccountNonce,
+		Fee:        victim.Data.Fee,
+		ReplacedBy: tx.Hash,
+		Time:       time.Now(),
+	})
+
+	if len(pool.recentEvictions) > maxRecentEvictions {
+		pool.recentEvictions = pool.recentEvictions[len(pool.recentEvictions)-maxRecentEvictions:]
+	}
+
+	metrics.MetricsTxPoolEvictedMeter.Mark(1)
+
+	return true
+}
+
+// GetEvictions returns the total number of evicted transactions and the recently evicted ones.
+func (pool *TransactionPool) GetEvictions() (uint64, []*EvictedTx) {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	recent := make([]*EvictedTx, len(pool.recentEvictions))
+	copy(recent, pool.recentEvictions)
+
+	return pool.evictedCount, recent
+}
+
+func (pool *TransactionPool) addTransaction(tx *types.Transaction, statedb *state.Statedb, local bool) {
 	poolTx := newPooledTx(tx)
+	poolTx.local = local
 	pool.hashToTxMap[tx.Hash] = poolTx
-	pool.pendingQueue.add(poolTx)
+
+	if tx.Data.AccountNonce == pool.executableNonce(tx.Data.From, statedb) {
+		pool.pendingQueue.add(poolTx)
+		pool.promoteAccount(tx.Data.From, tx.Data.AccountNonce+1)
+	} else {
+		pool.queuedTxs.add(poolTx)
+	}
+
+	if pool.journal != nil && (local || pool.config.JournalRemotes) {
+		if err := pool.journal.insert(tx, local); err != nil && err != errNoActiveJournal {
+			pool.log.Warn("failed to journal transaction %s, %s", tx.Hash.ToHex(), err)
+		}
+	}
+}
+
+// executableNonce returns the nonce of the next executable transaction of the account, which follows
+// the account nonce in state, the transactions being processed by miner and the pending transactions.
+func (pool *TransactionPool) executableNonce(addr common.Address, statedb *state.Statedb) uint64 {
+	nonce := statedb.GetNonce(addr)
+	if processing, ok := pool.processingNonces[addr]; ok && processing >= nonce {
+		nonce = processing + 1
+	}
+
+	for pool.pendingQueue.get(addr, nonce) != nil {
+		nonce++
+	}
+
+	return nonce
+}
+
+// promoteAccount moves the queued transactions of the account with contiguous nonces
+// from the specified nonce into the pending queue.
+func (pool *TransactionPool) promoteAccount(addr common.Address, nonce uint64) {
+	for tx := pool.queuedTxs.remove(addr, nonce); tx != nil; tx = pool.queuedTxs.remove(addr, nonce) {
+		pool.pendingQueue.add(tx)
+		nonce++
+	}
+}
+
+// resetExecutables demotes the pending transactions that are not executable anymore, e.g. a
+// transaction with lower nonce is removed due to timeout, and promotes the queued transactions
+// that become executable as the account nonce changed.
+func (pool *TransactionPool) resetExecutables(statedb *state.Statedb) {
+	pool.processingNonces = make(map[common.Address]uint64)
+	for hash := range pool.processingTxs {
+		if tx := pool.hashToTxMap[hash]; tx != nil {
+			if nonce, ok := pool.processingNonces[tx.Data.From]; !ok || tx.Data.AccountNonce > nonce {
+				pool.processingNonces[tx.Data.From] = tx.Data.AccountNonce
+			}
+		}
+	}
+
+	for addr, collection := range pool.pendingQueue.txs {
+		nonce := pool.executableNonce(addr, statedb)
+		for _, tx := range collection.list() {
+			if tx.Data.AccountNonce > nonce {
+				poolTx := collection.get(tx.Data.AccountNonce)
+				pool.pendingQueue.remove(addr, tx.Data.AccountNonce)
+				pool.queuedTxs.add(poolTx)
+				pool.log.Debug("demote tx %s due to nonce gap, account %s, tx nonce %d, expected nonce %d", tx.Hash.ToHex(),
+					addr.ToHex(), tx.Data.AccountNonce, nonce)
+			}
+		}
+	}
+
+	for addr := range pool.queuedTxs.txs {
+		pool.promoteAccount(addr, pool.executableNonce(addr, statedb))
+	}
 }
 
 // GetTransaction returns a transaction if it is contained in the pool and nil otherwise.
@@ -252,7 +471,12 @@ func (pool *TransactionPool) GetTransaction(txHash common.Hash) *types.Transacti
 // RemoveTransaction removes a transaction from pool.
 func (pool *TransactionPool) RemoveTransaction(txHash common.Hash) {
 	if tx := pool.hashToTxMap[txHash]; tx != nil {
-		pool.pendingQueue.remove(tx.Data.From, tx.Data.AccountNonce)
+		if pendingTx := pool.pendingQueue.get(tx.Data.From, tx.Data.AccountNonce); pendingTx != nil && pendingTx.Hash == txHash {
+			pool.pendingQueue.remove(tx.Data.From, tx.Data.AccountNonce)
+		} else if queuedTx := pool.queuedTxs.get(tx.Data.From, tx.Data.AccountNonce); queuedTx != nil && queuedTx.Hash == txHash {
+			pool.queuedTxs.remove(tx.Data.From, tx.Data.AccountNonce)
+		}
+
 		delete(pool.processingTxs, txHash)
 		delete(pool.hashToTxMap, txHash)
 	}
@@ -263,7 +487,7 @@ func (pool *TransactionPool) removeTransactions() {
 	pool.mutex.Lock()
 	defer pool.mutex.Unlock()
 
-	state, err := pool.seele.GetCurrentState()
+	state, err := pool.chain.GetCurrentState()
 	if err != nil {
 		pool.log.Warn("failed to get current state, err: %s", err)
 		return
@@ -288,6 +512,8 @@ func (pool *TransactionPool) removeTransactions() {
 			pool.RemoveTransaction(txHash)
 		}
 	}
+
+	pool.resetExecutables(state)
 }
 
 // GetProcessableTransactions retrieves processable transactions from pool.
@@ -309,6 +535,7 @@ func (pool *TransactionPool) GetProcessableTransactions(size int) ([]*types.Tran
 		totalSize = tmpSize
 		txs = append(txs, tx)
 		pool.processingTxs[tx.Hash] = struct{}{}
+		pool.processingNonces[tx.Data.From] = tx.Data.AccountNonce
 	}
 
 	return txs, totalSize
@@ -345,6 +572,34 @@ func (pool *TransactionPool) GetTransactions(processing, pending bool) []*types.
 	return txs
 }
 
-// Stop terminates the transaction pool.
+// GetQueuedTxCount returns the total number of queued transactions that are not executable due to nonce gap.
+func (pool *TransactionPool) GetQueuedTxCount() int {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	return pool.queuedTxs.count()
+}
+
+// GetQueuedTransactions returns the queued transactions that are not executable due to nonce gap.
+func (pool *TransactionPool) GetQueuedTransactions() []*types.Transaction {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	return pool.queuedTxs.list()
+}
+
+// Stop terminates the transaction pool and closes the journal.
 func (pool *TransactionPool) Stop() {
+	if pool.journal == nil {
+		return
+	}
+
+	close(pool.quit)
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if err := pool.journal.close(); err != nil {
+		pool.log.Warn("failed to close transaction journal, %s", err)
+	}
 }
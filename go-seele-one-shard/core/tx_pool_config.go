@@ -5,9 +5,20 @@
 
 package core
 
+import (
+	"math/big"
+	"time"
+)
+
 // TransactionPoolConfig is the configuration of the transaction pool.
 type TransactionPoolConfig struct {
-	Capacity uint // Maximum number of transactions in the pool.
+	Capacity     uint     // Maximum number of transactions in the pool.
+	AccountSlots uint     // Maximum number of transactions of an account in the pool, 0 means unlimited.
+	MinFee       *big.Int // Minimum fee of transactions to accept, nil means no limit.
+
+	JournalFile       string        // File to persist transactions in the pool, journal is disabled if empty.
+	JournalRemotes    bool          // Whether to persist the transactions received from network.
+	RejournalInterval time.Duration // Time interval to compact the journal file.
 }
 
 // DefaultTxPoolConfig returns the default configuration of the transaction pool.
@@ -16,6 +27,12 @@ func DefaultTxPoolConfig() *TransactionPoolConfig {
 		// 1 simple transaction is about 152 byte size. So 1000 transactions is about 152KB, and 10000 transaction is about 1.52MB.
 		// We want to cache transactions for about 100 blocks (about 500k transactions), which means at least 25 minutes block generation consume,
 		// the memory usage will be <=100MB for tx pool.
-		Capacity: 500000,
+		Capacity:     500000,
+		AccountSlots: 1000,
+		MinFee:       big.NewInt(1),
+
+		// The journal file name is resolved under the data folder of each chain by the service.
+		JournalFile:       "txpool.journal",
+		RejournalInterval: time.Hour,
 	}
 }
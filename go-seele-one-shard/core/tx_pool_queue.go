@@ -53,10 +53,73 @@ func (h *txHeapByFee) Pop() interface{} {
 	return x
 }
 
+// txHeapByTailFee is the heap of accounts sorted by the fee of the highest nonce transaction,
+// the lowest fee first, and the newer one first if fees are equal. Accounts with local tail
+// transactions are sorted after the remote ones, since local transactions are never evicted.
+type txHeapByTailFee []*txCollection
+
+func (h txHeapByTailFee) Len() int {
+	return len(h)
+}
+
+func (h txHeapByTailFee) Less(i, j int) bool {
+	iTx := h[i].last()
+	jTx := h[j].last()
+
+	if iTx.local != jTx.local {
+		return jTx.local
+	}
+
+	r := iTx.Data.Fee.Cmp(jTx.Data.Fee)
+	switch r {
+	case -1:
+		return true
+	case 1:
+		return false
+	default:
+		return iTx.timestamp.After(jTx.timestamp)
+	}
+}
+
+func (h txHeapByTailFee) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].tailHeapIndex = i
+	h[j].tailHeapIndex = j
+}
+
+func (h *txHeapByTailFee) Push(x interface{}) {
+	q := x.(*txCollection)
+	q.tailHeapIndex = h.Len()
+	*h = append(*h, q)
+}
+
+func (h *txHeapByTailFee) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+// evictable returns the remote transaction with the lowest fee among the highest nonce
+// transactions of all accounts, so that evicting it will not lead to nonce gap.
+func (h txHeapByTailFee) evictable() *pooledTx {
+	if h.Len() == 0 {
+		return nil
+	}
+
+	if tx := h[0].last(); !tx.local {
+		return tx
+	}
+
+	return nil
+}
+
 // pendingQueue represents the fee sorted transactions that grouped by account.
 type pendingQueue struct {
-	txs     map[common.Address]*txCollection
-	feeHeap txHeapByFee
+	txs      map[common.Address]*txCollection
+	feeHeap  txHeapByFee
+	tailHeap txHeapByTailFee
 }
 
 func newPendingQueue() *pendingQueue {
@@ -70,12 +133,15 @@ func (q *pendingQueue) add(tx *pooledTx) {
 		if updated := !collection.add(tx); updated {
 			heap.Fix(&q.feeHeap, collection.heapIndex)
 		}
+
+		heap.Fix(&q.tailHeap, collection.tailHeapIndex)
 	} else {
 		collection := newTxCollection()
 		collection.add(tx)
 
 		q.txs[tx.Data.From] = collection
 		heap.Push(&q.feeHeap, collection)
+		heap.Push(&q.tailHeap, collection)
 	}
 }
 
@@ -101,11 +167,27 @@ func (q *pendingQueue) remove(addr common.Address, nonce uint64) {
 	if collection.len() == 0 {
 		delete(q.txs, addr)
 		heap.Remove(&q.feeHeap, collection.heapIndex)
+		heap.Remove(&q.tailHeap, collection.tailHeapIndex)
 	} else {
 		heap.Fix(&q.feeHeap, collection.heapIndex)
+		heap.Fix(&q.tailHeap, collection.tailHeapIndex)
 	}
 }
 
+// accountCount returns the number of transactions of the specified account.
+func (q *pendingQueue) accountCount(addr common.Address) int {
+	if collection := q.txs[addr]; collection != nil {
+		return collection.len()
+	}
+
+	return 0
+}
+
+// evictable returns the evictable transaction with the lowest fee in pending queue.
+func (q *pendingQueue) evictable() *pooledTx {
+	return q.tailHeap.evictable()
+}
+
 func (q *pendingQueue) count() int {
 	sum := 0
 
@@ -141,8 +223,10 @@ func (q *pendingQueue) pop() *types.Transaction {
 	if collection.len() == 0 {
 		delete(q.txs, tx.Data.From)
 		heap.Remove(&q.feeHeap, collection.heapIndex)
+		heap.Remove(&q.tailHeap, collection.tailHeapIndex)
 	} else {
 		heap.Fix(&q.feeHeap, collection.heapIndex)
+		heap.Fix(&q.tailHeap, collection.tailHeapIndex)
 	}
 
 	return tx
@@ -157,3 +241,94 @@ func (q *pendingQueue) list() []*types.Transaction {
 
 	return result
 }
+
+// queuedSet represents the transactions that are not executable yet due to nonce gap,
+// which are grouped by account and promoted into the pending queue once the gap is filled.
+type queuedSet struct {
+	txs      map[common.Address]*txCollection
+	tailHeap txHeapByTailFee
+}
+
+func newQueuedSet() *queuedSet {
+	return &queuedSet{
+		txs: make(map[common.Address]*txCollection),
+	}
+}
+
+func (q *queuedSet) add(tx *pooledTx) {
+	collection := q.txs[tx.Data.From]
+	if collection == nil {
+		collection = newTxCollection()
+		collection.add(tx)
+
+		q.txs[tx.Data.From] = collection
+		heap.Push(&q.tailHeap, collection)
+	} else {
+		collection.add(tx)
+		heap.Fix(&q.tailHeap, collection.tailHeapIndex)
+	}
+}
+
+func (q *queuedSet) get(addr common.Address, nonce uint64) *pooledTx {
+	if collection := q.txs[addr]; collection != nil {
+		return collection.get(nonce)
+	}
+
+	return nil
+}
+
+func (q *queuedSet) remove(addr common.Address, nonce uint64) *pooledTx {
+	collection := q.txs[addr]
+	if collection == nil {
+		return nil
+	}
+
+	tx := collection.get(nonce)
+	if tx == nil {
+		return nil
+	}
+
+	collection.remove(nonce)
+	if collection.len() == 0 {
+		delete(q.txs, addr)
+		heap.Remove(&q.tailHeap, collection.tailHeapIndex)
+	} else {
+		heap.Fix(&q.tailHeap, collection.tailHeapIndex)
+	}
+
+	return tx
+}
+
+// accountCount returns the number of transactions of the specified account.
+func (q *queuedSet) accountCount(addr common.Address) int {
+	if collection := q.txs[addr]; collection != nil {
+		return collection.len()
+	}
+
+	return 0
+}
+
+// evictable returns the evictable transaction with the lowest fee in queued set.
+func (q *queuedSet) evictable() *pooledTx {
+	return q.tailHeap.evictable()
+}
+
+func (q *queuedSet) count() int {
+	sum := 0
+
+	for _, collection := range q.txs {
+		sum += collection.len()
+	}
+
+	return sum
+}
+
+func (q *queuedSet) list() []*types.Transaction {
+	var result []*types.Transaction
+
+	for _, collection := range q.txs {
+		result = append(result, collection.list()...)
+	}
+
+	return result
+}
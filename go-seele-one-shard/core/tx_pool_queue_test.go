@@ -186,6 +186,81 @@ func Test_pendingQueue_list(t *testing.T) {
 	assert.Equal(t, txs[3], ptx3.Transaction)
 }
 
+func Test_pendingQueue_evictable(t *testing.T) {
+	q := newPendingQueue()
+	assert.Equal(t, q.evictable() == nil, true)
+
+	// only the highest nonce tx of account is evictable
+	ptx1 := newMockPooledTx(1, 1, 5)
+	q.add(ptx1)
+	ptx2 := newMockPooledTx(1, 3, 6)
+	q.add(ptx2)
+	ptx3 := newMockPooledTx(2, 2, 1)
+	q.add(ptx3)
+	assert.Equal(t, q.evictable(), ptx3)
+
+	// the newer one is evicted if fees are equal
+	ptx4 := newMockPooledTx(3, 2, 1)
+	ptx4.timestamp = ptx3.timestamp.Add(time.Second)
+	q.add(ptx4)
+	assert.Equal(t, q.evictable(), ptx4)
+
+	// local tx is never evicted
+	ptx4.local = true
+	q.remove(uintToAddress(3), 1)
+	q.add(ptx4)
+	assert.Equal(t, q.evictable(), ptx3)
+
+	// tail heap is updated when tx removed or popped
+	q.remove(uintToAddress(2), 1)
+	assert.Equal(t, q.evictable(), ptx2)
+
+	q.remove(uintToAddress(1), 6)
+	assert.Equal(t, q.evictable(), ptx1)
+
+	assert.Equal(t, q.pop(), ptx4.Transaction)
+	assert.Equal(t, q.evictable(), ptx1)
+
+	assert.Equal(t, q.pop(), ptx1.Transaction)
+	assert.Equal(t, q.evictable() == nil, true)
+}
+
+func Test_queuedSet_evictable(t *testing.T) {
+	q := newQueuedSet()
+	assert.Equal(t, q.evictable() == nil, true)
+
+	ptx1 := newMockPooledTx(1, 1, 5)
+	q.add(ptx1)
+	ptx2 := newMockPooledTx(1, 4, 9)
+	q.add(ptx2)
+	ptx3 := newMockPooledTx(2, 3, 7)
+	q.add(ptx3)
+	assert.Equal(t, q.evictable(), ptx3)
+
+	assert.Equal(t, q.remove(uintToAddress(2), 7), ptx3)
+	assert.Equal(t, q.evictable(), ptx2)
+
+	assert.Equal(t, q.remove(uintToAddress(1), 9), ptx2)
+	assert.Equal(t, q.evictable(), ptx1)
+
+	ptx1.local = true
+	assert.Equal(t, q.evictable() == nil, true)
+
+	assert.Equal(t, q.remove(uintToAddress(1), 5), ptx1)
+	assert.Equal(t, len(q.tailHeap), 0)
+}
+
+func Benchmark_PendingQueue_evictable(b *testing.B) {
+	q := preparePendingQueue(prepareTxs(DefaultTxPoolConfig().Capacity, 3))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if q.evictable() == nil {
+			b.Fatal()
+		}
+	}
+}
+
 func Benchmark_PendingQueue_popN(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		b.StopTimer()
@@ -7,7 +7,10 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -21,15 +24,19 @@ import (
 	"github.com/seeleteam/go-seele/log"
 )
 
+// randomAccount generates a random account that belongs to chain 0, which is the chain of test pool.
 func randomAccount(t *testing.T) (*ecdsa.PrivateKey, common.Address) {
-	privKey, keyErr := crypto.GenerateKey()
-	if keyErr != nil {
-		t.Fatalf("Failed to generate ECDSA private key, error = %s", keyErr.Error())
+	for {
+		privKey, keyErr := crypto.GenerateKey()
+		if keyErr != nil {
+			t.Fatalf("Failed to generate ECDSA private key, error = %s", keyErr.Error())
+		}
+
+		addr := common.HexMustToAddres(crypto.PubkeyToString(&privKey.PublicKey))
+		if addr.GetChainNum(common.DefaultNumOfChains) == 0 {
+			return privKey, addr
+		}
 	}
-
-	hexAddress := crypto.PubkeyToString(&privKey.PublicKey)
-
-	return privKey, common.HexMustToAddres(hexAddress)
 }
 
 func newTestPoolTx(t *testing.T, amount int64, nonce uint64) *pooledTx {
@@ -72,6 +79,14 @@ func (chain mockBlockchain) GetCurrentState() (*state.Statedb, error) {
 	return chain.statedb, nil
 }
 
+func (chain mockBlockchain) CurrentBlock() *types.Block {
+	return &types.Block{Header: &types.BlockHeader{Height: 0}}
+}
+
+func (chain mockBlockchain) ValidateTxReplay(tx *types.Transaction, height uint64) error {
+	return tx.ValidateVersion(height, 0)
+}
+
 func (chain mockBlockchain) GetStore() store.BlockchainStore {
 	return chain.chainStore
 }
@@ -81,10 +96,14 @@ func newTestPool(config *TransactionPoolConfig) (*TransactionPool, *mockBlockcha
 	pool := &TransactionPool{
 		config:        *config,
 		chain:         chain,
+		numOfChains:   common.DefaultNumOfChains,
 		hashToTxMap:   make(map[common.Hash]*pooledTx),
 		pendingQueue:  newPendingQueue(),
+		queuedTxs:     newQueuedSet(),
 		processingTxs: make(map[common.Hash]struct{}),
 		log:           log.GetLogger("test"),
+
+		processingNonces: make(map[common.Address]uint64),
 	}
 
 	return pool, chain
@@ -161,6 +180,151 @@ func Test_TransactionPool_Add_PoolFull(t *testing.T) {
 	assert.Equal(t, err, errTxPoolFull)
 }
 
+func Test_TransactionPool_Add_EvictLowestFee(t *testing.T) {
+	config := DefaultTxPoolConfig()
+	config.Capacity = 2
+	pool, chain := newTestPool(config)
+	defer chain.dispose()
+
+	privKey1, addr1 := randomAccount(t)
+	chain.addAccount(addr1, 100, 10)
+	poolTx1 := newTestPoolEx(t, privKey1, addr1, 10, 10, 5)
+	poolTx2 := newTestPoolEx(t, privKey1, addr1, 10, 11, 5)
+	assert.Equal(t, pool.AddTransaction(poolTx1.Transaction), error(nil))
+	assert.Equal(t, pool.AddTransaction(poolTx2.Transaction), error(nil))
+
+	// same fee could not evict
+	privKey2, addr2 := randomAccount(t)
+	chain.addAccount(addr2, 100, 10)
+	poolTx3 := newTestPoolEx(t, privKey2, addr2, 10, 10, 5)
+	assert.Equal(t, pool.AddTransaction(poolTx3.Transaction), errTxPoolFull)
+
+	// the highest nonce tx is evicted to avoid nonce gap
+	poolTx3 = newTestPoolEx(t, privKey2, addr2, 10, 10, 6)
+	assert.Equal(t, pool.AddTransaction(poolTx3.Transaction), error(nil))
+	assert.Equal(t, pool.GetTransaction(poolTx1.Hash) != nil, true)
+	assert.Equal(t, pool.GetTransaction(poolTx2.Hash) == nil, true)
+	assert.Equal(t, pool.GetTransaction(poolTx3.Hash) != nil, true)
+
+	count, recent := pool.GetEvictions()
+	assert.Equal(t, count, uint64(1))
+	assert.Equal(t, len(recent), 1)
+	assert.Equal(t, recent[0].Hash, poolTx2.Hash)
+	assert.Equal(t, recent[0].ReplacedBy, poolTx3.Hash)
+}
+
+func Test_TransactionPool_Add_LocalNotEvicted(t *testing.T) {
+	config := DefaultTxPoolConfig()
+	config.Capacity = 1
+	pool, chain := newTestPool(config)
+	defer chain.dispose()
+
+	poolTx1 := newTestPoolTx(t, 10, 100)
+	chain.addAccount(poolTx1.Data.From, 20, 100)
+	assert.Equal(t, pool.AddLocalTransaction(poolTx1.Transaction), error(nil))
+
+	privKey, addr := randomAccount(t)
+	chain.addAccount(addr, 100, 10)
+	poolTx2 := newTestPoolEx(t, privKey, addr, 10, 10, 5)
+	assert.Equal(t, pool.AddTransaction(poolTx2.Transaction), errTxPoolFull)
+}
+
+func Test_TransactionPool_Add_AccountSlotsFull(t *testing.T) {
+	config := DefaultTxPoolConfig()
+	config.AccountSlots = 1
+	pool, chain := newTestPool(config)
+	defer chain.dispose()
+
+	privKey, addr := randomAccount(t)
+	chain.addAccount(addr, 100, 10)
+	assert.Equal(t, pool.AddTransaction(newTestPoolEx(t, privKey, addr, 10, 10, 1).Transaction), error(nil))
+	assert.Equal(t, pool.AddTransaction(newTestPoolEx(t, privKey, addr, 10, 11, 1).Transaction), errTxAccountSlotsFull)
+
+	// replacement is allowed
+	assert.Equal(t, pool.AddTransaction(newTestPoolEx(t, privKey, addr, 10, 10, 2).Transaction), error(nil))
+}
+
+func Test_TransactionPool_Add_FeeTooLow(t *testing.T) {
+	config := DefaultTxPoolConfig()
+	config.MinFee = big.NewInt(10)
+	pool, chain := newTestPool(config)
+	defer chain.dispose()
+
+	privKey, addr := randomAccount(t)
+	chain.addAccount(addr, 100, 10)
+	assert.Equal(t, pool.AddTransaction(newTestPoolEx(t, privKey, addr, 10, 10, 9).Transaction), errTxFeeTooLow)
+	assert.Equal(t, pool.AddTransaction(newTestPoolEx(t, privKey, addr, 10, 10, 10).Transaction), error(nil))
+}
+
+func Test_TransactionPool_Add_QueuedAndPromoted(t *testing.T) {
+	pool, chain := newTestPool(DefaultTxPoolConfig())
+	defer chain.dispose()
+
+	privKey, addr := randomAccount(t)
+	chain.addAccount(addr, 100, 10)
+
+	// nonce gap, queued
+	poolTx12 := newTestPoolEx(t, privKey, addr, 10, 12, 1)
+	assert.Equal(t, pool.AddTransaction(poolTx12.Transaction), error(nil))
+	assert.Equal(t, pool.GetPendingTxCount(), 0)
+	assert.Equal(t, pool.GetQueuedTxCount(), 1)
+
+	txs, _ := pool.GetProcessableTransactions(BlockByteLimit)
+	assert.Equal(t, len(txs), 0)
+
+	// gap filled, promoted
+	poolTx11 := newTestPoolEx(t, privKey, addr, 10, 11, 1)
+	assert.Equal(t, pool.AddTransaction(poolTx11.Transaction), error(nil))
+	assert.Equal(t, pool.GetQueuedTxCount(), 2)
+
+	poolTx10 := newTestPoolEx(t, privKey, addr, 10, 10, 1)
+	assert.Equal(t, pool.AddTransaction(poolTx10.Transaction), error(nil))
+	assert.Equal(t, pool.GetPendingTxCount(), 3)
+	assert.Equal(t, pool.GetQueuedTxCount(), 0)
+
+	txs, _ = pool.GetProcessableTransactions(BlockByteLimit)
+	assert.Equal(t, len(txs), 3)
+	for i, tx := range txs {
+		assert.Equal(t, tx.Data.AccountNonce, uint64(10+i))
+	}
+
+	// follows the transactions being processed
+	poolTx13 := newTestPoolEx(t, privKey, addr, 10, 13, 1)
+	assert.Equal(t, pool.AddTransaction(poolTx13.Transaction), error(nil))
+	assert.Equal(t, pool.GetPendingTxCount(), 1)
+}
+
+func Test_TransactionPool_ResetExecutables(t *testing.T) {
+	pool, chain := newTestPool(DefaultTxPoolConfig())
+	defer chain.dispose()
+
+	privKey, addr := randomAccount(t)
+	chain.addAccount(addr, 100, 10)
+
+	poolTx10 := newTestPoolEx(t, privKey, addr, 10, 10, 1)
+	poolTx11 := newTestPoolEx(t, privKey, addr, 10, 11, 1)
+	poolTx13 := newTestPoolEx(t, privKey, addr, 10, 13, 1)
+	pool.addTransactions([]*types.Transaction{poolTx10.Transaction, poolTx11.Transaction, poolTx13.Transaction})
+	assert.Equal(t, pool.GetPendingTxCount(), 2)
+	assert.Equal(t, pool.GetQueuedTxCount(), 1)
+
+	// removing tx 10 demotes tx 11
+	pool.RemoveTransaction(poolTx10.Hash)
+	pool.removeTransactions()
+	assert.Equal(t, pool.GetPendingTxCount(), 0)
+	assert.Equal(t, pool.GetQueuedTxCount(), 2)
+
+	// gap filled after account nonce changed, all are promoted
+	chain.statedb.SetNonce(addr, 11)
+	poolTx12 := newTestPoolEx(t, privKey, addr, 10, 12, 1)
+	pool.addTransactions([]*types.Transaction{poolTx12.Transaction})
+	assert.Equal(t, pool.GetQueuedTxCount(), 3)
+
+	pool.removeTransactions()
+	assert.Equal(t, pool.GetPendingTxCount(), 3)
+	assert.Equal(t, pool.GetQueuedTxCount(), 0)
+}
+
 func Test_TransactionPool_Add_TxNonceUsed(t *testing.T) {
 	pool, chain := newTestPool(DefaultTxPoolConfig())
 	defer chain.dispose()
@@ -236,8 +400,11 @@ func Test_GetReinjectTransaction(t *testing.T) {
 		chain:         bc,
 		hashToTxMap:   make(map[common.Hash]*pooledTx),
 		pendingQueue:  newPendingQueue(),
+		queuedTxs:     newQueuedSet(),
 		processingTxs: make(map[common.Hash]struct{}),
 		log:           log.GetLogger("test"),
+
+		processingNonces: make(map[common.Address]uint64),
 	}
 
 	b1 := newTestBlock(bc, bc.genesisBlock.HeaderHash, 1, 0, 4*types.TransactionPreSize)
@@ -377,3 +544,84 @@ func Test_TransactionPool_GetProcessableTransactions(t *testing.T) {
 	assert.Equal(t, len(txs), 0)
 	assert.Equal(t, size, 0)
 }
+
+func Test_TransactionPool_Journal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "SeeleCoreTxJournal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := DefaultTxPoolConfig()
+	config.JournalFile = filepath.Join(dir, "0", config.JournalFile)
+
+	chain := newMockBlockchain()
+	defer chain.dispose()
+
+	pool := NewTransactionPool(*config, chain, 0, common.DefaultNumOfChains)
+
+	localTx := newTestPoolTx(t, 10, 100)
+	chain.addAccount(localTx.Data.From, 20, 100)
+	assert.Equal(t, pool.AddLocalTransaction(localTx.Transaction), error(nil))
+
+	remoteTx := newTestPoolTx(t, 10, 100)
+	chain.addAccount(remoteTx.Data.From, 20, 100)
+	assert.Equal(t, pool.AddTransaction(remoteTx.Transaction), error(nil))
+
+	usedTx := newTestPoolTx(t, 10, 100)
+	chain.addAccount(usedTx.Data.From, 20, 100)
+	assert.Equal(t, pool.AddLocalTransaction(usedTx.Transaction), error(nil))
+	pool.Stop()
+
+	// only the local transactions are replayed, and the used nonce is dropped after restart.
+	chain.statedb.SetNonce(usedTx.Data.From, 101)
+	pool = NewTransactionPool(*config, chain, 0, common.DefaultNumOfChains)
+	defer pool.Stop()
+
+	assert.Equal(t, len(pool.hashToTxMap), 1)
+	assert.Equal(t, pool.GetTransaction(localTx.Hash) != nil, true)
+}
+
+func Test_TransactionPool_JournalRemotes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "SeeleCoreTxJournalRemotes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := DefaultTxPoolConfig()
+	config.JournalFile = filepath.Join(dir, "0", config.JournalFile)
+	config.JournalRemotes = true
+
+	chain := newMockBlockchain()
+	defer chain.dispose()
+
+	pool := NewTransactionPool(*config, chain, 0, common.DefaultNumOfChains)
+
+	localTx := newTestPoolTx(t, 10, 100)
+	chain.addAccount(localTx.Data.From, 20, 100)
+	assert.Equal(t, pool.AddLocalTransaction(localTx.Transaction), error(nil))
+
+	remoteTx := newTestPoolTx(t, 10, 100)
+	chain.addAccount(remoteTx.Data.From, 20, 100)
+	assert.Equal(t, pool.AddTransaction(remoteTx.Transaction), error(nil))
+	pool.Stop()
+
+	// the remote transactions are replayed as remote ones
+	pool = NewTransactionPool(*config, chain, 0, common.DefaultNumOfChains)
+
+	assert.Equal(t, len(pool.hashToTxMap), 2)
+	assert.Equal(t, pool.hashToTxMap[localTx.Hash].local, true)
+	assert.Equal(t, pool.hashToTxMap[remoteTx.Hash].local, false)
+
+	// the flag is kept when the journal is regenerated
+	assert.Equal(t, pool.rotateJournal(), error(nil))
+	pool.Stop()
+
+	pool = NewTransactionPool(*config, chain, 0, common.DefaultNumOfChains)
+	defer pool.Stop()
+
+	assert.Equal(t, len(pool.hashToTxMap), 2)
+	assert.Equal(t, pool.hashToTxMap[localTx.Hash].local, true)
+	assert.Equal(t, pool.hashToTxMap[remoteTx.Hash].local, false)
+}
@@ -26,6 +26,8 @@ type BlockHeader struct {
 	CreateTimestamp   *big.Int       // CreateTimestamp is the timestamp when the block is created
 	Nonce             uint64         // Nonce is the pow of the block
 	ExtraData         []byte         // ExtraData stores the extra info of block header.
+	MiningData        MiningDataPack // MiningData is used to verify the chain that the block is mined on
+	LogBloom          Bloom          // LogBloom is the bloom filter of contract addresses and topics of logs in receipts
 }
 
 // Clone returns a clone of the block header.
@@ -41,6 +43,7 @@ func (header *BlockHeader) Clone() *BlockHeader {
 	}
 
 	clone.ExtraData = common.CopyBytes(header.ExtraData)
+	clone.MiningData = header.MiningData.Clone()
 
 	return &clone
 }
@@ -64,7 +67,8 @@ type Block struct {
 // array is copied, but each transaction is not copied.
 // So any change of the input transaction will affect the block.
 // The input receipt array is the same behavior with transation array.
-func NewBlock(header *BlockHeader, txs []*Transaction, receipts []*Receipt, debts []*Debt, chainNum uint64) *Block {
+// The debts of txs are created with the specified number of chains.
+func NewBlock(header *BlockHeader, txs []*Transaction, receipts []*Receipt, debts []*Debt, chainNum, numOfChains uint64) *Block {
 	block := &Block{
 		Header: header.Clone(),
 		ChainNum: chainNum,
@@ -83,8 +87,9 @@ func NewBlock(header *BlockHeader, txs []*Transaction, receipts []*Receipt, debt
 	}
 
 	block.Header.ReceiptHash = ReceiptMerkleRootHash(receipts)
+	block.Header.LogBloom = CreateBloom(receipts)
 	block.Header.DebtHash = DebtMerkleRootHash(debts)
-	block.Header.TxDebtHash = DebtMerkleRootHash(NewDebts(txs))
+	block.Header.TxDebtHash = DebtMerkleRootHash(NewDebts(txs, numOfChains))
 	
 	// Calculate the block header hash.
 	block.HeaderHash = block.Header.Hash()
@@ -0,0 +1,130 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package types
+
+import (
+	"errors"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/common/hexutil"
+	"github.com/seeleteam/go-seele/crypto"
+)
+
+// BloomByteLength is the byte length of the log bloom filter, which is 2048 bits.
+const BloomByteLength = 256
+
+var errBloomTooLong = errors.New("bloom filter is too long")
+
+// Bloom represents a bloom filter of the contract addresses and topics of logs in a block.
+type Bloom [BloomByteLength]byte
+
+// Add adds the specified data into the bloom filter.
+func (b *Bloom) Add(data []byte) {
+	for _, bit := range bloomBits(data) {
+		b[BloomByteLength-1-bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// Test checks whether the specified data may be in the bloom filter.
+func (b Bloom) Test(data []byte) bool {
+	for _, bit := range bloomBits(data) {
+		if b[BloomByteLength-1-bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatchFilter checks whether the bloom filter may contain the logs that satisfy the specified
+// addresses and topics. See Log.MatchFilter for the filter criteria.
+func (b Bloom) MatchFilter(addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 {
+		matched := false
+		for _, addr := range addresses {
+			if b.Test(addr.Bytes()) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	for _, alternatives := range topics {
+		if len(alternatives) == 0 {
+			continue
+		}
+
+		matched := false
+		for _, topic := range alternatives {
+			if b.Test(topic.Bytes()) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MarshalText marshals the bloom filter to hex.
+func (b Bloom) MarshalText() ([]byte, error) {
+	return []byte(hexutil.BytesToHex(b[:])), nil
+}
+
+// UnmarshalText unmarshals the bloom filter from hex.
+func (b *Bloom) UnmarshalText(text []byte) error {
+	data, err := hexutil.HexToBytes(string(text))
+	if err != nil {
+		return err
+	}
+
+	if len(data) > BloomByteLength {
+		return errBloomTooLong
+	}
+
+	copy(b[BloomByteLength-len(data):], data)
+	return nil
+}
+
+// CreateBloom creates the bloom filter with the contract addresses and topics of logs in the specified receipts.
+func CreateBloom(receipts []*Receipt) Bloom {
+	var bloom Bloom
+
+	for _, r := range receipts {
+		if r == nil {
+			continue
+		}
+
+		for _, log := range r.Logs {
+			bloom.Add(log.Address.Bytes())
+			for _, topic := range log.Topics {
+				bloom.Add(topic.Bytes())
+			}
+		}
+	}
+
+	return bloom
+}
+
+// bloomBits returns the 3 bit positions in bloom filter for the specified data.
+func bloomBits(data []byte) []uint {
+	hash := crypto.HashBytes(data).Bytes()
+
+	bits := make([]uint, 3)
+	for i := range bits {
+		bits[i] = (uint(hash[2*i])<<8 | uint(hash[2*i+1])) & (BloomByteLength*8 - 1)
+	}
+
+	return bits
+}
@@ -0,0 +1,87 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package types
+
+import (
+	"testing"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLog() *Log {
+	return &Log{
+		Address: *crypto.MustGenerateRandomAddress(),
+		Topics:  []common.Hash{common.StringToHash("Transfer"), common.StringToHash("from")},
+		Data:    []byte("data"),
+	}
+}
+
+func Test_Bloom_AddAndTest(t *testing.T) {
+	var bloom Bloom
+	assert.Equal(t, bloom.Test([]byte("seele")), false)
+
+	bloom.Add([]byte("seele"))
+	assert.Equal(t, bloom.Test([]byte("seele")), true)
+	assert.Equal(t, bloom.Test([]byte("go-seele")), false)
+}
+
+func Test_CreateBloom(t *testing.T) {
+	log := newTestLog()
+	receipts := []*Receipt{&Receipt{}, &Receipt{Logs: []*Log{log}}}
+
+	bloom := CreateBloom(receipts)
+	assert.Equal(t, bloom.Test(log.Address.Bytes()), true)
+	assert.Equal(t, bloom.Test(log.Topics[0].Bytes()), true)
+	assert.Equal(t, bloom.Test(log.Topics[1].Bytes()), true)
+
+	assert.Equal(t, CreateBloom(nil), Bloom{})
+}
+
+func Test_Bloom_MatchFilter(t *testing.T) {
+	log := newTestLog()
+	bloom := CreateBloom([]*Receipt{&Receipt{Logs: []*Log{log}}})
+	otherAddr := *crypto.MustGenerateRandomAddress()
+	otherTopic := common.StringToHash("Approval")
+
+	assert.Equal(t, bloom.MatchFilter(nil, nil), true)
+	assert.Equal(t, bloom.MatchFilter([]common.Address{otherAddr, log.Address}, nil), true)
+	assert.Equal(t, bloom.MatchFilter([]common.Address{otherAddr}, nil), false)
+	assert.Equal(t, bloom.MatchFilter(nil, [][]common.Hash{{otherTopic, log.Topics[0]}}), true)
+	assert.Equal(t, bloom.MatchFilter(nil, [][]common.Hash{{otherTopic}}), false)
+	assert.Equal(t, bloom.MatchFilter(nil, [][]common.Hash{{}, {log.Topics[1]}}), true)
+}
+
+func Test_Bloom_MarshalText(t *testing.T) {
+	var bloom Bloom
+	bloom.Add([]byte("seele"))
+
+	text, err := bloom.MarshalText()
+	assert.Equal(t, err, nil)
+
+	var decoded Bloom
+	assert.Equal(t, decoded.UnmarshalText(text), nil)
+	assert.Equal(t, decoded, bloom)
+}
+
+func Test_Log_MatchFilter(t *testing.T) {
+	log := newTestLog()
+	otherAddr := *crypto.MustGenerateRandomAddress()
+	otherTopic := common.StringToHash("Approval")
+
+	assert.Equal(t, log.MatchFilter(nil, nil), true)
+	assert.Equal(t, log.MatchFilter([]common.Address{log.Address}, nil), true)
+	assert.Equal(t, log.MatchFilter([]common.Address{otherAddr}, nil), false)
+
+	// topics match by position
+	assert.Equal(t, log.MatchFilter(nil, [][]common.Hash{{log.Topics[0]}}), true)
+	assert.Equal(t, log.MatchFilter(nil, [][]common.Hash{{log.Topics[1]}}), false)
+	assert.Equal(t, log.MatchFilter(nil, [][]common.Hash{{}, {otherTopic, log.Topics[1]}}), true)
+
+	// more topic positions than the log has
+	assert.Equal(t, log.MatchFilter(nil, [][]common.Hash{{}, {}, {}}), false)
+}
@@ -6,7 +6,10 @@
 package types
 
  import (
+	"bytes"
+	"errors"
  	"math/big"
+	"sort"
 
  	"github.com/seeleteam/go-seele/common"
  	"github.com/seeleteam/go-seele/crypto"
@@ -14,10 +17,19 @@ package types
  )
 
  // DebtSize debt serialized size
- const DebtSize = 96
+ const DebtSize = 119
+
+var (
+	// ErrDebtProofMismatch is returned when the debt is not proved by the merkle proof.
+	ErrDebtProofMismatch = errors.New("debt merkle proof mismatch")
+
+	// ErrDebtSourceInvalid is returned when the debt is not attached with a valid source block.
+	ErrDebtSourceInvalid = errors.New("invalid debt source")
+)
 
  type DebtData struct {
  	TxHash  common.Hash    // the hash of the executed transaction
+	From    common.Address // the sender of the executed transaction, which determines the source chain
  	Shard   uint           // target shard
  	Account common.Address // debt for account
  	Amount  *big.Int       // debt amount
@@ -29,10 +41,62 @@ package types
  type Debt struct {
  	Hash common.Hash // Debt hash of DebtData
  	Data DebtData
+
+	// Source proves the debt is generated in a block of the source chain, which is committed
+	// in the debt merkle root hash of the block that packs the debt.
+	Source *DebtSource `rlp:"nil"`
  }
 
+// DebtSource is the source block of debt with the merkle proof of debt in the debt trie
+// of source block, whose root hash is the TxDebtHash of source block header.
+type DebtSource struct {
+	BlockHash common.Hash // hash of the source block
+	Height    uint64      // height of the source block
+	Proof     [][]byte    // encoded trie nodes on the path to the debt
+}
+
 type DebtIndex indexInBlock
 
+// DebtStatus represents the lifecycle status of a cross chain debt.
+type DebtStatus byte
+
+const (
+	// DebtStatusCreated means the source tx of debt is packed, but not confirmed yet.
+	DebtStatusCreated DebtStatus = iota
+
+	// DebtStatusPending means the source tx of debt is confirmed and the debt could be packed.
+	DebtStatusPending
+
+	// DebtStatusApplied means the debt is packed in the canonical chain of target chain.
+	DebtStatusApplied
+
+	// DebtStatusReverted means the debt was packed, but reverted due to chain reorganization.
+	DebtStatusReverted
+)
+
+// String returns the readable name of debt status.
+func (s DebtStatus) String() string {
+	switch s {
+	case DebtStatusCreated:
+		return "created"
+	case DebtStatusPending:
+		return "pending"
+	case DebtStatusApplied:
+		return "applied"
+	case DebtStatusReverted:
+		return "reverted"
+	default:
+		return "unknown"
+	}
+}
+
+// DebtRecord is a debt with its lifecycle status, which is persisted by debt pool.
+type DebtRecord struct {
+	Debt      *Debt
+	Status    DebtStatus
+	Timestamp uint64 // unix time when the debt is added into pool
+}
+
 // DebtMerkleRootHash calculates and returns the merkle root hash of the specified debts.
 // If the given receipts are empty, return empty hash.
 func DebtMerkleRootHash(debts []*Debt) common.Hash {
@@ -40,6 +104,10 @@ func DebtMerkleRootHash(debts []*Debt) common.Hash {
 		return common.EmptyHash
 	}
 
+	return newDebtTrie(debts).Hash()
+}
+
+func newDebtTrie(debts []*Debt) *trie.Trie {
 	debtTrie, err := trie.NewTrie(common.EmptyHash, make([]byte, 0), nil)
 	if err != nil {
 		panic(err)
@@ -54,11 +122,66 @@ func DebtMerkleRootHash(debts []*Debt) common.Hash {
 		debtTrie.Put(d.Hash.Bytes(), buff)
 	}
 
-	return debtTrie.Hash()
+	return debtTrie
+}
+
+// getDebtProof returns the merkle proof of the debt with specified hash in the specified debt trie.
+// The trie nodes are sorted, so that the proof is deterministic.
+func getDebtProof(debtTrie *trie.Trie, hash common.Hash) ([][]byte, error) {
+	nodes, err := debtTrie.GetProof(hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	proof := make([][]byte, 0, len(nodes))
+	for _, n := range nodes {
+		proof = append(proof, n)
+	}
+
+	sort.Slice(proof, func(i, j int) bool {
+		return bytes.Compare(proof[i], proof[j]) < 0
+	})
+
+	return proof, nil
+}
+
+// VerifyDebtProof verifies the debt against the specified debt merkle root hash of source block
+// with the merkle proof in the source of debt.
+func VerifyDebtProof(root common.Hash, debt *Debt) error {
+	if debt.Source == nil {
+		return ErrDebtSourceInvalid
+	}
+
+	proof := make(map[string][]byte)
+	for _, n := range debt.Source.Proof {
+		proof[string(crypto.HashBytes(n).Bytes())] = n
+	}
+
+	value, err := trie.VerifyProof(root, debt.Hash.Bytes(), proof)
+	if err != nil || value == nil {
+		return ErrDebtProofMismatch
+	}
+
+	// the debt is put in the debt trie of source block without source.
+	if !bytes.Equal(value, common.SerializePanic(&Debt{Hash: debt.Hash, Data: debt.Data})) {
+		return ErrDebtProofMismatch
+	}
+
+	return nil
 }
 
+// Size returns the serialized size of debt, including the proof of source block if any.
 func (d *Debt) Size() int {
-	return DebtSize
+	if d.Source == nil {
+		return DebtSize
+	}
+
+	size := DebtSize + common.HashLength + 8
+	for _, n := range d.Source.Proof {
+		size += len(n)
+	}
+
+	return size
 }
 
 func GetDebtShareFee(fee *big.Int) *big.Int {
@@ -68,7 +191,9 @@ func GetDebtShareFee(fee *big.Int) *big.Int {
 	return share
 }
 
-func NewDebt(tx *Transaction) *Debt {
+// NewDebt returns the debt of the specified tx, which is identified as cross chain with the
+// specified number of chains. Returns nil if the tx does not transfer to another chain or shard.
+func NewDebt(tx *Transaction, numOfChains uint64) *Debt {
 	if tx == nil || tx.Data.To.IsEmpty() || tx.Data.To.IsReserved() {
 		return nil
 	}
@@ -78,8 +203,8 @@ func NewDebt(tx *Transaction) *Debt {
 		return nil
 	}
 
-	fromChainNum := tx.Data.From.GetChainNum()
-	toChainNum := tx.Data.To.GetChainNum()
+	fromChainNum := tx.Data.From.GetChainNum(numOfChains)
+	toChainNum := tx.Data.To.GetChainNum(numOfChains)
 	shard := tx.Data.To.Shard()
 	if shard == common.LocalShardNumber && fromChainNum == toChainNum {
 		return nil
@@ -87,6 +212,7 @@ func NewDebt(tx *Transaction) *Debt {
 
 	data := DebtData{
 		TxHash:  tx.Hash,
+		From:    tx.Data.From,
 		Shard:   shard,
 		Account: tx.Data.To,
 		Amount:  big.NewInt(0).Set(tx.Data.Amount),
@@ -106,11 +232,11 @@ func NewDebt(tx *Transaction) *Debt {
 	return debt
 }
 
-func NewDebts(txs []*Transaction) []*Debt {
+func NewDebts(txs []*Transaction, numOfChains uint64) []*Debt {
 	debts := make([]*Debt, 0)
 
 	for _, tx := range txs {
-		d := NewDebt(tx)
+		d := NewDebt(tx, numOfChains)
 		if d != nil {
 			debts = append(debts, d)
 		}
@@ -119,15 +245,28 @@ func NewDebts(txs []*Transaction) []*Debt {
 	return debts
 }
 
-func NewDebtMap(txs []*Transaction) [][]*Debt {
-	debts := make([][]*Debt, common.ShardCount+1)
+// NewDebtMap returns the debts of the txs in the specified block grouped by target shard.
+// Each debt is attached with its source block and the merkle proof in the debt trie of block,
+// so that the debt could be verified against the source block header.
+func NewDebtMap(block *Block, numOfChains uint64) ([][]*Debt, error) {
+	debts := NewDebts(block.Transactions, numOfChains)
+	debtTrie := newDebtTrie(debts)
+	debtMap := make([][]*Debt, common.ShardCount+1)
 
-	for _, tx := range txs {
-		d := NewDebt(tx)
-		if d != nil {
-			debts[d.Data.Shard] = append(debts[d.Data.Shard], d)
+	for _, d := range debts {
+		proof, err := getDebtProof(debtTrie, d.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		d.Source = &DebtSource{
+			BlockHash: block.HeaderHash,
+			Height:    block.Header.Height,
+			Proof:     proof,
 		}
+
+		debtMap[d.Data.Shard] = append(debtMap[d.Data.Shard], d)
 	}
 
-	return debts
+	return debtMap, nil
 }
@@ -18,7 +18,7 @@ import (
 func Test_NewDebt(t *testing.T) {
 	tx1 := newTestTx(t, 1, 1, 1, true)
 
-	d1 := NewDebt(tx1)
+	d1 := NewDebt(tx1, common.DefaultNumOfChains)
 	assert.Equal(t, d1.Data.Amount, big.NewInt(1))
 	assert.Equal(t, d1.Data.Account, tx1.Data.To)
 	assert.Equal(t, d1.Data.Shard, tx1.Data.To.Shard())
@@ -31,7 +31,7 @@ func Test_MerkleRoot(t *testing.T) {
 
 	for i := 0; i < 100; i++ {
 		tx := newTestTx(t, 1, 1, 1, true)
-		d := NewDebt(tx)
+		d := NewDebt(tx, common.DefaultNumOfChains)
 
 		debts = append(debts, d)
 	}
@@ -50,7 +50,7 @@ func Test_MerkleRoot(t *testing.T) {
 func Test_DebtSize(t *testing.T) {
 	tx := newTestTx(t, 1, 1, 1, true)
 
-	d := NewDebt(tx)
+	d := NewDebt(tx, common.DefaultNumOfChains)
 
 	array := []*Debt{d, d}
 	buff := common.SerializePanic(array)
@@ -71,3 +71,63 @@ func Test_FeeShare(t *testing.T) {
 		}
 	}
 }
+
+func Test_DebtProof(t *testing.T) {
+	txs := make([]*Transaction, 0)
+	for i := 0; i < 10; i++ {
+		txs = append(txs, newTestTx(t, 1, 1, 1, true))
+	}
+
+	root := DebtMerkleRootHash(NewDebts(txs, common.DefaultNumOfChains))
+	block := &Block{
+		HeaderHash:   common.StringToHash("block"),
+		Header:       &BlockHeader{Height: 5, TxDebtHash: root},
+		Transactions: txs,
+	}
+
+	debtMap, err := NewDebtMap(block, common.DefaultNumOfChains)
+	assert.Equal(t, err, nil)
+
+	debts := make([]*Debt, 0)
+	for _, shardDebts := range debtMap {
+		debts = append(debts, shardDebts...)
+	}
+	assert.Equal(t, len(debts), len(txs))
+
+	for _, d := range debts {
+		assert.Equal(t, d.Source.BlockHash, block.HeaderHash)
+		assert.Equal(t, d.Source.Height, block.Header.Height)
+		assert.Equal(t, VerifyDebtProof(root, d), nil)
+		assert.Equal(t, d.Size() > DebtSize, true)
+	}
+
+	// source is attached with the debt
+	var decoded Debt
+	assert.Equal(t, common.Deserialize(common.SerializePanic(debts[3]), &decoded), nil)
+	assert.Equal(t, VerifyDebtProof(root, &decoded), nil)
+
+	// tampered debt
+	tampered := *debts[3]
+	tampered.Data.Amount = big.NewInt(100)
+	assert.Equal(t, VerifyDebtProof(root, &tampered), ErrDebtProofMismatch)
+
+	// forged debt with the proof of another debt
+	tampered.Hash = crypto.MustHash(tampered.Data)
+	assert.Equal(t, VerifyDebtProof(root, &tampered), ErrDebtProofMismatch)
+
+	// debt not in trie
+	other := NewDebt(newTestTx(t, 1, 1, 1, true), common.DefaultNumOfChains)
+	other.Source = debts[3].Source
+	assert.Equal(t, VerifyDebtProof(root, other), ErrDebtProofMismatch)
+
+	// debt without source
+	assert.Equal(t, VerifyDebtProof(root, NewDebt(txs[0], common.DefaultNumOfChains)), ErrDebtSourceInvalid)
+}
+
+func Test_DebtStatus(t *testing.T) {
+	assert.Equal(t, DebtStatusCreated.String(), "created")
+	assert.Equal(t, DebtStatusPending.String(), "pending")
+	assert.Equal(t, DebtStatusApplied.String(), "applied")
+	assert.Equal(t, DebtStatusReverted.String(), "reverted")
+	assert.Equal(t, DebtStatus(100).String(), "unknown")
+}
@@ -26,3 +26,45 @@ type Log struct {
 	// index of the transaction in the block
 	TxIndex uint `json:"transactionIndex" gencodec:"required"`
 }
+
+// MatchFilter checks whether the log satisfies the specified addresses and topics.
+// The log matches if its address is one of the addresses, or the addresses are empty.
+// The topics is a list of topic alternatives by position, the log matches if its topic
+// at each position is one of the alternatives, or the alternatives are empty.
+func (log *Log) MatchFilter(addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 && !containsAddress(addresses, log.Address) {
+		return false
+	}
+
+	if len(topics) > len(log.Topics) {
+		return false
+	}
+
+	for i, alternatives := range topics {
+		if len(alternatives) > 0 && !containsHash(alternatives, log.Topics[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsAddress(addresses []common.Address, addr common.Address) bool {
+	for _, a := range addresses {
+		if a.Equal(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h.Equal(hash) {
+			return true
+		}
+	}
+
+	return false
+}
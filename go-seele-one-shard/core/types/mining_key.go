@@ -0,0 +1,52 @@
+/**
+* @file
+* @copyright defined in go-seele/LICENSE
+ */
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/crypto"
+)
+
+// MiningDataPack is the data a miner used to compute the mining key, which
+// decides the chain that the miner is entitled to mine a block on.
+type MiningDataPack struct {
+	Heights  []uint64      // Heights of the historical blocks picked from each chain
+	TxHashes []common.Hash // TxHashes of the transactions picked from the historical blocks
+	Nonce    uint64        // Nonce is the pow of the mining key
+}
+
+// Clone returns a clone of the mining data pack.
+func (pack *MiningDataPack) Clone() MiningDataPack {
+	clone := MiningDataPack{
+		Nonce: pack.Nonce,
+	}
+
+	if pack.Heights != nil {
+		clone.Heights = make([]uint64, len(pack.Heights))
+		copy(clone.Heights, pack.Heights)
+	}
+
+	if pack.TxHashes != nil {
+		clone.TxHashes = make([]common.Hash, len(pack.TxHashes))
+		copy(clone.TxHashes, pack.TxHashes)
+	}
+
+	return clone
+}
+
+// Key calculates and returns the mining key of the data pack mined by the specified creator.
+// The creator is hashed together, so that the key could not be reused by other miners.
+func (pack *MiningDataPack) Key(creator common.Address) common.Hash {
+	return crypto.MustHash([]interface{}{creator, pack})
+}
+
+// GetChainNumByMiningKey returns the chain number that the specified mining key maps to.
+func GetChainNumByMiningKey(key common.Hash, numOfChains uint64) uint64 {
+	keyInt := new(big.Int).SetBytes(key.Bytes())
+	return new(big.Int).Mod(keyInt, new(big.Int).SetUint64(numOfChains)).Uint64()
+}
@@ -9,10 +9,13 @@ import (
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"runtime"
 	"sync"
 
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/crypto"
 	"github.com/seeleteam/go-seele/trie"
@@ -23,6 +26,24 @@ const (
 
 	// TransactionPreSize is the transaction size excluding payload size
 	TransactionPreSize = 169
+
+	// gasMeteredTxExtSize is the size of version, gas price and gas limit of gas metered transaction.
+	gasMeteredTxExtSize = 24
+
+	// TxVersionLegacy is the version of legacy transaction, which pays the contract
+	// creation and execution in fixed tiers besides the transaction fee.
+	TxVersionLegacy uint64 = 0
+
+	// TxVersionGasMetered is the version of transaction that carries the gas price and gas limit,
+	// which pays the used gas besides the transaction fee, and the execution is bounded by the gas limit.
+	TxVersionGasMetered uint64 = 1
+
+	// TxVersionReplayProtected is the version of gas metered transaction that also carries the network id
+	// and chain number, so that the transaction could not be replayed on other networks or chains.
+	TxVersionReplayProtected uint64 = 2
+
+	// MaxTxGasLimit is the maximum gas that a transaction could use.
+	MaxTxGasLimit = uint64(10000000)
 )
 
 var (
@@ -56,6 +77,27 @@ var (
 	// ErrSigMissing is returned when the transaction signature is missing.
 	ErrSigMissing = errors.New("signature missing")
 
+	// ErrVersionUnsupported is returned when the transaction version is unknown.
+	ErrVersionUnsupported = errors.New("unsupported transaction version")
+
+	// ErrGasPriceInvalid is returned when the gas price of gas metered transaction is nil or not positive.
+	ErrGasPriceInvalid = errors.New("gas price is nil or not positive")
+
+	// ErrIntrinsicGas is returned when the gas limit is lower than the intrinsic gas of transaction.
+	ErrIntrinsicGas = errors.New("gas limit is lower than intrinsic gas")
+
+	// ErrGasLimitExceeded is returned when the gas limit is larger than the MaxTxGasLimit.
+	ErrGasLimitExceeded = errors.New("gas limit exceeds the maximum gas limit of transaction")
+
+	// ErrNetworkMismatch is returned when the network id of replay protected transaction mismatch with the local network.
+	ErrNetworkMismatch = errors.New("network id mismatch")
+
+	// ErrChainNumMismatch is returned when the sender or the chain number of replay protected transaction mismatch with the chain.
+	ErrChainNumMismatch = errors.New("chain number mismatch")
+
+	// ErrReplayUnprotected is returned when the transaction is not replay protected since the replay protection height.
+	ErrReplayUnprotected = errors.New("transaction is not replay protected")
+
 	emptyTxRootHash = common.EmptyHash
 
 	// MaxPayloadSize limits the payload size to prevent malicious transactions.
@@ -71,6 +113,103 @@ type TransactionData struct {
 	Fee          *big.Int       // Transaction Fee
 	Timestamp    uint64         // Timestamp is used for the miner reward transaction, referring to the block timestamp
 	Payload      common.Bytes   // Payload is the extra data of the transaction
+
+	Version  uint64   // Version is the transaction version, and the following fields are only available since TxVersionGasMetered
+	GasPrice *big.Int // GasPrice is the fee paid for each used gas
+	GasLimit uint64   // GasLimit is the maximum gas that the transaction could use
+
+	NetworkID uint64 // NetworkID is the network that the transaction belongs to, available since TxVersionReplayProtected
+	ChainNum  uint64 // ChainNum is the chain of sender to execute the transaction, available since TxVersionReplayProtected
+}
+
+// txDataRLP is the RLP layout of transaction data. The legacy transaction is encoded without
+// any extension, so that its hash keeps unchanged. Otherwise, the version and version specific
+// fields are encoded in extensions.
+type txDataRLP struct {
+	From         common.Address
+	To           common.Address
+	Amount       *big.Int
+	AccountNonce uint64
+	Fee          *big.Int
+	Timestamp    uint64
+	Payload      common.Bytes
+	Extensions   []rlp.RawValue `rlp:"tail"`
+}
+
+// extensions returns the pointers of version specific fields in encoding order,
+// or nil if the version is unsupported.
+func (data *TransactionData) extensions() []interface{} {
+	switch data.Version {
+	case TxVersionLegacy:
+		return []interface{}{}
+	case TxVersionGasMetered:
+		return []interface{}{&data.Version, &data.GasPrice, &data.GasLimit}
+	case TxVersionReplayProtected:
+		return []interface{}{&data.Version, &data.GasPrice, &data.GasLimit, &data.NetworkID, &data.ChainNum}
+	default:
+		return nil
+	}
+}
+
+// EncodeRLP implements rlp.Encoder
+func (data TransactionData) EncodeRLP(w io.Writer) error {
+	enc := txDataRLP{data.From, data.To, data.Amount, data.AccountNonce, data.Fee, data.Timestamp, data.Payload, nil}
+
+	extensions := data.extensions()
+	if extensions == nil {
+		return ErrVersionUnsupported
+	}
+
+	for _, field := range extensions {
+		raw, err := rlp.EncodeToBytes(field)
+		if err != nil {
+			return err
+		}
+
+		enc.Extensions = append(enc.Extensions, raw)
+	}
+
+	return rlp.Encode(w, &enc)
+}
+
+// DecodeRLP implements rlp.Decoder
+func (data *TransactionData) DecodeRLP(s *rlp.Stream) error {
+	var dec txDataRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+
+	*data = TransactionData{
+		From:         dec.From,
+		To:           dec.To,
+		Amount:       dec.Amount,
+		AccountNonce: dec.AccountNonce,
+		Fee:          dec.Fee,
+		Timestamp:    dec.Timestamp,
+		Payload:      dec.Payload,
+	}
+
+	if len(dec.Extensions) == 0 {
+		return nil
+	}
+
+	// the legacy version is never encoded explicitly
+	if err := rlp.DecodeBytes(dec.Extensions[0], &data.Version); err != nil {
+		return err
+	}
+
+	extensions := data.extensions()
+	if data.Version == TxVersionLegacy || len(extensions) != len(dec.Extensions) {
+		return ErrVersionUnsupported
+	}
+
+	for i := 1; i < len(extensions); i++ {
+		if err := rlp.DecodeBytes(dec.Extensions[i], extensions[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Transaction represents a transaction in the blockchain.
@@ -126,7 +265,9 @@ func (tx *Transaction) IsCrossShardTx() bool {
 	return false
 }
 
-func (tx *Transaction) IsCrossChainTx() bool {
+// IsCrossChainTx returns true if the tx transfers to another chain of the same shard,
+// which is identified with the specified number of chains.
+func (tx *Transaction) IsCrossChainTx(numOfChains uint64) bool {
 	if tx.Data.To.IsEmpty() {
 		return false
 	}
@@ -135,16 +276,55 @@ func (tx *Transaction) IsCrossChainTx() bool {
 		return false
 	}
 
-	if tx.Data.From.Shard() == tx.Data.To.Shard() && tx.Data.From.GetChainNum() != tx.Data.To.GetChainNum() {
+	if tx.Data.From.Shard() == tx.Data.To.Shard() && tx.Data.From.GetChainNum(numOfChains) != tx.Data.To.GetChainNum(numOfChains) {
 		return true
 	}
 
 	return false
 }
 
-// Size return the transaction size
+// IsGasMetered returns true if the transaction pays the used gas with gas price and gas limit.
+func (tx *Transaction) IsGasMetered() bool {
+	return tx.Data.Version >= TxVersionGasMetered
+}
+
+// MaxGasFee returns the gas fee of the gas limit, which is prepaid before execution.
+// Returns 0 for the transaction that is not gas metered.
+func (tx *Transaction) MaxGasFee() *big.Int {
+	if !tx.IsGasMetered() {
+		return big.NewInt(0)
+	}
+
+	return new(big.Int).Mul(tx.Data.GasPrice, new(big.Int).SetUint64(tx.Data.GasLimit))
+}
+
+// IntrinsicGas returns the gas consumed by a gas metered transaction before execution,
+// which depends on the payload and whether to create a contract.
+func IntrinsicGas(payload []byte, contractCreation bool) uint64 {
+	gas := params.TxGas
+	if contractCreation {
+		gas = params.TxGasContractCreation
+	}
+
+	for _, b := range payload {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGas
+		}
+	}
+
+	return gas
+}
+
+// Size return the transaction size, including the version specific fields.
 func (tx *Transaction) Size() int {
-	return TransactionPreSize + len(tx.Data.Payload)
+	size := TransactionPreSize + len(tx.Data.Payload)
+	if tx.Data.Version >= TxVersionGasMetered {
+		size += gasMeteredTxExtSize
+	}
+
+	return size
 }
 
 // NewTransaction creates a new transaction to transfer asset.
@@ -153,6 +333,47 @@ func NewTransaction(from, to common.Address, amount *big.Int, fee *big.Int, nonc
 	return newTx(from, to, amount, fee, nonce, nil)
 }
 
+// NewGasMeteredTransaction creates a new transaction with the specified gas price and gas limit.
+// If the to address is empty, the payload is used as code to create a contract.
+func NewGasMeteredTransaction(from, to common.Address, amount, fee, gasPrice *big.Int, gasLimit, nonce uint64, payload []byte) (*Transaction, error) {
+	txData := TransactionData{
+		From:         from,
+		To:           to,
+		AccountNonce: nonce,
+		Payload:      common.CopyBytes(payload),
+		Version:      TxVersionGasMetered,
+		GasLimit:     gasLimit,
+	}
+
+	if gasPrice != nil {
+		txData.GasPrice = new(big.Int).Set(gasPrice)
+	}
+
+	return newTxWithData(txData, amount, fee)
+}
+
+// NewReplayProtectedTransaction creates a new gas metered transaction that could only be executed
+// in the specified network and the specified chain, which should be the chain of sender.
+// If the to address is empty, the payload is used as code to create a contract.
+func NewReplayProtectedTransaction(from, to common.Address, amount, fee, gasPrice *big.Int, gasLimit, nonce uint64, payload []byte, networkID, chainNum uint64) (*Transaction, error) {
+	txData := TransactionData{
+		From:         from,
+		To:           to,
+		AccountNonce: nonce,
+		Payload:      common.CopyBytes(payload),
+		Version:      TxVersionReplayProtected,
+		GasLimit:     gasLimit,
+		NetworkID:    networkID,
+		ChainNum:     chainNum,
+	}
+
+	if gasPrice != nil {
+		txData.GasPrice = new(big.Int).Set(gasPrice)
+	}
+
+	return newTxWithData(txData, amount, fee)
+}
+
 func newTx(from common.Address, to common.Address, amount *big.Int, fee *big.Int, nonce uint64, payload []byte) (*Transaction, error) {
 	txData := TransactionData{
 		From:         from,
@@ -161,6 +382,10 @@ func newTx(from common.Address, to common.Address, amount *big.Int, fee *big.Int
 		Payload:      common.CopyBytes(payload),
 	}
 
+	return newTxWithData(txData, amount, fee)
+}
+
+func newTxWithData(txData TransactionData, amount *big.Int, fee *big.Int) (*Transaction, error) {
 	if amount != nil {
 		txData.Amount = new(big.Int).Set(amount)
 	}
@@ -216,6 +441,11 @@ func (tx Transaction) ValidateWithoutState(signNeeded bool, shardNeeded bool) er
 		return ErrPayloadEmpty
 	}
 
+	// validate gas and replay protection
+	if err := tx.validateVersionedFields(); err != nil {
+		return err
+	}
+
 	// validate shard of from address
 	if shardNeeded {
 		if common.IsShardEnabled() {
@@ -246,6 +476,29 @@ func (tx Transaction) ValidateWithoutState(signNeeded bool, shardNeeded bool) er
 	return nil
 }
 
+func (tx Transaction) validateVersionedFields() error {
+	switch tx.Data.Version {
+	case TxVersionLegacy:
+		return nil
+	case TxVersionGasMetered, TxVersionReplayProtected:
+		if tx.Data.GasPrice == nil || tx.Data.GasPrice.Sign() <= 0 {
+			return ErrGasPriceInvalid
+		}
+
+		if tx.Data.GasLimit > MaxTxGasLimit {
+			return ErrGasLimitExceeded
+		}
+
+		if tx.Data.GasLimit < IntrinsicGas(tx.Data.Payload, tx.Data.To.IsEmpty()) {
+			return ErrIntrinsicGas
+		}
+
+		return nil
+	default:
+		return ErrVersionUnsupported
+	}
+}
+
 // NewContractTransaction returns a transaction to create a smart contract.
 func NewContractTransaction(from common.Address, amount *big.Int, fee *big.Int, nonce uint64, code []byte) (*Transaction, error) {
 	return newTx(from, common.EmptyAddress, amount, fee, nonce, code)
@@ -284,7 +537,42 @@ func NewRewardTransaction(miner common.Address, reward *big.Int, timestamp uint6
 	return rewardTx, nil
 }
 
-// Sign signs the transaction with the specified private key.
+// ValidateChain validates the sender of tx belongs to the specified chain, which is identified with the
+// specified number of chains. For replay protected tx, the signed chain number should also match.
+func (tx *Transaction) ValidateChain(chainNum uint64, numOfChains uint64) error {
+	if tx.Data.From.GetChainNum(numOfChains) != chainNum {
+		return ErrChainNumMismatch
+	}
+
+	if tx.Data.Version >= TxVersionReplayProtected && tx.Data.ChainNum != chainNum {
+		return ErrChainNumMismatch
+	}
+
+	return nil
+}
+
+// ValidateVersion validates whether the transaction version is allowed in the block of specified height.
+// Since the specified replay protection height, only the replay protected transactions are allowed,
+// and 0 means no limit.
+func (tx *Transaction) ValidateVersion(height, replayProtectionHeight uint64) error {
+	if replayProtectionHeight > 0 && height >= replayProtectionHeight && tx.Data.Version < TxVersionReplayProtected {
+		return ErrReplayUnprotected
+	}
+
+	return nil
+}
+
+// ValidateNetwork validates the signed network id of replay protected transaction matches the specified network.
+func (tx *Transaction) ValidateNetwork(networkID uint64) error {
+	if tx.Data.Version >= TxVersionReplayProtected && tx.Data.NetworkID != networkID {
+		return ErrNetworkMismatch
+	}
+
+	return nil
+}
+
+// Sign signs the transaction with the specified private key. Both legacy and versioned transactions
+// are supported, and the version specific fields, e.g. network id and chain number, are also signed.
 func (tx *Transaction) Sign(privKey *ecdsa.PrivateKey) {
 	tx.Hash = crypto.MustHash(tx.Data)
 	tx.Signature = *crypto.MustSign(privKey, tx.Hash.Bytes())
@@ -302,8 +590,9 @@ func (tx *Transaction) Validate(statedb stateDB) error {
 // ValidateState validates state dependent fields in tx.
 func (tx *Transaction) ValidateState(statedb stateDB) error {
 	consumed := new(big.Int).Add(tx.Data.Amount, tx.Data.Fee)
+	consumed.Add(consumed, tx.MaxGasFee())
 	if balance := statedb.GetBalance(tx.Data.From); consumed.Cmp(balance) > 0 {
-		return fmt.Errorf("balance is not enough, account:%s, balance:%v, amount:%v, fee:%v", tx.Data.From.ToHex(), balance, tx.Data.Amount, tx.Data.Fee)
+		return fmt.Errorf("balance is not enough, account:%s, balance:%v, amount:%v, fee:%v, max gas fee:%v", tx.Data.From.ToHex(), balance, tx.Data.Amount, tx.Data.Fee, tx.MaxGasFee())
 	}
 
 	if accountNonce := statedb.GetNonce(tx.Data.From); tx.Data.AccountNonce < accountNonce {
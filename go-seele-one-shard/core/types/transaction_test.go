@@ -12,6 +12,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
 	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/crypto"
@@ -347,6 +348,123 @@ func Test_Transaction_RlpRewardTx(t *testing.T) {
 	assertTxRlp(t, tx)
 }
 
+func Test_Transaction_RlpGasMeteredTx(t *testing.T) {
+	from := *crypto.MustGenerateRandomAddress()
+	to := *crypto.MustGenerateRandomAddress()
+	tx, err := NewGasMeteredTransaction(from, to, big.NewInt(3), big.NewInt(1), big.NewInt(2), 30000, 38, []byte("test input message"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, tx.IsGasMetered(), true)
+	assert.Equal(t, tx.MaxGasFee(), big.NewInt(60000))
+
+	assertTxRlp(t, tx)
+
+	// gas fields are signed
+	legacyTx, err := NewMessageTransaction(from, to, big.NewInt(3), big.NewInt(1), 38, []byte("test input message"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, legacyTx.Hash != tx.Hash, true)
+
+	// gas fields are counted in size
+	assert.Equal(t, legacyTx.Size(), TransactionPreSize+len(legacyTx.Data.Payload))
+	assert.Equal(t, tx.Size(), legacyTx.Size()+gasMeteredTxExtSize)
+
+	// unknown version
+	tx.Data.Version = 99
+	_, err = common.Serialize(tx)
+	assert.Equal(t, err, ErrVersionUnsupported)
+
+	extensions := []rlp.RawValue{common.SerializePanic(uint64(99))}
+	encoded := common.SerializePanic(txDataRLP{from, to, big.NewInt(3), 38, big.NewInt(1), 0, nil, extensions})
+	assert.Equal(t, common.Deserialize(encoded, &TransactionData{}), ErrVersionUnsupported)
+}
+
+func Test_Transaction_ReplayProtected(t *testing.T) {
+	fromPrivKey, from := randomAccount(t)
+	to := randomAddress(t)
+
+	tx, err := NewReplayProtectedTransaction(from, to, big.NewInt(3), big.NewInt(1), big.NewInt(2), 30000, 38, nil, 1, from.GetChainNum(common.DefaultNumOfChains))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, tx.IsGasMetered(), true)
+	assert.Equal(t, tx.ValidateChain(from.GetChainNum(common.DefaultNumOfChains), common.DefaultNumOfChains), nil)
+	tx.Sign(fromPrivKey)
+
+	assertTxRlp(t, tx)
+
+	// network id and chain number are signed
+	gasTx, err := NewGasMeteredTransaction(from, to, big.NewInt(3), big.NewInt(1), big.NewInt(2), 30000, 38, nil)
+	assert.Equal(t, err, nil)
+	gasTx.Sign(fromPrivKey)
+	assert.Equal(t, gasTx.Hash != tx.Hash, true)
+
+	// network mismatch
+	assert.Equal(t, tx.ValidateWithoutState(true, false), nil)
+	assert.Equal(t, tx.ValidateNetwork(1), nil)
+	assert.Equal(t, tx.ValidateNetwork(2), ErrNetworkMismatch)
+	assert.Equal(t, tx.ValidateNetwork(0), ErrNetworkMismatch)
+	assert.Equal(t, gasTx.ValidateNetwork(2), nil)
+
+	// chain number mismatch
+	chainNum := from.GetChainNum(common.DefaultNumOfChains)
+	assert.Equal(t, tx.ValidateChain(chainNum+1, common.DefaultNumOfChains), ErrChainNumMismatch)
+
+	tampered := *tx
+	tampered.Data.ChainNum++
+	tampered.Sign(fromPrivKey)
+	assert.Equal(t, tampered.ValidateWithoutState(true, false), nil)
+	assert.Equal(t, tampered.ValidateChain(chainNum, common.DefaultNumOfChains), ErrChainNumMismatch)
+	assert.Equal(t, gasTx.ValidateChain(chainNum, common.DefaultNumOfChains), nil)
+}
+
+func Test_Transaction_ValidateVersion(t *testing.T) {
+	from := randomAddress(t)
+	to := randomAddress(t)
+	legacyTx := newTestTx(t, 3, 1, 38, false)
+	tx, err := NewReplayProtectedTransaction(from, to, big.NewInt(3), big.NewInt(1), big.NewInt(2), 30000, 38, nil, 1, from.GetChainNum(common.DefaultNumOfChains))
+	assert.Equal(t, err, nil)
+
+	// no limit
+	assert.Equal(t, legacyTx.ValidateVersion(100, 0), nil)
+	assert.Equal(t, tx.ValidateVersion(100, 0), nil)
+
+	// both are allowed before the replay protection height
+	assert.Equal(t, legacyTx.ValidateVersion(99, 100), nil)
+	assert.Equal(t, tx.ValidateVersion(99, 100), nil)
+
+	assert.Equal(t, legacyTx.ValidateVersion(100, 100), ErrReplayUnprotected)
+	assert.Equal(t, tx.ValidateVersion(100, 100), nil)
+}
+
+func Test_Transaction_RlpLegacyHashUnchanged(t *testing.T) {
+	tx := newTestTx(t, 3, 1, 38, false)
+
+	legacy := []interface{}{tx.Data.From, tx.Data.To, tx.Data.Amount, tx.Data.AccountNonce, tx.Data.Fee, tx.Data.Timestamp, tx.Data.Payload}
+	assert.Equal(t, tx.Hash, crypto.MustHash(legacy))
+}
+
+func Test_Transaction_Validate_Gas(t *testing.T) {
+	fromPrivKey, from := randomAccount(t)
+	to := randomAddress(t)
+
+	_, err := NewGasMeteredTransaction(from, to, big.NewInt(3), big.NewInt(1), nil, 30000, 38, nil)
+	assert.Equal(t, err, ErrGasPriceInvalid)
+
+	_, err = NewGasMeteredTransaction(from, to, big.NewInt(3), big.NewInt(1), big.NewInt(1), MaxTxGasLimit+1, 38, nil)
+	assert.Equal(t, err, ErrGasLimitExceeded)
+
+	_, err = NewGasMeteredTransaction(from, to, big.NewInt(3), big.NewInt(1), big.NewInt(1), IntrinsicGas(nil, false)-1, 38, nil)
+	assert.Equal(t, err, ErrIntrinsicGas)
+
+	_, err = NewGasMeteredTransaction(from, common.EmptyAddress, big.NewInt(3), big.NewInt(1), big.NewInt(1), IntrinsicGas(nil, false), 38, []byte{1})
+	assert.Equal(t, err, ErrIntrinsicGas)
+
+	// balance should cover the max gas fee
+	tx, err := NewGasMeteredTransaction(from, to, big.NewInt(3), big.NewInt(1), big.NewInt(2), 30000, 38, nil)
+	assert.Equal(t, err, nil)
+	tx.Sign(fromPrivKey)
+
+	assert.Equal(t, tx.Validate(newTestStateDB(from, 38, 60003)) != nil, true)
+	assert.Equal(t, tx.Validate(newTestStateDB(from, 38, 60004)), nil)
+}
+
 func Test_Transaction_InvalidAmount(t *testing.T) {
 	_, fromAddress := randomAccount(t)
 	toAddress := randomAddress(t)
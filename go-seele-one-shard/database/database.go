@@ -17,6 +17,7 @@ type Database interface {
 	Delete(key []byte) error
 	DeleteSring(key string) error
 	NewBatch() Batch
+	NewIterator(prefix []byte) Iterator
 }
 
 // Batch is the interface of batch for database
@@ -26,3 +27,13 @@ type Batch interface {
 	Commit() error
 	Rollback()
 }
+
+// Iterator is the interface of iterator for database, which iterates
+// over the key/value pairs in key order.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Release()
+	Error() error
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/seeleteam/go-seele/database"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var (
@@ -106,6 +107,12 @@ func (db *LevelDB) NewBatch() database.Batch {
 	return batch
 }
 
+// NewIterator constructs and returns an iterator over the keys with the given prefix.
+// Note, the iterator should be released after used.
+func (db *LevelDB) NewIterator(prefix []byte) database.Iterator {
+	return db.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
 // NewTestDatabase creates a database instance under temp folder.
 func NewTestDatabase() (db database.Database, dispose func()) {
 	dir, err := ioutil.TempDir("", "Seele-LevelDB-")
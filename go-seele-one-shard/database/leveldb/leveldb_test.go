@@ -108,6 +108,32 @@ func Test_LevelDB_Newbatch(t *testing.T) {
 	}
 }
 
+func Test_LevelDB_NewIterator(t *testing.T) {
+	// Init levelDB
+	dir := prepareDbFolder("", "leveldbtest")
+	defer os.RemoveAll(dir)
+	db := newDbInstance(dir)
+	defer db.Close()
+
+	db.PutString("a1", "1")
+	db.PutString("b1", "2")
+	db.PutString("b2", "3")
+	db.PutString("c1", "4")
+
+	it := db.NewIterator([]byte("b"))
+	defer it.Release()
+
+	var keys, values []string
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+		values = append(values, string(it.Value()))
+	}
+
+	assert.Equal(t, it.Error(), nil)
+	assert.Equal(t, keys, []string{"b1", "b2"})
+	assert.Equal(t, values, []string{"2", "3"})
+}
+
 func prepareDbFolder(pathRoot string, subDir string) string {
 	dir, err := ioutil.TempDir(pathRoot, subDir)
 	if err != nil {
@@ -36,6 +36,11 @@ type HandleNewTxMsg struct {
 	ChainNum    uint64
 }
 
+type HandleNewDebtMsg struct {
+	Debt        *types.Debt
+	ChainNum    uint64
+}
+
 // eventListener is a struct which defines a function as a listener
 type eventListener struct {
 	// Callable is a callable function
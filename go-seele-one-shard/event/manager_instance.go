@@ -21,5 +21,8 @@ var BlockMinedEventManager = NewEventManager()
 // TransactionInsertedEventManager represents the event that a new transaction is inserted into txpool
 var TransactionInsertedEventManager = NewEventManager()
 
+// DebtInsertedEventManager represents the event that a new debt is inserted into debt pool
+var DebtInsertedEventManager = NewEventManager()
+
 // ChainHeaderChangedEventMananger represents the event that chain header is changed
 var ChainHeaderChangedEventMananger = NewEventManager()
@@ -17,6 +17,12 @@ import (
 
 var MetricsWriteBlockMeter = metrics.GetOrRegisterMeter("core.blockchain.writeBlock.time", nil)
 
+// MetricsTxPoolEvictedMeter is the meter of transactions evicted from the tx pool by better paying ones
+var MetricsTxPoolEvictedMeter = metrics.GetOrRegisterMeter("core.txpool.evicted", nil)
+
+// MetricsTxPoolRejectedMeter is the meter of transactions rejected by the tx pool due to fee or quota
+var MetricsTxPoolRejectedMeter = metrics.GetOrRegisterMeter("core.txpool.rejected", nil)
+
 // Config infos for influxdb
 type Config struct {
 	Addr     string        `json:"address"`
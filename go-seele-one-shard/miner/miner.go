@@ -15,7 +15,6 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
-	"encoding/binary"
 
 	metrics "github.com/rcrowley/go-metrics"
 	"github.com/seeleteam/go-seele/common"
@@ -23,10 +22,7 @@ import (
 	"github.com/seeleteam/go-seele/core/types"
 	"github.com/seeleteam/go-seele/event"
 	"github.com/seeleteam/go-seele/log"
-	"github.com/seeleteam/go-seele/crypto"
 	"github.com/seeleteam/go-seele/miner/pow"
-	"github.com/seeleteam/go-seele/database"
-	"github.com/seeleteam/go-seele/core/state"
 )
 
 var (
@@ -38,29 +34,22 @@ var (
 
 	// ErrNodeIsSyncing is returned when the node is syncing
 	ErrNodeIsSyncing = errors.New("can not start miner when syncing")
+
+	// ErrMiningKeyNotFound is returned when failed to find a valid mining key
+	ErrMiningKeyNotFound = errors.New("mining key not found")
 )
 
 const (
-	// number of chains
-	numOfChains = 3
-	StartHeightOfGetMiningKeyFromChain = 4
+	StartHeightOfGetMiningKeyFromChain = pow.MiningDataStartHeight
 	longDist	= 3
 	shortDist	= 1
 )
 
-type MiningDataPack struct {
-	Heights  [numOfChains]uint64
-	TxHashes [numOfChains]common.Hash
-	Nonce    uint64 
-}
-
 // SeeleBackend wraps all methods required for minier.
 type SeeleBackend interface {
-	TxPool() [numOfChains]*core.TransactionPool
-	BlockChain() [numOfChains]*core.Blockchain
-	DebtPool()   [numOfChains]*core.DebtPool
-	AccountStateDB() database.Database
-	GetCurrentState() (*state.Statedb, error)
+	TxPool() []*core.TransactionPool
+	BlockChain() []*core.Blockchain
+	DebtPool()   []*core.DebtPool
 }
 
 // Miner defines base elements of miner
@@ -85,6 +74,7 @@ type Miner struct {
 	hashrate             metrics.Meter // Meter tracking the average hashrate
 
 	miningKeyHash		 common.Hash
+	miningData           types.MiningDataPack // the data pack of the mining key, committed in block header
 }
 
 // NewMiner constructs and returns a miner instance
@@ -155,15 +145,11 @@ func (miner *Miner) Start() error {
 	miner.log.Info("miner start with %d threads", miner.threads)
 	miner.stopChan = make(chan struct{})
 
-	if err := miner.NewMiningLoop(); err != nil { // try to start the mining loop
-		miner.log.Warn(err.Error())
-		atomic.StoreInt32(&miner.mining, 0)
-
-		return err
-	}
-
 	atomic.StoreInt32(&miner.stopped, 0)
-	go miner.waitBlock()
+	go miner.waitBlock(miner.stopChan)
+
+	// try to start the mining loop, and the mining key is mined asynchronously
+	miner.NewMiningLoop()
 
 	miner.log.Info("Miner is started.")
 
@@ -244,15 +230,12 @@ func (miner *Miner) newTxCallback(e event.Event) {
 
 	// if not mining, start mining
 	if atomic.LoadInt32(&miner.stopped) == 0 && atomic.LoadInt32(&miner.canStart) == 1 && atomic.CompareAndSwapInt32(&miner.mining, 0, 1) {
-		if err := miner.NewMiningLoop(); err != nil {
-			miner.log.Warn(err.Error())
-			atomic.StoreInt32(&miner.mining, 0)
-		}
+		miner.NewMiningLoop()
 	}
 }
 
 // waitBlock waits for blocks to be mined continuously
-func (miner *Miner) waitBlock() {
+func (miner *Miner) waitBlock(stopChan chan struct{}) {
 out:
 	for {
 		select {
@@ -280,7 +263,7 @@ out:
 			atomic.StoreInt32(&miner.mining, 0)
 			// loop mining after mining completed
 			miner.newTxCallback(event.EmptyEvent)
-		case <-miner.stopChan:
+		case <-stopChan:
 			break out
 		}
 	}
@@ -291,10 +274,9 @@ func (miner *Miner) prepareNewBlock(chainNum uint64) error {
 	miner.log.Debug("starting mining the new block")
 
 	timestamp := time.Now().Unix()
-	
-	stateDB, err := miner.seele.GetCurrentState()
+
 	blockchains := miner.seele.BlockChain()
-	parent, err := blockchains[chainNum].GetCurrentInfo()
+	parent, stateDB, err := blockchains[chainNum].GetCurrentInfo()
 	if err != nil {
 		return fmt.Errorf("failed to get current info, %s", err)
 	}
@@ -318,14 +300,16 @@ func (miner *Miner) prepareNewBlock(chainNum uint64) error {
 		Height:            height + 1,
 		CreateTimestamp:   big.NewInt(timestamp),
 		Difficulty:        difficult,
+		MiningData:        miner.miningData.Clone(),
 	}
 
 	miner.log.Debug("miner a block with coinbase %s", miner.coinbase.ToHex())
 	miner.current = &Task{
-		header:    header,
-		createdAt: time.Now(),
-		coinbase:  miner.coinbase,
-		chainNum:  chainNum,
+		header:      header,
+		createdAt:   time.Now(),
+		coinbase:    miner.coinbase,
+		chainNum:    chainNum,
+		numOfChains: uint64(len(miner.seele.BlockChain())),
 	}
 
 	err = miner.current.applyTransactionsAndDebts(miner.seele, stateDB, miner.log)
@@ -393,98 +377,88 @@ func (miner *Miner) Hashrate() float64 {
 	return miner.hashrate.Rate1()
 }
 
-func (miner *Miner) NewMiningLoop() error {
+// NewMiningLoop mines a mining key to determine the chain to work on, and then commits a new
+// task on the chain to mine. The mining key is mined off the caller's goroutine, and the miner
+// stops mining if failed to prepare the task.
+func (miner *Miner) NewMiningLoop() {
+	stopChan := miner.stopChan
+
+	miner.wg.Add(1)
+	go func() {
+		defer miner.wg.Done()
 
-	// get a random key from previous transactions and 
+		if err := miner.prepareMiningTask(stopChan); err != nil {
+			miner.log.Warn("failed to prepare mining task, %s", err)
+			atomic.StoreInt32(&miner.mining, 0)
+		}
+	}()
+}
+
+// prepareMiningTask gets a mining key and prepares the new block on the chain that the key maps to.
+func (miner *Miner) prepareMiningTask(stopChan chan struct{}) error {
+	// get a random key from previous transactions and
 	// determine which chain the miner will work on
-	err := miner.getMiningKey()
+	err := miner.getMiningKey(stopChan)
 	if err != nil {
 		miner.log.Info("Failed to get the mining key")
 		return err
 	}
-	
-	miningKeyHashInt := new(big.Int)
-	miningKeyHashInt.SetBytes(miner.miningKeyHash.Bytes())
-	chainNum := miner.getChainNumByMiningKey(miningKeyHashInt)
 
-	// for debug use only
 	chains := miner.seele.BlockChain()
+	chainNum := types.GetChainNumByMiningKey(miner.miningKeyHash, uint64(len(chains)))
+
+	// for debug use only
 	currentBlock := chains[chainNum].CurrentBlock()
 	blockHeight := currentBlock.Header.Height
 	miner.log.Info("Got Mining key: %s, chainNum: %d, height: %d", miner.miningKeyHash.ToHex(), chainNum, blockHeight)
 
 	// try to prepare the new block on a certain chain
-	if err := miner.prepareNewBlock(chainNum); err != nil {
-		return err
-	}
-	
-	return nil
-} 
-
-func (miner *Miner) getChainNumByMiningKey(miningKeyHashInt *big.Int) uint64 {
-
-	result := new(big.Int)
-	result = result.Mod(miningKeyHashInt, big.NewInt(numOfChains))
-	chainNum := result.Uint64()
-	return chainNum
+	return miner.prepareNewBlock(chainNum)
 }
 
-func int2bytes(num int) (b []byte) {
-	b = make([]byte, 4)
-	binary.BigEndian.PutUint32(b, uint32(num))
-	return
-}
-
-// TODO: generate the mining key from the historical data
-//func (miner *Miner) getMiningKey() (common.Hash, error) {
-
-//	rand.Seed(time.Now().UnixNano())
-//	x := rand.Intn(100000)
-//	MiningKeyHash := crypto.HashBytes(int2bytes(x))
-
-//	return MiningKeyHash, nil
-
-//}
-
-func (miner *Miner) getMiningKey() error {
-
+// getMiningKey picks a historical transaction from each chain and mines a key on them.
+// If a chain is too short to pick from, the zero height and empty tx hash are used instead.
+func (miner *Miner) getMiningKey(stopChan chan struct{}) error {
 	chains := miner.seele.BlockChain()
-	var heights  [numOfChains]uint64
-	var txHashes [numOfChains]common.Hash
+	heights := make([]uint64, len(chains))
+	txHashes := make([]common.Hash, len(chains))
 
-	for i := 0; i < numOfChains; i++ {
+	for i := range chains {
 		currentBlock := chains[i].CurrentBlock()
 		blockHeight := currentBlock.Header.Height
-		
+
 		if blockHeight > StartHeightOfGetMiningKeyFromChain {
-			heights[i] = uint64(rand.Intn(longDist - shortDist)) + blockHeight - longDist
+			heights[i] = uint64(rand.Intn(longDist-shortDist)) + blockHeight - longDist
 			blockPicked, err := chains[i].GetStore().GetBlockByHeight(heights[i])
 			if err != nil {
-				return err
+				// the blocks before the HEAD block of state snapshot are not stored
+				heights[i], blockPicked = blockHeight, currentBlock
 			}
+
 			transactions := blockPicked.Transactions
 			transactionIndex := rand.Intn(len(transactions))
 			txHashes[i] = transactions[transactionIndex].Hash
-		} else {
-			rand.Seed(time.Now().UnixNano())
-			x := rand.Intn(100000)
-			miner.miningKeyHash = crypto.HashBytes(int2bytes(x))
-			return nil
 		}
 	}
 
-	dataPack := &MiningDataPack {
-		Heights:	heights,
-		TxHashes:	txHashes,
+	dataPack := &types.MiningDataPack{
+		Heights:  heights,
+		TxHashes: txHashes,
+	}
+
+	miner.miningKeyHash = common.EmptyHash
+	miner.commitTaskToKeyMining(dataPack, stopChan)
+
+	if miner.miningKeyHash.IsEmpty() {
+		return ErrMiningKeyNotFound
 	}
 
-	miner.commitTaskToKeyMining(dataPack)
-	
 	return nil
 }
 
-// commitTask commits the given task to the miner
-func (miner *Miner) commitTaskToKeyMining(dataPack *MiningDataPack) {
+// commitTaskToKeyMining mines the mining key of the data pack with all threads in parallel,
+// and waits until the key is found by any thread or the miner is stopped.
+func (miner *Miner) commitTaskToKeyMining(dataPack *types.MiningDataPack, stopChan chan struct{}) {
 	if atomic.LoadInt32(&miner.mining) != 1 {
 		return
 	}
@@ -498,6 +472,7 @@ func (miner *Miner) commitTaskToKeyMining(dataPack *MiningDataPack) {
 		step = math.MaxUint64 / uint64(threads)
 	}
 
+	var wg sync.WaitGroup
 	var isNonceFound int32
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	for i := 0; i < threads; i++ {
@@ -517,28 +492,27 @@ func (miner *Miner) commitTaskToKeyMining(dataPack *MiningDataPack) {
 			max = math.MaxUint64
 		}
 
-		miner.wg.Add(1)
+		wg.Add(1)
 		go func(tseed uint64, tmin uint64, tmax uint64) {
-			defer miner.wg.Done()
-			miner.StartMiningForKey(dataPack, tseed, tmin, tmax, &isNonceFound)
+			defer wg.Done()
+			pack := dataPack.Clone()
+			miner.StartMiningForKey(&pack, tseed, tmin, tmax, stopChan, &isNonceFound)
 		}(tSeed, min, max)
-
-		miner.wg.Wait()
 	}
-}
 
+	wg.Wait()
+}
 
-
-func (miner *Miner) StartMiningForKey(dataPack *MiningDataPack, seed uint64, min uint64, max uint64, isNonceFound *int32) {
-
+// StartMiningForKey mines the nonce of the data pack until the mining key is found.
+func (miner *Miner) StartMiningForKey(dataPack *types.MiningDataPack, seed uint64, min uint64, max uint64, stopChan chan struct{}, isNonceFound *int32) {
 	var nonce = seed
 	var hashInt big.Int
-	target := pow.GetMiningTarget(new(big.Int).SetUint64(30000000))
+	target := pow.GetMiningTarget(pow.MiningKeyDifficulty)
 
 KeyMiner:
 	for {
 		select {
-		case <-miner.stopChan:
+		case <-stopChan:
 			logAbort(miner.log)
 			break KeyMiner
 
@@ -549,19 +523,20 @@ KeyMiner:
 			}
 
 			dataPack.Nonce = nonce
-			hash := crypto.MustHash(dataPack)
+			hash := dataPack.Key(miner.coinbase)
 			hashInt.SetBytes(hash.Bytes())
 
 			// found
 			if hashInt.Cmp(target) <= 0 {
-				miner.miningKeyHash = hash
-
 				select {
-				case <-miner.stopChan:
+				case <-stopChan:
 					logAbort(miner.log)
 				default:
-					atomic.StoreInt32(isNonceFound, 1)
-					miner.log.Info("key mining, nonce finding succeeded: %s", hash.ToHex())
+					if atomic.CompareAndSwapInt32(isNonceFound, 0, 1) {
+						miner.miningKeyHash = hash
+						miner.miningData = dataPack.Clone()
+						miner.log.Info("key mining, nonce finding succeeded: %s", hash.ToHex())
+					}
 				}
 
 				break KeyMiner
@@ -574,7 +549,7 @@ KeyMiner:
 			// outage
 			if nonce == seed-1 {
 				select {
-				case <-miner.stopChan:
+				case <-stopChan:
 					logAbort(miner.log)
 				default:
 					miner.log.Warn("key mining, nonce finding outage")
@@ -586,4 +561,4 @@ KeyMiner:
 			nonce++
 		}
 	}
-}
\ No newline at end of file
+}
@@ -7,9 +7,7 @@ package miner
 
 import (
 	"crypto/ecdsa"
-	"io/ioutil"
 	"math/big"
-	"os"
 	"runtime"
 	"testing"
 
@@ -17,11 +15,15 @@ import (
 	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/core"
 	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/core/types"
 	"github.com/seeleteam/go-seele/crypto"
 	"github.com/seeleteam/go-seele/database"
 	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/seeleteam/go-seele/miner/pow"
 )
 
+const testNetworkID = 1
+
 var defaultMinerAddr = common.BytesToAddress([]byte{1})
 var seele SeeleBackend = TestSeeleBackend{}
 
@@ -62,14 +64,11 @@ func Test_SetCoinbase(t *testing.T) {
 }
 
 func Test_Start(t *testing.T) {
-	// Init LevelDB
-	dir := prepareDbFolder("", "leveldbtest")
-	defer os.RemoveAll(dir)
-	db := newDbInstance(dir)
-	defer db.Close()
+	backend, dispose := newTestSeeleBackend()
+	defer dispose()
 
 	miner := createMiner()
-	miner.seele = NewTestSeeleBackend(db)
+	miner.seele = backend
 	miner.mining = 1
 
 	err := miner.Start()
@@ -80,6 +79,7 @@ func Test_Start(t *testing.T) {
 	err = miner.Start()
 	assert.Equal(t, err, ErrNodeIsSyncing)
 
+	// the mining key is mined asynchronously
 	miner.canStart = 1
 	err = miner.Start()
 	defer miner.Close()
@@ -93,6 +93,46 @@ func Test_Start(t *testing.T) {
 	assert.Equal(t, miner.mining, int32(0))
 }
 
+func Test_getMiningKey(t *testing.T) {
+	backend, dispose := newTestSeeleBackend()
+	defer dispose()
+
+	defaultDifficulty := pow.MiningKeyDifficulty
+	pow.MiningKeyDifficulty = big.NewInt(1000)
+	defer func() {
+		pow.MiningKeyDifficulty = defaultDifficulty
+	}()
+
+	miner := createMiner()
+	miner.seele = backend
+	miner.mining = 1
+	miner.SetThreads(4)
+
+	// the chains are too short to pick from
+	assert.Equal(t, miner.getMiningKey(make(chan struct{})), nil)
+	assert.Equal(t, miner.miningData.Heights, make([]uint64, common.DefaultNumOfChains))
+	assert.Equal(t, miner.miningData.TxHashes, make([]common.Hash, common.DefaultNumOfChains))
+
+	chainNum := types.GetChainNumByMiningKey(miner.miningKeyHash, common.DefaultNumOfChains)
+	assert.Equal(t, miner.miningData.Key(miner.coinbase), miner.miningKeyHash)
+	assert.Equal(t, pow.ValidateMiningData(miner.coinbase, &miner.miningData, chainNum, common.DefaultNumOfChains), nil)
+}
+
+func Test_getMiningKey_Stopped(t *testing.T) {
+	backend, dispose := newTestSeeleBackend()
+	defer dispose()
+
+	miner := createMiner()
+	miner.seele = backend
+	miner.mining = 1
+	miner.SetThreads(4)
+
+	// all threads exit once stopped
+	stopChan := make(chan struct{})
+	close(stopChan)
+	assert.Equal(t, miner.getMiningKey(stopChan), ErrMiningKeyNotFound)
+}
+
 func createMiner() *Miner {
 	return NewMiner(defaultMinerAddr, seele)
 }
@@ -112,34 +152,49 @@ func checkMinerMembers(miner *Miner, addr common.Address, seele SeeleBackend, t
 
 // TestSeeleBackend implements the SeeleBackend interface.
 type TestSeeleBackend struct {
-	txPool     *core.TransactionPool
-	debtPool   *core.DebtPool
-	blockchain *core.Blockchain
+	txPools    []*core.TransactionPool
+	debtPools  []*core.DebtPool
+	blockchain []*core.Blockchain
 }
 
-func NewTestSeeleBackend(db database.Database) *TestSeeleBackend {
+// newTestSeeleBackend returns a backend of the default number of chains, and a func to dispose
+// the databases of chains.
+func newTestSeeleBackend() (*TestSeeleBackend, func()) {
 	seeleBeckend := &TestSeeleBackend{}
+	var disposes []func()
+
+	for i := uint64(0); i < common.DefaultNumOfChains; i++ {
+		db, dispose := leveldb.NewTestDatabase()
+		disposes = append(disposes, dispose)
+
+		chain := newTestBlockchain(db, i)
+		seeleBeckend.blockchain = append(seeleBeckend.blockchain, chain)
+		config := core.DefaultTxPoolConfig()
+		config.JournalFile = ""
+		seeleBeckend.txPools = append(seeleBeckend.txPools, core.NewTransactionPool(*config, chain, i, common.DefaultNumOfChains))
+		seeleBeckend.debtPools = append(seeleBeckend.debtPools, core.NewDebtPool(chain, nil))
+	}
 
-	seeleBeckend.txPool = newTestPool(core.DefaultTxPoolConfig(), db)
-	seeleBeckend.blockchain = newTestBlockchain(db)
-	seeleBeckend.debtPool = core.NewDebtPool(seeleBeckend.blockchain)
-
-	return seeleBeckend
+	return seeleBeckend, func() {
+		for _, dispose := range disposes {
+			dispose()
+		}
+	}
 }
 
-func (t TestSeeleBackend) TxPool() *core.TransactionPool {
-	return t.txPool
+func (t TestSeeleBackend) TxPool() []*core.TransactionPool {
+	return t.txPools
 }
 
-func (t TestSeeleBackend) DebtPool() *core.DebtPool {
-	return t.debtPool
+func (t TestSeeleBackend) DebtPool() []*core.DebtPool {
+	return t.debtPools
 }
 
-func (t TestSeeleBackend) BlockChain() *core.Blockchain {
+func (t TestSeeleBackend) BlockChain() []*core.Blockchain {
 	return t.blockchain
 }
 
-func newTestBlockchain(db database.Database) *core.Blockchain {
+func newTestBlockchain(db database.Database, chainNum uint64) *core.Blockchain {
 	bcStore := store.NewCachedStore(store.NewBlockchainDatabase(db))
 
 	genesis := newTestGenesis()
@@ -147,7 +202,7 @@ func newTestBlockchain(db database.Database) *core.Blockchain {
 		panic(err)
 	}
 
-	bc, err := core.NewBlockchain(bcStore, db, "")
+	bc, err := core.NewBlockchain(bcStore, db, "", chainNum, common.DefaultNumOfChains, testNetworkID)
 	if err != nil {
 		panic(err)
 	}
@@ -155,31 +210,13 @@ func newTestBlockchain(db database.Database) *core.Blockchain {
 	return bc
 }
 
-func newDbInstance(dbPath string) database.Database {
-	db, err := leveldb.NewLevelDB(dbPath)
-	if err != nil {
-		panic(err)
-	}
-
-	return db
-}
-
-func prepareDbFolder(pathRoot string, subDir string) string {
-	dir, err := ioutil.TempDir(pathRoot, subDir)
-	if err != nil {
-		panic(err)
-	}
-
-	return dir
-}
-
 func newTestGenesis() *core.Genesis {
 	accounts := make(map[common.Address]*big.Int)
 	for _, account := range testGenesisAccounts {
 		accounts[account.addr] = account.amount
 	}
 
-	return core.GetGenesis(core.GenesisInfo{accounts, 1, 0})
+	return core.GetGenesis(core.GenesisInfo{Accounts: accounts, Difficult: 1})
 }
 
 var testGenesisAccounts = []*testAccount{
@@ -208,10 +245,3 @@ type testAccount struct {
 	amount  *big.Int
 	nonce   uint64
 }
-
-func newTestPool(config *core.TransactionPoolConfig, db database.Database) *core.TransactionPool {
-	chain := newTestBlockchain(db)
-	txPool := core.NewTransactionPool(*config, chain)
-
-	return txPool
-}
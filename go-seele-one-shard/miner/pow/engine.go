@@ -10,21 +10,80 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/core/types"
 )
 
+const (
+	// MiningDataStartHeight is the height that a chain must exceed before the mining data pack picks
+	// a historical transaction from it. Until then, the zero height and empty tx hash are used.
+	MiningDataStartHeight uint64 = 4
+
+	// MiningDataMaxDepth is the max distance that the historical block picked in the mining data pack
+	// could be behind the parent block, or behind the block picked in the pack of parent block, so that
+	// a mining data pack could not be reused for long.
+	MiningDataMaxDepth uint64 = 16
+)
+
 var (
 	// maxUint256 is a big integer representing 2^256
 	maxUint256 = new(big.Int).Exp(big.NewInt(2), big.NewInt(256), big.NewInt(0))
 
-	errBlockNonceInvalid = errors.New("invalid block nonce")
+	// MiningKeyDifficulty is the difficulty to compute a valid mining key.
+	MiningKeyDifficulty = big.NewInt(30000000)
+
+	// ErrBlockNonceInvalid is returned when the block nonce does not meet the difficulty.
+	ErrBlockNonceInvalid = errors.New("invalid block nonce")
+
+	// ErrMiningDataHistoryUnknown is returned when the history that the mining data pack refers to is not
+	// known locally yet, e.g. the parent block or the other chains lag behind. The block is not invalid,
+	// and could be validated again later.
+	ErrMiningDataHistoryUnknown = errors.New("mining data history is not known yet")
+
+	errMiningDataInvalid = errors.New("invalid mining data pack")
+
+	errMiningDataStale = errors.New("mining data pack is stale")
+
+	errMiningKeyNonceInvalid = errors.New("invalid mining key nonce")
+
+	errMiningKeyChainMismatch = errors.New("mining key does not match the chain number")
+
+	errMiningDataTxNotFound = errors.New("mining data transaction not found in chain history")
 )
 
+// ChainReader is the interface to read the history of all chains, which is used to
+// validate the historical transactions in the mining data pack.
+type ChainReader interface {
+	// GetHeadHeader returns the HEAD block header of the specified chain.
+	GetHeadHeader(chainNum uint64) (*types.BlockHeader, error)
+
+	// GetCanonicalHash returns the hash of the block of the specified height in the
+	// canonical chain of the specified chain.
+	GetCanonicalHash(chainNum uint64, height uint64) (common.Hash, error)
+
+	// GetBlockHeader returns the block header of the specified hash in the specified chain.
+	GetBlockHeader(chainNum uint64, hash common.Hash) (*types.BlockHeader, error)
+
+	// GetBlock returns the block of the specified hash in the specified chain.
+	GetBlock(chainNum uint64, hash common.Hash) (*types.Block, error)
+}
+
 // Engine provides the consensus operations based on POW.
-type Engine struct{}
+type Engine struct {
+	numOfChains uint64
+	chains      ChainReader
+}
+
+// NewEngine returns a POW engine of the specified number of chains, which validates the mining
+// data pack against the history of the specified chains. If chains is nil, only the mining key
+// is validated.
+func NewEngine(numOfChains uint64, chains ChainReader) *Engine {
+	return &Engine{numOfChains, chains}
+}
 
-// ValidateHeader validates the specified header and returns error if validation failed.
-func (engine Engine) ValidateHeader(blockHeader *types.BlockHeader) error {
+// ValidateHeader validates the specified header of the block on the specified chain
+// and returns error if validation failed.
+func (engine Engine) ValidateHeader(blockHeader *types.BlockHeader, chainNum uint64) error {
 	headerHash := blockHeader.Hash()
 	var hashInt big.Int
 	hashInt.SetBytes(headerHash.Bytes())
@@ -32,7 +91,164 @@ func (engine Engine) ValidateHeader(blockHeader *types.BlockHeader) error {
 	target := GetMiningTarget(blockHeader.Difficulty)
 
 	if hashInt.Cmp(target) > 0 {
-		return errBlockNonceInvalid
+		return ErrBlockNonceInvalid
+	}
+
+	if err := ValidateMiningData(blockHeader.Creator, &blockHeader.MiningData, chainNum, engine.numOfChains); err != nil {
+		return err
+	}
+
+	if engine.chains == nil {
+		return nil
+	}
+
+	return validateMiningDataHistory(blockHeader, chainNum, engine.chains)
+}
+
+// ValidateMiningData recomputes the mining key of the specified data pack mined by the specified
+// creator, and returns error if the key is not a valid pow or the key does not map to the
+// specified chain number.
+// Note, the data pack should contain a historical transaction of each of the specified number of chains.
+func ValidateMiningData(creator common.Address, pack *types.MiningDataPack, chainNum, numOfChains uint64) error {
+	if uint64(len(pack.Heights)) != numOfChains || uint64(len(pack.TxHashes)) != numOfChains {
+		return errMiningDataInvalid
+	}
+
+	key := pack.Key(creator)
+	var keyInt big.Int
+	keyInt.SetBytes(key.Bytes())
+
+	if keyInt.Cmp(GetMiningTarget(MiningKeyDifficulty)) > 0 {
+		return errMiningKeyNonceInvalid
+	}
+
+	if types.GetChainNumByMiningKey(key, numOfChains) != chainNum {
+		return errMiningKeyChainMismatch
+	}
+
+	return nil
+}
+
+// validateMiningDataHistory validates the mining data pack of the header on the specified chain against
+// its parent header and the history of chains:
+//   - The entry of the chain that the block is mined on is picked from the recent ancestors, and it
+//     is empty only if the parent height proves the chain was not longer than MiningDataStartHeight.
+//   - The entry of another chain is empty only if it is empty in the pack of parent as well and the parent
+//     is not far beyond MiningDataStartHeight, and it is not far behind the entry in the pack of parent.
+//   - The historical transaction is included in the block of the specified height.
+//
+// ErrMiningDataHistoryUnknown is returned if the history is not known locally yet. The history not stored
+// locally, e.g. the blocks before the HEAD block of state snapshot, is not validated.
+func validateMiningDataHistory(header *types.BlockHeader, chainNum uint64, chains ChainReader) error {
+	parent, err := chains.GetBlockHeader(chainNum, header.PreviousBlockHash)
+	if err != nil {
+		return ErrMiningDataHistoryUnknown
+	}
+
+	pack, parentPack := &header.MiningData, &parent.MiningData
+
+	// the pack of genesis block is empty
+	if len(parentPack.Heights) != len(pack.Heights) {
+		parentPack = &types.MiningDataPack{
+			Heights:  make([]uint64, len(pack.Heights)),
+			TxHashes: make([]common.Hash, len(pack.Heights)),
+		}
+	}
+
+	for i, height := range pack.Heights {
+		txHash := pack.TxHashes[i]
+
+		if uint64(i) == chainNum {
+			if txHash.IsEmpty() {
+				if height != 0 || parent.Height > MiningDataStartHeight {
+					return errMiningDataInvalid
+				}
+
+				continue
+			}
+
+			if height > parent.Height || height+MiningDataMaxDepth < parent.Height {
+				return errMiningDataStale
+			}
+
+			err = validateAncestorTx(header.PreviousBlockHash, parent, chainNum, height, txHash, chains)
+		} else {
+			if txHash.IsEmpty() {
+				if height != 0 || !parentPack.TxHashes[i].IsEmpty() || parent.Height > MiningDataStartHeight+MiningDataMaxDepth {
+					return errMiningDataInvalid
+				}
+
+				continue
+			}
+
+			if height+MiningDataMaxDepth < parentPack.Heights[i] {
+				return errMiningDataStale
+			}
+
+			err = validateCanonicalTx(uint64(i), height, txHash, chains)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateAncestorTx validates the tx is included in the ancestor block of the specified height,
+// so that the tx picked on a forked branch is validated against the branch.
+func validateAncestorTx(parentHash common.Hash, parent *types.BlockHeader, chainNum, height uint64, txHash common.Hash, chains ChainReader) error {
+	ancestor, hash := parent, parentHash
+
+	for ancestor.Height > height {
+		var err error
+		hash = ancestor.PreviousBlockHash
+
+		// the ancestors are not stored before the HEAD block of state snapshot
+		if ancestor, err = chains.GetBlockHeader(chainNum, hash); err != nil {
+			return nil
+		}
+	}
+
+	block, err := chains.GetBlock(chainNum, hash)
+	if err != nil {
+		return nil
+	}
+
+	if block.FindTransaction(txHash) == nil {
+		return errMiningDataTxNotFound
+	}
+
+	return nil
+}
+
+// validateCanonicalTx validates the tx is included in the block of the specified height
+// in the canonical chain of the specified chain.
+func validateCanonicalTx(chainNum, height uint64, txHash common.Hash, chains ChainReader) error {
+	head, err := chains.GetHeadHeader(chainNum)
+	if err != nil || height > head.Height {
+		return ErrMiningDataHistoryUnknown
+	}
+
+	// the blocks are not stored before the HEAD block of state snapshot
+	hash, err := chains.GetCanonicalHash(chainNum, height)
+	if err != nil {
+		return nil
+	}
+
+	block, err := chains.GetBlock(chainNum, hash)
+	if err != nil {
+		return nil
+	}
+
+	if block.FindTransaction(txHash) == nil {
+		// the block is not confirmed yet, and may be reorganized later
+		if height+common.ConfirmedBlockNumber > head.Height {
+			return ErrMiningDataHistoryUnknown
+		}
+
+		return errMiningDataTxNotFound
 	}
 
 	return nil
@@ -6,6 +6,7 @@
 package pow
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"testing"
@@ -14,7 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/core/types"
-	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/miner/pow/powtest"
 )
 
 func Test_GetDifficult(t *testing.T) {
@@ -72,38 +73,266 @@ func Test_ValidateRewardAmount(t *testing.T) {
 }
 
 func Test_ValidateHeader(t *testing.T) {
-	var engine Engine
+	engine := NewEngine(common.DefaultNumOfChains, nil)
 
 	// block is validated for difficulty is so low
 	header := newTestBlockHeader(t)
-	err := engine.ValidateHeader(header)
+	err := engine.ValidateHeader(header, 0)
 	assert.Equal(t, err, nil)
 
 	// block is not validated for difficulty is so high
 	header.Difficulty = big.NewInt(10000000000)
-	err = engine.ValidateHeader(header)
-	assert.Equal(t, err, errBlockNonceInvalid)
+	err = engine.ValidateHeader(header, 0)
+	assert.Equal(t, err, ErrBlockNonceInvalid)
+}
+
+func Test_ValidateHeader_MiningData(t *testing.T) {
+	engine := NewEngine(common.DefaultNumOfChains, nil)
+
+	// mining key maps to another chain
+	header := newTestBlockHeader(t)
+	err := engine.ValidateHeader(header, 1)
+	assert.Equal(t, err, errMiningKeyChainMismatch)
+
+	// mining key is mined by another creator
+	header.Creator = common.BytesToAddress([]byte("creator"))
+	err = engine.ValidateHeader(header, 0)
+	assert.Equal(t, err, errMiningKeyNonceInvalid)
+
+	// mining data is tampered
+	header = newTestBlockHeader(t)
+	header.MiningData.TxHashes[1] = common.StringToHash("tx")
+	err = engine.ValidateHeader(header, 0)
+	assert.Equal(t, err, errMiningKeyNonceInvalid)
+
+	// mining data is missing
+	header.MiningData = types.MiningDataPack{}
+	err = engine.ValidateHeader(header, 0)
+	assert.Equal(t, err, errMiningDataInvalid)
+}
+
+func Test_ValidateMiningData(t *testing.T) {
+	for i := uint64(0); i < common.DefaultNumOfChains; i++ {
+		pack := powtest.NewMiningData(i)
+		assert.Equal(t, ValidateMiningData(powtest.Creator, &pack, i, common.DefaultNumOfChains), nil)
+		assert.Equal(t, types.GetChainNumByMiningKey(pack.Key(powtest.Creator), common.DefaultNumOfChains), i)
+	}
+
+	// heights are not aligned with the number of chains
+	pack := powtest.NewMiningData(0)
+	pack.Heights = pack.Heights[1:]
+	assert.Equal(t, ValidateMiningData(powtest.Creator, &pack, 0, common.DefaultNumOfChains), errMiningDataInvalid)
+
+	// nonce is invalid
+	pack = powtest.NewMiningData(0)
+	pack.Nonce++
+	assert.Equal(t, ValidateMiningData(powtest.Creator, &pack, 0, common.DefaultNumOfChains), errMiningKeyNonceInvalid)
+}
+
+type testChain struct {
+	head      *types.BlockHeader
+	canonical map[uint64]common.Hash
+	headers   map[common.Hash]*types.BlockHeader
+	blocks    map[common.Hash]*types.Block
+}
+
+type testChainReader map[uint64]*testChain
+
+func newTestChainReader() testChainReader {
+	chains := make(testChainReader)
+	for i := uint64(0); i < common.DefaultNumOfChains; i++ {
+		chains[i] = &testChain{
+			canonical: make(map[uint64]common.Hash),
+			headers:   make(map[common.Hash]*types.BlockHeader),
+			blocks:    make(map[common.Hash]*types.Block),
+		}
+	}
+
+	return chains
+}
+
+// extend appends a canonical block of the specified txs on the specified chain, and returns the block hash.
+func (chains testChainReader) extend(chainNum uint64, pack types.MiningDataPack, txs ...*types.Transaction) common.Hash {
+	chain := chains[chainNum]
+	header := &types.BlockHeader{MiningData: pack, Difficulty: big.NewInt(1), CreateTimestamp: big.NewInt(0)}
+	if chain.head != nil {
+		header.PreviousBlockHash = chain.canonical[chain.head.Height]
+		header.Height = chain.head.Height + 1
+	}
+
+	hash := header.Hash()
+	chain.head = header
+	chain.canonical[header.Height] = hash
+	chain.headers[hash] = header
+	chain.blocks[hash] = &types.Block{HeaderHash: hash, Header: header, Transactions: txs}
+
+	return hash
+}
+
+func (chains testChainReader) GetHeadHeader(chainNum uint64) (*types.BlockHeader, error) {
+	if chains[chainNum].head == nil {
+		return nil, errors.New("head not found")
+	}
+
+	return chains[chainNum].head, nil
+}
+
+func (chains testChainReader) GetCanonicalHash(chainNum uint64, height uint64) (common.Hash, error) {
+	hash, found := chains[chainNum].canonical[height]
+	if !found {
+		return common.EmptyHash, errors.New("canonical hash not found")
+	}
+
+	return hash, nil
+}
+
+func (chains testChainReader) GetBlockHeader(chainNum uint64, hash common.Hash) (*types.BlockHeader, error) {
+	header, found := chains[chainNum].headers[hash]
+	if !found {
+		return nil, errors.New("header not found")
+	}
+
+	return header, nil
+}
+
+func (chains testChainReader) GetBlock(chainNum uint64, hash common.Hash) (*types.Block, error) {
+	block, found := chains[chainNum].blocks[hash]
+	if !found {
+		return nil, errors.New("block not found")
+	}
+
+	return block, nil
+}
+
+func newTestChildHeader(parentHash common.Hash, pack types.MiningDataPack) *types.BlockHeader {
+	return &types.BlockHeader{PreviousBlockHash: parentHash, MiningData: pack}
+}
+
+func Test_validateMiningDataHistory_Empty(t *testing.T) {
+	chains := newTestChainReader()
+	emptyPack := powtest.NewMiningData(0)
+
+	// parent is unknown
+	header := newTestChildHeader(common.StringToHash("unknown"), emptyPack)
+	assert.Equal(t, validateMiningDataHistory(header, 0, chains), ErrMiningDataHistoryUnknown)
+
+	// empty entries are accepted while the chain is short
+	var parentHash common.Hash
+	for i := uint64(0); i <= MiningDataStartHeight; i++ {
+		parentHash = chains.extend(0, emptyPack)
+		header = newTestChildHeader(parentHash, emptyPack)
+		assert.Equal(t, validateMiningDataHistory(header, 0, chains), nil)
+	}
+
+	// empty entry of own chain is rejected once the parent proves the chain is long enough
+	parentHash = chains.extend(0, emptyPack)
+	header = newTestChildHeader(parentHash, emptyPack)
+	assert.Equal(t, validateMiningDataHistory(header, 0, chains), errMiningDataInvalid)
+
+	// empty entry of another chain is rejected if it is not empty in the pack of parent
+	chains = newTestChainReader()
+	pack := powtest.NewMiningData(0)
+	pack.TxHashes[1] = common.StringToHash("tx")
+	parentHash = chains.extend(0, pack)
+	header = newTestChildHeader(parentHash, emptyPack)
+	assert.Equal(t, validateMiningDataHistory(header, 0, chains), errMiningDataInvalid)
+
+	// empty entry of another chain is rejected once the parent is far beyond the start height
+	chains = newTestChainReader()
+	tx := &types.Transaction{Hash: common.StringToHash("tx")}
+	for i := uint64(0); i <= MiningDataStartHeight+MiningDataMaxDepth+1; i++ {
+		parentHash = chains.extend(0, emptyPack, tx)
+	}
+	pack = powtest.NewMiningData(0)
+	pack.Heights[0], pack.TxHashes[0] = chains[0].head.Height, tx.Hash
+	header = newTestChildHeader(parentHash, pack)
+	assert.Equal(t, validateMiningDataHistory(header, 0, chains), errMiningDataInvalid)
+}
+
+func Test_validateMiningDataHistory(t *testing.T) {
+	chains := newTestChainReader()
+	tx0 := &types.Transaction{Hash: common.StringToHash("tx0")}
+	tx1 := &types.Transaction{Hash: common.StringToHash("tx1")}
+
+	emptyPack := powtest.NewMiningData(0)
+	for i := uint64(0); i < 10; i++ {
+		chains.extend(0, emptyPack, tx0)
+		chains.extend(1, emptyPack, tx1)
+	}
+
+	parentHash := chains[0].canonical[9]
+	newPack := func(height0, height1 uint64) types.MiningDataPack {
+		pack := powtest.NewMiningData(0)
+		pack.Heights[0], pack.TxHashes[0] = height0, tx0.Hash
+		pack.Heights[1], pack.TxHashes[1] = height1, tx1.Hash
+		return pack
+	}
+
+	// txs are included in the history
+	pack := newPack(8, 5)
+	assert.Equal(t, validateMiningDataHistory(newTestChildHeader(parentHash, pack), 0, chains), nil)
+
+	// tx is not included in the ancestor of own chain
+	pack.TxHashes[0] = common.StringToHash("unknown")
+	assert.Equal(t, validateMiningDataHistory(newTestChildHeader(parentHash, pack), 0, chains), errMiningDataTxNotFound)
+
+	// own chain entry is beyond the parent
+	pack = newPack(10, 5)
+	assert.Equal(t, validateMiningDataHistory(newTestChildHeader(parentHash, pack), 0, chains), errMiningDataStale)
+
+	// tx is not included in the confirmed block of another chain
+	pack = newPack(8, 5)
+	pack.TxHashes[1] = common.StringToHash("unknown")
+	assert.Equal(t, validateMiningDataHistory(newTestChildHeader(parentHash, pack), 0, chains), errMiningDataTxNotFound)
+
+	// tx is not included in the unconfirmed block of another chain, which may be reorganized later
+	pack.Heights[1] = 8
+	assert.Equal(t, validateMiningDataHistory(newTestChildHeader(parentHash, pack), 0, chains), ErrMiningDataHistoryUnknown)
+
+	// another chain lags behind
+	pack = newPack(8, 12)
+	assert.Equal(t, validateMiningDataHistory(newTestChildHeader(parentHash, pack), 0, chains), ErrMiningDataHistoryUnknown)
+
+	// another chain entry is far behind the entry in the pack of parent
+	parentHash = chains.extend(0, newPack(9, 9+MiningDataMaxDepth), tx0)
+	pack = newPack(9, 5)
+	assert.Equal(t, validateMiningDataHistory(newTestChildHeader(parentHash, pack), 0, chains), errMiningDataStale)
+
+	// history not stored before the HEAD block of state snapshot is not validated
+	pack = newPack(9, 5)
+	pack.Heights[1] = 5 + MiningDataMaxDepth
+	for h := uint64(10); h <= pack.Heights[1]; h++ {
+		chains.extend(1, emptyPack, tx1)
+	}
+	hash := chains[1].canonical[pack.Heights[1]]
+	delete(chains[1].blocks, hash)
+	pack.TxHashes[1] = common.StringToHash("unknown")
+	assert.Equal(t, validateMiningDataHistory(newTestChildHeader(parentHash, pack), 0, chains), nil)
+}
+
+func Test_ValidateHeader_MiningDataHistory(t *testing.T) {
+	chains := newTestChainReader()
+	header := newTestBlockHeader(t)
+	header.Difficulty = big.NewInt(1)
+
+	// parent is not known yet
+	assert.Equal(t, NewEngine(common.DefaultNumOfChains, chains).ValidateHeader(header, 0), ErrMiningDataHistoryUnknown)
+
+	// parent is genesis
+	header.PreviousBlockHash = chains.extend(0, types.MiningDataPack{})
+	assert.Equal(t, NewEngine(common.DefaultNumOfChains, chains).ValidateHeader(header, 0), nil)
 }
 
 func newTestBlockHeader(t *testing.T) *types.BlockHeader {
 	return &types.BlockHeader{
 		PreviousBlockHash: common.StringToHash("PreviousBlockHash"),
-		Creator:           randomAddress(t),
+		Creator:           powtest.Creator,
 		StateHash:         common.StringToHash("StateHash"),
 		TxHash:            common.StringToHash("TxHash"),
 		Difficulty:        big.NewInt(1),
 		Height:            1,
 		CreateTimestamp:   big.NewInt(time.Now().Unix()),
 		Nonce:             1,
+		MiningData:        powtest.NewMiningData(0),
 	}
 }
-
-func randomAddress(t *testing.T) common.Address {
-	privKey, keyErr := crypto.GenerateKey()
-	if keyErr != nil {
-		t.Fatalf("Failed to generate ECDSA private key, error = %s", keyErr.Error())
-	}
-	hexAddress := crypto.PubkeyToString(&privKey.PublicKey)
-
-	return common.HexMustToAddres(hexAddress)
-}
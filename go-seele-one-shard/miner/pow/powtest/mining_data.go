@@ -0,0 +1,29 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+// Package powtest provides the pow utilities for test purpose only.
+package powtest
+
+import (
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/types"
+)
+
+// Creator is the block creator that the test mining data packs are mined for.
+var Creator = common.HexMustToAddres("0x4c10f2cd2159bb432094e3be7e17904c2b4aeb21")
+
+// miningKeyNonces are the nonces of valid mining keys on each chain, which are mined by Creator
+// on the data pack of zero heights and empty tx hashes with the default number of chains.
+var miningKeyNonces = []uint64{167602510, 13478385, 81395418}
+
+// NewMiningData returns a valid mining data pack of the specified chain mined by Creator.
+// Note, only the default number of chains is supported.
+func NewMiningData(chainNum uint64) types.MiningDataPack {
+	return types.MiningDataPack{
+		Heights:  make([]uint64, common.DefaultNumOfChains),
+		TxHashes: make([]common.Hash, common.DefaultNumOfChains),
+		Nonce:    miningKeyNonces[chainNum],
+	}
+}
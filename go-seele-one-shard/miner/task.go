@@ -27,7 +27,8 @@ type Task struct {
 	createdAt time.Time
 	coinbase  common.Address
 
-	chainNum  uint64
+	chainNum    uint64
+	numOfChains uint64
 }
 
 // applyTransactionsAndDebts TODO need to check more about the transactions, such as gas limit
@@ -41,7 +42,9 @@ func (task *Task) applyTransactionsAndDebts(seele SeeleBackend, statedb *state.S
 		return err
 	}
 
-	task.chooseTransactions(seele, statedb, log, size)
+	if err = task.chooseTransactions(seele, statedb, log, size); err != nil {
+		return err
+	}
 
 	log.Info("chainNum:%d, mining block height:%d, reward:%s, transaction number:%d, debt number: %d",
 		task.chainNum, task.header.Height, reward, len(task.txs), len(task.debts))
@@ -102,9 +105,7 @@ func (task *Task) handleMinerRewardTx(statedb *state.Statedb) (*big.Int, error)
 	return reward, nil
 }
 
-func (task *Task) chooseTransactions(seele SeeleBackend, statedb *state.Statedb, log *log.SeeleLog, size int) {
-	txIndex := 1 // the first tx is miner reward
-
+func (task *Task) chooseTransactions(seele SeeleBackend, statedb *state.Statedb, log *log.SeeleLog, size int) error {
 	// subtract the size of the reward
 	for _, tx := range task.txs {
 		size -= tx.Size()
@@ -119,34 +120,40 @@ func (task *Task) chooseTransactions(seele SeeleBackend, statedb *state.Statedb,
 			break
 		}
 
-		for _, tx := range txs {
-			if err := tx.Validate(statedb); err != nil {
-				TxPools[task.chainNum].RemoveTransaction(tx.Hash)
-				log.Error("failed to validate tx %s, for %s", tx.Hash.ToHex(), err)
-				txsSize = txsSize - tx.Size()
-				continue
+		validate := func(tx *types.Transaction, statedb *state.Statedb) error {
+			if err := Blockchains[task.chainNum].ValidateTxReplay(tx, task.header.Height); err != nil {
+				return err
 			}
 
-			receipt, err := Blockchains[task.chainNum].ApplyTransaction(tx, txIndex, task.coinbase, statedb, task.header)
+			return tx.Validate(statedb)
+		}
+
+		handle := func(tx *types.Transaction, receipt *types.Receipt, err error) error {
 			if err != nil {
 				TxPools[task.chainNum].RemoveTransaction(tx.Hash)
 				log.Error("failed to apply tx %s, %s", tx.Hash.ToHex(), err)
 				txsSize = txsSize - tx.Size()
-				continue
+				return nil
 			}
 
 			task.txs = append(task.txs, tx)
 			task.receipts = append(task.receipts, receipt)
-			txIndex++
+			return nil
+		}
+
+		if err := Blockchains[task.chainNum].ApplyTransactions(statedb, txs, len(task.txs), task.header, validate, handle); err != nil {
+			return err
 		}
 
 		size -= txsSize
 	}
+
+	return nil
 }
 
 // generateBlock builds a block from task
 func (task *Task) generateBlock() *types.Block {
-	return types.NewBlock(task.header, task.txs, task.receipts, task.debts, task.chainNum)
+	return types.NewBlock(task.header, task.txs, task.receipts, task.debts, task.chainNum, task.numOfChains)
 }
 
 // Result is the result mined by engine. It contains the raw task and mined block.
@@ -53,6 +53,22 @@ type BasicConfig struct {
 
 	// coinbase used by the miner
 	Coinbase string `json:"coinbase"`
+
+	// The number of workers to execute txs of block concurrently, txs are executed sequentially if less than 2.
+	ParallelTxWorkers int `json:"parallelTxWorkers"`
+
+	// Prune the state of old blocks periodically if true, and the pruned state can no longer be queried.
+	// The state of all blocks is kept by default.
+	StatePruning bool `json:"statePruning"`
+
+	// The number of recent blocks whose state is kept for each chain, core.DefaultStateRetention is used if 0.
+	StateRetention uint64 `json:"stateRetention"`
+
+	// Retrieve the state of the trusted checkpoint from peers for the empty chain instead of replaying all blocks.
+	SnapshotSync bool `json:"snapshotSync"`
+
+	// The trusted checkpoints of chains, which are required to synchronize or import the state snapshot.
+	SnapshotCheckpoints []core.Checkpoint `json:"snapshotCheckpoints"`
 }
 
 // HTTPServer config for http server
@@ -0,0 +1,195 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package p2p
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/log"
+)
+
+const (
+	// BanListFileName is the file name of banned nodes, which is saved in the same folder with the discovery nodes.
+	BanListFileName = "bans.json"
+
+	// DefaultBanDuration is the duration that a misbehaving peer is banned for.
+	DefaultBanDuration = 24 * time.Hour
+)
+
+// BanInfo represents a node that is banned until the expiration time. The IP of node
+// is also banned if known, so that the node could not reconnect with a new node ID.
+type BanInfo struct {
+	ID         common.Address `json:"id"`
+	IP         net.IP         `json:"ip,omitempty"`
+	Expiration time.Time      `json:"expiration"`
+	Reason     string         `json:"reason"`
+}
+
+// banList is a thread safe collection of banned nodes, and the expired ones are removed lazily.
+type banList struct {
+	bans map[common.Address]*BanInfo
+	path string // empty path for in-memory ban list
+	log  *log.SeeleLog
+	lock sync.RWMutex
+}
+
+func newBanList(log *log.SeeleLog) *banList {
+	return &banList{
+		bans: make(map[common.Address]*BanInfo),
+		log:  log,
+	}
+}
+
+// load loads the unexpired bans from the file in node dir, and saves the later changes to it.
+func (b *banList) load(nodeDir string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.path = filepath.Join(nodeDir, BanListFileName)
+	if !common.FileOrFolderExists(b.path) {
+		return
+	}
+
+	data, err := ioutil.ReadFile(b.path)
+	if err != nil {
+		b.log.Error("failed to read ban list file for:[%s]", err)
+		return
+	}
+
+	var bans []*BanInfo
+	if err = json.Unmarshal(data, &bans); err != nil {
+		b.log.Error("failed to unmarshal ban list for:[%s]", err)
+		return
+	}
+
+	now := time.Now()
+	for _, ban := range bans {
+		if ban.Expiration.After(now) {
+			b.bans[ban.ID] = ban
+		}
+	}
+
+	b.log.Debug("load %d banned nodes from file", len(b.bans))
+}
+
+// add bans the node and its IP for the duration, and the expiration of an existing ban
+// is overridden. The IP could be nil if unknown.
+func (b *banList) add(id common.Address, ip net.IP, duration time.Duration, reason string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.bans[id] = &BanInfo{
+		ID:         id,
+		IP:         ip,
+		Expiration: time.Now().Add(duration),
+		Reason:     reason,
+	}
+
+	b.save()
+}
+
+// remove unbans the node, and returns false if the node is not banned.
+func (b *banList) remove(id common.Address) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if _, ok := b.bans[id]; !ok {
+		return false
+	}
+
+	delete(b.bans, id)
+	b.save()
+
+	return true
+}
+
+// isBanned returns true if either the node or the IP is banned. The IP could be nil if unknown.
+func (b *banList) isBanned(id common.Address, ip net.IP) bool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	now := time.Now()
+	if ban, ok := b.bans[id]; ok && ban.Expiration.After(now) {
+		return true
+	}
+
+	if len(ip) == 0 {
+		return false
+	}
+
+	for _, ban := range b.bans {
+		if ban.IP.Equal(ip) && ban.Expiration.After(now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// list returns the unexpired bans sorted by expiration.
+func (b *banList) list() []BanInfo {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.removeExpired()
+
+	bans := make([]BanInfo, 0, len(b.bans))
+	for _, ban := range b.bans {
+		bans = append(bans, *ban)
+	}
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Expiration.Before(bans[j].Expiration)
+	})
+
+	return bans
+}
+
+func (b *banList) removeExpired() {
+	now := time.Now()
+	for id, ban := range b.bans {
+		if !ban.Expiration.After(now) {
+			delete(b.bans, id)
+		}
+	}
+}
+
+// save dumps the unexpired bans into the file, and must be called with lock held.
+func (b *banList) save() {
+	if len(b.path) == 0 {
+		return
+	}
+
+	b.removeExpired()
+
+	bans := make([]*BanInfo, 0, len(b.bans))
+	for _, ban := range b.bans {
+		bans = append(bans, ban)
+	}
+
+	data, err := json.MarshalIndent(bans, "", "\t")
+	if err != nil {
+		b.log.Error("json marshal ban list occur error, for:[%s]", err)
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Dir(b.path), os.ModePerm); err != nil {
+		b.log.Error("failed to create folder of ban list, for:[%s]", err)
+		return
+	}
+
+	if err = ioutil.WriteFile(b.path, data, 0666); err != nil {
+		b.log.Error("ban list backup failed, for:[%s]", err)
+	}
+}
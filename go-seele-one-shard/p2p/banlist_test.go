@@ -0,0 +1,85 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package p2p
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_banList(t *testing.T) {
+	bans := newBanList(log.GetLogger("p2p"))
+	id1 := common.BytesToAddress([]byte("node1"))
+	id2 := common.BytesToAddress([]byte("node2"))
+
+	ip1 := net.ParseIP("192.168.1.1")
+	ip2 := net.ParseIP("192.168.1.2")
+
+	bans.add(id1, ip1, time.Hour, "invalid block")
+	bans.add(id2, ip2, -time.Second, "expired")
+	assert.Equal(t, bans.isBanned(id1, nil), true)
+	assert.Equal(t, bans.isBanned(id2, nil), false)
+
+	// the banned IP could not reconnect with a new node ID
+	id3 := common.BytesToAddress([]byte("node3"))
+	assert.Equal(t, bans.isBanned(id3, ip1), true)
+	assert.Equal(t, bans.isBanned(id3, ip2), false)
+	assert.Equal(t, bans.isBanned(id3, nil), false)
+
+	list := bans.list()
+	assert.Equal(t, len(list), 1)
+	assert.Equal(t, list[0].ID, id1)
+	assert.Equal(t, list[0].IP, ip1)
+	assert.Equal(t, list[0].Reason, "invalid block")
+
+	assert.Equal(t, bans.remove(id1), true)
+	assert.Equal(t, bans.remove(id1), false)
+	assert.Equal(t, bans.isBanned(id1, ip1), false)
+	assert.Equal(t, bans.isBanned(id3, ip1), false)
+}
+
+func Test_banList_UnknownIP(t *testing.T) {
+	bans := newBanList(log.GetLogger("p2p"))
+	id1 := common.BytesToAddress([]byte("node1"))
+	id2 := common.BytesToAddress([]byte("node2"))
+
+	// the node without known IP does not ban any IP
+	bans.add(id1, nil, time.Hour, "banned by admin")
+	assert.Equal(t, bans.isBanned(id1, net.ParseIP("192.168.1.1")), true)
+	assert.Equal(t, bans.isBanned(id2, net.ParseIP("192.168.1.1")), false)
+}
+
+func Test_banList_Persist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "banlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	id := common.BytesToAddress([]byte("node1"))
+	bans := newBanList(log.GetLogger("p2p"))
+	bans.load(dir)
+	ip := net.ParseIP("192.168.1.1")
+	bans.add(id, ip, time.Hour, "timeout")
+
+	// bans are loaded from the file in node dir
+	loaded := newBanList(log.GetLogger("p2p"))
+	loaded.load(dir)
+	assert.Equal(t, loaded.isBanned(id, nil), true)
+	assert.Equal(t, loaded.isBanned(common.BytesToAddress([]byte("node2")), ip), true)
+
+	bans.remove(id)
+	loaded = newBanList(log.GetLogger("p2p"))
+	loaded.load(dir)
+	assert.Equal(t, loaded.isBanned(id, ip), false)
+}
@@ -44,6 +44,21 @@ type connection struct {
 	fd     net.Conn   // tcp connection
 	rmutux sync.Mutex // read msg lock
 	wmutux sync.Mutex // write msg lock
+
+	// frames are encrypted and authenticated after the handshake, nil before that.
+	egress  *frameCipher
+	ingress *frameCipher
+}
+
+// secure encrypts and authenticates all the following frames with the specified ciphers.
+func (c *connection) secure(egress, ingress *frameCipher) {
+	c.wmutux.Lock()
+	c.egress = egress
+	c.wmutux.Unlock()
+
+	c.rmutux.Lock()
+	c.ingress = ingress
+	c.rmutux.Unlock()
 }
 
 // readFull receive from fd till outBuf is full,
@@ -131,18 +146,33 @@ func (c *connection) ReadMsg() (msgRecv *Message, err error) {
 		return &Message{}, errMagic
 	}
 
-	if size > maxSize {
+	limit := maxSize
+	if c.ingress != nil {
+		limit += uint32(c.ingress.overhead())
+	}
+
+	if size > limit {
 		mlog := log.GetLogger("p2p")
-		mlog.Debug("Failed to get data, payload size %d exceeds the limit %d, sender is %s", size, maxSize, c.fd.RemoteAddr().String())
+		mlog.Debug("Failed to get data, payload size %d exceeds the limit %d, sender is %s", size, limit, c.fd.RemoteAddr().String())
 		return &Message{}, errSize
 	}
 
+	if c.ingress != nil && size == 0 {
+		return &Message{}, errFrameFormat
+	}
+
 	if size > 0 {
 		msgRecv.Payload = make([]byte, size)
 		if err = c.readFull(msgRecv.Payload); err != nil {
 			return &Message{}, err
 		}
 
+		if c.ingress != nil {
+			if msgRecv.Code, msgRecv.Payload, err = c.ingress.openFrame(headbuff, msgRecv.Payload); err != nil {
+				return &Message{}, err
+			}
+		}
+
 		if err = msgRecv.UnZip(); err != nil {
 			return &Message{}, err
 		}
@@ -165,17 +195,26 @@ func (c *connection) WriteMsg(msg *Message) error {
 	binary.BigEndian.PutUint32(b[headBuffSizeStart:headBuffSizeEnd], uint32(len(msg.Payload)))
 	binary.BigEndian.PutUint16(b[headBuffCodeStart:headBuffCodeEnd], msg.Code)
 	binary.BigEndian.PutUint16(b[headBuffMagicStart:headBuffMagicEnd], magicNumber)
+	body := msg.Payload
+
+	// the message code is encrypted in frame body
+	if c.egress != nil {
+		var err error
+		if b, body, err = c.egress.sealFrame(msg.Code, msg.Payload); err != nil {
+			return err
+		}
+	}
 
 	if err := c.writeFull(b); err != nil {
 		return err
 	}
 
-	if len(msg.Payload) > 0 {
-		if err := c.writeFull(msg.Payload); err != nil {
+	if len(body) > 0 {
+		if err := c.writeFull(body); err != nil {
 			return err
 		}
 	}
 	metricsSendMessageCountMeter.Mark(1)
-	metricsSendPortSpeedMeter.Mark(headBuffLength + int64(len(msg.Payload)))
+	metricsSendPortSpeedMeter.Mark(headBuffLength + int64(len(body)))
 	return nil
 }
@@ -76,6 +76,8 @@ func Test_Conn_ReadFullAndWriteFull(t *testing.T) {
 }
 
 func Test_connection(t *testing.T) {
+	defer func(size uint32) { maxSize = size }(maxSize)
+
 	con, ln, err := newConnection()
 	defer ln.Close()
 	defer con.close()
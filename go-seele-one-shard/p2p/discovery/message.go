@@ -48,40 +48,39 @@ const (
 	discoveryProtocolVersion uint = 1
 )
 
+// The sender ID of messages is recovered from the packet signature.
 type ping struct {
-	Version   uint // TODO add version check
-	SelfID    common.Address
+	Version   uint
 	SelfShard uint
 
 	to *Node
 }
 
 type pong struct {
-	SelfID    common.Address
 	SelfShard uint
+	PingHash  common.Hash // hash of the ping packet, which proves the endpoint of sender
+
+	Transports Transport // transports that the sender supports
+	QvicPort   uint16
 }
 
 type findNode struct {
-	SelfID  common.Address
 	QueryID common.Address // the ID we want to query in Kademila
 
 	to *Node // the node that send request to
 }
 
 type neighbors struct {
-	SelfID common.Address
-	Nodes  []*rpcNode
+	Nodes []*rpcNode
 }
 
 type findShardNode struct {
-	SelfID       common.Address
 	RequestShard uint // request shard info
 
 	to *Node
 }
 
 type shardNode struct {
-	SelfID       common.Address
 	RequestShard uint // request shard info
 	Nodes        []*rpcNode
 }
@@ -114,43 +113,55 @@ func msgTypeToByte(t msgType) byte {
 	return byte(t)
 }
 
-func generateBuff(code msgType, encoding []byte) []byte {
-	buff := []byte{msgTypeToByte(code)}
-
-	return append(buff, encoding...)
-}
-
-// handle send pong msg and add pending
-func (m *ping) handle(t *udp, from *net.UDPAddr) {
-	node := NewNodeWithAddr(m.SelfID, from, m.SelfShard)
-	t.addNode(node, false)
-	t.timeoutNodesCount.Set(m.SelfID.ToHex(), 0)
-
-	// response with pong
+// handle send pong msg, and ping back if the endpoint of sender is not proven yet
+func (m *ping) handle(t *udp, from *net.UDPAddr, fromID common.Address, hash common.Hash) {
 	if m.Version != discoveryProtocolVersion {
+		t.log.Debug("received [pingMsg] with unsupported version %d from: %s", m.Version, from)
 		return
 	}
 
+	node := NewNodeWithAddr(fromID, from, m.SelfShard)
 	resp := &pong{
-		SelfID:    t.self.ID,
-		SelfShard: t.self.Shard,
+		SelfShard:  t.self.Shard,
+		PingHash:   hash,
+		Transports: t.self.Transports,
+		QvicPort:   uint16(t.self.QvicPort),
 	}
 
 	t.log.Debug("received [pingMsg] and send [pongMsg] to: %s", node)
 	t.sendMsg(pongMsgType, resp, node.ID, node.GetUDPAddr())
+
+	// the node is added only after it responds our ping with pong
+	if t.hasEndpointProof(fromID, from) {
+		t.timeoutNodesCount.Set(fromID.ToHex(), 0)
+	} else {
+		t.ping(node)
+	}
 }
 
 // send send ping message and handle callback
 func (m *ping) send(t *udp) {
 	t.log.Debug("send [pingMsg] to: %s", m.to)
 
+	buff, hash, err := t.encodeMsg(pingMsgType, m)
+	if err != nil {
+		t.log.Warn("failed to encode [pingMsg], %s", err)
+		return
+	}
+
 	p := &pending{
 		from: m.to,
 		code: pongMsgType,
 
-		callback: func(resp interface{}, addr *net.UDPAddr) (done bool) {
+		callback: func(resp interface{}, fromID common.Address, addr *net.UDPAddr) (done bool) {
 			r := resp.(*pong)
-			n := NewNodeWithAddr(r.SelfID, addr, r.SelfShard)
+			if !r.PingHash.Equal(hash) {
+				t.log.Debug("received [pongMsg] from: %s, but it's not bound to the ping", addr)
+				return false
+			}
+
+			n := NewNodeWithAddr(fromID, addr, r.SelfShard)
+			n.Transports, n.QvicPort = r.Transports, int(r.QvicPort)
 			t.addNode(n, true)
 			t.timeoutNodesCount.Set(n.ID.ToHex(), 0)
 
@@ -164,12 +175,18 @@ func (m *ping) send(t *udp) {
 	}
 
 	t.addPending <- p
-	t.sendMsg(pingMsgType, m, m.to.ID, m.to.GetUDPAddr())
+	t.sendPacket(pingMsgType, buff, m.to.ID, m.to.GetUDPAddr())
 }
 
 // handle response find node request
-func (m *findNode) handle(t *udp, from *net.UDPAddr) {
-	t.log.Debug("received request [findNodeMsg] from: %s, id: %s", from, m.SelfID.ToHex())
+func (m *findNode) handle(t *udp, from *net.UDPAddr, fromID common.Address) {
+	t.log.Debug("received request [findNodeMsg] from: %s, id: %s", from, fromID.ToHex())
+
+	// do not response to the node that doesn't prove its endpoint, which may be a forged address
+	if !t.hasEndpointProof(fromID, from) {
+		t.log.Debug("ignore request [findNodeMsg] from: %s without endpoint proof", from)
+		return
+	}
 
 	nodes := t.table.findNodeWithTarget(crypto.HashBytes(m.QueryID.Bytes()))
 
@@ -183,11 +200,10 @@ func (m *findNode) handle(t *udp, from *net.UDPAddr) {
 	}
 
 	response := &neighbors{
-		Nodes:  rpcs,
-		SelfID: t.self.ID,
+		Nodes: rpcs,
 	}
 
-	t.sendMsg(neighborsMsgType, response, m.SelfID, from)
+	t.sendMsg(neighborsMsgType, response, fromID, from)
 }
 
 // send send find node message and handle callback
@@ -198,21 +214,23 @@ func (m *findNode) send(t *udp) {
 		from: m.to,
 		code: neighborsMsgType,
 
-		callback: func(resp interface{}, addr *net.UDPAddr) (done bool) {
+		callback: func(resp interface{}, fromID common.Address, addr *net.UDPAddr) (done bool) {
 			r := resp.(*neighbors)
 
-			t.log.Debug("received [neighborsMsg] from: %s with %d nodes", r.SelfID.ToHex(), len(r.Nodes))
+			t.log.Debug("received [neighborsMsg] from: %s with %d nodes", fromID.ToHex(), len(r.Nodes))
 			if r.Nodes == nil || len(r.Nodes) == 0 {
 				return true
 			}
 
-			for _, n := range r.Nodes {
+			nodes := make([]*Node, len(r.Nodes))
+			for i, n := range r.Nodes {
 				t.log.Debug("received node: %s", n.SelfID.ToHex())
-
-				node := n.ToNode()
-				t.addNode(node, false)
+				nodes[i] = n.ToNode()
 			}
 
+			// callback is called in the reply loop, so ping the nodes asynchronously
+			go t.proveEndpoints(nodes)
+
 			return true
 		},
 		errorCallBack: func() {
@@ -232,7 +250,6 @@ func sendFindNodeRequest(u *udp, nodes []*Node, target common.Address) {
 	concurrentCount := 0
 	for _, n := range nodes {
 		f := &findNode{
-			SelfID:  u.self.ID,
 			QueryID: target,
 			to:      n,
 		}
@@ -251,7 +268,6 @@ func sendFindNodeRequest(u *udp, nodes []*Node, target common.Address) {
 
 func sendFindShardNodeRequest(u *udp, shard uint, to *Node) {
 	query := &findShardNode{
-		SelfID:       u.self.ID,
 		RequestShard: shard,
 
 		to: to,
@@ -267,14 +283,18 @@ func (m *findShardNode) send(t *udp) {
 		from: m.to,
 		code: shardNodeMsgType,
 
-		callback: func(resp interface{}, addr *net.UDPAddr) (done bool) {
+		callback: func(resp interface{}, fromID common.Address, addr *net.UDPAddr) (done bool) {
 			r := resp.(*shardNode)
 			t.log.Debug("got response [shardNodeMsg] with nodes number %d in shard %d from:%s",
 				len(r.Nodes), r.RequestShard, addr)
-			for _, node := range r.Nodes {
-				t.addNode(node.ToNode(), false)
+
+			nodes := make([]*Node, len(r.Nodes))
+			for i, node := range r.Nodes {
+				nodes[i] = node.ToNode()
 			}
 
+			go t.proveEndpoints(nodes)
+
 			return true
 		},
 		errorCallBack: func() {
@@ -285,9 +305,14 @@ func (m *findShardNode) send(t *udp) {
 	t.sendMsg(findShardNodeMsgType, m, m.to.ID, m.to.GetUDPAddr())
 }
 
-func (m *findShardNode) handle(t *udp, from *net.UDPAddr) {
+func (m *findShardNode) handle(t *udp, from *net.UDPAddr, fromID common.Address) {
 	t.log.Debug("got request [findShardNodeMsg] from: %s, find shard %d", from, m.RequestShard)
 
+	if !t.hasEndpointProof(fromID, from) {
+		t.log.Debug("ignore request [findShardNodeMsg] from: %s without endpoint proof", from)
+		return
+	}
+
 	var nodes []*Node
 	if m.RequestShard == t.self.Shard {
 		nodes = t.table.GetRandNodes(responseNodeNumber)
@@ -306,10 +331,9 @@ func (m *findShardNode) handle(t *udp, from *net.UDPAddr) {
 	}
 
 	response := &shardNode{
-		SelfID:       t.self.ID,
 		RequestShard: m.RequestShard,
 		Nodes:        rpcNodes,
 	}
 
-	t.sendMsg(shardNodeMsgType, response, m.SelfID, from)
+	t.sendMsg(shardNodeMsgType, response, fromID, from)
 }
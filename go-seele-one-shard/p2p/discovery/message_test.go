@@ -97,15 +97,55 @@ func Test_Message_Ping_Handle(t *testing.T) {
 	p := testPing()
 	udp := newTestUDP()
 	from, _ := net.ResolveUDPAddr("udp", "127.0.0.1:9666")
+	fromID := *crypto.MustGenerateRandomAddress()
 
-	p.handle(udp, from)
+	// ping back since the endpoint is not proven
+	p.handle(udp, from, fromID, common.StringToHash("ping"))
 	receivedMsg := <-udp.writer
 	assert.Equal(t, receivedMsg.code, pongMsgType)
+	receivedMsg = <-udp.writer
+	assert.Equal(t, receivedMsg.code, pingMsgType)
+	assert.Equal(t, receivedMsg.toID, fromID)
+	assert.Equal(t, udp.db.size(), 0)
+
+	// no ping back for the proven endpoint
+	<-udp.addPending
+	udp.db.add(NewNodeWithAddr(fromID, from, 1), false)
+	p.handle(udp, from, fromID, common.StringToHash("ping"))
+	receivedMsg = <-udp.writer
+	assert.Equal(t, receivedMsg.code, pongMsgType)
+	assert.Equal(t, len(udp.writer), 0)
 
 	// invalid version
 	p.Version = discoveryProtocolVersion + 1
-	p.handle(udp, from)
-	assert.Equal(t, true, true) // do nothing and silent
+	p.handle(udp, from, fromID, common.StringToHash("ping"))
+	assert.Equal(t, len(udp.writer), 0) // do nothing and silent
+}
+
+func Test_Message_Ping_EndpointProof(t *testing.T) {
+	p := testPing()
+	udp := newTestUDP()
+
+	p.send(udp)
+	pending := <-udp.addPending
+	receivedMsg := <-udp.writer
+	pingHash := crypto.HashBytes(receivedMsg.buff)
+
+	// pong not bound to the ping
+	resp := &pong{SelfShard: 1, PingHash: common.StringToHash("ping")}
+	assert.Equal(t, pending.callback(resp, p.to.ID, p.to.GetUDPAddr()), false)
+	assert.Equal(t, udp.db.size(), 0)
+
+	resp.PingHash = pingHash
+	resp.Transports, resp.QvicPort = TransportTCP|TransportQvic, 8090
+	assert.Equal(t, pending.callback(resp, p.to.ID, p.to.GetUDPAddr()), true)
+	assert.Equal(t, udp.db.size(), 1)
+	assert.Equal(t, udp.hasEndpointProof(p.to.ID, p.to.GetUDPAddr()), true)
+
+	// transports advertised by pong
+	node, _ := udp.db.FindByNodeID(p.to.ID)
+	assert.Equal(t, node.Transports.Has(TransportQvic), true)
+	assert.Equal(t, node.QvicPort, 8090)
 }
 
 func Test_Message_Ping_Send(t *testing.T) {
@@ -123,8 +163,14 @@ func Test_Message_FindNode_Handle(t *testing.T) {
 	udp := newTestUDP()
 	udp.table = testTable()
 	from, _ := net.ResolveUDPAddr("udp", "127.0.0.1:9666")
+	fromID := *crypto.MustGenerateRandomAddress()
 
-	f.handle(udp, from)
+	// ignore the request without endpoint proof
+	f.handle(udp, from, fromID)
+	assert.Equal(t, len(udp.writer), 0)
+
+	udp.db.add(NewNodeWithAddr(fromID, from, 1), false)
+	f.handle(udp, from, fromID)
 	receivedMsg := <-udp.writer
 	assert.Equal(t, receivedMsg.code, neighborsMsgType)
 }
@@ -144,8 +190,10 @@ func Test_Message_FindShardNode_Handle(t *testing.T) {
 	udp := newTestUDP()
 	udp.table = testTable()
 	from, _ := net.ResolveUDPAddr("udp", "127.0.0.1:9666")
+	fromID := *crypto.MustGenerateRandomAddress()
+	udp.db.add(NewNodeWithAddr(fromID, from, 1), false)
 
-	fs.handle(udp, from)
+	fs.handle(udp, from, fromID)
 	receivedMsg := <-udp.writer
 	assert.Equal(t, receivedMsg.code, shardNodeMsgType)
 }
@@ -186,7 +234,6 @@ func testPing() *ping {
 
 	p := &ping{
 		Version:   discoveryProtocolVersion,
-		SelfID:    common.HexMustToAddres("0xd0c549b022f5a17a8f50a4a448d20ba579d01781"),
 		SelfShard: 1,
 		to:        node,
 	}
@@ -199,7 +246,6 @@ func testFindNode() *findNode {
 	node := r.ToNode()
 
 	f := &findNode{
-		SelfID:  common.HexMustToAddres("0xd0c549b022f5a17a8f50a4a448d20ba579d01781"),
 		QueryID: common.HexMustToAddres("0xd0c549b022f5a17a8f50a4a448d20ba579d01782"),
 		to:      node,
 	}
@@ -212,7 +258,6 @@ func testFindShardNode() *findShardNode {
 	node := r.ToNode()
 
 	fs := &findShardNode{
-		SelfID:       common.HexMustToAddres("0xd0c549b022f5a17a8f50a4a448d20ba579d01781"),
 		RequestShard: 1,
 		to:           node,
 	}
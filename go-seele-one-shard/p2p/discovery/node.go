@@ -24,6 +24,22 @@ var (
 	nodeHeader = "snode://"
 )
 
+// Transport is the bit flags of transport protocols that a node accepts p2p connections over.
+type Transport uint8
+
+const (
+	// TransportTCP indicates the node accepts p2p connections over TCP, with the same port as UDP.
+	TransportTCP Transport = 1 << iota
+
+	// TransportQvic indicates the node accepts p2p connections over qvic, with the qvic port.
+	TransportQvic
+)
+
+// Has returns true if the transport flags contain the specified transport.
+func (t Transport) Has(transport Transport) bool {
+	return t&transport != 0
+}
+
 // Node the node that contains its public key and network address
 type Node struct {
 	ID               common.Address //public key actually
@@ -32,6 +48,10 @@ type Node struct {
 
 	Shard uint //node shard number
 
+	// transports that the node supports, TCP by default
+	Transports Transport
+	QvicPort   int
+
 	// node id for Kademila, which is generated from public key
 	// better to get it with getSha()
 	sha common.Hash
@@ -40,10 +60,11 @@ type Node struct {
 // NewNode new node with its value
 func NewNode(id common.Address, ip net.IP, port int, shard uint) *Node {
 	return &Node{
-		ID:      id,
-		IP:      ip,
-		UDPPort: port,
-		Shard:   shard,
+		ID:         id,
+		IP:         ip,
+		UDPPort:    port,
+		Shard:      shard,
+		Transports: TransportTCP,
 	}
 }
 
@@ -23,6 +23,8 @@ func Test_NodeId(t *testing.T) {
 	assert.Equal(t, node.IP.String(), "192.168.122.132")
 	assert.Equal(t, node.UDPPort, 9000)
 	assert.Equal(t, node.Shard, uint(1))
+	assert.Equal(t, node.Transports.Has(TransportTCP), true)
+	assert.Equal(t, node.Transports.Has(TransportQvic), false)
 
 	assert.Equal(t, node.String(), id)
 }
@@ -0,0 +1,78 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package discovery
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/crypto/secp256k1"
+)
+
+// packet format: signature || msg type || expiration || payload
+const (
+	packetSigLength        = 65
+	packetTypeLength       = 1
+	packetExpirationLength = 8
+	packetHeadLength       = packetSigLength + packetTypeLength + packetExpirationLength
+
+	// packetExpiration is the time a packet is valid after it's sent, to prevent replay.
+	packetExpiration = 20 * time.Second
+)
+
+var (
+	errPacketTooSmall  = errors.New("packet is too small")
+	errPacketSignature = errors.New("invalid packet signature")
+	errPacketExpired   = errors.New("packet is expired")
+)
+
+// encodePacket signs the msg type, expiration and payload with the node private key,
+// and returns the packet and its hash.
+func encodePacket(privateKey *ecdsa.PrivateKey, code msgType, payload []byte) ([]byte, common.Hash, error) {
+	packet := make([]byte, packetHeadLength+len(payload))
+	packet[packetSigLength] = msgTypeToByte(code)
+	expiration := uint64(time.Now().Add(packetExpiration).Unix())
+	binary.BigEndian.PutUint64(packet[packetSigLength+packetTypeLength:packetHeadLength], expiration)
+	copy(packet[packetHeadLength:], payload)
+
+	sig, err := crypto.Sign(privateKey, crypto.HashBytes(packet[packetSigLength:]).Bytes())
+	if err != nil {
+		return nil, common.EmptyHash, err
+	}
+
+	copy(packet, sig.Sig)
+	return packet, crypto.HashBytes(packet), nil
+}
+
+// decodePacket verifies the packet, and returns the msg type, the sender ID recovered
+// from the signature, the payload and the packet hash.
+func decodePacket(packet []byte) (code msgType, fromID common.Address, payload []byte, hash common.Hash, err error) {
+	if len(packet) < packetHeadLength {
+		return 0, common.EmptyAddress, nil, common.EmptyHash, errPacketTooSmall
+	}
+
+	pubKey, err := secp256k1.RecoverPubkey(crypto.HashBytes(packet[packetSigLength:]).Bytes(), packet[:packetSigLength])
+	if err != nil {
+		return 0, common.EmptyAddress, nil, common.EmptyHash, errPacketSignature
+	}
+
+	pub := crypto.ToECDSAPub(pubKey)
+	if pub == nil || pub.X == nil || pub.Y == nil {
+		return 0, common.EmptyAddress, nil, common.EmptyHash, errPacketSignature
+	}
+
+	expiration := binary.BigEndian.Uint64(packet[packetSigLength+packetTypeLength : packetHeadLength])
+	if expiration < uint64(time.Now().Unix()) {
+		return 0, common.EmptyAddress, nil, common.EmptyHash, errPacketExpired
+	}
+
+	code = byteToMsgType(packet[packetSigLength])
+	return code, *crypto.GetAddress(pub), packet[packetHeadLength:], crypto.HashBytes(packet), nil
+}
@@ -0,0 +1,95 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package discovery
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Packet_EncodeDecode(t *testing.T) {
+	id, key, _ := crypto.GenerateKeyPair()
+	payload := []byte("discovery payload")
+
+	packet, hash, err := encodePacket(key, pingMsgType, payload)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, hash, crypto.HashBytes(packet))
+
+	code, fromID, decoded, decodedHash, err := decodePacket(packet)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, code, pingMsgType)
+	assert.Equal(t, fromID, *id)
+	assert.Equal(t, decoded, payload)
+	assert.Equal(t, decodedHash, hash)
+}
+
+func Test_Packet_Invalid(t *testing.T) {
+	id, key, _ := crypto.GenerateKeyPair()
+
+	// too small
+	_, _, _, _, err := decodePacket(make([]byte, packetHeadLength-1))
+	assert.Equal(t, err, errPacketTooSmall)
+
+	// the tampered payload is signed by another node
+	packet, _, _ := encodePacket(key, pongMsgType, []byte("discovery payload"))
+	packet[len(packet)-1]++
+	_, fromID, _, _, err := decodePacket(packet)
+	if err == nil {
+		assert.Equal(t, fromID.Equal(*id), false)
+	}
+
+	// invalid signature
+	packet, _, _ = encodePacket(key, pongMsgType, []byte("discovery payload"))
+	copy(packet, make([]byte, packetSigLength))
+	_, _, _, _, err = decodePacket(packet)
+	assert.Equal(t, err, errPacketSignature)
+
+	// expired packet is signed with the expiration
+	packet, _, _ = encodePacket(key, pongMsgType, []byte("discovery payload"))
+	expiration := uint64(time.Now().Add(-time.Second).Unix())
+	binary.BigEndian.PutUint64(packet[packetSigLength+packetTypeLength:packetHeadLength], expiration)
+	_, fromID, _, _, err = decodePacket(packet)
+	if err == nil {
+		assert.Equal(t, fromID.Equal(*id), false)
+	}
+
+	sig, _ := crypto.Sign(key, crypto.HashBytes(packet[packetSigLength:]).Bytes())
+	copy(packet, sig.Sig)
+	_, _, _, _, err = decodePacket(packet)
+	assert.Equal(t, err, errPacketExpired)
+}
+
+func Test_UDP_HandleMsg_Signed(t *testing.T) {
+	u := newTestUDP()
+	u.gotReply = make(chan *reply, 1)
+
+	id, key, _ := crypto.GenerateKeyPair()
+	from, _ := net.ResolveUDPAddr("udp", "127.0.0.1:9555")
+	payload, _ := common.Serialize(&pong{SelfShard: 1})
+
+	// sender ID is recovered from the signature
+	packet, _, _ := encodePacket(key, pongMsgType, payload)
+	u.handleMsg(from, packet)
+	r := <-u.gotReply
+	assert.Equal(t, r.fromID, *id)
+	assert.Equal(t, r.code, pongMsgType)
+
+	// unsigned packet is dropped
+	u.handleMsg(from, append([]byte{msgTypeToByte(pongMsgType)}, payload...))
+	assert.Equal(t, len(u.gotReply), 0)
+
+	// packet from itself is dropped
+	packet, _, _ = encodePacket(u.privateKey, pongMsgType, payload)
+	u.self.ID = *crypto.GetAddress(&u.privateKey.PublicKey)
+	u.handleMsg(from, packet)
+	assert.Equal(t, len(u.gotReply), 0)
+}
@@ -6,14 +6,13 @@
 package discovery
 
 import (
-	"net"
-
-	"github.com/seeleteam/go-seele/common"
+	"crypto/ecdsa"
 )
 
-// StartService start node udp service
-func StartService(nodeDir string, myID common.Address, myAddr *net.UDPAddr, bootstrap []*Node, shard uint) *Database {
-	udp := newUDP(myID, myAddr, shard)
+// StartService start node udp service for the self node, and the packets are signed with the private key of node.
+// The transports of self node are advertised to other nodes.
+func StartService(nodeDir string, privateKey *ecdsa.PrivateKey, self *Node, bootstrap []*Node) *Database {
+	udp := newUDP(privateKey, self)
 
 	if bootstrap != nil {
 		udp.trustNodes = bootstrap
@@ -10,16 +10,16 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/crypto"
 )
 
 func Test_Server_StartService(t *testing.T) {
 	nodeDir := "."
-	myID := common.HexMustToAddres("0xd0c549b022f5a17a8f50a4a448d20ba579d01781")
+	myKey, _ := crypto.GenerateKey()
 	myAddr, _ := net.ResolveUDPAddr("udp", "127.0.0.1:9777")
 	bootstrap := make([]*Node, 0)
-	shard := uint(1)
+	self := NewNodeWithAddr(*crypto.GetAddress(&myKey.PublicKey), myAddr, 1)
 
-	db := StartService(nodeDir, myID, myAddr, bootstrap, shard)
+	db := StartService(nodeDir, myKey, self, bootstrap)
 	assert.Equal(t, db != nil, true)
 }
@@ -7,6 +7,7 @@ package discovery
 
 import (
 	"container/list"
+	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -36,6 +37,7 @@ const (
 
 type udp struct {
 	conn           *net.UDPConn
+	privateKey     *ecdsa.PrivateKey // private key to sign packets
 	self           *Node
 	table          *Table
 	trustNodes     []*Node
@@ -59,7 +61,7 @@ type pending struct {
 
 	deadline time.Time
 
-	callback func(resp interface{}, addr *net.UDPAddr) (done bool)
+	callback func(resp interface{}, fromID common.Address, addr *net.UDPAddr) (done bool)
 
 	errorCallBack func()
 }
@@ -81,18 +83,22 @@ type reply struct {
 	data interface{}
 }
 
-func newUDP(id common.Address, addr *net.UDPAddr, shard uint) *udp {
+// newUDP creates the discovery service of the self node, and its ID must be the
+// address of the private key.
+func newUDP(privateKey *ecdsa.PrivateKey, self *Node) *udp {
 	log := log.GetLogger("discovery")
+	addr := self.GetUDPAddr()
 	conn, err := getUDPConn(addr)
 	if err != nil {
 		panic(fmt.Sprintf("failed to listen addr %s ", addr.String()))
 	}
 
 	transport := &udp{
-		conn:      conn,
-		table:     newTable(id, addr, shard, log),
-		self:      NewNodeWithAddr(id, addr, shard),
-		localAddr: addr,
+		conn:       conn,
+		privateKey: privateKey,
+		table:      newTable(self.ID, addr, self.Shard, log),
+		self:       self,
+		localAddr:  addr,
 
 		db: NewDatabase(log),
 
@@ -107,14 +113,27 @@ func newUDP(id common.Address, addr *net.UDPAddr, shard uint) *udp {
 	return transport
 }
 
-func (u *udp) sendMsg(t msgType, msg interface{}, toID common.Address, toAddr *net.UDPAddr) {
+// encodeMsg serializes the msg into a signed packet, and returns the packet and its hash.
+func (u *udp) encodeMsg(t msgType, msg interface{}) ([]byte, common.Hash, error) {
 	encoding, err := common.Serialize(msg)
+	if err != nil {
+		return nil, common.EmptyHash, err
+	}
+
+	return encodePacket(u.privateKey, t, encoding)
+}
+
+func (u *udp) sendMsg(t msgType, msg interface{}, toID common.Address, toAddr *net.UDPAddr) {
+	buff, _, err := u.encodeMsg(t, msg)
 	if err != nil {
 		u.log.Info(err.Error())
 		return
 	}
 
-	buff := generateBuff(t, encoding)
+	u.sendPacket(t, buff, toID, toAddr)
+}
+
+func (u *udp) sendPacket(t msgType, buff []byte, toID common.Address, toAddr *net.UDPAddr) {
 	s := &send{
 		buff:   buff,
 		toID:   toID,
@@ -160,99 +179,103 @@ func (u *udp) sendLoop() {
 }
 
 func (u *udp) handleMsg(from *net.UDPAddr, data []byte) {
-	if len(data) > 0 {
-		code := byteToMsgType(data[0])
+	code, fromID, payload, hash, err := decodePacket(data)
+	if err != nil {
+		u.log.Debug("failed to decode packet from %s, %s", from, err)
+		return
+	}
 
-		if common.PrintExplosionLog {
-			u.log.Debug("receive msg type: %s", codeToStr(code))
-		}
-		switch code {
-		case pingMsgType:
-			msg := &ping{}
-			err := common.Deserialize(data[1:], &msg)
-			if err != nil {
-				u.log.Warn(err.Error())
-				return
-			}
+	if fromID.Equal(u.self.ID) {
+		return
+	}
 
-			// response ping
-			msg.handle(u, from)
-		case pongMsgType:
-			msg := &pong{}
-			err := common.Deserialize(data[1:], &msg)
-			if err != nil {
-				u.log.Warn(err.Error())
-				return
-			}
+	if common.PrintExplosionLog {
+		u.log.Debug("receive msg type: %s", codeToStr(code))
+	}
+	switch code {
+	case pingMsgType:
+		msg := &ping{}
+		err := common.Deserialize(payload, &msg)
+		if err != nil {
+			u.log.Warn(err.Error())
+			return
+		}
 
-			r := &reply{
-				fromID:   msg.SelfID,
-				fromAddr: from,
-				code:     code,
-				data:     msg,
-				err:      false,
-			}
+		// response ping
+		msg.handle(u, from, fromID, hash)
+	case pongMsgType:
+		msg := &pong{}
+		err := common.Deserialize(payload, &msg)
+		if err != nil {
+			u.log.Warn(err.Error())
+			return
+		}
 
-			u.gotReply <- r
-		case findNodeMsgType:
-			msg := &findNode{}
+		r := &reply{
+			fromID:   fromID,
+			fromAddr: from,
+			code:     code,
+			data:     msg,
+			err:      false,
+		}
 
-			err := common.Deserialize(data[1:], &msg)
-			if err != nil {
-				u.log.Warn(err.Error())
-				return
-			}
+		u.gotReply <- r
+	case findNodeMsgType:
+		msg := &findNode{}
 
-			//response find
-			msg.handle(u, from)
-		case neighborsMsgType:
-			msg := &neighbors{}
-			err := common.Deserialize(data[1:], &msg)
-			if err != nil {
-				u.log.Warn(err.Error())
-				return
-			}
+		err := common.Deserialize(payload, &msg)
+		if err != nil {
+			u.log.Warn(err.Error())
+			return
+		}
 
-			r := &reply{
-				fromID:   msg.SelfID,
-				fromAddr: from,
-				code:     code,
-				data:     msg,
-				err:      false,
-			}
+		//response find
+		msg.handle(u, from, fromID)
+	case neighborsMsgType:
+		msg := &neighbors{}
+		err := common.Deserialize(payload, &msg)
+		if err != nil {
+			u.log.Warn(err.Error())
+			return
+		}
 
-			u.gotReply <- r
-		case findShardNodeMsgType:
-			msg := &findShardNode{}
-			err := common.Deserialize(data[1:], &msg)
-			if err != nil {
-				u.log.Warn(err.Error())
-				return
-			}
+		r := &reply{
+			fromID:   fromID,
+			fromAddr: from,
+			code:     code,
+			data:     msg,
+			err:      false,
+		}
 
-			msg.handle(u, from)
-		case shardNodeMsgType:
-			msg := &shardNode{}
-			err := common.Deserialize(data[1:], &msg)
-			if err != nil {
-				u.log.Warn(err.Error())
-				return
-			}
+		u.gotReply <- r
+	case findShardNodeMsgType:
+		msg := &findShardNode{}
+		err := common.Deserialize(payload, &msg)
+		if err != nil {
+			u.log.Warn(err.Error())
+			return
+		}
 
-			r := &reply{
-				fromID:   msg.SelfID,
-				fromAddr: from,
-				code:     code,
-				data:     msg,
-				err:      false,
-			}
+		msg.handle(u, from, fromID)
+	case shardNodeMsgType:
+		msg := &shardNode{}
+		err := common.Deserialize(payload, &msg)
+		if err != nil {
+			u.log.Warn(err.Error())
+			return
+		}
 
-			u.gotReply <- r
-		default:
-			u.log.Error("unknown code %d", code)
+		r := &reply{
+			fromID:   fromID,
+			fromAddr: from,
+			code:     code,
+			data:     msg,
+			err:      false,
 		}
-	} else {
-		u.log.Info("wrong length")
+
+		u.gotReply <- r
+	default:
+		u.log.Error("unknown code %d", code)
 	}
 }
 
@@ -303,16 +326,20 @@ func (u *udp) loopReply() {
 			for el := pendingList.Front(); el != nil; el = el.Next() {
 				p := el.Value.(*pending)
 
-				if p.code == r.code && p.from.GetUDPAddr().String() == r.fromAddr.String() {
+				// the reply must be sent by the requested node if its ID is known
+				if p.code == r.code && p.from.GetUDPAddr().String() == r.fromAddr.String() &&
+					(p.from.ID.IsEmpty() || p.from.ID.Equal(r.fromID)) {
 					if r.err {
 						p.errorCallBack()
 						pendingList.Remove(el)
-					} else {
-						if p.callback(r.data, r.fromAddr) {
-							pendingList.Remove(el)
-						}
+						break
+					}
+
+					// try next pending if the reply is not for this one, e.g. pong of another ping
+					if p.callback(r.data, r.fromID, r.fromAddr) {
+						pendingList.Remove(el)
+						break
 					}
-					break
 				}
 			}
 		case p := <-u.addPending:
@@ -430,7 +457,6 @@ func (u *udp) pingPongService() {
 func (u *udp) ping(value *Node) {
 	p := &ping{
 		Version:   discoveryProtocolVersion,
-		SelfID:    u.self.ID,
 		SelfShard: u.self.Shard,
 
 		to: value,
@@ -439,6 +465,25 @@ func (u *udp) ping(value *Node) {
 	p.send(u)
 }
 
+// hasEndpointProof returns true if the node of specified ID has responded our ping
+// with pong from the specified address.
+func (u *udp) hasEndpointProof(id common.Address, addr *net.UDPAddr) bool {
+	n, ok := u.db.FindByNodeID(id)
+	return ok && n.GetUDPAddr().String() == addr.String()
+}
+
+// proveEndpoints pings the nodes whose endpoints are not proven yet, and the nodes
+// will be added after they response with pong.
+func (u *udp) proveEndpoints(nodes []*Node) {
+	for _, n := range nodes {
+		if n == nil || u.self.ID.Equal(n.ID) || u.hasEndpointProof(n.ID, n.GetUDPAddr()) {
+			continue
+		}
+
+		u.ping(n)
+	}
+}
+
 func (u *udp) StartServe(nodeDir string) {
 	go u.readLoop()
 	go u.loopReply()
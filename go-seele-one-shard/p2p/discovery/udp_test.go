@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/orcaman/concurrent-map"
 	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/crypto"
 	"github.com/seeleteam/go-seele/log"
 )
 
@@ -31,15 +32,20 @@ func newTestUDP() *udp {
 	node2 := newNode(id2)
 
 	self := newNode(selfID)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
 
 	log := log.GetLogger("discovery")
 	addr, _ := net.ResolveUDPAddr("udp", "127.0.0.1:9666")
 	return &udp{
+		privateKey:        key,
 		trustNodes:        []*Node{node1, node2},
 		table:             newTable(self.ID, addr, 1, log),
 		self:              NewNodeWithAddr(self.ID, addr, 1),
 		db:                NewDatabase(log),
-		writer:            make(chan *send, 1),
+		writer:            make(chan *send, 2),
 		addPending:        make(chan *pending, 1),
 		log:               log,
 		timeoutNodesCount: cmap.New(),
@@ -47,13 +53,12 @@ func newTestUDP() *udp {
 }
 
 func Test_UDP_NewUDP(t *testing.T) {
-	a := "0xd0c549b022f5a17a8f50a4a448d20ba579d01781"
-	id := common.HexMustToAddres(a)
+	id, key, _ := crypto.GenerateKeyPair()
 	addr, _ := net.ResolveUDPAddr("udp", "127.0.0.1:9666")
 
-	udp := newUDP(id, addr, 0)
+	udp := newUDP(key, NewNodeWithAddr(*id, addr, 0))
 	assert.Equal(t, udp != nil, true)
-	assert.Equal(t, udp.self, NewNodeWithAddr(id, addr, 0))
+	assert.Equal(t, udp.self, NewNodeWithAddr(*id, addr, 0))
 	assert.Equal(t, udp.localAddr, addr)
 }
 
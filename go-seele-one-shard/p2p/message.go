@@ -85,12 +85,41 @@ func (msg *Message) UnZip() error {
 }
 
 // ProtoHandShake handshake message for two peer to exchange base information
-// TODO add public key or other information for encryption?
 type ProtoHandShake struct {
-	Caps      []Cap
+	Caps      []Cap // all versions of supported protocols, the highest common versions are negotiated
 	NodeID    common.Address
-	Params    []byte
+	Params    []byte // JSON encoded genesis info shared by all shards, or its hash since genesisHashHandshakeVersion
 	NetworkID uint64
+
+	// Ext is the extension negotiated by the handshake version, which is absent for legacy nodes.
+	Ext []HandshakeExt `rlp:"tail"`
+}
+
+// HandshakeExt is the extension of handshake message, whose fields are valid since specific versions.
+type HandshakeExt struct {
+	Version uint // handshake version of the node
+
+	// SessionKey is the ephemeral public key to derive the session keys with ECDH, which are
+	// used to encrypt and authenticate the frames after handshake since secureHandshakeVersion.
+	SessionKey []byte
+}
+
+// version returns the handshake version of the node, which is 0 for legacy nodes.
+func (hs *ProtoHandShake) version() uint {
+	if len(hs.Ext) == 0 {
+		return 0
+	}
+
+	return hs.Ext[0].Version
+}
+
+// sessionKey returns the ephemeral public key to derive the session keys, nil for legacy nodes.
+func (hs *ProtoHandShake) sessionKey() []byte {
+	if len(hs.Ext) == 0 {
+		return nil
+	}
+
+	return hs.Ext[0].SessionKey
 }
 
 type MsgReader interface {
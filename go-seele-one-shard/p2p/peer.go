@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/seeleteam/go-seele/log"
@@ -19,6 +20,13 @@ import (
 const (
 	pingInterval   = 15 * time.Second                 // ping interval for peer tcp connection. Should be 15
 	discServerQuit = "disconnect because server quit" // p2p.server need quit, all peers should quit as it can
+	discPeerBanned = "disconnect because peer is banned"
+
+	// BanScoreThreshold is the score below which a peer is disconnected and banned.
+	BanScoreThreshold = -100
+
+	// maxPeerScore caps the score of a peer, so that the long good behaviour could not cover up the misbehaviour.
+	maxPeerScore = 100
 )
 
 // Peer represents a connected remote node.
@@ -29,6 +37,7 @@ type Peer struct {
 	disconnection chan string
 	protocolMap   map[string]protocolRW // protocol cap => protocol read write wrapper
 	rw            *connection
+	score         int32 // updated atomically by the protocols according to the behaviour of peer
 
 	wg   sync.WaitGroup
 	log  *log.SeeleLog
@@ -220,6 +229,26 @@ func (p *Peer) sendCtlMsg(msgCode uint16) error {
 	return nil
 }
 
+// Score returns the current score of the peer.
+func (p *Peer) Score() int {
+	return int(atomic.LoadInt32(&p.score))
+}
+
+// addScore adds the delta to the score of the peer, and returns the new score.
+func (p *Peer) addScore(delta int) int {
+	for {
+		old := atomic.LoadInt32(&p.score)
+		score := old + int32(delta)
+		if score > maxPeerScore {
+			score = maxPeerScore
+		}
+
+		if atomic.CompareAndSwapInt32(&p.score, old, score) {
+			return int(score)
+		}
+	}
+}
+
 // Disconnect terminates the peer connection with the given reason.
 // It returns immediately and does not wait until the connection is closed.
 func (p *Peer) Disconnect(reason string) {
@@ -280,6 +309,7 @@ type PeerInfo struct {
 	} `json:"network"`
 	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
 	Shard     uint                   `json:"shard"`     // shard id of the node
+	Score     int                    `json:"score"`     // score of the peer behaviour
 }
 
 // Info returns data of the peer but not contain id and name.
@@ -306,6 +336,7 @@ func (p *Peer) Info() *PeerInfo {
 		Caps:      caps,
 		Protocols: protocols,
 		Shard:     p.getShardNumber(),
+		Score:     p.Score(),
 	}
 	info.Network.LocalAddress = p.LocalAddr().String()
 	info.Network.RemoteAddress = p.RemoteAddr().String()
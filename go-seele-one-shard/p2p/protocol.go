@@ -7,6 +7,7 @@ package p2p
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/seeleteam/go-seele/common"
 )
@@ -51,3 +52,35 @@ type Cap struct {
 func (cap Cap) String() string {
 	return fmt.Sprintf("%s/%d", cap.Name, cap.Version)
 }
+
+// matchProtocols returns the protocols of the highest common version for each protocol name
+// with the remote caps. The protocols are sorted by name, so that both sides of connection
+// allocate the same message codes for them.
+func matchProtocols(protocols []Protocol, caps []Cap) []Protocol {
+	remoteCaps := make(map[Cap]bool)
+	for _, cap := range caps {
+		remoteCaps[cap] = true
+	}
+
+	matched := make(map[string]Protocol)
+	for _, proto := range protocols {
+		if !remoteCaps[proto.cap()] {
+			continue
+		}
+
+		if p, ok := matched[proto.Name]; !ok || proto.Version > p.Version {
+			matched[proto.Name] = proto
+		}
+	}
+
+	result := make([]Protocol, 0, len(matched))
+	for _, proto := range matched {
+		result = append(result, proto)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
@@ -28,6 +28,26 @@ func Test_Protocol_String(t *testing.T) {
 	assert.Equal(t, strings.Compare(str, "udp/1"), 0)
 }
 
+func Test_matchProtocols(t *testing.T) {
+	protocols := []Protocol{
+		{Name: "udp", Version: 1, Length: 16},
+		{Name: "udp", Version: 2, Length: 16},
+		{Name: "tcp", Version: 1, Length: 16},
+		{Name: "seele", Version: 1, Length: 16},
+	}
+
+	// highest common version of each name, sorted by name
+	caps := []Cap{{"udp", 1}, {"udp", 2}, {"udp", 3}, {"tcp", 1}, {"seele", 2}}
+	matched := matchProtocols(protocols, caps)
+	assert.Equal(t, len(matched), 2)
+	assert.Equal(t, matched[0].cap(), Cap{"tcp", 1})
+	assert.Equal(t, matched[1].cap(), Cap{"udp", 2})
+
+	// no common version
+	matched = matchProtocols(protocols, []Cap{{"seele", 2}})
+	assert.Equal(t, len(matched), 0)
+}
+
 func newProtocol() *Protocol {
 	return &Protocol{
 		Name:    "udp",
@@ -243,6 +243,24 @@ func (mgr *QvicMgr) Accept() (net.Conn, error) {
 	}
 }
 
+// TCPAddr returns the local address of the TCP listener, nil if not listened.
+func (mgr *QvicMgr) TCPAddr() net.Addr {
+	if mgr.tcpListenner == nil {
+		return nil
+	}
+
+	return mgr.tcpListenner.Addr()
+}
+
+// QvicAddr returns the local address of the qvic protocol, nil if not listened.
+func (mgr *QvicMgr) QvicAddr() net.Addr {
+	if mgr.udpfd == nil {
+		return nil
+	}
+
+	return mgr.udpfd.LocalAddr()
+}
+
 // Close clean for QvicMgr object.
 func (mgr *QvicMgr) Close() {
 	mgr.log.Info("qvic Close called")
@@ -0,0 +1,171 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package p2p
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/crypto/ecies"
+)
+
+const (
+	// rekeyFrames is the number of frames encrypted with a session key before it's renewed.
+	rekeyFrames = 1 << 20
+
+	// sessionSharedLength is the length of the ECDH shared secret to derive session keys.
+	sessionSharedLength = 32
+
+	// frameCodeLength is the length of the message code encrypted in the frame body.
+	frameCodeLength = 2
+)
+
+var (
+	errInvalidSessionKey = errors.New("invalid ephemeral session public key")
+	errFrameAuth         = errors.New("Failed to decrypt frame, message authentication failed")
+	errFrameFormat       = errors.New("Failed to decrypt frame, invalid frame format")
+
+	labelInitiator = []byte("initiator")
+	labelResponder = []byte("responder")
+	labelRekey     = []byte("rekey")
+)
+
+// frameCipher encrypts and authenticates the frames in one direction of a connection with
+// AES-GCM. The nonce of each frame is the frame counter, so that a replayed, reordered or
+// dropped frame fails the authentication. The key is renewed every rekeyFrames frames.
+type frameCipher struct {
+	key     []byte
+	aead    cipher.AEAD
+	counter uint64
+}
+
+func newFrameCipher(key []byte) (*frameCipher, error) {
+	fc := &frameCipher{}
+	if err := fc.setKey(key); err != nil {
+		return nil, err
+	}
+
+	return fc, nil
+}
+
+func (fc *frameCipher) setKey(key []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	fc.key, fc.aead, fc.counter = key, aead, 0
+	return nil
+}
+
+func (fc *frameCipher) nonce() []byte {
+	nonce := make([]byte, fc.aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], fc.counter)
+	return nonce
+}
+
+// next moves to the next frame, and renews the key if needed.
+func (fc *frameCipher) next() error {
+	fc.counter++
+	if fc.counter < rekeyFrames {
+		return nil
+	}
+
+	return fc.setKey(crypto.HashBytes(fc.key, labelRekey).Bytes())
+}
+
+// seal encrypts the plaintext of a frame, and the header is authenticated but not encrypted.
+func (fc *frameCipher) seal(header, plaintext []byte) ([]byte, error) {
+	ciphertext := fc.aead.Seal(nil, fc.nonce(), plaintext, header)
+	return ciphertext, fc.next()
+}
+
+// open decrypts and authenticates the ciphertext of a frame.
+func (fc *frameCipher) open(header, ciphertext []byte) ([]byte, error) {
+	plaintext, err := fc.aead.Open(nil, fc.nonce(), ciphertext, header)
+	if err != nil {
+		return nil, errFrameAuth
+	}
+
+	return plaintext, fc.next()
+}
+
+// overhead returns the size of the message code and authentication tag in each frame.
+func (fc *frameCipher) overhead() int {
+	return frameCodeLength + fc.aead.Overhead()
+}
+
+// sealFrame encrypts the message code and payload, and returns the frame header and body.
+func (fc *frameCipher) sealFrame(code uint16, payload []byte) ([]byte, []byte, error) {
+	header := make([]byte, headBuffLength)
+	binary.BigEndian.PutUint32(header[headBuffSizeStart:headBuffSizeEnd], uint32(len(payload)+fc.overhead()))
+	binary.BigEndian.PutUint16(header[headBuffMagicStart:headBuffMagicEnd], magicNumber)
+
+	plaintext := make([]byte, frameCodeLength+len(payload))
+	binary.BigEndian.PutUint16(plaintext, code)
+	copy(plaintext[frameCodeLength:], payload)
+
+	body, err := fc.seal(header, plaintext)
+	return header, body, err
+}
+
+// openFrame decrypts the frame body, and returns the message code and payload.
+func (fc *frameCipher) openFrame(header, body []byte) (uint16, []byte, error) {
+	plaintext, err := fc.open(header, body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(plaintext) < frameCodeLength {
+		return 0, nil, errFrameFormat
+	}
+
+	return binary.BigEndian.Uint16(plaintext), plaintext[frameCodeLength:], nil
+}
+
+// newSessionCiphers derives the session keys with ECDH from the local ephemeral private key and
+// the remote ephemeral public key exchanged in the handshake, and returns the frame ciphers for
+// egress and ingress directions. Since the handshake is signed by the node key, the session is
+// authenticated to the remote node.
+func newSessionCiphers(ephemeralKey *ecdsa.PrivateKey, remotePubKey []byte, nounceCnt uint64, initiator bool) (egress *frameCipher, ingress *frameCipher, err error) {
+	pub := crypto.ToECDSAPub(remotePubKey)
+	if pub == nil || pub.X == nil || pub.Y == nil {
+		return nil, nil, errInvalidSessionKey
+	}
+
+	shared, err := ecies.ImportECDSA(ephemeralKey).GenerateShared(ecies.ImportECDSAPublic(pub), sessionSharedLength, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nounce := make([]byte, 8)
+	binary.BigEndian.PutUint64(nounce, nounceCnt)
+
+	egressKey := crypto.HashBytes(shared, nounce, labelInitiator).Bytes()
+	ingressKey := crypto.HashBytes(shared, nounce, labelResponder).Bytes()
+	if !initiator {
+		egressKey, ingressKey = ingressKey, egressKey
+	}
+
+	if egress, err = newFrameCipher(egressKey); err != nil {
+		return nil, nil, err
+	}
+
+	if ingress, err = newFrameCipher(ingressKey); err != nil {
+		return nil, nil, err
+	}
+
+	return egress, ingress, nil
+}
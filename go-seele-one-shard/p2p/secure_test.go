@@ -0,0 +1,442 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package p2p
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core"
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/p2p/discovery"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSessionCiphers(t *testing.T) (*frameCipher, *frameCipher, *frameCipher, *frameCipher) {
+	initiatorKey, _ := crypto.GenerateKey()
+	responderKey, _ := crypto.GenerateKey()
+
+	egress1, ingress1, err := newSessionCiphers(initiatorKey, crypto.FromECDSAPub(&responderKey.PublicKey), 100, true)
+	assert.Equal(t, err, nil)
+
+	egress2, ingress2, err := newSessionCiphers(responderKey, crypto.FromECDSAPub(&initiatorKey.PublicKey), 100, false)
+	assert.Equal(t, err, nil)
+
+	return egress1, ingress1, egress2, ingress2
+}
+
+func Test_newSessionCiphers(t *testing.T) {
+	egress1, ingress1, egress2, ingress2 := newTestSessionCiphers(t)
+
+	assert.Equal(t, egress1.key, ingress2.key)
+	assert.Equal(t, ingress1.key, egress2.key)
+	assert.Equal(t, egress1.key == nil, false)
+	assert.Equal(t, string(egress1.key) == string(ingress1.key), false)
+
+	key, _ := crypto.GenerateKey()
+	_, _, err := newSessionCiphers(key, nil, 100, true)
+	assert.Equal(t, err, errInvalidSessionKey)
+
+	_, _, err = newSessionCiphers(key, []byte{1, 2, 3}, 100, true)
+	assert.Equal(t, err, errInvalidSessionKey)
+}
+
+func Test_frameCipher(t *testing.T) {
+	egress, _, _, ingress := newTestSessionCiphers(t)
+
+	header, body, err := egress.sealFrame(ctlMsgPingCode, []byte("hello"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(body), 5+egress.overhead())
+
+	code, payload, err := ingress.openFrame(header, body)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, code, ctlMsgPingCode)
+	assert.Equal(t, payload, []byte("hello"))
+
+	// replayed frame
+	_, _, err = ingress.openFrame(header, body)
+	assert.Equal(t, err, errFrameAuth)
+
+	// tampered frame
+	header, body, err = egress.sealFrame(ctlMsgPingCode, []byte("hello"))
+	assert.Equal(t, err, nil)
+	body[0] ^= 1
+
+	_, _, err = ingress.openFrame(header, body)
+	assert.Equal(t, err, errFrameAuth)
+}
+
+func Test_frameCipher_Rekey(t *testing.T) {
+	egress, _, _, ingress := newTestSessionCiphers(t)
+	key := egress.key
+
+	egress.counter = rekeyFrames - 1
+	ingress.counter = rekeyFrames - 1
+
+	for i := 0; i < 2; i++ {
+		header, body, err := egress.sealFrame(ctlMsgPingCode, []byte("hello"))
+		assert.Equal(t, err, nil)
+
+		_, payload, err := ingress.openFrame(header, body)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, payload, []byte("hello"))
+	}
+
+	assert.Equal(t, egress.counter, uint64(1))
+	assert.Equal(t, egress.key == nil, false)
+	assert.Equal(t, egress.key, ingress.key)
+	assert.Equal(t, string(egress.key) == string(key), false)
+}
+
+func Test_connection_Secure(t *testing.T) {
+	con, ln, err := newConnection()
+	defer ln.Close()
+	defer con.close()
+	assert.Equal(t, err, nil)
+
+	fd1, err := ln.Accept()
+	assert.Equal(t, err, nil)
+	con1 := &connection{fd: fd1}
+
+	egress1, ingress1, egress2, ingress2 := newTestSessionCiphers(t)
+	con.secure(egress1, ingress1)
+	con1.secure(egress2, ingress2)
+
+	for _, str := range []string{"", getRandomString(10), getRandomString(zipBytesLimit * 2)} {
+		msg := newMessage(str)
+		assert.Equal(t, con.WriteMsg(msg), nil)
+
+		recv, err := con1.ReadMsg()
+		assert.Equal(t, err, nil)
+		assert.Equal(t, recv.Code, ctlMsgPingCode)
+		assert.Equal(t, string(recv.Payload), str)
+	}
+
+	// cleartext frame is not accepted
+	plain := &connection{fd: con.fd}
+	assert.Equal(t, plain.WriteMsg(newMessage("hello world!")), nil)
+
+	_, err = con1.ReadMsg()
+	assert.Equal(t, err, errFrameAuth)
+}
+
+func newTestHandshakeServer() *Server {
+	var genesis core.GenesisInfo
+	server := NewServer(genesis, *testConfig(), testProtocol())
+	server.SelfNode = discovery.NewNode(*crypto.GetAddress(&server.PrivateKey.PublicKey), nil, 0, 1)
+
+	return server
+}
+
+func Test_doHandShake_Secure(t *testing.T) {
+	client, server := newTestHandshakeServer(), newTestHandshakeServer()
+	var caps []Cap
+	for _, proto := range client.Protocols {
+		caps = append(caps, proto.cap())
+	}
+
+	fd1, fd2 := net.Pipe()
+	clientPeer := NewPeer(&connection{fd: fd1}, client.Protocols, client.log, server.SelfNode)
+	serverPeer := NewPeer(&connection{fd: fd2}, server.Protocols, server.log, nil)
+	defer clientPeer.close()
+	defer serverPeer.close()
+
+	errCh := make(chan error)
+	go func() {
+		_, _, err := server.doHandShake(caps, serverPeer, inboundConn, nil)
+		errCh <- err
+	}()
+
+	recvMsg, _, err := client.doHandShake(caps, clientPeer, outboundConn, server.SelfNode)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, <-errCh, nil)
+	assert.Equal(t, recvMsg.NodeID, server.SelfNode.ID)
+
+	go clientPeer.rw.WriteMsg(newMessage("hello world!"))
+	recv, err := serverPeer.rw.ReadMsg()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(recv.Payload), "hello world!")
+
+	go serverPeer.rw.WriteMsg(newMessage("hello peer!!"))
+	recv, err = clientPeer.rw.ReadMsg()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(recv.Payload), "hello peer!!")
+}
+
+func Test_doHandShake_UnexpectedNode(t *testing.T) {
+	client, server := newTestHandshakeServer(), newTestHandshakeServer()
+	var caps []Cap
+	for _, proto := range client.Protocols {
+		caps = append(caps, proto.cap())
+	}
+
+	// dial a node but another one responds
+	dialDest := newTestHandshakeServer().SelfNode
+
+	fd1, fd2 := net.Pipe()
+	clientPeer := NewPeer(&connection{fd: fd1}, client.Protocols, client.log, dialDest)
+	serverPeer := NewPeer(&connection{fd: fd2}, server.Protocols, server.log, nil)
+	defer clientPeer.close()
+	defer serverPeer.close()
+
+	// server fails to verify the msg sent to another node, and closes the connection
+	go func() {
+		if _, _, err := server.doHandShake(caps, serverPeer, inboundConn, nil); err != nil {
+			fd2.Close()
+		}
+	}()
+
+	_, _, err := client.doHandShake(caps, clientPeer, outboundConn, dialDest)
+	assert.Equal(t, err != nil, true)
+}
+
+// respondTestHandShake responds the handshake of client with the specified handshake msg.
+func respondTestHandShake(t *testing.T, server *Server, peer *Peer, handshakeMsg *ProtoHandShake) {
+	recvWrapMsg, err := peer.rw.ReadMsg()
+	assert.Equal(t, err, nil)
+
+	recvMsg, nounceCnt, err := server.unPackWrapHSMsg(recvWrapMsg)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, recvMsg.version(), uint(handshakeVersion))
+
+	handshakeMsg.NodeID = server.SelfNode.ID
+	wrapMsg, err := server.packWrapHSMsg(handshakeMsg, recvMsg.NodeID[0:], nounceCnt)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, peer.rw.WriteMsg(wrapMsg), nil)
+}
+
+// legacyHandShake is the handshake msg of legacy nodes, which has no extension.
+type legacyHandShake struct {
+	Caps      []Cap
+	NodeID    common.Address
+	Params    []byte
+	NetworkID uint64
+}
+
+// packLegacyHSMsg packs the handshake msg as legacy nodes do, which signs the md5sum
+// of handshake msg and the nounce only.
+func packLegacyHSMsg(privKey *ecdsa.PrivateKey, handshakeMsg *legacyHandShake, nounceCnt uint64) *Message {
+	hdmsgRLP := common.SerializePanic(handshakeMsg)
+	md5Sum := md5.Sum(hdmsgRLP)
+
+	extBuf := make([]byte, legacyExtraDataLen)
+	copy(extBuf, md5Sum[:])
+	binary.BigEndian.PutUint64(extBuf[16:], nounceCnt)
+
+	signature := crypto.MustSign(privKey, crypto.MustHash(extBuf).Bytes())
+	enc := append(extBuf, signature.Sig...)
+
+	payload := append(hdmsgRLP, enc...)
+	payload = append(payload, make([]byte, 4)...)
+	binary.BigEndian.PutUint32(payload[len(payload)-4:], uint32(len(enc)))
+
+	return &Message{Code: ctlMsgProtoHandshake, Payload: payload}
+}
+
+// unpackLegacyHSMsg unpacks the handshake msg as legacy nodes do.
+func unpackLegacyHSMsg(recvWrapMsg *Message) (*legacyHandShake, uint64, error) {
+	size := uint32(len(recvWrapMsg.Payload))
+	extraEncLen := binary.BigEndian.Uint32(recvWrapMsg.Payload[size-4:])
+	recvHSMsgLen := size - extraEncLen - 4
+	nounceCnt := binary.BigEndian.Uint64(recvWrapMsg.Payload[recvHSMsgLen+16:])
+	recvEnc := recvWrapMsg.Payload[recvHSMsgLen : size-4]
+
+	recvMsg := &legacyHandShake{}
+	if err := common.Deserialize(recvWrapMsg.Payload[:recvHSMsgLen], recvMsg); err != nil {
+		return nil, 0, err
+	}
+
+	sig := crypto.Signature{Sig: recvEnc[legacyExtraDataLen:]}
+	if !sig.Verify(recvMsg.NodeID, crypto.MustHash(recvEnc[:legacyExtraDataLen]).Bytes()) {
+		return nil, 0, errors.New("received public key not match")
+	}
+
+	md5Sum := md5.Sum(recvWrapMsg.Payload[:recvHSMsgLen])
+	if !bytes.Equal(md5Sum[:], recvEnc[:16]) {
+		return nil, 0, errors.New("received md5sum not match")
+	}
+
+	return recvMsg, nounceCnt, nil
+}
+
+// newTestLegacyHSMsg returns the handshake msg of the specified server as legacy nodes do.
+func newTestLegacyHSMsg(server *Server, caps []Cap) *legacyHandShake {
+	params, _ := json.Marshal(server.genesis)
+
+	return &legacyHandShake{
+		Caps:      caps,
+		NodeID:    server.SelfNode.ID,
+		Params:    params,
+		NetworkID: server.Config.NetworkID,
+	}
+}
+
+// respondLegacyHandShake responds the handshake as legacy nodes do, and closes the connection
+// if failed to decode the handshake msg.
+func respondLegacyHandShake(server *Server, peer *Peer, caps []Cap) error {
+	recvWrapMsg, err := peer.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+
+	_, nounceCnt, err := unpackLegacyHSMsg(recvWrapMsg)
+	if err != nil {
+		peer.close()
+		return err
+	}
+
+	return peer.rw.WriteMsg(packLegacyHSMsg(server.PrivateKey, newTestLegacyHSMsg(server, caps), nounceCnt))
+}
+
+func Test_doHandShake_LegacyServer(t *testing.T) {
+	client, server := newTestHandshakeServer(), newTestHandshakeServer()
+	var caps []Cap
+	for _, proto := range client.Protocols {
+		caps = append(caps, proto.cap())
+	}
+
+	// legacy node could not decode the handshake extension, and closes the connection
+	fd1, fd2 := net.Pipe()
+	clientPeer := NewPeer(&connection{fd: fd1}, client.Protocols, client.log, server.SelfNode)
+	serverPeer := NewPeer(&connection{fd: fd2}, server.Protocols, server.log, nil)
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- respondLegacyHandShake(server, serverPeer, caps)
+	}()
+
+	_, _, err := client.doHandShake(caps, clientPeer, outboundConn, server.SelfNode)
+	assert.Equal(t, err, errHandshakeDowngraded)
+	assert.Equal(t, <-errCh != nil, true)
+	assert.Equal(t, client.legacyNodes.Contains(server.SelfNode.ID), true)
+	clientPeer.close()
+
+	// redial with the legacy handshake
+	fd1, fd2 = net.Pipe()
+	clientPeer = NewPeer(&connection{fd: fd1}, client.Protocols, client.log, server.SelfNode)
+	serverPeer = NewPeer(&connection{fd: fd2}, server.Protocols, server.log, nil)
+	defer clientPeer.close()
+	defer serverPeer.close()
+
+	go func() {
+		errCh <- respondLegacyHandShake(server, serverPeer, caps)
+	}()
+
+	recvMsg, _, err := client.doHandShake(caps, clientPeer, outboundConn, server.SelfNode)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, <-errCh, nil)
+	assert.Equal(t, recvMsg.version(), uint(0))
+
+	// frames are not encrypted
+	go clientPeer.rw.WriteMsg(newMessage("hello world!"))
+	plain := &connection{fd: fd2}
+	recv, err := plain.ReadMsg()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(recv.Payload), "hello world!")
+}
+
+func Test_doHandShake_LegacyClient(t *testing.T) {
+	client, server := newTestHandshakeServer(), newTestHandshakeServer()
+	var caps []Cap
+	for _, proto := range client.Protocols {
+		caps = append(caps, proto.cap())
+	}
+
+	fd1, fd2 := net.Pipe()
+	clientConn := &connection{fd: fd1}
+	serverPeer := NewPeer(&connection{fd: fd2}, server.Protocols, server.log, nil)
+	defer clientConn.close()
+	defer serverPeer.close()
+
+	errCh := make(chan error)
+	go func() {
+		_, _, err := server.doHandShake(caps, serverPeer, inboundConn, nil)
+		errCh <- err
+	}()
+
+	// legacy node sends the handshake msg first
+	assert.Equal(t, clientConn.WriteMsg(packLegacyHSMsg(client.PrivateKey, newTestLegacyHSMsg(client, caps), 100)), nil)
+
+	// legacy node could decode the response
+	recvWrapMsg, err := clientConn.ReadMsg()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, <-errCh, nil)
+
+	recvMsg, nounceCnt, err := unpackLegacyHSMsg(recvWrapMsg)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, nounceCnt, uint64(100))
+	assert.Equal(t, recvMsg.NodeID, server.SelfNode.ID)
+	assert.Equal(t, server.isGenesisMatched(recvMsg.Params), true)
+	assert.Equal(t, len(recvMsg.Params) == common.HashLength, false)
+
+	// frames are not encrypted
+	go clientConn.WriteMsg(newMessage("hello world!"))
+	recv, err := serverPeer.rw.ReadMsg()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(recv.Payload), "hello world!")
+}
+
+func Test_doHandShake_EmptySessionKey(t *testing.T) {
+	client, server := newTestHandshakeServer(), newTestHandshakeServer()
+	var caps []Cap
+	for _, proto := range client.Protocols {
+		caps = append(caps, proto.cap())
+	}
+
+	fd1, fd2 := net.Pipe()
+	clientPeer := NewPeer(&connection{fd: fd1}, client.Protocols, client.log, server.SelfNode)
+	serverPeer := NewPeer(&connection{fd: fd2}, server.Protocols, server.log, nil)
+	defer clientPeer.close()
+	defer serverPeer.close()
+
+	// session key is required since the secure handshake version
+	go respondTestHandShake(t, server, serverPeer, &ProtoHandShake{
+		Caps:      caps,
+		Params:    server.genesisHash.Bytes(),
+		NetworkID: server.Config.NetworkID,
+		Ext:       []HandshakeExt{{Version: secureHandshakeVersion}},
+	})
+
+	_, _, err := client.doHandShake(caps, clientPeer, outboundConn, server.SelfNode)
+	assert.Equal(t, err, errInvalidSessionKey)
+}
+
+func Test_packWrapHSMsg_Signature(t *testing.T) {
+	client, server := newTestHandshakeServer(), newTestHandshakeServer()
+
+	handshakeMsg := &ProtoHandShake{
+		NodeID: client.SelfNode.ID,
+		Params: client.genesisHash.Bytes(),
+		Ext:    []HandshakeExt{{Version: handshakeVersion}},
+	}
+
+	wrapMsg, err := client.packWrapHSMsg(handshakeMsg, server.SelfNode.ID[0:], 100)
+	assert.Equal(t, err, nil)
+
+	recvMsg, nounceCnt, err := server.unPackWrapHSMsg(wrapMsg)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, nounceCnt, uint64(100))
+	assert.Equal(t, recvMsg.version(), uint(handshakeVersion))
+
+	// msg sent to another node
+	_, _, err = newTestHandshakeServer().unPackWrapHSMsg(wrapMsg)
+	assert.Equal(t, err != nil, true)
+
+	// tampered handshake msg with the original signature
+	hdmsgRLP := common.SerializePanic(handshakeMsg)
+	handshakeMsg.NetworkID++
+	tampered := append(common.SerializePanic(handshakeMsg), wrapMsg.Payload[len(hdmsgRLP):]...)
+
+	_, _, err = server.unPackWrapHSMsg(&Message{Code: ctlMsgProtoHandshake, Payload: tampered})
+	assert.Equal(t, err.Error(), "unPackWrapHSMsg: received public key not match")
+}
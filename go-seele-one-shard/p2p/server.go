@@ -13,12 +13,12 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net"
 	"sort"
 	"sync"
 	"time"
 
+	"github.com/hashicorp/golang-lru"
 	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/core"
 	"github.com/seeleteam/go-seele/crypto"
@@ -45,7 +45,30 @@ const (
 	outboundConn = 2
 
 	// In transfering handshake msg, length of extra data
-	extraDataLen = 24
+	extraDataLen = 8
+
+	// In transfering handshake msg without extension, length of the legacy extra data
+	legacyExtraDataLen = 24
+
+	// Maximum number of nodes remembered to support the legacy handshake only
+	maxLegacyNodes = 1000
+
+	// handshakeVersion is the handshake version of the local node, which is advertised in the
+	// handshake extension, so that the features are only enabled when supported by both sides.
+	handshakeVersion = 2
+
+	// secureHandshakeVersion is the handshake version since which the frames are encrypted.
+	secureHandshakeVersion = 1
+
+	// genesisHashHandshakeVersion is the handshake version since which the hash of genesis info,
+	// instead of the JSON encoded genesis info, is accepted as the handshake params.
+	genesisHashHandshakeVersion = 2
+)
+
+var (
+	errPeerBanned = errors.New("peer is banned")
+
+	errHandshakeDowngraded = errors.New("peer closed the connection on the handshake extension")
 )
 
 // Config is the Configuration of p2p
@@ -64,6 +87,12 @@ type Config struct {
 
 	// PrivateKey private key for p2p module, do not use it as any accounts
 	PrivateKey *ecdsa.PrivateKey
+
+	// Transport is the transport of p2p connections, which is "tcp", "qvic" or "both". Default is "tcp".
+	Transport string `json:"transport"`
+
+	// QvicListenAddr is the udp address that qvic transport listens on, which must be different from the discovery address.
+	QvicListenAddr string `json:"qvicAddress"`
 }
 
 // Server manages all p2p peer connections.
@@ -74,8 +103,8 @@ type Server struct {
 	lock    sync.Mutex // protects running
 	running bool
 
-	kadDB    *discovery.Database
-	listener net.Listener
+	kadDB     *discovery.Database
+	transport transport
 
 	quit chan struct{}
 
@@ -83,8 +112,13 @@ type Server struct {
 
 	peerSet  *peerSet
 	peerLock sync.Mutex // lock for peer set
+	banList  *banList   // nodes that are not allowed to connect
 	log      *log.SeeleLog
 
+	// legacyNodes are the nodes that closed the connection on the handshake extension, which
+	// are dialed with the legacy handshake, since legacy nodes could not decode the extension.
+	legacyNodes *lru.Cache
+
 	// MaxPeers max number of peers that can be connected
 	MaxPeers int
 
@@ -98,21 +132,26 @@ type Server struct {
 
 	SelfNode *discovery.Node
 
-	genesis core.GenesisInfo
+	genesis     core.GenesisInfo
+	genesisHash common.Hash // hash of genesis info shared by all shards, to verify peers in the same network
 }
 
 // NewServer initialize a server
 func NewServer(genesis core.GenesisInfo, config Config, protocols []Protocol) *Server {
+	logger := log.GetLogger("p2p")
 	return &Server{
 		Config:          config,
 		running:         false,
-		log:             log.GetLogger("p2p"),
+		log:             logger,
 		MaxPeers:        defaultMaxPeers,
 		quit:            make(chan struct{}),
 		peerSet:         NewPeerSet(),
+		banList:         newBanList(logger),
+		legacyNodes:     common.MustNewCache(maxLegacyNodes),
 		MaxPendingPeers: 0,
 		Protocols:       protocols,
 		genesis:         genesis,
+		genesisHash:     genesis.Hash(),
 	}
 }
 
@@ -136,17 +175,27 @@ func (srv *Server) Start(nodeDir string, shard uint) (err error) {
 		return err
 	}
 
+	transports, qvicPort, err := parseTransports(&srv.Config)
+	if err != nil {
+		return err
+	}
+
+	// listen before discovery, so that the discovered nodes could be dialed with the transport.
+	if srv.transport, err = newTransport(&srv.Config, transports); err != nil {
+		return err
+	}
+
 	srv.log.Debug("Starting P2P networking...")
 	srv.SelfNode = discovery.NewNodeWithAddr(*address, addr, shard)
+	srv.SelfNode.Transports, srv.SelfNode.QvicPort = transports, qvicPort
 
 	srv.log.Info("p2p.Server.Start: MyNodeID [%s]", srv.SelfNode)
-	srv.kadDB = discovery.StartService(nodeDir, *address, addr, srv.StaticNodes, shard)
+	srv.banList.load(nodeDir)
+	srv.kadDB = discovery.StartService(nodeDir, srv.PrivateKey, srv.SelfNode, srv.StaticNodes)
 	srv.kadDB.SetHookForNewNode(srv.addNode)
 	srv.kadDB.SetHookForDeleteNode(srv.deleteNode)
 
-	if err := srv.startListening(); err != nil {
-		return err
-	}
+	srv.startListening()
 
 	srv.loopWG.Add(1)
 	go srv.run()
@@ -164,14 +213,17 @@ func (srv *Server) addNode(node *discovery.Node) {
 		return
 	}
 
-	//TODO UDPPort==> TCPPort
-	addr, err := net.ResolveTCPAddr("tcp4", fmt.Sprintf("%s:%d", node.IP.String(), node.UDPPort))
-	if err != nil {
-		srv.log.Error("failed to resolve tpc address %s", err)
+	if srv.banList.isBanned(node.ID, node.IP) {
+		srv.log.Debug("skip to connect to banned node %s", node)
+		return
+	}
+
+	if srv.transport == nil {
+		srv.log.Warn("failed to connect to node %s, server is not started", node)
 		return
 	}
 
-	conn, err := net.DialTimeout("tcp", addr.String(), defaultDialTimeout)
+	conn, err := srv.transport.dial(node, defaultDialTimeout)
 	if err != nil {
 		srv.log.Error("connect to a new node err: %s, node: %s", err, node)
 		if conn != nil {
@@ -182,7 +234,18 @@ func (srv *Server) addNode(node *discovery.Node) {
 	}
 
 	srv.log.Info("connect to a node with %s -> %s", conn.LocalAddr(), conn.RemoteAddr())
-	if err := srv.setupConn(conn, outboundConn, node); err != nil {
+	err = srv.setupConn(conn, outboundConn, node)
+
+	// redial the node with the legacy handshake
+	if err == errHandshakeDowngraded {
+		if conn, err = srv.transport.dial(node, defaultDialTimeout); err == nil {
+			err = srv.setupConn(conn, outboundConn, node)
+		} else if conn != nil {
+			conn.Close()
+		}
+	}
+
+	if err != nil {
 		srv.log.Info("failed to add new node. err=%s", err)
 	}
 }
@@ -260,17 +323,10 @@ running:
 	})
 }
 
-func (srv *Server) startListening() error {
-	// Launch the TCP listener.
-	listener, err := net.Listen("tcp", srv.Config.ListenAddr)
-	if err != nil {
-		return err
-	}
-
-	srv.listener = listener
+func (srv *Server) startListening() {
+	// Launch the accept loop of transport.
 	srv.loopWG.Add(1)
 	go srv.listenLoop()
-	return nil
 }
 
 type tempError interface {
@@ -303,7 +359,7 @@ func (srv *Server) listenLoop() {
 			err error
 		)
 		for {
-			fd, err = srv.listener.Accept()
+			fd, err = srv.transport.accept()
 			if tempErr, ok := err.(tempError); ok && tempErr.Temporary() {
 				continue
 			} else if err != nil {
@@ -328,7 +384,7 @@ func (srv *Server) listenLoop() {
 // Assume the inbound side is server side; outbound side is client side.
 func (srv *Server) setupConn(fd net.Conn, flags int, dialDest *discovery.Node) error {
 	srv.log.Info("setup connection with peer %s", dialDest)
-	peer := NewPeer(&connection{fd: fd}, srv.Protocols, srv.log, dialDest)
+	peer := NewPeer(&connection{fd: fd}, nil, srv.log, dialDest)
 	var caps []Cap
 	for _, proto := range srv.Protocols {
 		caps = append(caps, proto.cap())
@@ -341,6 +397,15 @@ func (srv *Server) setupConn(fd net.Conn, flags int, dialDest *discovery.Node) e
 		return err
 	}
 
+	if srv.banList.isBanned(recvMsg.NodeID, addrIP(fd.RemoteAddr())) {
+		srv.log.Info("reject connection of banned node %s", recvMsg.NodeID.ToHex())
+		peer.close()
+		return errPeerBanned
+	}
+
+	// run the highest common version of protocols with the peer
+	peer = NewPeer(peer.rw, matchProtocols(srv.Protocols, recvMsg.Caps), srv.log, dialDest)
+
 	srv.log.Debug("handshake succeed. %s -> %s", fd.LocalAddr(), fd.RemoteAddr())
 	peerNodeID := recvMsg.NodeID
 	if flags == inboundConn {
@@ -370,68 +435,64 @@ func (srv *Server) setupConn(fd net.Conn, flags int, dialDest *discovery.Node) e
 	return nil
 }
 
+// peerIsValidate checks the peer is in the same network, and supports a common version
+// of any protocol at least.
 func (srv *Server) peerIsValidate(recvMsg *ProtoHandShake) bool {
-	var genesis core.GenesisInfo
-	err := json.Unmarshal(recvMsg.Params, &genesis)
-	if err != nil {
+	if !srv.isGenesisMatched(recvMsg.Params) {
 		return false
 	}
 
-	for key, val := range genesis.Accounts {
-		v, ok := srv.genesis.Accounts[key]
-		if !ok {
-			return false
-		}
-		if val.Cmp(v) != 0 {
-			return false
-		}
-	}
-
 	if srv.Config.NetworkID != recvMsg.NetworkID {
 		return false
 	}
 
-	var caps []Cap
-	for _, proto := range srv.Protocols {
-		caps = append(caps, proto.cap())
+	return len(matchProtocols(srv.Protocols, recvMsg.Caps)) > 0
+}
+
+// isGenesisMatched checks the genesis info of peer in the handshake params, which is the hash
+// of genesis info, or the JSON encoded genesis info if the receiver is unknown or a legacy node.
+func (srv *Server) isGenesisMatched(params []byte) bool {
+	if len(params) == common.HashLength {
+		return bytes.Equal(params, srv.genesisHash.Bytes())
 	}
-	if len(caps) != len(recvMsg.Caps) {
+
+	var genesis core.GenesisInfo
+	if err := json.Unmarshal(params, &genesis); err != nil {
 		return false
 	}
 
-	var str string
-	var tag = true
-	len := len(caps)
-	for i := 0; i < len; i++ {
-		str = caps[i].String()
-		for j := 0; j < len; j++ {
-			if recvMsg.Caps[j].String() != str {
-				tag = false
-				continue
-			}
-			tag = true
-			break
-		}
-		if !tag {
-			break
-		}
-	}
-	return tag
+	hash := genesis.Hash()
+	return hash.Equal(srv.genesisHash)
 }
 
 // doHandShake Communicate each other
 func (srv *Server) doHandShake(caps []Cap, peer *Peer, flags int, dialDest *discovery.Node) (recvMsg *ProtoHandShake, nounceCnt uint64, err error) {
 	var renounceCnt uint64
+	ephemeralKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, 0, err
+	}
+
 	handshakeMsg := &ProtoHandShake{Caps: caps}
 	handshakeMsg.NetworkID = srv.Config.NetworkID
-	params, err := json.Marshal(srv.genesis)
-	if err != nil {
+	handshakeMsg.Ext = []HandshakeExt{{
+		Version:    handshakeVersion,
+		SessionKey: crypto.FromECDSAPub(&ephemeralKey.PublicKey),
+	}}
+
+	// the version of peer is unknown until its handshake msg is received
+	if handshakeMsg.Params, err = json.Marshal(srv.genesis); err != nil {
 		return nil, 0, err
 	}
-	handshakeMsg.Params = params
+
 	nodeID := srv.SelfNode.ID
 	copy(handshakeMsg.NodeID[0:], nodeID[0:])
 	if flags == outboundConn {
+		// legacy nodes could not decode the handshake extension
+		if srv.legacyNodes.Contains(dialDest.ID) {
+			handshakeMsg.Ext = nil
+		}
+
 		// client side. Send msg first
 		binary.Read(rand.Reader, binary.BigEndian, &nounceCnt)
 		wrapMsg, err := srv.packWrapHSMsg(handshakeMsg, dialDest.ID[0:], nounceCnt)
@@ -444,6 +505,12 @@ func (srv *Server) doHandShake(caps []Cap, peer *Peer, flags int, dialDest *disc
 		}
 
 		recvWrapMsg, err := peer.rw.ReadMsg()
+		if err != nil && handshakeMsg.version() > 0 {
+			srv.log.Info("node %s closed the connection on the handshake extension, %s", dialDest.ID.ToHex(), err)
+			srv.legacyNodes.Add(dialDest.ID, nil)
+			return nil, 0, errHandshakeDowngraded
+		}
+
 		if err != nil {
 			return nil, 0, err
 		}
@@ -457,6 +524,10 @@ func (srv *Server) doHandShake(caps []Cap, peer *Peer, flags int, dialDest *disc
 			return nil, 0, errors.New("client nounceCnt is changed")
 		}
 
+		if !recvMsg.NodeID.Equal(dialDest.ID) {
+			return nil, 0, errors.New("node id is not the dialed one")
+		}
+
 		if !srv.peerIsValidate(recvMsg) {
 			return nil, 0, errors.New("node is not consitent with groups")
 		}
@@ -476,6 +547,15 @@ func (srv *Server) doHandShake(caps []Cap, peer *Peer, flags int, dialDest *disc
 			return nil, 0, errors.New("node is not consitent with groups")
 		}
 
+		// legacy nodes could not decode the handshake extension
+		if recvMsg.version() == 0 {
+			handshakeMsg.Ext = nil
+		}
+
+		if recvMsg.version() >= genesisHashHandshakeVersion {
+			handshakeMsg.Params = srv.genesisHash.Bytes()
+		}
+
 		wrapMsg, err := srv.packWrapHSMsg(handshakeMsg, recvMsg.NodeID[0:], nounceCnt)
 		if err != nil {
 			return nil, 0, err
@@ -485,11 +565,26 @@ func (srv *Server) doHandShake(caps []Cap, peer *Peer, flags int, dialDest *disc
 			return nil, 0, err
 		}
 	}
+
+	// encrypt and authenticate the following frames with session keys if supported by both sides.
+	if recvMsg.version() < secureHandshakeVersion {
+		srv.log.Warn("frames with node %s are not encrypted, handshake version %d", recvMsg.NodeID.ToHex(), recvMsg.version())
+		return
+	}
+
+	egress, ingress, err := newSessionCiphers(ephemeralKey, recvMsg.sessionKey(), nounceCnt, flags == outboundConn)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	peer.rw.secure(egress, ingress)
 	return
 }
 
 // packWrapHSMsg compose the wrapped send msg.
-// A 32 byte ExtraData is used for verification process.
+// For the handshake msg with extension, a 8 byte ExtraData of nounce is signed along with the handshake
+// msg and the peer node id. Otherwise, the legacy 24 byte ExtraData of the md5sum of handshake msg and
+// nounce is signed, so that the legacy nodes could verify it.
 func (srv *Server) packWrapHSMsg(handshakeMsg *ProtoHandShake, peerNodeID []byte, nounceCnt uint64) (*Message, error) {
 	// Serialize should handle big-endian
 	hdmsgRLP, err := common.Serialize(handshakeMsg)
@@ -500,22 +595,21 @@ func (srv *Server) packWrapHSMsg(handshakeMsg *ProtoHandShake, peerNodeID []byte
 	wrapMsg := Message{
 		Code: ctlMsgProtoHandshake,
 	}
-	md5Inst := md5.New()
-	if _, err := md5Inst.Write(hdmsgRLP); err != nil {
-		return &Message{}, err
-	}
-	extBuf := make([]byte, extraDataLen)
 
-	// first 16 bytes, contains md5sum of hdmsgRLP;
-	// then 8 bytes for client side nounce;
-	copy(extBuf, md5Inst.Sum(nil))
-	binary.BigEndian.PutUint64(extBuf[16:], nounceCnt)
+	var extBuf []byte
+	var signature *crypto.Signature
+	if handshakeMsg.version() == 0 {
+		extBuf = legacyExtraData(hdmsgRLP, nounceCnt)
+		signature = crypto.MustSign(srv.PrivateKey, crypto.MustHash(extBuf).Bytes())
+	} else {
+		extBuf = make([]byte, extraDataLen)
+		binary.BigEndian.PutUint64(extBuf, nounceCnt)
+		signature = crypto.MustSign(srv.PrivateKey, handshakeHash(hdmsgRLP, extBuf, peerNodeID).Bytes())
+	}
 
-	// Sign with local privateKey first
-	signature := crypto.MustSign(srv.PrivateKey, crypto.MustHash(extBuf).Bytes())
-	enc := make([]byte, extraDataLen+len(signature.Sig))
+	enc := make([]byte, len(extBuf)+len(signature.Sig))
 	copy(enc, extBuf)
-	copy(enc[extraDataLen:], signature.Sig)
+	copy(enc[len(extBuf):], signature.Sig)
 
 	// Format of wrapMsg payload, [handshake's rlp body, encoded extra data, length of encoded extra data]
 	size := uint32(len(hdmsgRLP) + len(enc) + 4)
@@ -526,6 +620,17 @@ func (srv *Server) packWrapHSMsg(handshakeMsg *ProtoHandShake, peerNodeID []byte
 	return &wrapMsg, nil
 }
 
+// legacyExtraData returns the legacy extra data of handshake msg, the first 16 bytes contains
+// md5sum of the handshake msg, and then 8 bytes for client side nounce.
+func legacyExtraData(hdmsgRLP []byte, nounceCnt uint64) []byte {
+	extBuf := make([]byte, legacyExtraDataLen)
+	md5Sum := md5.Sum(hdmsgRLP)
+	copy(extBuf, md5Sum[:])
+	binary.BigEndian.PutUint64(extBuf[16:], nounceCnt)
+
+	return extBuf
+}
+
 // unPackWrapHSMsg verify received msg, and recover the handshake msg
 func (srv *Server) unPackWrapHSMsg(recvWrapMsg *Message) (recvMsg *ProtoHandShake, nounceCnt uint64, err error) {
 	size := uint32(len(recvWrapMsg.Payload))
@@ -535,36 +640,69 @@ func (srv *Server) unPackWrapHSMsg(recvWrapMsg *Message) (recvMsg *ProtoHandShak
 	}
 
 	extraEncLen := binary.BigEndian.Uint32(recvWrapMsg.Payload[size-4:])
+	if extraEncLen < extraDataLen || extraEncLen > size-4 {
+		err = errors.New("received msg with invalid extra data length")
+		return
+	}
+
 	recvHSMsgLen := size - extraEncLen - 4
-	nounceCnt = binary.BigEndian.Uint64(recvWrapMsg.Payload[recvHSMsgLen+16:])
+	hdmsgRLP := recvWrapMsg.Payload[:recvHSMsgLen]
 	recvEnc := recvWrapMsg.Payload[recvHSMsgLen : size-4]
 	recvMsg = &ProtoHandShake{}
-	if err = common.Deserialize(recvWrapMsg.Payload[:recvHSMsgLen], recvMsg); err != nil {
+	if err = common.Deserialize(hdmsgRLP, recvMsg); err != nil {
 		return
 	}
-	// verify signature
+
+	// the legacy nodes send the handshake msg without extension along with the legacy extra data
+	if recvMsg.version() == 0 {
+		nounceCnt, err = verifyLegacyHSMsg(recvMsg.NodeID, hdmsgRLP, recvEnc)
+		return
+	}
+
+	nounceCnt = binary.BigEndian.Uint64(recvEnc)
+
+	// verify signature of the whole handshake msg sent to local node to prevent modification
 	sig := crypto.Signature{
 		Sig: recvEnc[extraDataLen:],
 	}
 
-	if !sig.Verify(recvMsg.NodeID, crypto.MustHash(recvEnc[0:extraDataLen]).Bytes()) {
+	selfID := crypto.GetAddress(&srv.PrivateKey.PublicKey)
+	if !sig.Verify(recvMsg.NodeID, handshakeHash(hdmsgRLP, recvEnc[:extraDataLen], selfID[:]).Bytes()) {
 		err = errors.New("unPackWrapHSMsg: received public key not match")
 		return
 	}
 
-	// verify recvMsg's payload md5sum to prevent modification
-	md5Inst := md5.New()
-	if _, err = md5Inst.Write(recvWrapMsg.Payload[:recvHSMsgLen]); err != nil {
-		return
+	srv.log.Debug("unPackWrapHSMsg: verify OK!")
+	return
+}
+
+// verifyLegacyHSMsg verifies the handshake msg with the legacy extra data, and returns the nounce.
+func verifyLegacyHSMsg(nodeID common.Address, hdmsgRLP []byte, recvEnc []byte) (uint64, error) {
+	if len(recvEnc) < legacyExtraDataLen {
+		return 0, errors.New("received msg with invalid extra data length")
 	}
 
-	if !bytes.Equal(md5Inst.Sum(nil), recvEnc[:16]) {
-		err = errors.New("unPackWrapHSMsg: received md5sum not match")
-		return
+	// verify signature
+	sig := crypto.Signature{
+		Sig: recvEnc[legacyExtraDataLen:],
 	}
 
-	srv.log.Debug("unPackWrapHSMsg: verify OK!")
-	return
+	if !sig.Verify(nodeID, crypto.MustHash(recvEnc[:legacyExtraDataLen]).Bytes()) {
+		return 0, errors.New("unPackWrapHSMsg: received public key not match")
+	}
+
+	// verify recvMsg's payload md5sum to prevent modification
+	md5Sum := md5.Sum(hdmsgRLP)
+	if !bytes.Equal(md5Sum[:], recvEnc[:16]) {
+		return 0, errors.New("unPackWrapHSMsg: received md5sum not match")
+	}
+
+	return binary.BigEndian.Uint64(recvEnc[16:legacyExtraDataLen]), nil
+}
+
+// handshakeHash returns the hash of the handshake msg, extra data and the receiver node id to sign.
+func handshakeHash(hdmsgRLP []byte, extData []byte, peerNodeID []byte) common.Hash {
+	return crypto.HashBytes(hdmsgRLP, extData, peerNodeID)
 }
 
 // Stop terminates the execution of the p2p server
@@ -577,14 +715,73 @@ func (srv *Server) Stop() {
 	}
 	srv.running = false
 
-	if srv.listener != nil {
-		srv.listener.Close()
+	if srv.transport != nil {
+		srv.transport.close()
 	}
 
 	close(srv.quit)
 	srv.Wait()
 }
 
+// AdjustPeerScore adds the delta to the score of the connected peer according to its behaviour,
+// and the peer is disconnected and banned if the score falls below BanScoreThreshold.
+func (srv *Server) AdjustPeerScore(id common.Address, delta int, reason string) {
+	p := srv.peerSet.find(id)
+	if p == nil {
+		return
+	}
+
+	score := p.addScore(delta)
+	if delta < 0 {
+		srv.log.Debug("peer %s score is decreased by %d to %d, %s", id.ToHex(), -delta, score, reason)
+	}
+
+	if score < BanScoreThreshold {
+		srv.log.Warn("peer %s is banned as score %d is below threshold, %s", id.ToHex(), score, reason)
+		srv.BanNode(id, DefaultBanDuration, reason)
+	}
+}
+
+// BanNode bans the node and its IP for the duration, and disconnects it if connected.
+// The IP is taken from the connection if connected, otherwise from the discovery database.
+func (srv *Server) BanNode(id common.Address, duration time.Duration, reason string) {
+	var ip net.IP
+	p := srv.peerSet.find(id)
+	if p != nil {
+		ip = addrIP(p.RemoteAddr())
+	} else if srv.kadDB != nil {
+		if node, ok := srv.kadDB.FindByNodeID(id); ok {
+			ip = node.IP
+		}
+	}
+
+	srv.banList.add(id, ip, duration, reason)
+
+	if p != nil {
+		go p.Disconnect(discPeerBanned)
+	}
+}
+
+// addrIP returns the IP of the network address, or nil if the address has no IP, e.g. pipe.
+func addrIP(addr net.Addr) net.IP {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+
+	return net.ParseIP(host)
+}
+
+// UnbanNode removes the ban of node, and returns false if the node is not banned.
+func (srv *Server) UnbanNode(id common.Address) bool {
+	return srv.banList.remove(id)
+}
+
+// BannedNodes returns the nodes that are banned currently.
+func (srv *Server) BannedNodes() []BanInfo {
+	return srv.banList.list()
+}
+
 // PeerInfos array of PeerInfo for sort alphabetically by node identifier
 type PeerInfos []PeerInfo
 
@@ -7,6 +7,8 @@ package p2p
 
 import (
 	"crypto/ecdsa"
+	"encoding/json"
+	"net"
 	"strings"
 	"testing"
 
@@ -28,6 +30,7 @@ func Test_NewServer(t *testing.T) {
 	assert.Equal(t, server.MaxPeers, defaultMaxPeers)
 	assert.Equal(t, server.MaxPendingPeers, 0)
 	assert.Equal(t, server.genesis, genesis)
+	assert.Equal(t, server.genesisHash, genesis.Hash())
 
 	// verify the peerSet
 	assert.Equal(t, server.peerSet != nil, true)
@@ -133,6 +136,110 @@ func Test_peerIsValidate(t *testing.T) {
 	assert.Equal(t, strings.Contains(err.Error(), " received public key not match"), true)
 }
 
+func Test_peerIsValidate_Negotiation(t *testing.T) {
+	var genesis core.GenesisInfo
+	config := testConfig()
+	server := NewServer(genesis, *config, []Protocol{
+		{Name: "udp", Version: 1, Length: 1048},
+		{Name: "udp", Version: 2, Length: 1048},
+	})
+
+	// a different version of protocol is supported too
+	recvMsg := &ProtoHandShake{
+		Caps:      []Cap{{"udp", 1}, {"udp", 3}},
+		Params:    genesis.Hash().Bytes(),
+		NetworkID: config.NetworkID,
+	}
+	assert.Equal(t, server.peerIsValidate(recvMsg), true)
+
+	// no common version
+	recvMsg.Caps = []Cap{{"udp", 3}}
+	assert.Equal(t, server.peerIsValidate(recvMsg), false)
+
+	// different genesis
+	recvMsg.Caps = []Cap{{"udp", 2}}
+	recvMsg.Params = core.GenesisInfo{Difficult: 10}.Hash().Bytes()
+	assert.Equal(t, server.peerIsValidate(recvMsg), false)
+
+	// different network
+	recvMsg.Params = genesis.Hash().Bytes()
+	recvMsg.NetworkID = config.NetworkID + 1
+	assert.Equal(t, server.peerIsValidate(recvMsg), false)
+}
+
+func Test_peerIsValidate_LegacyGenesis(t *testing.T) {
+	var genesis core.GenesisInfo
+	config := testConfig()
+	server := NewServer(genesis, *config, testProtocol())
+
+	// legacy nodes send the JSON encoded genesis info
+	params, err := json.Marshal(genesis)
+	assert.Equal(t, err, nil)
+
+	recvMsg := &ProtoHandShake{
+		Caps:      []Cap{testProtocol()[0].cap()},
+		Params:    params,
+		NetworkID: config.NetworkID,
+	}
+	assert.Equal(t, server.peerIsValidate(recvMsg), true)
+
+	// the default values are used for absent fields
+	recvMsg.Params = []byte(`{"accounts":{},"difficult":1}`)
+	assert.Equal(t, server.peerIsValidate(recvMsg), true)
+
+	recvMsg.Params, err = json.Marshal(core.GenesisInfo{ReplayProtectionHeight: 10})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, server.peerIsValidate(recvMsg), false)
+
+	recvMsg.Params = []byte("invalid genesis")
+	assert.Equal(t, server.peerIsValidate(recvMsg), false)
+}
+
+func Test_doHandShake_GenesisParams(t *testing.T) {
+	client, server := newTestHandshakeServer(), newTestHandshakeServer()
+	var caps []Cap
+	for _, proto := range client.Protocols {
+		caps = append(caps, proto.cap())
+	}
+
+	// the genesis hash is only sent to the node of a version that understands it
+	for _, version := range []uint{0, genesisHashHandshakeVersion} {
+		fd1, fd2 := net.Pipe()
+		clientPeer := NewPeer(&connection{fd: fd1}, client.Protocols, client.log, nil)
+		serverPeer := NewPeer(&connection{fd: fd2}, server.Protocols, server.log, nil)
+		go server.doHandShake(caps, serverPeer, inboundConn, nil)
+
+		handshakeMsg := &ProtoHandShake{Caps: caps, NodeID: client.SelfNode.ID, NetworkID: client.Config.NetworkID}
+		handshakeMsg.Params, _ = json.Marshal(client.genesis)
+		if version > 0 {
+			handshakeMsg.Ext = []HandshakeExt{{Version: version}}
+		}
+
+		wrapMsg, err := client.packWrapHSMsg(handshakeMsg, server.SelfNode.ID[0:], 100)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, clientPeer.rw.WriteMsg(wrapMsg), nil)
+
+		recvWrapMsg, err := clientPeer.rw.ReadMsg()
+		assert.Equal(t, err, nil)
+
+		recvMsg, _, err := client.unPackWrapHSMsg(recvWrapMsg)
+		assert.Equal(t, err, nil)
+
+		if version < genesisHashHandshakeVersion {
+			assert.Equal(t, len(recvMsg.Ext), 0)
+			assert.Equal(t, len(recvMsg.Params) > common.HashLength, true)
+		} else {
+			assert.Equal(t, recvMsg.version(), uint(handshakeVersion))
+			assert.Equal(t, recvMsg.Params, client.genesisHash.Bytes())
+		}
+
+		assert.Equal(t, client.peerIsValidate(recvMsg), true)
+
+		clientPeer.close()
+		serverPeer.close()
+	}
+}
+
 func Test_PeerInfos(t *testing.T) {
 	peerInfos := testPeerInfos()
 
@@ -159,6 +266,47 @@ func Test_PeerInfos(t *testing.T) {
 	assert.Equal(t, len(peerInfoArray), 1)
 }
 
+func Test_AdjustPeerScore(t *testing.T) {
+	var genesis core.GenesisInfo
+	config := testConfig()
+	server := NewServer(genesis, *config, nil)
+
+	peer, err := newTestPeer("0x6b9fd39a9f1273c46fba8951b62de5b95cd3dd84", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.addPeer(peer)
+
+	done := make(chan error)
+	go func() {
+		done <- peer.run()
+	}()
+
+	// score is capped by the max score
+	server.AdjustPeerScore(peer.Node.ID, 2*maxPeerScore, "useful data")
+	assert.Equal(t, peer.Score(), maxPeerScore)
+
+	server.AdjustPeerScore(peer.Node.ID, BanScoreThreshold-maxPeerScore, "invalid block")
+	assert.Equal(t, server.banList.isBanned(peer.Node.ID, nil), false)
+
+	// disconnected and banned below the threshold, along with the remote IP
+	server.AdjustPeerScore(peer.Node.ID, -1, "invalid block")
+	assert.Equal(t, server.banList.isBanned(peer.Node.ID, nil), true)
+	assert.Equal(t, strings.Contains((<-done).Error(), discPeerBanned), true)
+
+	ip := addrIP(peer.RemoteAddr())
+	assert.Equal(t, ip.Equal(net.ParseIP("127.0.0.1")), true)
+	assert.Equal(t, server.banList.isBanned(common.BytesToAddress([]byte("node2")), ip), true)
+
+	bans := server.BannedNodes()
+	assert.Equal(t, len(bans), 1)
+	assert.Equal(t, bans[0].IP, ip)
+	assert.Equal(t, bans[0].Reason, "invalid block")
+
+	assert.Equal(t, server.UnbanNode(peer.Node.ID), true)
+	assert.Equal(t, len(server.BannedNodes()), 0)
+}
+
 func testConfig() *Config {
 	return &Config{
 		ListenAddr:    "127.0.0.1:8080",
@@ -0,0 +1,152 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/seeleteam/go-seele/p2p/discovery"
+	"github.com/seeleteam/go-seele/p2p/qvic"
+)
+
+const (
+	// TransportTCP accepts and dials p2p connections over TCP only, which is the default transport.
+	TransportTCP = "tcp"
+
+	// TransportQvic accepts and dials p2p connections over qvic only.
+	TransportQvic = "qvic"
+
+	// TransportBoth accepts p2p connections over both TCP and qvic, and prefers qvic to dial.
+	TransportBoth = "both"
+)
+
+var (
+	errInvalidTransport  = errors.New("invalid transport, must be tcp, qvic or both")
+	errQvicAddrRequired  = errors.New("qvic address is required for qvic transport")
+	errNoCommonTransport = errors.New("no common transport with the node")
+)
+
+// transport accepts the inbound connections and dials the outbound connections of p2p server.
+type transport interface {
+	accept() (net.Conn, error)
+	dial(node *discovery.Node, timeout time.Duration) (net.Conn, error)
+	close()
+}
+
+// parseTransports returns the transport flags and qvic port of the specified config,
+// which are advertised to other nodes by discovery.
+func parseTransports(config *Config) (transports discovery.Transport, qvicPort int, err error) {
+	switch config.Transport {
+	case "", TransportTCP:
+		return discovery.TransportTCP, 0, nil
+	case TransportQvic:
+		transports = discovery.TransportQvic
+	case TransportBoth:
+		transports = discovery.TransportTCP | discovery.TransportQvic
+	default:
+		return 0, 0, errInvalidTransport
+	}
+
+	if len(config.QvicListenAddr) == 0 {
+		return 0, 0, errQvicAddrRequired
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", config.QvicListenAddr)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return transports, addr.Port, nil
+}
+
+// newTransport starts listening on the addresses in the specified config for the specified
+// transports, which are parsed from the config by parseTransports.
+func newTransport(config *Config, transports discovery.Transport) (transport, error) {
+	if !transports.Has(discovery.TransportQvic) {
+		listener, err := net.Listen("tcp", config.ListenAddr)
+		if err != nil {
+			return nil, err
+		}
+
+		return &tcpTransport{listener}, nil
+	}
+
+	// qvic manager accepts both TCP and qvic connections if TCP address is specified.
+	tcpAddr := ""
+	if transports.Has(discovery.TransportTCP) {
+		tcpAddr = config.ListenAddr
+	}
+
+	mgr := qvic.NewQvicMgr()
+	if err := mgr.Listen(tcpAddr, config.QvicListenAddr); err != nil {
+		mgr.Close()
+		return nil, err
+	}
+
+	return &qvicTransport{mgr, transports}, nil
+}
+
+// dialTCP connects to the node over TCP.
+func dialTCP(node *discovery.Node, timeout time.Duration) (net.Conn, error) {
+	//TODO UDPPort==> TCPPort
+	addr, err := net.ResolveTCPAddr("tcp4", fmt.Sprintf("%s:%d", node.IP.String(), node.UDPPort))
+	if err != nil {
+		return nil, err
+	}
+
+	return net.DialTimeout("tcp", addr.String(), timeout)
+}
+
+// tcpTransport is the transport over TCP only.
+type tcpTransport struct {
+	listener net.Listener
+}
+
+func (t *tcpTransport) accept() (net.Conn, error) {
+	return t.listener.Accept()
+}
+
+func (t *tcpTransport) dial(node *discovery.Node, timeout time.Duration) (net.Conn, error) {
+	if !node.Transports.Has(discovery.TransportTCP) {
+		return nil, errNoCommonTransport
+	}
+
+	return dialTCP(node, timeout)
+}
+
+func (t *tcpTransport) close() {
+	t.listener.Close()
+}
+
+// qvicTransport is the transport over qvic, and optionally TCP.
+type qvicTransport struct {
+	mgr        *qvic.QvicMgr
+	transports discovery.Transport
+}
+
+func (t *qvicTransport) accept() (net.Conn, error) {
+	return t.mgr.Accept()
+}
+
+func (t *qvicTransport) dial(node *discovery.Node, timeout time.Duration) (net.Conn, error) {
+	// prefer qvic to avoid the head-of-line blocking of TCP
+	if node.Transports.Has(discovery.TransportQvic) && node.QvicPort > 0 {
+		return t.mgr.DialTimeout("qvic", fmt.Sprintf("%s:%d", node.IP.String(), node.QvicPort), timeout)
+	}
+
+	if t.transports.Has(discovery.TransportTCP) && node.Transports.Has(discovery.TransportTCP) {
+		return dialTCP(node, timeout)
+	}
+
+	return nil, errNoCommonTransport
+}
+
+func (t *qvicTransport) close() {
+	t.mgr.Close()
+}
@@ -0,0 +1,114 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package p2p
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/p2p/discovery"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTransportNode(addr string, transports discovery.Transport, qvicPort int) *discovery.Node {
+	udpAddr, _ := net.ResolveUDPAddr("udp", addr)
+	node := discovery.NewNodeWithAddr(*crypto.MustGenerateRandomAddress(), udpAddr, 1)
+	node.Transports, node.QvicPort = transports, qvicPort
+	return node
+}
+
+func Test_parseTransports(t *testing.T) {
+	// TCP by default
+	transports, qvicPort, err := parseTransports(&Config{})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, transports, discovery.TransportTCP)
+	assert.Equal(t, qvicPort, 0)
+
+	transports, qvicPort, err = parseTransports(&Config{Transport: TransportBoth, QvicListenAddr: "127.0.0.1:8102"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, transports, discovery.TransportTCP|discovery.TransportQvic)
+	assert.Equal(t, qvicPort, 8102)
+
+	_, _, err = parseTransports(&Config{Transport: TransportQvic})
+	assert.Equal(t, err, errQvicAddrRequired)
+
+	_, _, err = parseTransports(&Config{Transport: "udp"})
+	assert.Equal(t, err, errInvalidTransport)
+}
+
+// newTestTransport returns a transport listening on the free ports of the specified transports.
+func newTestTransport(t *testing.T, name string) transport {
+	config := &Config{ListenAddr: "127.0.0.1:0", Transport: name}
+	if name != TransportTCP {
+		config.QvicListenAddr = "127.0.0.1:0"
+	}
+
+	transports, _, err := parseTransports(config)
+	assert.Equal(t, err, nil)
+
+	tr, err := newTransport(config, transports)
+	assert.Equal(t, err, nil)
+
+	return tr
+}
+
+func Test_tcpTransport(t *testing.T) {
+	tr := newTestTransport(t, TransportTCP)
+	defer tr.close()
+
+	addr := tr.(*tcpTransport).listener.Addr().String()
+
+	// the node doesn't support TCP
+	_, err := tr.dial(newTestTransportNode(addr, discovery.TransportQvic, 8102), time.Second)
+	assert.Equal(t, err, errNoCommonTransport)
+
+	conn, err := tr.dial(newTestTransportNode(addr, discovery.TransportTCP, 0), time.Second)
+	assert.Equal(t, err, nil)
+	defer conn.Close()
+
+	accepted, err := tr.accept()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, accepted.RemoteAddr().String(), conn.LocalAddr().String())
+	accepted.Close()
+}
+
+func Test_qvicTransport(t *testing.T) {
+	server := newTestTransport(t, TransportBoth)
+	defer server.close()
+
+	client := newTestTransport(t, TransportQvic)
+	defer client.close()
+
+	mgr := server.(*qvicTransport).mgr
+	addr := mgr.TCPAddr().String()
+	qvicPort := mgr.QvicAddr().(*net.UDPAddr).Port
+
+	// qvic only client could not dial a TCP only node
+	_, err := client.dial(newTestTransportNode(addr, discovery.TransportTCP, 0), time.Second)
+	assert.Equal(t, err, errNoCommonTransport)
+
+	// qvic is preferred
+	conn, err := client.dial(newTestTransportNode(addr, discovery.TransportTCP|discovery.TransportQvic, qvicPort), 2*time.Second)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reflect.TypeOf(conn).String(), "*qvic.QConn")
+	defer conn.Close()
+
+	accepted, err := server.accept()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reflect.TypeOf(accepted).String(), "*qvic.QConn")
+
+	// server accepts TCP connections too
+	tcpConn, err := dialTCP(newTestTransportNode(addr, discovery.TransportTCP, 0), time.Second)
+	assert.Equal(t, err, nil)
+	defer tcpConn.Close()
+
+	accepted, err = server.accept()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reflect.TypeOf(accepted).String(), "*net.TCPConn")
+}
@@ -0,0 +1,90 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package seele
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/common/hexutil"
+	"github.com/seeleteam/go-seele/p2p"
+)
+
+// PrivateAdminAPI provides an API to manage the node, e.g. the banned nodes and the chain HEAD.
+type PrivateAdminAPI struct {
+	s *SeeleService
+}
+
+// NewPrivateAdminAPI creates a new PrivateAdminAPI object for rpc service.
+func NewPrivateAdminAPI(s *SeeleService) *PrivateAdminAPI {
+	return &PrivateAdminAPI{s}
+}
+
+// GetBannedNodes returns the nodes that are banned currently.
+func (api *PrivateAdminAPI) GetBannedNodes() ([]p2p.BanInfo, error) {
+	return api.s.p2pServer.BannedNodes(), nil
+}
+
+// BanNode bans the node for the duration in seconds, and disconnects it if connected.
+// The default ban duration is used if duration is not specified.
+func (api *PrivateAdminAPI) BanNode(id common.Address, duration *uint64) (bool, error) {
+	banDuration := p2p.DefaultBanDuration
+	if duration != nil {
+		banDuration = time.Duration(*duration) * time.Second
+	}
+
+	api.s.p2pServer.BanNode(id, banDuration, "banned by admin")
+	return true, nil
+}
+
+// UnbanNode removes the ban of node, and returns false if the node is not banned.
+func (api *PrivateAdminAPI) UnbanNode(id common.Address) (bool, error) {
+	return api.s.p2pServer.UnbanNode(id), nil
+}
+
+// SetHead rewinds the specified chain to the block of the specified hash or height, which becomes the
+// HEAD block, and all blocks that are not its ancestors are deleted. The txs and debts of the blocks
+// removed from the canonical chain are reinjected into pools. Returns the new HEAD block.
+func (api *PrivateAdminAPI) SetHead(hashHex string, height int64, chainNum *uint64) (map[string]interface{}, error) {
+	if chainNum == nil {
+		return nil, errChainNumRequired
+	}
+
+	num, err := getChainNum(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := api.s.chains[num]
+
+	var hash common.Hash
+	if len(hashHex) > 0 {
+		hashByte, err := hexutil.HexToBytes(hashHex)
+		if err != nil {
+			return nil, err
+		}
+
+		hash = common.BytesToHash(hashByte)
+	} else if height >= 0 {
+		if hash, err = chain.GetStore().GetBlockHash(uint64(height)); err != nil {
+			return nil, errBlockNotFound
+		}
+	} else {
+		return nil, fmt.Errorf("invalid height %d", height)
+	}
+
+	removed, added, err := chain.Rewind(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	api.s.txPools[num].HandleChainRewound(removed, added)
+	api.s.debtPools[num].HandleChainRewound(removed, added)
+
+	block := chain.CurrentBlock()
+	return rpcOutputBlock(block, false, chain.GetStore(), num)
+}
@@ -26,9 +26,15 @@ package seele
  	return &PrivateDebugAPI{s}
  }
 
- // PrintBlock retrieves a block and returns its pretty printed form, when height is -1 the chain head is returned
- func (api *PrivateDebugAPI) PrintBlock(height int64) (*types.Block, error) {
- 	block, err := getBlock(api.s.chains[0], height)
+ // PrintBlock retrieves a block and returns its pretty printed form, when height is -1 the chain head is returned.
+ // The chainNum is required if there are multiple chains.
+ func (api *PrivateDebugAPI) PrintBlock(height int64, chainNum *uint64) (*types.Block, error) {
+	num, err := getChainNum(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+ 	block, err := getBlock(api.s.chains[num], height)
  	if err != nil {
  		return nil, err
  	}
@@ -36,47 +42,120 @@ package seele
  	return block, nil
 }
 
-// // GetTxPoolContent returns the transactions contained within the transaction pool
-// func (api *PrivateDebugAPI) GetTxPoolContent() (map[string][]map[string]interface{}, error) {
-// 	txPool := api.s.TxPool()
-// 	data := txPool.GetTransactions(false, true)
+// GetTxPoolContent returns the transactions contained within the transaction pool of the specified chain,
+// or all chains if chainNum is not specified.
+func (api *PrivateDebugAPI) GetTxPoolContent(chainNum *uint64) (map[string][]map[string]interface{}, error) {
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
 
-// 	content := make(map[string][]map[string]interface{})
-// 	for _, tx := range data {
-// 		key := tx.Data.From.ToHex()
-// 		content[key] = append(content[key], PrintableOutputTx(tx))
-// 	}
+	content := make(map[string][]map[string]interface{})
+	for _, num := range chainNums {
+		for _, tx := range api.s.txPools[num].GetTransactions(false, true) {
+			key := tx.Data.From.ToHex()
+			content[key] = append(content[key], printableOutputPoolTx(tx, num))
+		}
+	}
 
-// 	return content, nil
-// }
+	return content, nil
+}
 
-// // GetTxPoolTxCount returns the number of transaction in the pool
-// func (api *PrivateDebugAPI) GetTxPoolTxCount() (uint64, error) {
-// 	txPool := api.s.TxPool()
-// 	return uint64(txPool.GetPendingTxCount()), nil
-// }
+// GetTxPoolTxCount returns the number of transaction in the pool of the specified chain,
+// or all chains if chainNum is not specified.
+func (api *PrivateDebugAPI) GetTxPoolTxCount(chainNum *uint64) (uint64, error) {
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return 0, err
+	}
 
-// // GetPendingTransactions returns all pending transactions
-// func (api *PrivateDebugAPI) GetPendingTransactions() ([]map[string]interface{}, error) {
-// 	pendingTxs := api.s.TxPool().GetTransactions(true, true)
-// 	transactions := make([]map[string]interface{}, 0)
-// 	for _, tx := range pendingTxs {
-// 		transactions = append(transactions, PrintableOutputTx(tx))
-// 	}
+	var count uint64
+	for _, num := range chainNums {
+		count += uint64(api.s.txPools[num].GetPendingTxCount())
+	}
 
-// 	return transactions, nil
-// }
+	return count, nil
+}
+
+// GetPendingTransactions returns all pending transactions of the specified chain,
+// or all chains if chainNum is not specified.
+func (api *PrivateDebugAPI) GetPendingTransactions(chainNum *uint64) ([]map[string]interface{}, error) {
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]map[string]interface{}, 0)
+	for _, num := range chainNums {
+		for _, tx := range api.s.txPools[num].GetTransactions(true, true) {
+			transactions = append(transactions, printableOutputPoolTx(tx, num))
+		}
+	}
+
+	return transactions, nil
+}
+
+// printableOutputPoolTx converts the given tx in the pool of the specified chain to the RPC output.
+func printableOutputPoolTx(tx *types.Transaction, chainNum uint64) map[string]interface{} {
+	output := PrintableOutputTx(tx)
+	output["chainNum"] = chainNum
+
+	return output
+}
+
+// GetPendingDebts returns all debts with status in the debt pool of the specified chain,
+// or all chains if chainNum is not specified.
+func (api *PrivateDebugAPI) GetPendingDebts(chainNum *uint64) ([]map[string]interface{}, error) {
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	debts := make([]map[string]interface{}, 0)
+	for _, num := range chainNums {
+		for _, debt := range api.s.debtPools[num].GetAll() {
+			output := map[string]interface{}{
+				"debt":     debt,
+				"chainNum": num,
+			}
+
+			if record := api.s.debtPools[num].GetDebtRecord(debt.Hash); record != nil {
+				output["status"] = record.Status.String()
+			}
 
-// // GetPendingDebts returns all pending debts
-// // func (api *PrivateDebugAPI) GetPendingDebts() ([]*types.Debt, error) {
-// // 	return api.s.DebtPool().GetAll(), nil
-// // }
+			debts = append(debts, output)
+		}
+	}
+
+	return debts, nil
+}
+
+// GetTxPoolEvictions returns the number of transactions evicted from the pool by better paying ones
+// and the recently evicted transactions of the specified chain, or all chains if chainNum is not specified.
+func (api *PrivateDebugAPI) GetTxPoolEvictions(chainNum *uint64) ([]map[string]interface{}, error) {
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(chainNums))
+	for _, num := range chainNums {
+		count, recent := api.s.txPools[num].GetEvictions()
+		result = append(result, map[string]interface{}{
+			"chainNum": num,
+			"evicted":  count,
+			"recent":   recent,
+		})
+	}
+
+	return result, nil
+}
 
  // TpsInfo tps detail info
  type TpsInfo struct {
- 	Count       [NumOfChains]uint64
-	Duration    [NumOfChains]uint64
-	Tps         [NumOfChains]float64 
+ 	Count       []uint64
+	Duration    []uint64
+	Tps         []float64 
  }
 
  // GetTPS get tps info
@@ -137,11 +216,11 @@ package seele
 
 // get tps info from all the chains
 func (api *PrivateDebugAPI) GetTPSFromAllChains() (*TpsInfo, error) {
-	var tps [NumOfChains]float64
-	var count [NumOfChains]uint64
-	var duration [NumOfChains]uint64
 	chains := api.s.BlockChain()
-	for i := 0; i < NumOfChains; i++{
+	tps := make([]float64, len(chains))
+	count := make([]uint64, len(chains))
+	duration := make([]uint64, len(chains))
+	for i := range chains {
 		block := chains[i].CurrentBlock()
 		timeInterval := uint64(150)
 		if block.Header.Height == 0 {
@@ -6,17 +6,17 @@
 package seele
 
  import (
-// 	"fmt"
+	"fmt"
  	"math/big"
-// 	"strings"
+	"strings"
 
  	"github.com/seeleteam/go-seele/common"
-// 	"github.com/seeleteam/go-seele/common/hexutil"
+	"github.com/seeleteam/go-seele/common/hexutil"
  	"github.com/seeleteam/go-seele/core"
-// 	"github.com/seeleteam/go-seele/core/state"
  	"github.com/seeleteam/go-seele/core/store"
+	"github.com/seeleteam/go-seele/core/svm"
  	"github.com/seeleteam/go-seele/core/types"
-// 	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/crypto"
  )
 
  // PublicSeeleAPI provides an API to access full node-related information.
@@ -26,6 +26,9 @@ package seele
 
 // const maxSizeLimit = 64
 
+// maxLogsBlockRange is the max number of blocks to filter logs in one GetLogs request.
+const maxLogsBlockRange = 10000
+
  // NewPublicSeeleAPI creates a new PublicSeeleAPI object for rpc service.
  func NewPublicSeeleAPI(s *SeeleService) *PublicSeeleAPI {
  	return &PublicSeeleAPI{s}
@@ -34,8 +37,8 @@ package seele
 // MinerInfo miner simple info
  type MinerInfo struct {
  	Coinbase           common.Address
- 	CurrentBlockHeight [NumOfChains]uint64
- 	HeaderHash         [NumOfChains]common.Hash
+ 	CurrentBlockHeight []uint64
+ 	HeaderHash         []common.Hash
  	Shard              uint
  	MinerStatus        string
  	MinerThread        int
@@ -45,71 +48,207 @@ package seele
  type GetBalanceResponse struct {
  	Account common.Address
  	Balance *big.Int
+	ChainNum uint64
  }
 
-// // GetLogsResponse response param for GetLogs api
-// type GetLogsResponse struct {
-// 	Txhash   common.Hash
-// 	LogIndex uint
-// 	Log      *types.Log
-// }
+// GetLogsResponse response param for GetLogs api
+type GetLogsResponse struct {
+	ChainNum    uint64
+	BlockHash   common.Hash
+	BlockHeight uint64
+	TxHash      common.Hash
+	TxIndex     uint
+	LogIndex    uint
+	Log         *types.Log
+	Removed     bool // true if the block of log is removed from the canonical chain due to reorg
+}
 
-// // Call is to execute a given transaction on a statedb of a given block height.
-// // It does not affect this statedb and blockchain and is useful for executing and retrieve values.
-// func (api *PublicSeeleAPI) Call(contract, payload string, height int64) (map[string]interface{}, error) {
-// 	contractAddr, err := common.HexToAddress(contract)
-// 	if err != nil {
-// 		return nil, fmt.Errorf("invalid contract address: %s", err)
-// 	}
+// LogFilter is the filter criteria of GetLogs api. A negative height means the chain head.
+// If ChainNum is not specified, the chain of the contract addresses is used.
+// Topics is a list of topic alternatives by position, empty alternatives match any topic.
+type LogFilter struct {
+	FromHeight int64
+	ToHeight   int64
+	ChainNum   *uint64
+	Addresses  []common.Address
+	Topics     [][]common.Hash
+}
 
-// 	msg, err := hexutil.HexToBytes(payload)
-// 	if err != nil {
-// 		return nil, fmt.Errorf("invalid payload, %s", err)
-// 	}
+// Call is to execute a given transaction on a copy of the statedb of a given block height.
+// It does not affect the statedb and blockchain and is useful for executing and retrieve values.
+func (api *PublicSeeleAPI) Call(contract, payload string, height int64) (map[string]interface{}, error) {
+	contractAddr, err := common.HexToAddress(contract)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contract address: %s", err)
+	}
 
-// 	// Get the block by block height, if the height is less than zero, get the current block.
-// 	block, err := getBlock(api.s.chain, height)
-// 	if err != nil {
-// 		return nil, err
-// 	}
+	msg, err := hexutil.HexToBytes(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload, %s", err)
+	}
 
-// 	// Get the statedb by the given block height
-// 	statedb, err := state.NewStatedb(block.Header.StateHash, api.s.accountStateDB)
-// 	if err != nil {
-// 		return nil, err
-// 	}
+	receipt, output, err := api.simulate(common.EmptyAddress, contractAddr, big.NewInt(0), msg, types.MaxTxGasLimit, height)
+	if err != nil {
+		return nil, err
+	}
 
-// 	coinbase := api.s.miner.GetCoinbase()
-// 	from := crypto.MustGenerateShardAddress(coinbase.Shard())
-// 	statedb.CreateAccount(*from)
-// 	statedb.SetBalance(*from, common.SeeleToFan)
+	return printableSimulatedReceipt(receipt, output)
+}
 
-// 	amount, fee, nonce := big.NewInt(0), big.NewInt(1), uint64(1)
-// 	tx, err := types.NewMessageTransaction(*from, contractAddr, amount, fee, nonce, msg)
-// 	if err != nil {
-// 		return nil, fmt.Errorf("failed to create transaction: %s", err)
-// 	}
+// EstimateGas executes the given transaction on a copy of the statedb of a given block height,
+// and returns the minimum gas limit required by a gas metered transaction as the used gas, which
+// could be more than the gas actually used due to the refund of cleared storage.
+// If the to address is empty, it estimates the contract creation with payload as the code.
+func (api *PublicSeeleAPI) EstimateGas(from common.Address, to, payload string, amount *big.Int, height int64) (map[string]interface{}, error) {
+	toAddr := common.EmptyAddress
+	if len(to) > 0 {
+		var err error
+		if toAddr, err = common.HexToAddress(to); err != nil {
+			return nil, fmt.Errorf("invalid to address: %s", err)
+		}
+	}
 
-// 	// Get the transaction receipt, and the fee give to the miner coinbase
-// 	receipt, err := api.s.chain.ApplyTransaction(tx, 0, coinbase, statedb, block.Header)
-// 	if err != nil {
-// 		return nil, err
-// 	}
+	msg, err := hexutil.HexToBytes(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload, %s", err)
+	}
 
-// 	// Format the receipt
-// 	result, err := PrintableReceipt(receipt)
-// 	if err != nil {
-// 		return nil, err
-// 	}
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
 
-// 	return result, nil
-// }
+	receipt, output, err := api.simulate(from, toAddr, amount, msg, types.MaxTxGasLimit, height)
+	if err != nil {
+		return nil, err
+	}
+
+	if !receipt.Failed {
+		if receipt.UsedGas, err = api.searchGasLimit(from, toAddr, amount, msg, receipt.UsedGas, height); err != nil {
+			return nil, err
+		}
+	}
+
+	return printableSimulatedReceipt(receipt, output)
+}
+
+// searchGasLimit returns the minimum gas limit that the tx succeeds with. The used gas excludes
+// the refund, so it is the lower bound, and the tx may run out of gas with it before refunding.
+func (api *PublicSeeleAPI) searchGasLimit(from, to common.Address, amount *big.Int, payload []byte, usedGas uint64, height int64) (uint64, error) {
+	succeeded := func(gasLimit uint64) (bool, error) {
+		receipt, _, err := api.simulate(from, to, amount, payload, gasLimit, height)
+		if err != nil {
+			return false, err
+		}
+
+		return !receipt.Failed, nil
+	}
+
+	lo, hi := types.IntrinsicGas(payload, to.IsEmpty()), types.MaxTxGasLimit
+	if usedGas > lo {
+		lo = usedGas
+	}
+
+	// the used gas is enough in most cases without refund
+	if ok, err := succeeded(lo); ok || err != nil {
+		return lo, err
+	}
+
+	for lo++; lo < hi; {
+		mid := lo + (hi-lo)/2
+		ok, err := succeeded(mid)
+		if err != nil {
+			return 0, err
+		}
+
+		if ok {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	return hi, nil
+}
+
+// simulate executes a gas metered tx with the minimum fee and gas price, and the specified gas limit
+// on a copy of the statedb of the specified block height. The gas fee is credited to the from account
+// in advance, so that the result is not affected by the balance.
+// The chain is decided by the from address, or the to address if from address is empty.
+// If the from address is empty, a random account with enough balance is used.
+func (api *PublicSeeleAPI) simulate(from, to common.Address, amount *big.Int, payload []byte, gasLimit uint64, height int64) (*types.Receipt, []byte, error) {
+	chainNum := from.GetChainNum(api.s.numOfChains)
+	if from.IsEmpty() {
+		chainNum = to.GetChainNum(api.s.numOfChains)
+	}
+
+	if chainNum >= uint64(len(api.s.chains)) {
+		return nil, nil, fmt.Errorf("invalid chain number %d", chainNum)
+	}
+
+	// Get the block by block height, if the height is less than zero, get the current block.
+	chain := api.s.chains[chainNum]
+	block, err := getBlock(chain, height)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Get a new statedb by the given block height, which is never committed.
+	statedb, err := chain.GetStateByRootHash(block.Header.StateHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if from.IsEmpty() {
+		from = *crypto.MustGenerateShardAddress(common.LocalShardNumber)
+		statedb.CreateAccount(from)
+		statedb.SetBalance(from, new(big.Int).Add(common.SeeleToFan, amount))
+	}
+
+	tx, err := types.NewGasMeteredTransaction(from, to, amount, big.NewInt(1), big.NewInt(1), gasLimit, statedb.GetNonce(from), payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create transaction: %s", err)
+	}
+
+	statedb.AddBalance(from, new(big.Int).Add(tx.MaxGasFee(), tx.Data.Fee))
+
+	ctx := &svm.Context{
+		Tx:          tx,
+		Statedb:     statedb,
+		BlockHeader: block.Header.Clone(),
+		BcStore:     chain.GetStore(),
+		NumOfChains: api.s.numOfChains,
+	}
+
+	return svm.Simulate(ctx)
+}
+
+// printableSimulatedReceipt converts the receipt and output of simulated transaction to the RPC output.
+func printableSimulatedReceipt(receipt *types.Receipt, output []byte) (map[string]interface{}, error) {
+	outMap, err := PrintableReceipt(receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	outMap["result"] = hexutil.BytesToHex(output)
+	if _, ok := outMap["logs"]; !ok {
+		outMap["logs"] = make([]map[string]interface{}, 0)
+	}
+
+	if receipt.Failed {
+		outMap["error"] = string(receipt.Result)
+		if reason, ok := svm.UnpackRevertReason(output); ok {
+			outMap["revertReason"] = reason
+		}
+	}
+
+	return outMap, nil
+}
 
 // GetInfo gets the account address that mining rewards will be send to.
 func (api *PublicSeeleAPI) GetInfo() (MinerInfo, error) {
-	var CurBlkHeight [NumOfChains]uint64
-	var HdHash [NumOfChains]common.Hash
-	for i := 0; i < NumOfChains; i++ {
+	CurBlkHeight := make([]uint64, len(api.s.chains))
+	HdHash := make([]common.Hash, len(api.s.chains))
+	for i := range api.s.chains {
 		block := api.s.chains[i].CurrentBlock()
 		CurBlkHeight[i] = block.Header.Height
 		HdHash[i] = block.HeaderHash
@@ -139,7 +278,7 @@ func (api *PublicSeeleAPI) GetInfo() (MinerInfo, error) {
  		account = api.s.Miner().GetCoinbase()
  	}
 
- 	state, err := api.s.GetCurrentState()
+ 	state, err := api.s.GetCurrentState(account)
  	if err != nil {
  		return nil, err
  	}
@@ -147,6 +286,7 @@ func (api *PublicSeeleAPI) GetInfo() (MinerInfo, error) {
  	return &GetBalanceResponse{
  		Account: account,
  		Balance: state.GetBalance(account),
+		ChainNum: account.GetChainNum(api.s.numOfChains),
  	}, nil
  }
 
@@ -157,7 +297,7 @@ func (api *PublicSeeleAPI) GetInfo() (MinerInfo, error) {
 	// assign chain number to the transaction
 	txMsg := &transactionMsg {
 		Tx: &tx,
-		ChainNum: tx.Data.From.GetChainNum(),
+		ChainNum: tx.Data.From.GetChainNum(api.s.numOfChains),
 	}
 	
  	var err error
@@ -166,7 +306,7 @@ func (api *PublicSeeleAPI) GetInfo() (MinerInfo, error) {
  			api.s.seeleProtocol.SendDifferentShardTx(txMsg, shard)
  		}
  	} else {
- 		err = api.s.txPools[txMsg.ChainNum].AddTransaction(&tx)
+ 		err = api.s.txPools[txMsg.ChainNum].AddLocalTransaction(&tx)
  	}
 
  	if err != nil {
@@ -182,7 +322,7 @@ func (api *PublicSeeleAPI) GetInfo() (MinerInfo, error) {
  		account = api.s.Miner().GetCoinbase()
  	}
 
- 	state, err := api.s.GetCurrentState()
+ 	state, err := api.s.GetCurrentState(account)
  	if err != nil {
  		return 0, err
  	}
@@ -190,30 +330,41 @@ func (api *PublicSeeleAPI) GetInfo() (MinerInfo, error) {
  	return state.GetNonce(account), nil
  }
 
-// GetBlockHeight get the block height of the chain head
-// func (api *PublicSeeleAPI) GetBlockHeight() (uint64, error) {
-// 	block := api.s.chain.CurrentBlock()
-// 	return block.Header.Height, nil
-// }
+// GetBlockHeight get the block height of the chain head, the chainNum is required if there are multiple chains.
+func (api *PublicSeeleAPI) GetBlockHeight(chainNum *uint64) (uint64, error) {
+	num, err := getChainNum(api.s, chainNum)
+	if err != nil {
+		return 0, err
+	}
+
+	block := api.s.chains[num].CurrentBlock()
+	return block.Header.Height, nil
+}
 
  // GetBlock returns the requested block.
-  func (api *PublicSeeleAPI) GetBlock(hashHex string, height int64, fulltx bool) (map[string]interface{}, error) {
+  func (api *PublicSeeleAPI) GetBlock(hashHex string, height int64, fulltx bool, chainNum *uint64) (map[string]interface{}, error) {
   	if len(hashHex) > 0 {
-  		return api.GetBlockByHash(hashHex, fulltx)
+  		return api.GetBlockByHash(hashHex, fulltx, chainNum)
   	}
 
-	  return api.GetBlockByHeight(height, fulltx)
+	  return api.GetBlockByHeight(height, fulltx, chainNum)
   }
 
  // GetBlockByHeight returns the requested block. When blockNr is -1 the chain head is returned. When fullTx is true all
- // transactions in the block are returned in full detail, otherwise only the transaction hash is returned
-  func (api *PublicSeeleAPI) GetBlockByHeight(height int64, fulltx bool) (map[string]interface{}, error) {
-  	block, err := getBlock(api.s.chains[0], height)
+ // transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
+ // The chainNum is required if there are multiple chains.
+  func (api *PublicSeeleAPI) GetBlockByHeight(height int64, fulltx bool, chainNum *uint64) (map[string]interface{}, error) {
+	num, err := getChainNum(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+  	block, err := getBlock(api.s.chains[num], height)
   	if err != nil {
   		return nil, err
   	}
 
-  	return rpcOutputBlock(block, fulltx, api.s.chains[0].GetStore())
+  	return rpcOutputBlock(block, fulltx, api.s.chains[num].GetStore(), num)
  }
 
 // // GetBlocks returns the size of requested block. When the blockNr is -1 the chain head is returned.
@@ -247,80 +398,118 @@ func (api *PublicSeeleAPI) GetInfo() (MinerInfo, error) {
 // }
 
  // GetBlockByHash returns the requested block. When fullTx is true all transactions in the block are returned in full
- // detail, otherwise only the transaction hash is returned
- func (api *PublicSeeleAPI) GetBlockByHash(hashHex string, fulltx bool) (map[string]interface{}, error) {
- 	//store := api.s.chain.GetStore()
- 	//hashByte, err := hexutil.HexToBytes(hashHex)
- 	//if err != nil {
- 	//	return nil, err
- 	//}
-
- 	//hash := common.BytesToHash(hashByte)
- 	//block, err := store.GetBlock(hash)
- 	//if err != nil {
- 	//	return nil, err
- 	//}
-
-	 //return rpcOutputBlock(block, fulltx, store)
-	 return nil, nil
+ // detail, otherwise only the transaction hash is returned. All chains are searched if chainNum is not specified.
+ func (api *PublicSeeleAPI) GetBlockByHash(hashHex string, fulltx bool, chainNum *uint64) (map[string]interface{}, error) {
+ 	hashByte, err := hexutil.HexToBytes(hashHex)
+ 	if err != nil {
+ 		return nil, err
+ 	}
+
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+ 	hash := common.BytesToHash(hashByte)
+	for _, num := range chainNums {
+		store := api.s.chains[num].GetStore()
+		if block, err := store.GetBlock(hash); err == nil {
+			return rpcOutputBlock(block, fulltx, store, num)
+		}
+	}
+
+	return nil, errBlockNotFound
  }
 
-// // GetLogs Get the logs that satisfies the condition in the block by height and filter
-// func (api *PublicSeeleAPI) GetLogs(height int64, contract string, topics string) ([]GetLogsResponse, error) {
-// 	// Check input parameters
-// 	contractAddress, err := common.HexToAddress(contract)
-// 	if err != nil {
-// 		return nil, fmt.Errorf("Invalid contract address, %s", err)
-// 	}
+// GetLogs returns the logs that satisfy the specified filter in the block range of the specified chain.
+// The log bloom in block header is used to skip the blocks without any matched log.
+func (api *PublicSeeleAPI) GetLogs(filter LogFilter) ([]GetLogsResponse, error) {
+	chainNum, err := getChainNumByAddresses(api.s, filter.ChainNum, filter.Addresses)
+	if err != nil {
+		return nil, err
+	}
 
-// 	hash, err := common.HexToHash(topics)
-// 	if err != nil {
-// 		return nil, fmt.Errorf("Invalid topic, %s", err)
-// 	}
+	chain := api.s.chains[chainNum]
+	headHeight := chain.CurrentBlock().Header.Height
+	fromHeight, toHeight := headHeight, headHeight
+	if filter.FromHeight >= 0 {
+		fromHeight = uint64(filter.FromHeight)
+	}
 
-// 	// Do filter
-// 	block, err := getBlock(api.s.chain, height)
-// 	if err != nil {
-// 		return nil, err
-// 	}
+	if filter.ToHeight >= 0 && uint64(filter.ToHeight) < headHeight {
+		toHeight = uint64(filter.ToHeight)
+	}
 
-// 	store := api.s.chain.GetStore()
-// 	receipts, err := store.GetReceiptsByBlockHash(block.HeaderHash)
-// 	if err != nil {
-// 		return nil, err
-// 	}
+	if fromHeight > toHeight {
+		return nil, fmt.Errorf("invalid block range [%d, %d]", fromHeight, toHeight)
+	}
 
-// 	logs := make([]GetLogsResponse, 0)
-// 	for _, receipt := range receipts {
-// 		for logIndex, log := range receipt.Logs {
-// 			// Matches contract address
-// 			if !contractAddress.Equal(log.Address) {
-// 				continue
-// 			}
+	if toHeight-fromHeight >= maxLogsBlockRange {
+		return nil, fmt.Errorf("block range is too large, the max range is %d", maxLogsBlockRange)
+	}
 
-// 			// Matches topics
-// 			// Because of the topics is always only one
-// 			if len(log.Topics) < 1 || !hash.Equal(log.Topics[0]) {
-// 				continue
-// 			}
+	store := chain.GetStore()
+	logs := make([]GetLogsResponse, 0)
+	for height := fromHeight; height <= toHeight; height++ {
+		hash, err := store.GetBlockHash(height)
+		if err != nil {
+			return nil, err
+		}
+
+		header, err := store.GetBlockHeader(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		if !header.LogBloom.MatchFilter(filter.Addresses, filter.Topics) {
+			continue
+		}
+
+		receipts, err := store.GetReceiptsByBlockHash(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, filterLogs(chainNum, hash, height, receipts, filter.Addresses, filter.Topics, false)...)
+	}
 
-// 			logs = append(logs, GetLogsResponse{
-// 				Txhash:   receipt.TxHash,
-// 				LogIndex: uint(logIndex),
-// 				Log:      log,
-// 			})
-// 		}
-// 	}
+	return logs, nil
+}
 
-// 	return logs, nil
-// }
+// filterLogs returns the logs in the specified receipts of block that match the addresses and topics.
+func filterLogs(chainNum uint64, hash common.Hash, height uint64, receipts []*types.Receipt, addresses []common.Address, topics [][]common.Hash, removed bool) []GetLogsResponse {
+	logs := make([]GetLogsResponse, 0)
+
+	var logIndex uint
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			if log.MatchFilter(addresses, topics) {
+				logs = append(logs, GetLogsResponse{
+					ChainNum:    chainNum,
+					BlockHash:   hash,
+					BlockHeight: height,
+					TxHash:      receipt.TxHash,
+					TxIndex:     log.TxIndex,
+					LogIndex:    logIndex,
+					Log:         log,
+					Removed:     removed,
+				})
+			}
+
+			logIndex++
+		}
+	}
+
+	return logs
+}
 
  // rpcOutputBlock converts the given block to the RPC output which depends on fullTx
- func rpcOutputBlock(b *types.Block, fullTx bool, store store.BlockchainStore) (map[string]interface{}, error) {
+ func rpcOutputBlock(b *types.Block, fullTx bool, store store.BlockchainStore, chainNum uint64) (map[string]interface{}, error) {
  	head := b.Header
  	fields := map[string]interface{}{
- 		"header": head,
- 		"hash":   b.HeaderHash.ToHex(),
+ 		"header":   head,
+ 		"hash":     b.HeaderHash.ToHex(),
+		"chainNum": chainNum,
  	}
 
  	txs := b.Transactions
@@ -391,86 +580,149 @@ func (api *PublicSeeleAPI) GetInfo() (MinerInfo, error) {
  	return transaction
  }
 
-// // PrintableReceipt converts the given Receipt to the RPC output
-// func PrintableReceipt(re *types.Receipt) (map[string]interface{}, error) {
-// 	result := ""
-// 	if re.Failed {
-// 		result = string(re.Result)
-// 	} else {
-// 		result = hexutil.BytesToHex(re.Result)
-// 	}
-// 	outMap := map[string]interface{}{
-// 		"result":    result,
-// 		"poststate": re.PostState.ToHex(),
-// 		"txhash":    re.TxHash.ToHex(),
-// 		"contract":  "0x",
-// 		"failed":    re.Failed,
-// 		"usedGas":   re.UsedGas,
-// 		"totalFee":  re.TotalFee,
-// 	}
+// PrintableReceipt converts the given Receipt to the RPC output
+func PrintableReceipt(re *types.Receipt) (map[string]interface{}, error) {
+	result := ""
+	if re.Failed {
+		result = string(re.Result)
+	} else {
+		result = hexutil.BytesToHex(re.Result)
+	}
+	outMap := map[string]interface{}{
+		"result":    result,
+		"poststate": re.PostState.ToHex(),
+		"txhash":    re.TxHash.ToHex(),
+		"contract":  "0x",
+		"failed":    re.Failed,
+		"usedGas":   re.UsedGas,
+		"totalFee":  re.TotalFee,
+		"status":    receiptStatus(re),
+	}
 
-// 	if len(re.ContractAddress) > 0 {
-// 		contractAddr, err := common.NewAddress(re.ContractAddress)
-// 		if err != nil {
-// 			return nil, err
-// 		}
+	if len(re.ContractAddress) > 0 {
+		contractAddr, err := common.NewAddress(re.ContractAddress)
+		if err != nil {
+			return nil, err
+		}
 
-// 		outMap["contract"] = contractAddr.ToHex()
-// 	}
+		outMap["contract"] = contractAddr.ToHex()
+	}
 
-// 	if len(re.Logs) > 0 {
-// 		var logOuts []map[string]interface{}
+	if len(re.Logs) > 0 {
+		var logOuts []map[string]interface{}
 
-// 		for _, log := range re.Logs {
-// 			logOut, err := printableLog(log)
-// 			if err != nil {
-// 				return nil, err
-// 			}
+		for _, log := range re.Logs {
+			logOut, err := printableLog(log)
+			if err != nil {
+				return nil, err
+			}
 
-// 			logOuts = append(logOuts, logOut)
-// 		}
+			logOuts = append(logOuts, logOut)
+		}
 
-// 		outMap["logs"] = logOuts
-// 	}
+		outMap["logs"] = logOuts
+	}
 
-// 	return outMap, nil
-// }
+	return outMap, nil
+}
 
-// func printableLog(log *types.Log) (map[string]interface{}, error) {
-// 	if (len(log.Data) % 32) > 0 {
-// 		return nil, fmt.Errorf("invalid log data length %v", len(log.Data))
-// 	}
+// receiptStatus returns the execution status of the receipt.
+func receiptStatus(re *types.Receipt) string {
+	if re.Failed {
+		return "failed"
+	}
 
-// 	outMap := map[string]interface{}{
-// 		"address": log.Address.ToHex(),
-// 	}
+	return "success"
+}
 
-// 	// data
-// 	dataLen := len(log.Data) / 32
-// 	if dataLen > 0 {
-// 		var data []string
-// 		for i := 0; i < dataLen; i++ {
-// 			data = append(data, hexutil.BytesToHex(log.Data[i*32:(i+1)*32]))
-// 		}
-// 		outMap["data"] = data
-// 	}
+func printableLog(log *types.Log) (map[string]interface{}, error) {
+	if (len(log.Data) % 32) > 0 {
+		return nil, fmt.Errorf("invalid log data length %v", len(log.Data))
+	}
 
-// 	// topics
-// 	switch len(log.Topics) {
-// 	case 0:
-// 		// do not print empty topic
-// 	case 1:
-// 		outMap["topic"] = log.Topics[0].ToHex()
-// 	default:
-// 		var topics []string
-// 		for _, t := range log.Topics {
-// 			topics = append(topics, t.ToHex())
-// 		}
-// 		outMap["topics"] = fmt.Sprintf("[%v]", strings.Join(topics, ", "))
-// 	}
+	outMap := map[string]interface{}{
+		"address": log.Address.ToHex(),
+	}
 
-// 	return outMap, nil
-// }
+	// data
+	dataLen := len(log.Data) / 32
+	if dataLen > 0 {
+		var data []string
+		for i := 0; i < dataLen; i++ {
+			data = append(data, hexutil.BytesToHex(log.Data[i*32:(i+1)*32]))
+		}
+		outMap["data"] = data
+	}
+
+	// topics
+	switch len(log.Topics) {
+	case 0:
+		// do not print empty topic
+	case 1:
+		outMap["topic"] = log.Topics[0].ToHex()
+	default:
+		var topics []string
+		for _, t := range log.Topics {
+			topics = append(topics, t.ToHex())
+		}
+		outMap["topics"] = fmt.Sprintf("[%v]", strings.Join(topics, ", "))
+	}
+
+	return outMap, nil
+}
+
+// getChainNums returns the specified chain number, or all chain numbers if not specified.
+func getChainNums(s *SeeleService, chainNum *uint64) ([]uint64, error) {
+	if chainNum != nil {
+		if *chainNum >= uint64(len(s.chains)) {
+			return nil, fmt.Errorf("invalid chain number %d", *chainNum)
+		}
+
+		return []uint64{*chainNum}, nil
+	}
+
+	chainNums := make([]uint64, len(s.chains))
+	for i := range chainNums {
+		chainNums[i] = uint64(i)
+	}
+
+	return chainNums, nil
+}
+
+// getChainNum returns the specified chain number. If not specified, the only chain is used,
+// otherwise errChainNumRequired is returned, because the chain can't be decided by a block height.
+func getChainNum(s *SeeleService, chainNum *uint64) (uint64, error) {
+	if chainNum == nil {
+		if len(s.chains) > 1 {
+			return 0, errChainNumRequired
+		}
+
+		return 0, nil
+	}
+
+	if *chainNum >= uint64(len(s.chains)) {
+		return 0, fmt.Errorf("invalid chain number %d", *chainNum)
+	}
+
+	return *chainNum, nil
+}
+
+// getChainNumByAddresses returns the specified chain number, or the chain of the addresses if not
+// specified. All the addresses must be on the same chain if the chain number is not specified.
+func getChainNumByAddresses(s *SeeleService, chainNum *uint64, addresses []common.Address) (uint64, error) {
+	if chainNum != nil || len(addresses) == 0 {
+		return getChainNum(s, chainNum)
+	}
+
+	num := addresses[0].GetChainNum(s.numOfChains)
+	for _, addr := range addresses[1:] {
+		if addr.GetChainNum(s.numOfChains) != num {
+			return 0, fmt.Errorf("addresses are on different chains, %s", errChainNumRequired)
+		}
+	}
+
+	return num, nil
+}
 
  // getBlock returns block by height,when height is -1 the chain head is returned
  func getBlock(chain *core.Blockchain, height int64) (*types.Block, error) {
@@ -24,7 +24,7 @@ import (
 )
 
 func getTmpConfig() *node.Config {
-	acctAddr := crypto.MustGenerateRandomAddress()
+	acctAddr := newTestChainAddress(0)
 
 	return &node.Config{
 		SeeleConfig: node.SeeleConfig{
@@ -68,6 +68,12 @@ func Test_PublicSeeleAPI(t *testing.T) {
 func Test_Call(t *testing.T) {
 	dbPath := filepath.Join(common.GetTempFolder(), ".Call")
 
+	// the simulated sender is generated in local shard
+	common.LocalShardNumber = 1
+	defer func() {
+		common.LocalShardNumber = common.UndefinedShardNumber
+	}()
+
 	api := newTestAPI(t, dbPath)
 	defer func() {
 		api.s.Stop()
@@ -76,7 +82,7 @@ func Test_Call(t *testing.T) {
 
 	// Create a contract/solidity/simple_storage.sol contract, get = 5
 	bytecode, _ := hexutil.HexToBytes("0x608060405234801561001057600080fd5b50600560008190555060df806100276000396000f3006080604052600436106049576000357c0100000000000000000000000000000000000000000000000000000000900463ffffffff16806360fe47b114604e5780636d4ce63c146078575b600080fd5b348015605957600080fd5b5060766004803603810190808035906020019092919050505060a0565b005b348015608357600080fd5b50608a60aa565b6040518082815260200191505060405180910390f35b8060008190555050565b600080549050905600a165627a7a723058207f6dc43a0d648e9f5a0cad5071cde46657de72eb87ab4cded53a7f1090f51e6d0029")
-	statedb, _ := api.s.chain.GetCurrentState()
+	statedb, _ := api.s.chains[0].GetCurrentState()
 	from := getFromAddress(statedb)
 	createContractTx, _ := types.NewContractTransaction(from, big.NewInt(0), big.NewInt(1), 0, bytecode)
 	contractAddressByte := sendTx(t, api, statedb, createContractTx)
@@ -87,7 +93,7 @@ func Test_Call(t *testing.T) {
 	assert.Equal(t, err, nil)
 
 	// The origin statedb
-	statedbOri, err := api.s.chain.GetCurrentState()
+	statedbOri, err := api.s.chains[0].GetCurrentState()
 	assert.Equal(t, err, nil)
 
 	// get payload
@@ -100,7 +106,7 @@ func Test_Call(t *testing.T) {
 	assert.Equal(t, result["result"], "0x0000000000000000000000000000000000000000000000000000000000000005")
 
 	// It is no diffrence to the origin statedb
-	statedbCur, err := api.s.chain.GetCurrentState()
+	statedbCur, err := api.s.chains[0].GetCurrentState()
 	assert.Equal(t, err, nil)
 	assert.Equal(t, statedbOri, statedbCur)
 
@@ -115,7 +121,8 @@ func Test_Call(t *testing.T) {
 	assert.Equal(t, result["result"], "0x0000000000000000000000000000000000000000000000000000000000000017")
 
 	// Verify the history result = 5
-	height, err := api.GetBlockHeight()
+	chainNum := uint64(0)
+	height, err := api.GetBlockHeight(&chainNum)
 	assert.Equal(t, err, nil)
 	result, err = api.Call(contractAddress.ToHex(), payload, int64(height-1))
 	assert.Equal(t, err, nil)
@@ -128,6 +135,60 @@ func Test_Call(t *testing.T) {
 	assert.Equal(t, err == nil, false)
 }
 
+func Test_EstimateGas(t *testing.T) {
+	dbPath := filepath.Join(common.GetTempFolder(), ".EstimateGas")
+
+	api := newTestAPI(t, dbPath)
+	defer func() {
+		api.s.Stop()
+		os.RemoveAll(dbPath)
+	}()
+
+	// Create a contract/solidity/simple_storage.sol contract, get = 5
+	bytecode, _ := hexutil.HexToBytes("0x608060405234801561001057600080fd5b50600560008190555060df806100276000396000f3006080604052600436106049576000357c0100000000000000000000000000000000000000000000000000000000900463ffffffff16806360fe47b114604e5780636d4ce63c146078575b600080fd5b348015605957600080fd5b5060766004803603810190808035906020019092919050505060a0565b005b348015608357600080fd5b50608a60aa565b6040518082815260200191505060405180910390f35b8060008190555050565b600080549050905600a165627a7a723058207f6dc43a0d648e9f5a0cad5071cde46657de72eb87ab4cded53a7f1090f51e6d0029")
+	statedb, _ := api.s.chains[0].GetCurrentState()
+	from := getFromAddress(statedb)
+	createContractTx, _ := types.NewContractTransaction(from, big.NewInt(0), big.NewInt(1), 0, bytecode)
+	contractAddress := common.BytesToAddress(sendTx(t, api, statedb, createContractTx))
+
+	// transfer
+	result, err := api.EstimateGas(from, newTestChainAddress(0).ToHex(), "0x", big.NewInt(1), -1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result["usedGas"], types.IntrinsicGas(nil, false))
+
+	// cross chain transfer is simulated on the chain of from address
+	result, err = api.EstimateGas(from, newTestChainAddress(1).ToHex(), "0x", big.NewInt(1000), -1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result["failed"], false)
+
+	// set 0 clears the storage, and the refund is excluded from the used gas
+	payload := "0x60fe47b10000000000000000000000000000000000000000000000000000000000000000"
+	result, err = api.EstimateGas(from, contractAddress.ToHex(), payload, nil, -1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result["failed"], false)
+
+	msg, _ := hexutil.HexToBytes(payload)
+	receipt, _, err := api.simulate(from, contractAddress, big.NewInt(0), msg, types.MaxTxGasLimit, -1)
+	assert.Equal(t, err, nil)
+
+	gasLimit := result["usedGas"].(uint64)
+	assert.Equal(t, gasLimit > receipt.UsedGas, true)
+
+	// the estimated gas is the minimum gas limit
+	receipt, _, err = api.simulate(from, contractAddress, big.NewInt(0), msg, gasLimit, -1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, receipt.Failed, false)
+
+	receipt, _, err = api.simulate(from, contractAddress, big.NewInt(0), msg, gasLimit-1, -1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, receipt.Failed, true)
+
+	// the failed tx is not searched
+	result, err = api.EstimateGas(from, contractAddress.ToHex(), "0x01", nil, -1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result["failed"], true)
+}
+
 func Test_GetLogs(t *testing.T) {
 	dbPath := filepath.Join(common.GetTempFolder(), ".GetLogs")
 
@@ -139,7 +200,7 @@ func Test_GetLogs(t *testing.T) {
 
 	// Create a simple_storage_1 contract
 	bytecode, _ := hexutil.HexToBytes("0x6080604052601760005534801561001557600080fd5b5061025f806100256000396000f30060806040526004361061004c576000357c0100000000000000000000000000000000000000000000000000000000900463ffffffff16806360fe47b1146100515780636d4ce63c1461007e575b600080fd5b34801561005d57600080fd5b5061007c600480360381019080803590602001909291905050506100a9565b005b34801561008a57600080fd5b5061009361011b565b6040518082815260200191505060405180910390f35b7fe84bb31d4e9adbff26e80edeecb6cf8f3a95d1ba519cf60a08a6e6f8d62d81006040518080602001828103825260078152602001807f6765744c6f67320000000000000000000000000000000000000000000000000081525060200191505060405180910390a18060008190555050565b60007f978acaf30839c63aff19afed19ff8f3a430103773a67e3890aa1639af9a71bc433604051808273ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200180602001828103825260068152602001807f6765744c6f6700000000000000000000000000000000000000000000000000008152506020019250505060405180910390a17f523b2fb716b59c8e374bb3ea0f14ce672f9ac295b25470c403ad377306abb1026040518080602001828103825260078152602001807f6765744c6f67310000000000000000000000000000000000000000000000000081525060200191505060405180910390a161022b60106100a9565b6000549050905600a165627a7a72305820e12478ad92a5a4181935da97e24de739c4928ac47b2c5c1cd3423513298c62390029")
-	statedb, _ := api.s.chain.GetCurrentState()
+	statedb, _ := api.s.chains[0].GetCurrentState()
 	from := getFromAddress(statedb)
 	createContractTx, _ := types.NewContractTransaction(from, big.NewInt(0), big.NewInt(1), 0, bytecode)
 	contractAddressByte := sendTx(t, api, statedb, createContractTx)
@@ -148,7 +209,7 @@ func Test_GetLogs(t *testing.T) {
 	contractAddress, err := common.HexToAddress(contractAddressHex)
 	assert.Equal(t, err, nil)
 	// The origin statedb
-	statedbOri, err := api.s.chain.GetCurrentState()
+	statedbOri, err := api.s.chains[0].GetCurrentState()
 	assert.Equal(t, err, nil)
 
 	// Call the get function
@@ -157,33 +218,61 @@ func Test_GetLogs(t *testing.T) {
 	getTx, err := types.NewMessageTransaction(from, contractAddress, big.NewInt(0), big.NewInt(10), 1, msg)
 	assert.Equal(t, err, nil)
 
-	receipt, err := api.s.chain.ApplyTransaction(getTx, 0, api.s.miner.GetCoinbase(), statedbOri, api.s.chain.CurrentBlock().Header)
+	receipt, err := api.s.chains[0].ApplyTransaction(getTx, 0, api.s.miner.GetCoinbase(), statedbOri, api.s.chains[0].CurrentBlock().Header)
 	assert.Equal(t, err, nil)
 
 	// Save the statedb and receipts
+	receipts := []*types.Receipt{receipt}
 	batch := api.s.accountStateDB.NewBatch()
-	block := api.s.chain.CurrentBlock()
-	block.Header.StateHash, _ = statedb.Commit(batch)
+	block := api.s.chains[0].CurrentBlock()
+	block.Header.StateHash, _ = statedbOri.Commit(batch)
+	block.Header.LogBloom = types.CreateBloom(receipts)
+	block.Header.Height++
+	block.Header.PreviousBlockHash = block.HeaderHash
+	block.HeaderHash = block.Header.Hash()
+	api.s.chains[0].GetStore().PutBlock(block, big.NewInt(1), true)
+	api.s.chains[0].GetStore().PutReceipts(block.HeaderHash, receipts)
 	batch.Commit()
-	api.s.chain.GetStore().PutReceipts(block.HeaderHash, []*types.Receipt{receipt})
 
 	// Verify the result
-	payload := "0xe84bb31d4e9adbff26e80edeecb6cf8f3a95d1ba519cf60a08a6e6f8d62d8100"
-	result, err := api.GetLogs(-1, contractAddress.ToHex(), payload)
+	topic, err := common.HexToHash("0xe84bb31d4e9adbff26e80edeecb6cf8f3a95d1ba519cf60a08a6e6f8d62d8100")
+	assert.Equal(t, err, nil)
+	filter := LogFilter{
+		FromHeight: -1,
+		ToHeight:   -1,
+		Addresses:  []common.Address{contractAddress},
+		Topics:     [][]common.Hash{[]common.Hash{topic}},
+	}
+	result, err := api.GetLogs(filter)
 	assert.Equal(t, err, nil)
 	assert.Equal(t, len(result), 1)
-	assert.Equal(t, result[0].Txhash, receipt.TxHash)
+	assert.Equal(t, result[0].TxHash, receipt.TxHash)
 
 	addr := result[0].Log.Address
 	assert.Equal(t, addr, contractAddress)
 
 	name := result[0].Log.Topics
-	assert.Equal(t, name[0].ToHex(), payload)
+	assert.Equal(t, name[0], topic)
 
-	// Verify the invalid contractAddress and payload
-	result, err = api.GetLogs(-1, "contractAddress.ToHex()", payload)
+	// Verify the unmatched contractAddress and invalid chain number
+	filter.Addresses = []common.Address{*crypto.MustGenerateRandomAddress()}
+	result, err = api.GetLogs(filter)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(result), 0)
+
+	invalidChainNum := uint64(len(api.s.chains))
+	filter.ChainNum = &invalidChainNum
+	result, err = api.GetLogs(filter)
 	assert.Equal(t, err == nil, false)
-	result, err = api.GetLogs(-1, contractAddress.ToHex(), "payload")
+
+	// Verify the chain number is required if not decided by addresses
+	filter.ChainNum = nil
+	filter.Addresses = nil
+	result, err = api.GetLogs(filter)
+	assert.Equal(t, err, errChainNumRequired)
+
+	filter.Addresses = []common.Address{*newTestChainAddress(0), *newTestChainAddress(1)}
+	result, err = api.GetLogs(filter)
 	assert.Equal(t, err == nil, false)
 }
 
@@ -204,18 +293,18 @@ func newTestAPI(t *testing.T, dbPath string) *PublicSeeleAPI {
 }
 
 func sendTx(t *testing.T, api *PublicSeeleAPI, statedb *state.Statedb, tx *types.Transaction) []byte {
-	receipt, err := api.s.chain.ApplyTransaction(tx, 0, api.s.miner.GetCoinbase(), statedb, api.s.chain.CurrentBlock().Header)
+	receipt, err := api.s.chains[0].ApplyTransaction(tx, 0, api.s.miner.GetCoinbase(), statedb, api.s.chains[0].CurrentBlock().Header)
 	assert.Equal(t, err, nil)
 	assert.Equal(t, receipt.Failed, false)
 
 	// Save the statedb
 	batch := api.s.accountStateDB.NewBatch()
-	block := api.s.chain.CurrentBlock()
+	block := api.s.chains[0].CurrentBlock()
 	block.Header.StateHash, _ = statedb.Commit(batch)
 	block.Header.Height++
 	block.Header.PreviousBlockHash = block.HeaderHash
 	block.HeaderHash = block.Header.Hash()
-	api.s.chain.GetStore().PutBlock(block, big.NewInt(1), true)
+	api.s.chains[0].GetStore().PutBlock(block, big.NewInt(1), true)
 	batch.Commit()
 	return receipt.ContractAddress
 }
@@ -225,38 +314,54 @@ func callContract(t *testing.T, api *PublicSeeleAPI, contractAddress *common.Add
 	bytecode, err := hexutil.HexToBytes(payload)
 	assert.Equal(t, err, nil)
 
-	statedb, err := api.s.chain.GetCurrentState()
+	statedb, err := api.s.chains[0].GetCurrentState()
 	assert.Equal(t, err, nil)
 
 	from := getFromAddress(statedb)
 	callContractTx, err := types.NewMessageTransaction(from, *contractAddress, big.NewInt(0), big.NewInt(1), 0, bytecode)
 	assert.Equal(t, err, nil)
 
-	receipt, err := api.s.chain.ApplyTransaction(callContractTx, 0, api.s.miner.GetCoinbase(), statedb, api.s.chain.CurrentBlock().Header)
+	receipt, err := api.s.chains[0].ApplyTransaction(callContractTx, 0, api.s.miner.GetCoinbase(), statedb, api.s.chains[0].CurrentBlock().Header)
 	assert.Equal(t, err, nil)
 	assert.Equal(t, receipt.Failed, false)
 
 	// Save the statedb
 	batch := api.s.accountStateDB.NewBatch()
-	block := api.s.chain.CurrentBlock()
+	block := api.s.chains[0].CurrentBlock()
 	block.Header.StateHash, _ = statedb.Commit(batch)
 	block.Header.Height++
 	block.Header.PreviousBlockHash = block.HeaderHash
 	block.HeaderHash = block.Header.Hash()
-	api.s.chain.GetStore().PutBlock(block, big.NewInt(1), true)
+	api.s.chains[0].GetStore().PutBlock(block, big.NewInt(1), true)
 	batch.Commit()
 }
 
+// newTestChainAddress returns a random address of the specified chain in shard 1.
+func newTestChainAddress(chainNum uint64) *common.Address {
+	for {
+		addr := crypto.MustGenerateShardAddress(1)
+		if addr.GetChainNum(common.DefaultNumOfChains) == chainNum {
+			return addr
+		}
+	}
+}
+
+// getFromAddress returns a random account of the first chain with balance, and the
+// contract created by the account with nonce 0 is also in the first chain.
 func getFromAddress(statedb *state.Statedb) common.Address {
-	from := *crypto.MustGenerateRandomAddress()
+	from := *newTestChainAddress(0)
+	for contract := crypto.CreateAddress(from, 0); contract.GetChainNum(common.DefaultNumOfChains) != 0; contract = crypto.CreateAddress(from, 0) {
+		from = *newTestChainAddress(0)
+	}
+
 	statedb.CreateAccount(from)
 	statedb.SetBalance(from, common.SeeleToFan)
 	statedb.SetNonce(from, 0)
 	return from
 }
 
-func Test_GetBlocks(t *testing.T) {
-	dbPath := filepath.Join(common.GetTempFolder(), ".GetBlocks")
+func Test_GetBlockByHeight(t *testing.T) {
+	dbPath := filepath.Join(common.GetTempFolder(), ".GetBlockByHeight")
 
 	api := newTestAPI(t, dbPath)
 	defer func() {
@@ -265,44 +370,34 @@ func Test_GetBlocks(t *testing.T) {
 	}()
 
 	block0 := newTestBlock(0)
-	err := api.s.chain.GetStore().PutBlock(block0, block0.Header.Difficulty, true)
+	err := api.s.chains[0].GetStore().PutBlock(block0, block0.Header.Difficulty, true)
 	assert.Equal(t, err, nil)
 	block1 := newTestBlock(1)
-	err = api.s.chain.GetStore().PutBlock(block1, block1.Header.Difficulty, true)
+	err = api.s.chains[0].GetStore().PutBlock(block1, block1.Header.Difficulty, true)
 	assert.Equal(t, err, nil)
 	block2 := newTestBlock(2)
-	err = api.s.chain.GetStore().PutBlock(block2, block2.Header.Difficulty, true)
+	err = api.s.chains[0].GetStore().PutBlock(block2, block2.Header.Difficulty, true)
 	assert.Equal(t, err, nil)
 
-	result, err := api.GetBlocks(2, true, 2)
+	chainNum := uint64(0)
+	result, err := api.GetBlockByHeight(2, true, &chainNum)
 	assert.Equal(t, err, nil)
-	assert.Equal(t, len(result), 2)
-	assert.Equal(t, result[0]["hash"].(string), block2.Header.Hash().ToHex())
-	assert.Equal(t, result[1]["hash"].(string), block1.Header.Hash().ToHex())
-
-	result, err = api.GetBlocks(-1, true, 1)
-	assert.Equal(t, err == nil, true)
-	assert.Equal(t, len(result), 1)
-
-	result, err = api.GetBlocks(2, true, 6)
-	assert.Equal(t, err == nil, true)
-	assert.Equal(t, len(result), 3)
+	assert.Equal(t, result["hash"].(string), block2.Header.Hash().ToHex())
 
-	result, err = api.GetBlocks(2, true, 600)
-	assert.Equal(t, err == nil, true)
-	assert.Equal(t, len(result), 3)
+	result, err = api.GetBlockByHeight(1, true, &chainNum)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result["hash"].(string), block1.Header.Hash().ToHex())
 
-	result, err = api.GetBlocks(2, true, 0)
-	assert.Equal(t, err == nil, true)
-	assert.Equal(t, len(result), 0)
+	result, err = api.GetBlockByHeight(-1, true, &chainNum)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result["hash"].(string), api.s.chains[0].CurrentBlock().HeaderHash.ToHex())
 
-	result, err = api.GetBlocks(4, true, 2)
+	result, err = api.GetBlockByHeight(4, true, &chainNum)
 	assert.Equal(t, err != nil, true)
-	assert.Equal(t, len(result), 0)
 
-	result, err = api.GetBlocks(2, true, 3)
-	assert.Equal(t, err == nil, true)
-	assert.Equal(t, len(result), 3)
+	// chain number is required since the chain can't be decided by height
+	result, err = api.GetBlockByHeight(2, true, nil)
+	assert.Equal(t, err, errChainNumRequired)
 }
 
 func newTestBlock(height uint64) *types.Block {
@@ -348,7 +443,7 @@ func Test_GetBanlance(t *testing.T) {
 		os.RemoveAll(dbPath)
 	}()
 
-	statedb, err := api.s.chain.GetCurrentState()
+	statedb, err := api.s.chains[0].GetCurrentState()
 	assert.Equal(t, err, nil)
 
 	// set coinbase banlance
@@ -363,12 +458,12 @@ func Test_GetBanlance(t *testing.T) {
 
 	// save the statedb
 	batch := api.s.accountStateDB.NewBatch()
-	block := api.s.chain.CurrentBlock()
+	block := api.s.chains[0].CurrentBlock()
 	block.Header.StateHash, _ = statedb.Commit(batch)
 	block.Header.Height++
 	block.Header.PreviousBlockHash = block.HeaderHash
 	block.HeaderHash = block.Header.Hash()
-	api.s.chain.GetStore().PutBlock(block, big.NewInt(1), true)
+	api.s.chains[0].GetStore().PutBlock(block, big.NewInt(1), true)
 	batch.Commit()
 
 	// get EmptyAddress balance
@@ -390,3 +485,28 @@ func Test_GetBanlance(t *testing.T) {
 	assert.Equal(t, resp.Account, randomAcct1)
 	assert.Equal(t, resp.Balance, big.NewInt(0))
 }
+
+func Test_printableSimulatedReceipt(t *testing.T) {
+	receipt := &types.Receipt{
+		Result:  []byte("out of gas"),
+		Failed:  true,
+		UsedGas: 21000,
+	}
+
+	result, err := printableSimulatedReceipt(receipt, []byte{1, 2})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result["result"], "0x0102")
+	assert.Equal(t, result["error"], "out of gas")
+	assert.Equal(t, result["failed"], true)
+	assert.Equal(t, result["usedGas"], uint64(21000))
+
+	// logs are always returned even if empty
+	assert.Equal(t, result["logs"], make([]map[string]interface{}, 0))
+
+	receipt.Failed = false
+	receipt.Logs = []*types.Log{{Address: *crypto.MustGenerateRandomAddress(), Topics: []common.Hash{common.StringToHash("topic")}}}
+	result, err = printableSimulatedReceipt(receipt, nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(result["logs"].([]map[string]interface{})), 1)
+	assert.Equal(t, result["error"], nil)
+}
@@ -0,0 +1,84 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package seele
+
+import (
+	"context"
+	"fmt"
+
+	rpc "github.com/seeleteam/go-seele/rpc2"
+)
+
+// PublicSubscriptionAPI provides the real-time notifications of chain events,
+// which is only available on the connections that support notifications, e.g. websocket.
+type PublicSubscriptionAPI struct {
+	s *SeeleService
+}
+
+// NewPublicSubscriptionAPI creates a new PublicSubscriptionAPI object for rpc service.
+func NewPublicSubscriptionAPI(s *SeeleService) *PublicSubscriptionAPI {
+	return &PublicSubscriptionAPI{s}
+}
+
+// NewHeads subscribes the blocks added to or removed from the canonical chain.
+// If chainNum is not specified, blocks of all chains are notified.
+func (api *PublicSubscriptionAPI) NewHeads(ctx context.Context, chainNum *uint64) (*rpc.Subscription, error) {
+	return api.subscribe(ctx, &subscriber{typ: headsSubscription, chainNum: chainNum})
+}
+
+// NewPendingTransactions subscribes the transactions inserted into the tx pool.
+// If chainNum is not specified, transactions of all chains are notified.
+func (api *PublicSubscriptionAPI) NewPendingTransactions(ctx context.Context, chainNum *uint64) (*rpc.Subscription, error) {
+	return api.subscribe(ctx, &subscriber{typ: pendingTxsSubscription, chainNum: chainNum})
+}
+
+// Logs subscribes the logs that match the filter in the blocks added to or removed from
+// the canonical chain. The block heights of filter are ignored.
+// If chainNum of filter is not specified, logs of all chains are notified.
+func (api *PublicSubscriptionAPI) Logs(ctx context.Context, filter LogFilter) (*rpc.Subscription, error) {
+	return api.subscribe(ctx, &subscriber{typ: logsSubscription, chainNum: filter.ChainNum, filter: &filter})
+}
+
+// NewDebts subscribes the debts inserted into the debt pool.
+// If chainNum is not specified, debts of all chains are notified.
+func (api *PublicSubscriptionAPI) NewDebts(ctx context.Context, chainNum *uint64) (*rpc.Subscription, error) {
+	return api.subscribe(ctx, &subscriber{typ: debtsSubscription, chainNum: chainNum})
+}
+
+// subscribe creates a rpc subscription and forwards the notifications of subscriber
+// until the subscription is unsubscribed or the connection is closed.
+func (api *PublicSubscriptionAPI) subscribe(ctx context.Context, sub *subscriber) (*rpc.Subscription, error) {
+	if sub.chainNum != nil && *sub.chainNum >= uint64(len(api.s.chains)) {
+		return nil, fmt.Errorf("invalid chain number %d", *sub.chainNum)
+	}
+
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	api.s.events.subscribe(sub)
+
+	go func() {
+		defer api.s.events.unsubscribe(sub)
+
+		for {
+			select {
+			case data := <-sub.ch:
+				if err := notifier.Notify(rpcSub.ID, data); err != nil {
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
@@ -7,16 +7,21 @@ package seele
 
  import (
  	"errors"
-// 	"strconv"
+	"fmt"
+	"strconv"
 
  	"github.com/seeleteam/go-seele/common"
  	"github.com/seeleteam/go-seele/common/hexutil"
+	"github.com/seeleteam/go-seele/core/store"
  	"github.com/seeleteam/go-seele/core/types"
  )
 
  var (
  	errTransactionNotFound = errors.New("transaction not found")
-// 	errDebtNotFound        = errors.New("debt not found")
+	errDebtNotFound        = errors.New("debt not found")
+	errBlockNotFound       = errors.New("block not found")
+	errReceiptNotFound     = errors.New("receipt not found")
+	errChainNumRequired    = errors.New("chain number is required")
  )
 
  // TransactionPoolAPI provides an API to access transaction pool information.
@@ -30,17 +35,23 @@ package seele
  }
 
  // GetBlockTransactionCount returns the count of transactions in the block with the given block hash or height.
- func (api *TransactionPoolAPI) GetBlockTransactionCount(blockHash string, height int64) (int, error) {
+ func (api *TransactionPoolAPI) GetBlockTransactionCount(blockHash string, height int64, chainNum *uint64) (int, error) {
  	if len(blockHash) > 0 {
-		return api.GetBlockTransactionCountByHash(blockHash)
+		return api.GetBlockTransactionCountByHash(blockHash, chainNum)
  	}
 
- 	return api.GetBlockTransactionCountByHeight(height)
+ 	return api.GetBlockTransactionCountByHeight(height, chainNum)
  }
 
  // GetBlockTransactionCountByHeight returns the count of transactions in the block with the given height.
- func (api *TransactionPoolAPI) GetBlockTransactionCountByHeight(height int64) (int, error) {
- 	block, err := getBlock(api.s.chains[0], height)
+ // The chainNum is required if there are multiple chains.
+ func (api *TransactionPoolAPI) GetBlockTransactionCountByHeight(height int64, chainNum *uint64) (int, error) {
+	num, err := getChainNum(api.s, chainNum)
+	if err != nil {
+		return 0, err
+	}
+
+ 	block, err := getBlock(api.s.chains[num], height)
  	if err != nil {
  		return 0, err
  	}
@@ -49,176 +60,352 @@ package seele
  }
 
  // GetBlockTransactionCountByHash returns the count of transactions in the block with the given hash.
- func (api *TransactionPoolAPI) GetBlockTransactionCountByHash(blockHash string) (int, error) {
- 	store := api.s.chains[0].GetStore()
+ // All chains are searched if chainNum is not specified.
+ func (api *TransactionPoolAPI) GetBlockTransactionCountByHash(blockHash string, chainNum *uint64) (int, error) {
  	hashByte, err := hexutil.HexToBytes(blockHash)
  	if err != nil {
  		return 0, err
  	}
 
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return 0, err
+	}
+
  	hash := common.BytesToHash(hashByte)
- 	block, err := store.GetBlock(hash)
- 	if err != nil {
- 		return 0, err
- 	}
+	for _, num := range chainNums {
+		if block, err := api.s.chains[num].GetStore().GetBlock(hash); err == nil {
+			return len(block.Transactions), nil
+		}
+	}
 
- 	return len(block.Transactions), nil
+ 	return 0, errBlockNotFound
  }
 
-// // GetTransactionByBlockIndex returns the transaction in the block with the given block hash/height and index.
-// func (api *TransactionPoolAPI) GetTransactionByBlockIndex(hashHex string, height int64, index uint) (map[string]interface{}, error) {
-// 	if len(hashHex) > 0 {
-// 		return api.GetTransactionByBlockHashAndIndex(hashHex, index)
-// 	}
-
-// 	return api.GetTransactionByBlockHeightAndIndex(height, index)
-// }
-
-// // GetTransactionByBlockHeightAndIndex returns the transaction in the block with the given block height and index.
-// func (api *TransactionPoolAPI) GetTransactionByBlockHeightAndIndex(height int64, index uint) (map[string]interface{}, error) {
-// 	block, err := getBlock(api.s.chain, height)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	txs := block.Transactions
-// 	if index >= uint(len(txs)) {
-// 		return nil, errors.New("index out of block transaction list range, the max index is " + strconv.Itoa(len(txs)-1))
-// 	}
-
-// 	return PrintableOutputTx(txs[index]), nil
-// }
-
-// // GetTransactionByBlockHashAndIndex returns the transaction in the block with the given block hash and index.
-// func (api *TransactionPoolAPI) GetTransactionByBlockHashAndIndex(hashHex string, index uint) (map[string]interface{}, error) {
-// 	store := api.s.chain.GetStore()
-// 	hashByte, err := hexutil.HexToBytes(hashHex)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	hash := common.BytesToHash(hashByte)
-// 	block, err := store.GetBlock(hash)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	txs := block.Transactions
-// 	if index >= uint(len(txs)) {
-// 		return nil, errors.New("index out of block transaction list range, the max index is " + strconv.Itoa(len(txs)-1))
-// 	}
-
-// 	return PrintableOutputTx(txs[index]), nil
-// }
-
-// // GetReceiptByTxHash get receipt by transaction hash
-// func (api *TransactionPoolAPI) GetReceiptByTxHash(txHash string) (map[string]interface{}, error) {
-// 	hashByte, err := hexutil.HexToBytes(txHash)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	hash := common.BytesToHash(hashByte)
-
-// 	store := api.s.chain.GetStore()
-// 	receipt, err := store.GetReceiptByTxHash(hash)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	return PrintableReceipt(receipt)
-// }
+// GetTransactionByBlockIndex returns the transaction in the block with the given block hash/height and index.
+func (api *TransactionPoolAPI) GetTransactionByBlockIndex(hashHex string, height int64, index uint, chainNum *uint64) (map[string]interface{}, error) {
+	if len(hashHex) > 0 {
+		return api.GetTransactionByBlockHashAndIndex(hashHex, index, chainNum)
+	}
+
+	return api.GetTransactionByBlockHeightAndIndex(height, index, chainNum)
+}
+
+// GetTransactionByBlockHeightAndIndex returns the transaction in the block with the given block height and index.
+// The chainNum is required if there are multiple chains.
+func (api *TransactionPoolAPI) GetTransactionByBlockHeightAndIndex(height int64, index uint, chainNum *uint64) (map[string]interface{}, error) {
+	num, err := getChainNum(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := getBlock(api.s.chains[num], height)
+	if err != nil {
+		return nil, err
+	}
+
+	return printableBlockTx(block, index, num, api.s.numOfChains)
+}
+
+// GetTransactionByBlockHashAndIndex returns the transaction in the block with the given block hash and index.
+// All chains are searched if chainNum is not specified.
+func (api *TransactionPoolAPI) GetTransactionByBlockHashAndIndex(hashHex string, index uint, chainNum *uint64) (map[string]interface{}, error) {
+	hashByte, err := hexutil.HexToBytes(hashHex)
+	if err != nil {
+		return nil, err
+	}
+
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := common.BytesToHash(hashByte)
+	for _, num := range chainNums {
+		if block, err := api.s.chains[num].GetStore().GetBlock(hash); err == nil {
+			return printableBlockTx(block, index, num, api.s.numOfChains)
+		}
+	}
+
+	return nil, errBlockNotFound
+}
+
+// GetReceiptByTxHash get receipt by transaction hash, including the block and chain that the transaction is packed in.
+// All chains are searched if chainNum is not specified.
+func (api *TransactionPoolAPI) GetReceiptByTxHash(txHash string, chainNum *uint64) (map[string]interface{}, error) {
+	hashByte, err := hexutil.HexToBytes(txHash)
+	if err != nil {
+		return nil, err
+	}
+	hash := common.BytesToHash(hashByte)
+
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, num := range chainNums {
+		store := api.s.chains[num].GetStore()
+		txIndex, err := store.GetTxIndex(hash)
+		if err != nil {
+			continue
+		}
+
+		receipt, err := store.GetReceiptByTxHash(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		output, err := PrintableReceipt(receipt)
+		if err != nil {
+			return nil, err
+		}
+
+		header, err := store.GetBlockHeader(txIndex.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+
+		output["chainNum"] = num
+		output["blockHash"] = txIndex.BlockHash.ToHex()
+		output["blockHeight"] = header.Height
+		output["txIndex"] = txIndex.Index
+
+		return output, nil
+	}
+
+	return nil, errReceiptNotFound
+}
 
  // GetTransactionByHash returns the transaction by the given transaction hash.
- func (api *TransactionPoolAPI) GetTransactionByHash(txHash string) (map[string]interface{}, error) {
- 	store := api.s.chains[0].GetStore()
+ // All chains are searched if chainNum is not specified.
+ func (api *TransactionPoolAPI) GetTransactionByHash(txHash string, chainNum *uint64) (map[string]interface{}, error) {
  	hashByte, err := hexutil.HexToBytes(txHash)
  	if err != nil {
  		return nil, err
  	}
  	hash := common.BytesToHash(hashByte)
 
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
  	output := make(map[string]interface{})
 
 	// Try to get transaction in txpool
-	txpools := api.s.TxPool()
- 	tx := txpools[0].GetTransaction(hash)
- 	if tx != nil {
- 		addTxInfo(output, tx)
- 		output["status"] = "pool"
+	for _, num := range chainNums {
+		if tx := api.s.txPools[num].GetTransaction(hash); tx != nil {
+			addTxInfo(output, tx, api.s.numOfChains)
+			output["status"] = "pool"
+			output["chainNum"] = num
 
- 		return output, nil
- 	}
+			return output, nil
+		}
+	}
 
  	// Try to get finalized transaction
- 	txIndex, err := store.GetTxIndex(hash)
- 	if err != nil {
- 		api.s.log.Info(err.Error())
- 		return nil, errTransactionNotFound
- 	}
+	for _, num := range chainNums {
+		store := api.s.chains[num].GetStore()
+		txIndex, err := store.GetTxIndex(hash)
+		if err != nil {
+			continue
+		}
 
- 	if txIndex != nil {
  		block, err := store.GetBlock(txIndex.BlockHash)
  		if err != nil {
  			return nil, err
  		}
 
- 		addTxInfo(output, block.Transactions[txIndex.Index])
- 		output["status"] = "block"
- 		output["blockHash"] = block.HeaderHash.ToHex()
- 		output["blockHeight"] = block.Header.Height
- 		output["txIndex"] = txIndex.Index
-
- 		return output, nil
+		return printableBlockTx(block, txIndex.Index, num, api.s.numOfChains)
  	}
 
- 	return nil, nil
+	return nil, errTransactionNotFound
  }
 
- func addTxInfo(output map[string]interface{}, tx *types.Transaction) {
+ func addTxInfo(output map[string]interface{}, tx *types.Transaction, numOfChains uint64) {
  	output["transaction"] = PrintableOutputTx(tx)
- 	debt := types.NewDebt(tx)
+ 	debt := types.NewDebt(tx, numOfChains)
  	if debt != nil {
  		output["debt"] = debt
  	}
  }
 
-// // GetDebtByHash return the debt info by debt hash
-// func (api *TransactionPoolAPI) GetDebtByHash(debtHash string) (map[string]interface{}, error) {
-// 	hashByte, err := hexutil.HexToBytes(debtHash)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	hash := common.BytesToHash(hashByte)
-
-// 	output := make(map[string]interface{})
-// 	debt := api.s.DebtPool().GetDebtByHash(hash)
-// 	if debt != nil {
-// 		output["debt"] = debt
-// 		output["status"] = "pool"
-
-// 		return output, nil
-// 	}
-
-// 	store := api.s.chain.GetStore()
-// 	debtIndex, err := store.GetDebtIndex(hash)
-// 	if err != nil {
-// 		api.s.log.Info(err.Error())
-// 		return nil, errDebtNotFound
-// 	}
-
-// 	if debtIndex != nil {
-// 		block, err := store.GetBlock(debtIndex.BlockHash)
-// 		if err != nil {
-// 			return nil, err
-// 		}
-
-// 		output["debt"] = block.Debts[debtIndex.Index]
-// 		output["status"] = "block"
-// 		output["blockHash"] = block.HeaderHash.ToHex()
-// 		output["blockHeight"] = block.Header.Height
-// 		output["debtIndex"] = debtIndex
-
-// 		return output, nil
-// 	}
-
-// 	return nil, nil
-// }
+// printableBlockTx converts the transaction at the specified index in block to the RPC output.
+func printableBlockTx(block *types.Block, index uint, chainNum, numOfChains uint64) (map[string]interface{}, error) {
+	txs := block.Transactions
+	if index >= uint(len(txs)) {
+		return nil, errors.New("index out of block transaction list range, the max index is " + strconv.Itoa(len(txs)-1))
+	}
+
+	output := make(map[string]interface{})
+	addTxInfo(output, txs[index], numOfChains)
+	output["status"] = "block"
+	output["chainNum"] = chainNum
+	output["blockHash"] = block.HeaderHash.ToHex()
+	output["blockHeight"] = block.Header.Height
+	output["txIndex"] = index
+
+	return output, nil
+}
+
+// addDebtInfo adds the debt info into output, including the source transaction and the target chain.
+func (api *TransactionPoolAPI) addDebtInfo(output map[string]interface{}, debt *types.Debt, status types.DebtStatus) {
+	output["debt"] = debt
+	output["status"] = status.String()
+	output["applied"] = status == types.DebtStatusApplied
+	output["targetChainNum"] = debt.Data.ChainNum
+	output["sourceTxHash"] = debt.Data.TxHash.ToHex()
+
+	stores := make([]store.BlockchainStore, len(api.s.chains))
+	for i, chain := range api.s.chains {
+		stores[i] = chain.GetStore()
+	}
+
+	addDebtSourceInfo(output, debt.Data.TxHash, stores)
+}
+
+// addDebtSourceInfo adds the source block of the debt into output. The source chain is the
+// first one that has both the index of the source transaction and the indexed block header.
+func addDebtSourceInfo(output map[string]interface{}, txHash common.Hash, stores []store.BlockchainStore) {
+	for num, bcStore := range stores {
+		txIndex, err := bcStore.GetTxIndex(txHash)
+		if err != nil {
+			continue
+		}
+
+		header, err := bcStore.GetBlockHeader(txIndex.BlockHash)
+		if err != nil {
+			continue
+		}
+
+		output["sourceChainNum"] = num
+		output["sourceBlockHash"] = txIndex.BlockHash.ToHex()
+		output["sourceBlockHeight"] = header.Height
+
+		return
+	}
+}
+
+// GetDebtByHash return the debt info by debt hash, including the debt status
+// (created, pending, applied or reverted) on its target chain and the source transaction.
+// All chains are searched if chainNum is not specified.
+func (api *TransactionPoolAPI) GetDebtByHash(debtHash string, chainNum *uint64) (map[string]interface{}, error) {
+	hashByte, err := hexutil.HexToBytes(debtHash)
+	if err != nil {
+		return nil, err
+	}
+	hash := common.BytesToHash(hashByte)
+
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make(map[string]interface{})
+	for _, num := range chainNums {
+		if record := api.s.debtPools[num].GetDebtRecord(hash); record != nil {
+			api.addDebtInfo(output, record.Debt, record.Status)
+			output["chainNum"] = num
+
+			return output, nil
+		}
+	}
+
+	for _, num := range chainNums {
+		store := api.s.chains[num].GetStore()
+		debtIndex, err := store.GetDebtIndex(hash)
+		if err != nil {
+			continue
+		}
+
+		block, err := store.GetBlock(debtIndex.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+
+		if uint(len(block.Debts)) <= debtIndex.Index {
+			return nil, errDebtNotFound
+		}
+
+		api.addDebtInfo(output, block.Debts[debtIndex.Index], types.DebtStatusApplied)
+		output["chainNum"] = num
+		output["blockHash"] = block.HeaderHash.ToHex()
+		output["blockHeight"] = block.Header.Height
+		output["debtIndex"] = debtIndex.Index
+
+		return output, nil
+	}
+
+	return nil, errDebtNotFound
+}
+
+// Content returns the pending and queued transactions in the pool of the specified chain,
+// or all chains if chainNum is not specified. Transactions are grouped by account and nonce.
+// Pending transactions are executable, including the ones being processed by miner, while
+// queued transactions are waiting for the nonce gap to be filled.
+func (api *TransactionPoolAPI) Content(chainNum *uint64) (map[string]map[string]map[string]map[string]interface{}, error) {
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	content := map[string]map[string]map[string]map[string]interface{}{
+		"pending": make(map[string]map[string]map[string]interface{}),
+		"queued":  make(map[string]map[string]map[string]interface{}),
+	}
+
+	api.visitPoolTxs(chainNums, func(set string, num uint64, tx *types.Transaction) {
+		account := tx.Data.From.ToHex()
+		if content[set][account] == nil {
+			content[set][account] = make(map[string]map[string]interface{})
+		}
+
+		content[set][account][strconv.FormatUint(tx.Data.AccountNonce, 10)] = printableOutputPoolTx(tx, num)
+	})
+
+	return content, nil
+}
+
+// Inspect returns the summary of pending and queued transactions in the pool of the specified chain,
+// or all chains if chainNum is not specified, which is grouped by account and nonce like Content.
+func (api *TransactionPoolAPI) Inspect(chainNum *uint64) (map[string]map[string]map[string]string, error) {
+	chainNums, err := getChainNums(api.s, chainNum)
+	if err != nil {
+		return nil, err
+	}
+
+	content := map[string]map[string]map[string]string{
+		"pending": make(map[string]map[string]string),
+		"queued":  make(map[string]map[string]string),
+	}
+
+	api.visitPoolTxs(chainNums, func(set string, num uint64, tx *types.Transaction) {
+		account := tx.Data.From.ToHex()
+		if content[set][account] == nil {
+			content[set][account] = make(map[string]string)
+		}
+
+		to := "contract creation"
+		if !tx.Data.To.IsEmpty() {
+			to = tx.Data.To.ToHex()
+		}
+
+		content[set][account][strconv.FormatUint(tx.Data.AccountNonce, 10)] = fmt.Sprintf("%s: %v amount + %v fee, chain %d", to, tx.Data.Amount, tx.Data.Fee, num)
+	})
+
+	return content, nil
+}
+
+// visitPoolTxs calls the visit function with the pending and queued transactions in pools of the specified chains.
+func (api *TransactionPoolAPI) visitPoolTxs(chainNums []uint64, visit func(set string, chainNum uint64, tx *types.Transaction)) {
+	for _, num := range chainNums {
+		for _, tx := range api.s.txPools[num].GetTransactions(true, true) {
+			visit("pending", num, tx)
+		}
+
+		for _, tx := range api.s.txPools[num].GetQueuedTransactions() {
+			visit("queued", num, tx)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/common/hexutil"
 	"github.com/seeleteam/go-seele/core/state"
+	"github.com/seeleteam/go-seele/core/store"
 	"github.com/seeleteam/go-seele/core/types"
 	"github.com/seeleteam/go-seele/crypto"
 	"github.com/seeleteam/go-seele/log"
@@ -27,28 +28,28 @@ func Test_GetTxByHash(t *testing.T) {
 
 	// add tx
 	tx1 := newTestTx(t, api.s, 1, 2, 1)
-	err := api.s.txPool.AddTransaction(tx1)
+	err := api.s.txPools[0].AddTransaction(tx1)
 	assert.Equal(t, err, nil)
 
 	// verify pool tx
 	poolAPI := NewTransactionPoolAPI(api.s)
-	outputs, err := poolAPI.GetTransactionByHash(tx1.Hash.ToHex())
+	outputs, err := poolAPI.GetTransactionByHash(tx1.Hash.ToHex(), nil)
 	assert.Equal(t, err, nil)
 	assert.Equal(t, tx1.Hash.ToHex(), outputs["transaction"].(map[string]interface{})["hash"].(string))
 	assert.Equal(t, outputs["status"], "pool")
 
 	// save tx to block
-	block := api.s.chain.CurrentBlock()
+	block := api.s.chains[0].CurrentBlock()
 	block.Header.Height++
 	block.Header.PreviousBlockHash = block.HeaderHash
 	block.Transactions = []*types.Transaction{tx1}
 	block.HeaderHash = block.Header.Hash()
-	err = api.s.chain.GetStore().PutBlock(block, block.Header.Difficulty, true)
+	err = api.s.chains[0].GetStore().PutBlock(block, block.Header.Difficulty, true)
 	assert.Equal(t, err, nil)
 
 	// verify block tx
-	poolAPI.s.txPool.RemoveTransaction(tx1.Hash)
-	outputs, err = poolAPI.GetTransactionByHash(tx1.Hash.ToHex())
+	poolAPI.s.txPools[0].RemoveTransaction(tx1.Hash)
+	outputs, err = poolAPI.GetTransactionByHash(tx1.Hash.ToHex(), nil)
 	assert.Equal(t, err, nil)
 	assert.Equal(t, outputs["transaction"].(map[string]interface{})["hash"].(string), tx1.Hash.ToHex())
 	assert.Equal(t, outputs["status"], "block")
@@ -86,19 +87,19 @@ func Test_GetReceiptByHash(t *testing.T) {
 			TotalFee:  120,
 		},
 	}
-	block := api.s.chain.CurrentBlock()
+	block := api.s.chains[0].CurrentBlock()
 	block.Header.Height++
 	block.Header.PreviousBlockHash = block.HeaderHash
 	block.Transactions = []*types.Transaction{tx1}
 	block.HeaderHash = block.Header.Hash()
-	err := api.s.chain.GetStore().PutBlock(block, block.Header.Difficulty, true)
+	err := api.s.chains[0].GetStore().PutBlock(block, block.Header.Difficulty, true)
 	assert.Equal(t, err, nil)
-	err = api.s.chain.GetStore().PutReceipts(block.HeaderHash, receipts)
+	err = api.s.chains[0].GetStore().PutReceipts(block.HeaderHash, receipts)
 	assert.Equal(t, err, nil)
 
 	// verify block receipt
 	poolAPI := NewTransactionPoolAPI(api.s)
-	outputs, err := poolAPI.GetReceiptByTxHash(tx1.Hash.ToHex())
+	outputs, err := poolAPI.GetReceiptByTxHash(tx1.Hash.ToHex(), nil)
 	assert.Equal(t, err, nil)
 	assert.Equal(t, outputs["result"], hexutil.BytesToHex(receipts[0].Result))
 	assert.Equal(t, outputs["failed"], false)
@@ -109,12 +110,16 @@ func Test_GetReceiptByHash(t *testing.T) {
 }
 
 func newTestTx(t *testing.T, s *SeeleService, amount, fee int64, nonce uint64) *types.Transaction {
-	statedb, err := s.chain.GetCurrentState()
+	statedb, err := s.chains[0].GetCurrentState()
 	assert.Equal(t, err, nil)
 
 	// set initial balance
 	fromAddress, fromPrivKey, err := crypto.GenerateKeyPair()
 	assert.Equal(t, err, nil)
+	for fromAddress.GetChainNum(common.DefaultNumOfChains) != 0 {
+		fromAddress, fromPrivKey, err = crypto.GenerateKeyPair()
+		assert.Equal(t, err, nil)
+	}
 	statedb.CreateAccount(*fromAddress)
 	statedb.SetBalance(*fromAddress, common.SeeleToFan)
 	statedb.SetNonce(*fromAddress, nonce-1)
@@ -123,6 +128,9 @@ func newTestTx(t *testing.T, s *SeeleService, amount, fee int64, nonce uint64) *
 	assert.Equal(t, err, nil)
 
 	toAddress := crypto.MustGenerateShardAddress(fromAddress.Shard())
+	for toAddress.GetChainNum(common.DefaultNumOfChains) != 0 {
+		toAddress = crypto.MustGenerateShardAddress(fromAddress.Shard())
+	}
 
 	tx, err := types.NewTransaction(*fromAddress, *toAddress, big.NewInt(amount), big.NewInt(fee), nonce)
 	assert.Equal(t, err, nil)
@@ -133,12 +141,12 @@ func newTestTx(t *testing.T, s *SeeleService, amount, fee int64, nonce uint64) *
 
 func storeStatedb(t *testing.T, s *SeeleService, statedb *state.Statedb) error {
 	batch := s.accountStateDB.NewBatch()
-	block := s.chain.CurrentBlock()
+	block := s.chains[0].CurrentBlock()
 	block.Header.StateHash, _ = statedb.Commit(batch)
 	block.Header.Height++
 	block.Header.PreviousBlockHash = block.HeaderHash
 	block.HeaderHash = block.Header.Hash()
-	s.chain.GetStore().PutBlock(block, big.NewInt(1), true)
+	s.chains[0].GetStore().PutBlock(block, big.NewInt(1), true)
 	return batch.Commit()
 }
 
@@ -157,3 +165,26 @@ func newTestTxPoolAPI(t *testing.T, dbPath string) *TransactionPoolAPI {
 
 	return NewTransactionPoolAPI(ss)
 }
+
+func Test_addDebtSourceInfo(t *testing.T) {
+	block := newTestBlock(3)
+	txHash := block.Transactions[0].Hash
+
+	// the tx index of chain 0 refers to a block that doesn't exist
+	staleStore := store.NewMemStore()
+	staleStore.TxLookups[txHash] = types.TxIndex{BlockHash: common.StringToHash("stale block")}
+
+	sourceStore := store.NewMemStore()
+	assert.Equal(t, sourceStore.PutBlock(block, big.NewInt(1), true), nil)
+
+	output := make(map[string]interface{})
+	addDebtSourceInfo(output, txHash, []store.BlockchainStore{store.NewMemStore(), staleStore, sourceStore})
+	assert.Equal(t, output["sourceChainNum"], 2)
+	assert.Equal(t, output["sourceBlockHash"], block.HeaderHash.ToHex())
+	assert.Equal(t, output["sourceBlockHeight"], uint64(3))
+
+	// source not found
+	output = make(map[string]interface{})
+	addDebtSourceInfo(output, txHash, []store.BlockchainStore{staleStore})
+	assert.Equal(t, len(output), 0)
+}
@@ -17,8 +17,14 @@ const (
 	// SeeleProtoName protoName of Seele service
 	SeeleProtoName = "seele"
 
+	// SeeleVersion1 is the initial version of Seele protocol
+	SeeleVersion1 uint = 1
+
+	// SeeleVersion2 adds the node data messages to synchronise the state snapshot
+	SeeleVersion2 uint = 2
+
 	// SeeleVersion Version number of Seele protocol
-	SeeleVersion uint = 1
+	SeeleVersion = SeeleVersion2
 
 	// BlockChainDir blockchain data directory based on config.DataRoot
 	BlockChainDir = "/db/blockchain"
@@ -32,9 +38,15 @@ const (
 
 	// BlockChainRecoveryPointFile is used to store the recovery point info of blockchain.
 	BlockChainRecoveryPointFile = "recoveryPoint.json"
+)
 
-	// number of chains
-	NumOfChains = 3
+// scores of peer behaviours, and the peer is disconnected and banned if its score falls below p2p.BanScoreThreshold.
+const (
+	scoreUsefulData   = 1   // delivered a new block or the requested data
+	scoreTimeout      = -10 // not responded to the request in time
+	scoreMalformedMsg = -20 // sent a message that could not be decoded
+	scoreInvalidTx    = -20 // sent a transaction with invalid signature or fields
+	scoreInvalidData  = -50 // sent an invalid block or the mismatched data
 )
 
 // statusData the structure for peers to exchange status
@@ -46,6 +58,7 @@ type statusData struct {
 	GenesisBlock    common.Hash
 	Shard           uint
 	Difficult       uint64
+	NumOfChains     uint64
 }
 
 // blockHeadersQuery represents a block header query.
@@ -67,6 +80,12 @@ type blocksQuery struct {
 	ChainNum uint64
 }
 
+// nodeDataQuery represents a query of trie nodes of account state.
+type nodeDataQuery struct {
+	Magic  uint32        // Magic number for request
+	Hashes []common.Hash // Hashes of trie nodes to retrieve
+}
+
 // newBlockHash is the network packet for the block announcements.
 type newBlockHash struct {
 	Hash   common.Hash
@@ -27,7 +27,7 @@ type SyncInfo struct {
 // GetStatus gets the SyncInfo.
  func (api *PrivatedownloaderAPI) GetStatus(input interface{}, result *map[string]interface{}) error {
  	var info SyncInfo
- 	//api.d.getSyncInfo(&info)
+ 	api.d.getSyncInfo(&info)
 
  	*result = map[string]interface{}{
  		"status":     info.Status,
@@ -7,7 +7,7 @@ package downloader
 
 import (
 	"errors"
-	//"fmt"
+	"fmt"
 	"math/big"
 	rand2 "math/rand"
 	"sync"
@@ -32,9 +32,10 @@ const (
 	BlocksPreMsg uint16 = 11
 	// BlocksMsg message type for delivering blocks
 	BlocksMsg uint16 = 12
-
-	// number of chains
-	NumOfChains = 3
+	// GetNodeDataMsg message type for getting trie nodes of account state
+	GetNodeDataMsg uint16 = 14
+	// NodeDataMsg message type for delivering trie nodes of account state
+	NodeDataMsg uint16 = 15
 )
 
 // CodeToStr message code -> message string
@@ -50,6 +51,10 @@ func CodeToStr(code uint16) string {
 		return "downloader.BlocksPreMsg"
 	case BlocksMsg:
 		return "downloader.BlocksMsg"
+	case GetNodeDataMsg:
+		return "downloader.GetNodeDataMsg"
+	case NodeDataMsg:
+		return "downloader.NodeDataMsg"
 	default:
 		return "unknown"
 	}
@@ -60,6 +65,8 @@ var (
 	MaxBlockFetch = 10
 	// MaxHeaderFetch amount of block headers to be fetched per retrieval request
 	MaxHeaderFetch = 256
+	// MaxNodeDataFetch amount of trie nodes to be fetched per retrieval request
+	MaxNodeDataFetch = 384
 
 	// MaxForkAncestry maximum chain reorganisation
 	MaxForkAncestry = 90000
@@ -86,6 +93,23 @@ var (
 	errSyncErr         = errors.New("Err occurs when syncing")
 )
 
+// PeerEvent is the outcome of the data requested from a peer, which is reported to score the peer.
+type PeerEvent int
+
+const (
+	// PeerEventUsefulData indicates the peer delivered the requested data.
+	PeerEventUsefulData PeerEvent = iota
+
+	// PeerEventTimeout indicates the peer did not respond to the request in time.
+	PeerEventTimeout
+
+	// PeerEventInvalidData indicates the peer delivered the invalid data, e.g. invalid blocks or mismatched headers.
+	PeerEventInvalidData
+)
+
+// PeerEventHook is called when an outcome of the requests to peer occurs.
+type PeerEventHook func(peerID string, event PeerEvent)
+
 // Downloader sync block chain with remote peer
 type Downloader struct {
 	cancelCh   chan struct{}        // Cancel current synchronising session
@@ -93,12 +117,14 @@ type Downloader struct {
 	peers      map[string]*peerConn // peers map. peerID=>peer
 
 	syncStatus int
-	tm         [NumOfChains]*taskMgr
+	tm         []*taskMgr
 
-	chain     [NumOfChains]*core.Blockchain
+	chain     []*core.Blockchain
 	sessionWG sync.WaitGroup
 	log       *log.SeeleLog
 	lock      sync.RWMutex
+
+	peerEventHook PeerEventHook
 }
 
 // BlockHeadersMsgBody represents a message struct for BlockHeadersMsg
@@ -115,11 +141,18 @@ type BlocksMsgBody struct {
 	ChainNum uint64
 }
 
+// NodeDataMsgBody represents a message struct for NodeDataMsg
+type NodeDataMsgBody struct {
+	Magic uint32
+	Nodes [][]byte
+}
+
 // NewDownloader create Downloader
-func NewDownloader(chain [NumOfChains]*core.Blockchain) *Downloader {
+func NewDownloader(chain []*core.Blockchain) *Downloader {
 	d := &Downloader{
 		cancelCh:   make(chan struct{}),
 		peers:      make(map[string]*peerConn),
+		tm:         make([]*taskMgr, len(chain)),
 		chain:      chain,
 		syncStatus: statusNone,
 	}
@@ -128,6 +161,27 @@ func NewDownloader(chain [NumOfChains]*core.Blockchain) *Downloader {
 	return d
 }
 
+// SetHookForPeerEvent sets the hook to report the outcomes of the requests to peers.
+func (d *Downloader) SetHookForPeerEvent(hook PeerEventHook) {
+	d.peerEventHook = hook
+}
+
+func (d *Downloader) reportPeer(peerID string, event PeerEvent) {
+	if d.peerEventHook != nil {
+		d.peerEventHook(peerID, event)
+	}
+}
+
+// reportPeerErr reports the error caused by peer, and the other errors are ignored.
+func (d *Downloader) reportPeerErr(peerID string, err error) {
+	switch err {
+	case errWaitMsgTimeout:
+		d.reportPeer(peerID, PeerEventTimeout)
+	case errInvalidPacketReceived, errHashNotMatch, errMasterHeadersNotMatch:
+		d.reportPeer(peerID, PeerEventInvalidData)
+	}
+}
+
 func (d *Downloader) getReadableStatus() string {
 	var status string
 
@@ -145,21 +199,28 @@ func (d *Downloader) getReadableStatus() string {
 	return status
 }
 
-// getSyncInfo gets sync information of the current session.
-// func (d *Downloader) getSyncInfo(info *SyncInfo) {
-// 	d.lock.RLock()
-// 	defer d.lock.RUnlock()
+// getSyncInfo gets sync information of the current session, which synchronises one chain at a time.
+func (d *Downloader) getSyncInfo(info *SyncInfo) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 
-// 	info.Status = d.getReadableStatus()
-// 	if d.syncStatus != statusFetching {
-// 		return
-// 	}
+	info.Status = d.getReadableStatus()
+	if d.syncStatus != statusFetching {
+		return
+	}
 
-// 	info.Duration = fmt.Sprintf("%.2f", time.Now().Sub(d.tm.startTime).Seconds())
-// 	info.StartNum = d.tm.fromNo
-// 	info.Amount = d.tm.toNo - d.tm.fromNo + 1
-// 	info.Downloaded = d.tm.downloadedNum
-// }
+	for _, tm := range d.tm {
+		if tm == nil {
+			continue
+		}
+
+		info.Duration = fmt.Sprintf("%.2f", time.Now().Sub(tm.startTime).Seconds())
+		info.StartNum = tm.fromNo
+		info.Amount = tm.toNo - tm.fromNo + 1
+		info.Downloaded = tm.downloadedNum
+		return
+	}
+}
 
 // Synchronise try to sync with remote peer.
 func (d *Downloader) Synchronise(id string, chainNum uint64, head common.Hash, td *big.Int, localTD *big.Int) error {
@@ -184,6 +245,7 @@ func (d *Downloader) Synchronise(id string, chainNum uint64, head common.Hash, t
 	d.lock.Unlock()
 
 	err := d.doSynchronise(p, chainNum, head, td, localTD)
+	d.reportPeerErr(id, err)
 
 	d.lock.Lock()
 	d.syncStatus = statusNone
@@ -367,7 +429,7 @@ func (d *Downloader) RegisterPeer(peerID string, peer Peer) {
 	d.peers[peerID] = newConn
 
 	if d.syncStatus == statusFetching {
-		for i := 0; i < NumOfChains; i++ {
+		for i := range d.tm {
 			d.sessionWG.Add(1)
 			go d.peerDownload(newConn, d.tm[i])
 		}
@@ -443,6 +505,7 @@ outLoop:
 			msg, err := conn.waitMsg(magic, BlockHeadersMsg, d.cancelCh)
 			if err != nil {
 				d.log.Warn("peerDownload waitMsg BlockHeadersMsg err! %s", err)
+				d.reportPeerErr(peerID, err)
 				break
 			}
 
@@ -457,6 +520,7 @@ outLoop:
 
 			if err = tm.deliverHeaderMsg(peerID, headers); err != nil {
 				d.log.Warn("peerDownload deliverHeaderMsg err! %s", err)
+				d.reportPeerErr(peerID, err)
 				break
 			}
 
@@ -477,6 +541,7 @@ outLoop:
 			msg, err := conn.waitMsg(magic, BlocksMsg, d.cancelCh)
 			if err != nil {
 				d.log.Warn("peerDownload waitMsg BlocksMsg err! %s", err)
+				d.reportPeerErr(peerID, err)
 				break
 			}
 
@@ -490,6 +555,9 @@ outLoop:
 			d.log.Debug("got blocks message length %d. start %d, end %d, chainNum: %d", len(blocks), startHeight, endHeight, tm.chainNum)
 
 			tm.deliverBlockMsg(peerID, blocks)
+			if len(blocks) > 0 {
+				d.reportPeer(peerID, PeerEventUsefulData)
+			}
 			d.log.Debug("get request blocks success, chainNum: %d", tm.chainNum)
 		}
 
@@ -526,6 +594,12 @@ func (d *Downloader) processBlocks(headInfos []*downloadInfo, chainNum uint64) {
 
 		if err := d.chain[chainNum].WriteBlock(h.block); err != nil && err != core.ErrBlockAlreadyExists {
 			d.log.Error("failed to write block:%s", err)
+
+			// the block will be synchronized again after the history it refers to is known
+			if !core.IsBlockPending(err) {
+				d.reportPeer(h.peerID, PeerEventInvalidData)
+			}
+
 			d.Cancel()
 			break
 		}
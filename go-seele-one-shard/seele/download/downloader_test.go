@@ -19,6 +19,7 @@ import (
 	"github.com/seeleteam/go-seele/crypto"
 	"github.com/seeleteam/go-seele/database"
 	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/seeleteam/go-seele/miner/pow/powtest"
 	"github.com/seeleteam/go-seele/p2p"
 	"github.com/stretchr/testify/assert"
 )
@@ -74,13 +75,14 @@ func newTestBlock(t *testing.T, parentHash common.Hash, height uint64, db databa
 
 	header := &types.BlockHeader{
 		PreviousBlockHash: parentHash,
-		Creator:           *crypto.MustGenerateRandomAddress(),
+		Creator:           powtest.Creator,
 		StateHash:         stateHash,
 		TxHash:            types.MerkleRootHash(txs),
 		Height:            height,
 		Difficulty:        big.NewInt(difficulty),
 		CreateTimestamp:   big.NewInt(1),
 		Nonce:             10,
+		MiningData:        powtest.NewMiningData(0),
 	}
 
 	return &types.Block{
@@ -98,7 +100,7 @@ func newTestBlockchain(db database.Database) *core.Blockchain {
 		panic(err)
 	}
 
-	bc, err := core.NewBlockchain(bcStore, db, "")
+	bc, err := core.NewBlockchain(bcStore, db, "", 0, common.DefaultNumOfChains, 0)
 	if err != nil {
 		panic(err)
 	}
@@ -107,8 +109,8 @@ func newTestBlockchain(db database.Database) *core.Blockchain {
 
 func newTestDownloader(db database.Database) *Downloader {
 	bc := newTestBlockchain(db)
-	d := NewDownloader(bc)
-	d.tm = newTaskMgr(d, d.masterPeer, 1, 2)
+	d := NewDownloader([]*core.Blockchain{bc})
+	d.tm[0] = newTaskMgr(d, d.masterPeer, 0, 1, 2)
 
 	return d
 }
@@ -119,19 +121,25 @@ type TestPeer struct {
 	td    *big.Int // total difficulty
 }
 
-// Head retrieves a copy of the current head hash and total difficulty.
-func (p *TestPeer) Head() (hash common.Hash, td *big.Int) {
+// HeadByChain retrieves a copy of the current head hash and total difficulty of the specified chain.
+func (p *TestPeer) HeadByChain(chainNum uint64) (hash common.Hash, td *big.Int) {
 	return hash, new(big.Int).Set(p.td)
 }
 
 // RequestHeadersByHashOrNumber fetches a batch of blocks' headers
-func (p *TestPeer) RequestHeadersByHashOrNumber(magic uint32, origin common.Hash, num uint64, amount int, reverse bool) error {
+func (p *TestPeer) RequestHeadersByHashOrNumber(magic uint32, origin common.Hash, chainNum uint64, num uint64, amount int, reverse bool) error {
 	p.magic = magic
 	return nil
 }
 
 // RequestBlocksByHashOrNumber fetches a batch of blocks
-func (p *TestPeer) RequestBlocksByHashOrNumber(magic uint32, origin common.Hash, num uint64, amount int) error {
+func (p *TestPeer) RequestBlocksByHashOrNumber(magic uint32, origin common.Hash, chainNum uint64, num uint64, amount int) error {
+	return nil
+}
+
+// RequestNodeData fetches a batch of trie nodes
+func (p *TestPeer) RequestNodeData(magic uint32, hashes []common.Hash) error {
+	p.magic = magic
 	return nil
 }
 
@@ -145,8 +153,11 @@ func Test_Downloader_CodeToStr(t *testing.T) {
 	assert.Equal(t, CodeToStr(GetBlocksMsg), "downloader.GetBlocksMsg")
 	assert.Equal(t, CodeToStr(BlocksPreMsg), "downloader.BlocksPreMsg")
 	assert.Equal(t, CodeToStr(BlocksMsg), "downloader.BlocksMsg")
+	assert.Equal(t, CodeToStr(GetNodeDataMsg), "downloader.GetNodeDataMsg")
+	assert.Equal(t, CodeToStr(NodeDataMsg), "downloader.NodeDataMsg")
 	assert.Equal(t, CodeToStr(GetBlockHeadersMsg-1), "unknown")
 	assert.Equal(t, CodeToStr(BlocksMsg+1), "unknown")
+	assert.Equal(t, CodeToStr(NodeDataMsg+1), "unknown")
 }
 
 func Test_Downloader_GetReadableStatus(t *testing.T) {
@@ -184,9 +195,9 @@ func Test_Downloader_GetSyncInfo(t *testing.T) {
 	dl.getSyncInfo(&info)
 	assert.Equal(t, info.Status, "Downloading")
 	assert.Equal(t, len(info.Duration) > 0, true)
-	assert.Equal(t, info.StartNum, dl.tm.fromNo)
-	assert.Equal(t, info.Amount, dl.tm.toNo-dl.tm.fromNo+1)
-	assert.Equal(t, info.Downloaded, dl.tm.downloadedNum)
+	assert.Equal(t, info.StartNum, dl.tm[0].fromNo)
+	assert.Equal(t, info.Amount, dl.tm[0].toNo-dl.tm[0].fromNo+1)
+	assert.Equal(t, info.Downloaded, dl.tm[0].downloadedNum)
 
 	// case 2: NotSyncing
 	var info1 SyncInfo
@@ -221,20 +232,20 @@ func Test_Downloader_Synchronise(t *testing.T) {
 
 	// case 1: ErrIsSynchronising
 	dl.syncStatus = statusPreparing
-	err := dl.Synchronise(peerID, head, td, localTD)
+	err := dl.Synchronise(peerID, 0, head, td, localTD)
 	assert.Equal(t, err, ErrIsSynchronising)
 
 	dl.syncStatus = statusFetching
-	err = dl.Synchronise(peerID, head, td, localTD)
+	err = dl.Synchronise(peerID, 0, head, td, localTD)
 	assert.Equal(t, err, ErrIsSynchronising)
 
 	dl.syncStatus = statusCleaning
-	err = dl.Synchronise(peerID, head, td, localTD)
+	err = dl.Synchronise(peerID, 0, head, td, localTD)
 	assert.Equal(t, err, ErrIsSynchronising)
 
 	// case 2: peer not found
 	dl.syncStatus = statusNone
-	err = dl.Synchronise(peerID, head, td, localTD)
+	err = dl.Synchronise(peerID, 0, head, td, localTD)
 	assert.Equal(t, err, errPeerNotFound)
 }
 
@@ -248,21 +259,21 @@ func Test_Downloader_FindCommonAncestorHeight(t *testing.T) {
 	pc := testTaskMgrPeerConn("peerID")
 	height := uint64(1)
 
-	aHeight, err := dl.findCommonAncestorHeight(pc, height)
+	aHeight, err := dl.findCommonAncestorHeight(pc, 0, height)
 	assert.Equal(t, err, nil)
 	assert.Equal(t, aHeight, uint64(0))
 
 	// case 2: empty block
-	dl.chain = newTestBlockchain(db)
+	dl.chain = []*core.Blockchain{newTestBlockchain(db)}
 	height = 0
-	aHeight, err = dl.findCommonAncestorHeight(pc, height)
+	aHeight, err = dl.findCommonAncestorHeight(pc, 0, height)
 	assert.Equal(t, err, nil)
 	assert.Equal(t, aHeight, uint64(0))
 
 	// case 2: one block
-	genesisHash, err := dl.chain.GetStore().GetBlockHash(0)
+	genesisHash, err := dl.chain[0].GetStore().GetBlockHash(0)
 	assert.Equal(t, err, nil)
-	_, err = dl.chain.GetStore().GetBlock(genesisHash)
+	_, err = dl.chain[0].GetStore().GetBlock(genesisHash)
 	assert.Equal(t, err, nil)
 }
 
@@ -427,8 +438,8 @@ func Test_Downloader_PeerDownload(t *testing.T) {
 
 	// case 1: non-master peer
 	pc := newPeerConn(testPeer, "test", nil)
+	dl.sessionWG.Add(1)
 	go func() {
-		dl.sessionWG.Add(1)
 		dl.peerDownload(pc, taskMgr)
 	}()
 
@@ -438,8 +449,8 @@ func Test_Downloader_PeerDownload(t *testing.T) {
 	// case 2: master peer
 	dl.masterPeer = "masterPeer"
 	pc = newPeerConn(testPeer, "masterPeer", nil)
+	dl.sessionWG.Add(1)
 	go func() {
-		dl.sessionWG.Add(1)
 		dl.cancelCh = make(chan struct{})
 		dl.peerDownload(pc, taskMgr)
 	}()
@@ -448,8 +459,8 @@ func Test_Downloader_PeerDownload(t *testing.T) {
 	// case 3: BlockHeadersMsg
 	pc = newPeerConn(testPeer, "masterPeer", nil)
 	pc.peer = testPeer
+	dl.sessionWG.Add(1)
 	go func() {
-		dl.sessionWG.Add(1)
 		dl.cancelCh = make(chan struct{})
 		dl.peerDownload(pc, taskMgr)
 	}()
@@ -467,10 +478,10 @@ func Test_Downloader_ProcessBlocks(t *testing.T) {
 	db, dispose := leveldb.NewTestDatabase()
 	defer dispose()
 	dl := newTestDownloader(db)
-	dl.chain = newTestBlockchain(db)
+	dl.chain = []*core.Blockchain{newTestBlockchain(db)}
 
 	headInfos := []*downloadInfo{newDownloadInfo(1, taskStatusWaitProcessing)}
-	dl.processBlocks(headInfos)
+	dl.processBlocks(headInfos, 0)
 	assert.Equal(t, headInfos[0].status, taskStatusWaitProcessing)
 }
 
@@ -481,7 +492,7 @@ func Test_findCommonAncestorHeight_localHeightIsZero(t *testing.T) {
 	height := uint64(1000)
 	var testPeer *TestPeer
 	p := newPeerConn(testPeer, "test", nil)
-	ancestorHeight, err := dl.findCommonAncestorHeight(p, height)
+	ancestorHeight, err := dl.findCommonAncestorHeight(p, 0, height)
 	assert.Equal(t, nil, err)
 	assert.Equal(t, uint64(0), ancestorHeight)
 }
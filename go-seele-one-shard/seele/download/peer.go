@@ -7,7 +7,6 @@ package downloader
 
 import (
 	"errors"
-	"fmt"
 	"math/big"
 	"sync"
 	"time"
@@ -23,6 +22,7 @@ const MsgWaitTimeout = time.Second * 120
 var (
 	errReceivedQuitMsg = errors.New("Received quit msg")
 	errPeerQuit        = errors.New("Peer quit")
+	errWaitMsgTimeout  = errors.New("Wait for msg timeout")
 )
 
 // Peer define some interfaces that request peer data
@@ -30,6 +30,7 @@ type Peer interface {
 	HeadByChain(chainNum uint64) (common.Hash, *big.Int)
 	RequestHeadersByHashOrNumber(magic uint32, origin common.Hash, chainNum uint64, num uint64, amount int, reverse bool) error
 	RequestBlocksByHashOrNumber(magic uint32, origin common.Hash, chainNum uint64, num uint64, amount int) error
+	RequestNodeData(magic uint32, hashes []common.Hash) error
 	GetPeerRequestInfo() (uint32, common.Hash, uint64, int)
 }
 
@@ -92,9 +93,20 @@ Again:
 				goto Again
 			}
 			ret = reqMsg.Blocks
+		case NodeDataMsg:
+			var reqMsg NodeDataMsgBody
+			if err := common.Deserialize(msg.Payload, &reqMsg); err != nil {
+				goto Again
+			}
+			if reqMsg.Magic != magic {
+				p.log.Debug("Downloader.waitMsg  NodeDataMsg MAGIC_NOT_MATCH msg=%s pid=%s", CodeToStr(msgCode), p.peerID)
+				goto Again
+			}
+			ret = reqMsg.Nodes
 		}
 	case <-timeout.C:
-		err = fmt.Errorf("wait for msg %s timeout", CodeToStr(msgCode))
+		p.log.Warn("wait for msg %s timeout, pid=%s", CodeToStr(msgCode), p.peerID)
+		err = errWaitMsgTimeout
 	}
 
 	p.lockForWaiting.Lock()
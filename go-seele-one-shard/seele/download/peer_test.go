@@ -19,15 +19,19 @@ import (
 // TestDownloadPeer implements the inferace of Peer
 type TestDownloadPeer struct{}
 
-func (s TestDownloadPeer) Head() (common.Hash, *big.Int) {
+func (s TestDownloadPeer) HeadByChain(chainNum uint64) (common.Hash, *big.Int) {
 	return common.EmptyHash, nil
 }
 
-func (s TestDownloadPeer) RequestHeadersByHashOrNumber(magic uint32, origin common.Hash, num uint64, amount int, reverse bool) error {
+func (s TestDownloadPeer) RequestHeadersByHashOrNumber(magic uint32, origin common.Hash, chainNum uint64, num uint64, amount int, reverse bool) error {
 	return nil
 }
 
-func (s TestDownloadPeer) RequestBlocksByHashOrNumber(magic uint32, origin common.Hash, num uint64, amount int) error {
+func (s TestDownloadPeer) RequestBlocksByHashOrNumber(magic uint32, origin common.Hash, chainNum uint64, num uint64, amount int) error {
+	return nil
+}
+
+func (s TestDownloadPeer) RequestNodeData(magic uint32, hashes []common.Hash) error {
 	return nil
 }
 
@@ -84,7 +88,7 @@ func Test_Download_WaitMsg(t *testing.T) {
 		ret, err := pc.waitMsg(magic, msgCode, cancelCh)
 		assert.Equal(t, err, nil)
 		assert.Equal(t, ret != nil, true)
-		assert.Equal(t, ret, blockHeadersMsgHeader.Headers)
+		assert.Equal(t, ret.([]*types.BlockHeader)[0].Hash(), blockHeadersMsgHeader.Headers[0].Hash())
 	}()
 
 	time.Sleep(100 * time.Millisecond)
@@ -105,7 +109,7 @@ func Test_Download_WaitMsg(t *testing.T) {
 		blocks := ret.([]*types.Block)
 		for i, b := range blocks {
 			if !b.HeaderHash.Equal(blocksMsgHeader.Blocks[i].HeaderHash) {
-				t.Fatal("not equal")
+				t.Error("not equal")
 			}
 		}
 	}()
@@ -123,7 +127,7 @@ func Test_Download_WaitMsg(t *testing.T) {
 		blocks := ret2.([]*types.Block)
 		for i, b := range blocks {
 			if !b.HeaderHash.Equal(blocksMsgHeader.Blocks[i].HeaderHash) {
-				t.Fatal("not equal")
+				t.Error("not equal")
 			}
 		}
 	}()
@@ -201,7 +205,7 @@ func newTestBlocks() []*types.Block {
 		newDebt(),
 	}
 
-	block := types.NewBlock(headers[0], txs, receipts, debts)
+	block := types.NewBlock(headers[0], txs, receipts, debts, 0, common.DefaultNumOfChains)
 
 	return []*types.Block{block}
 }
@@ -0,0 +1,149 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package downloader
+
+import (
+	rand2 "math/rand"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core"
+	"github.com/seeleteam/go-seele/core/types"
+)
+
+// SynchroniseState retrieves the trusted checkpoint block of the local chain and its state from
+// remote peer, and then sets the block as the HEAD block of the local chain, so that only the blocks
+// after it need to be synchronised. The header chain of the block is verified back to the genesis
+// block to compute the total difficulty locally, and every retrieved trie node is verified against
+// the state root hash of the block.
+func (d *Downloader) SynchroniseState(id string, chainNum uint64) error {
+	cp := d.chain[chainNum].GetCheckpoint()
+	if cp == nil {
+		return core.ErrCheckpointRequired
+	}
+
+	d.lock.Lock()
+
+	if d.syncStatus != statusNone {
+		d.lock.Unlock()
+		return ErrIsSynchronising
+	}
+
+	// keep preparing status, so that no block download task is started for new peers.
+	d.syncStatus = statusPreparing
+	d.cancelCh = make(chan struct{})
+	d.masterPeer = id
+	conn, ok := d.peers[id]
+	if !ok {
+		close(d.cancelCh)
+		d.syncStatus = statusNone
+		d.lock.Unlock()
+		return errPeerNotFound
+	}
+	d.lock.Unlock()
+
+	err := d.doSynchroniseState(conn, chainNum, cp.Hash)
+	d.reportPeerErr(id, err)
+
+	d.lock.Lock()
+	d.syncStatus = statusNone
+	d.cancelCh = nil
+	d.lock.Unlock()
+
+	return err
+}
+
+func (d *Downloader) doSynchroniseState(conn *peerConn, chainNum uint64, head common.Hash) error {
+	d.log.Debug("Downloader.doSynchroniseState start, chainNum: %d", chainNum)
+
+	magic := rand2.Uint32()
+	go conn.peer.RequestBlocksByHashOrNumber(magic, head, chainNum, 0, 1)
+	msg, err := conn.waitMsg(magic, BlocksMsg, d.cancelCh)
+	if err != nil {
+		return err
+	}
+
+	blocks := msg.([]*types.Block)
+	if len(blocks) != 1 {
+		return errInvalidPacketReceived
+	}
+
+	block := blocks[0]
+	if block.HeaderHash != head {
+		return errHashNotMatch
+	}
+
+	verifier, err := d.chain[chainNum].NewHeaderChainVerifier(block.Header)
+	if err != nil {
+		return err
+	}
+
+	for !verifier.Done() {
+		magic := rand2.Uint32()
+		go conn.peer.RequestHeadersByHashOrNumber(magic, common.EmptyHash, chainNum, verifier.Next(), MaxHeaderFetch, true)
+		msg, err := conn.waitMsg(magic, BlockHeadersMsg, d.cancelCh)
+		if err != nil {
+			return err
+		}
+
+		headers := msg.([]*types.BlockHeader)
+		if len(headers) == 0 {
+			return errInvalidPacketReceived
+		}
+
+		for _, header := range headers {
+			if err = verifier.Verify(header); err != nil {
+				d.reportPeer(conn.peerID, PeerEventInvalidData)
+				return err
+			}
+		}
+	}
+
+	sync, err := d.chain[chainNum].StartStateSync(block.Header.StateHash)
+	if err != nil {
+		return err
+	}
+
+	d.log.Info("start to synchronise state, height:%d, root:%s, chainNum:%d", block.Header.Height, block.Header.StateHash.ToHex(), chainNum)
+
+	retrieved := 0
+	for !sync.Done() {
+		hashes, err := sync.Missing(MaxNodeDataFetch)
+		if err != nil {
+			return err
+		}
+
+		if len(hashes) == 0 {
+			continue
+		}
+
+		magic := rand2.Uint32()
+		go conn.peer.RequestNodeData(magic, hashes)
+		msg, err := conn.waitMsg(magic, NodeDataMsg, d.cancelCh)
+		if err != nil {
+			return err
+		}
+
+		// the state may be pruned by remote peer
+		nodes := msg.([][]byte)
+		if len(nodes) == 0 {
+			return errInvalidPacketReceived
+		}
+
+		if err = sync.Process(nodes); err != nil {
+			d.reportPeer(conn.peerID, PeerEventInvalidData)
+			return err
+		}
+
+		d.reportPeer(conn.peerID, PeerEventUsefulData)
+
+		retrieved += len(nodes)
+		d.log.Debug("retrieved %d trie nodes, chainNum: %d", retrieved, chainNum)
+	}
+
+	d.log.Info("state synchronised, retrieved trie nodes:%d, chainNum:%d", retrieved, chainNum)
+
+	return d.chain[chainNum].SetSnapshotHead(block, verifier)
+}
@@ -0,0 +1,38 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package downloader
+
+import (
+	"testing"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core"
+	"github.com/seeleteam/go-seele/database/leveldb"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Downloader_SynchroniseState(t *testing.T) {
+	db, dispose := leveldb.NewTestDatabase()
+	defer dispose()
+	dl := newTestDownloader(db)
+
+	// case 1: no trusted checkpoint
+	err := dl.SynchroniseState("peerID", 0)
+	assert.Equal(t, err, core.ErrCheckpointRequired)
+
+	dl.chain[0].SetCheckpoint(&core.Checkpoint{Height: 1, Hash: common.StringToHash("checkpoint")})
+
+	// case 2: ErrIsSynchronising
+	dl.syncStatus = statusFetching
+	err = dl.SynchroniseState("peerID", 0)
+	assert.Equal(t, err, ErrIsSynchronising)
+
+	// case 3: peer not found
+	dl.syncStatus = statusNone
+	err = dl.SynchroniseState("peerID", 0)
+	assert.Equal(t, err, errPeerNotFound)
+	assert.Equal(t, dl.syncStatus, statusNone)
+}
@@ -192,7 +192,7 @@ var (
 )
 
 func newTestTaskMgr(d *Downloader, db database.Database) *taskMgr {
-	taskMgr := newTaskMgr(d, masterPeer, from, to)
+	taskMgr := newTaskMgr(d, masterPeer, 0, from, to)
 
 	return taskMgr
 }
@@ -0,0 +1,230 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package seele
+
+import (
+	"sync"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core"
+	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/event"
+	"github.com/seeleteam/go-seele/log"
+)
+
+const (
+	// eventBuffSize is the buffer size of chain events waiting to be dispatched.
+	eventBuffSize = 4096
+
+	// subscriberBuffSize is the buffer size of notifications waiting to be sent to a subscriber.
+	subscriberBuffSize = 1024
+)
+
+type subscriptionType byte
+
+const (
+	headsSubscription subscriptionType = iota
+	pendingTxsSubscription
+	logsSubscription
+	debtsSubscription
+)
+
+// HeadNotification is the notification of a block added to or removed from the canonical chain.
+type HeadNotification struct {
+	ChainNum uint64
+	Hash     common.Hash
+	Header   *types.BlockHeader
+	Removed  bool // true if the block is removed from the canonical chain due to reorg
+}
+
+// TxNotification is the notification of a transaction inserted into the tx pool.
+type TxNotification struct {
+	ChainNum uint64
+	Tx       *types.Transaction
+}
+
+// DebtNotification is the notification of a debt inserted into the debt pool.
+type DebtNotification struct {
+	ChainNum uint64
+	Debt     *types.Debt
+}
+
+// subscriber is a subscription of the specified type, and chainNum is nil for all chains.
+type subscriber struct {
+	typ      subscriptionType
+	chainNum *uint64
+	filter   *LogFilter
+	ch       chan interface{}
+}
+
+func (sub *subscriber) matchChain(chainNum uint64) bool {
+	return sub.chainNum == nil || *sub.chainNum == chainNum
+}
+
+// eventSystem dispatches the chain, tx pool and debt pool events to subscribers.
+// Events are dispatched in order by a single goroutine, so that the reorg
+// notifications of canonical chain are consistent with the last head of each chain.
+type eventSystem struct {
+	chains      []*core.Blockchain
+	lastHeaders []common.Hash
+	events      chan event.Event
+	quit        chan struct{}
+
+	lock        sync.RWMutex
+	subscribers map[*subscriber]struct{}
+
+	log *log.SeeleLog
+}
+
+// newEventSystem creates an event system with the current heads of chains.
+func newEventSystem(chains []*core.Blockchain, log *log.SeeleLog) *eventSystem {
+	es := &eventSystem{
+		chains:      chains,
+		lastHeaders: make([]common.Hash, len(chains)),
+		events:      make(chan event.Event, eventBuffSize),
+		quit:        make(chan struct{}),
+		subscribers: make(map[*subscriber]struct{}),
+		log:         log,
+	}
+
+	for i, chain := range chains {
+		es.lastHeaders[i] = chain.CurrentBlock().HeaderHash
+	}
+
+	return es
+}
+
+// start registers the event listeners and starts to dispatch events.
+func (es *eventSystem) start() {
+	event.ChainHeaderChangedEventMananger.AddListener(es.onEvent)
+	event.TransactionInsertedEventManager.AddListener(es.onEvent)
+	event.DebtInsertedEventManager.AddListener(es.onEvent)
+
+	go es.loop()
+}
+
+// stop removes the event listeners and stops dispatching events.
+func (es *eventSystem) stop() {
+	event.ChainHeaderChangedEventMananger.RemoveListener(es.onEvent)
+	event.TransactionInsertedEventManager.RemoveListener(es.onEvent)
+	event.DebtInsertedEventManager.RemoveListener(es.onEvent)
+
+	close(es.quit)
+}
+
+// onEvent queues the event without blocking the event source, e.g. blockchain or tx pool.
+func (es *eventSystem) onEvent(e event.Event) {
+	select {
+	case es.events <- e:
+	default:
+		es.log.Warn("event system is busy, drop event %T", e)
+	}
+}
+
+func (es *eventSystem) subscribe(sub *subscriber) {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+
+	sub.ch = make(chan interface{}, subscriberBuffSize)
+	es.subscribers[sub] = struct{}{}
+}
+
+func (es *eventSystem) unsubscribe(sub *subscriber) {
+	es.lock.Lock()
+	defer es.lock.Unlock()
+
+	delete(es.subscribers, sub)
+}
+
+func (es *eventSystem) loop() {
+	for {
+		select {
+		case e := <-es.events:
+			switch msg := e.(type) {
+			case event.ChainHeaderChangedMsg:
+				es.handleChainHeaderChanged(msg.ChainNum, msg.HeaderHash)
+			case event.HandleNewTxMsg:
+				es.notify(pendingTxsSubscription, msg.ChainNum, &TxNotification{msg.ChainNum, msg.Tx})
+			case event.HandleNewDebtMsg:
+				es.notify(debtsSubscription, msg.ChainNum, &DebtNotification{msg.ChainNum, msg.Debt})
+			}
+		case <-es.quit:
+			return
+		}
+	}
+}
+
+// handleChainHeaderChanged notifies the blocks removed from and added to the canonical chain.
+func (es *eventSystem) handleChainHeaderChanged(chainNum uint64, newHeader common.Hash) {
+	if chainNum >= uint64(len(es.chains)) || newHeader.IsEmpty() {
+		return
+	}
+
+	removed, added, err := core.GetChangedBlocks(es.chains[chainNum].GetStore(), newHeader, es.lastHeaders[chainNum])
+	es.lastHeaders[chainNum] = newHeader
+	if err != nil {
+		es.log.Warn("failed to get changed blocks of chain %d, %s", chainNum, err)
+		return
+	}
+
+	for _, block := range removed {
+		es.notifyBlock(chainNum, block, true)
+	}
+
+	for _, block := range added {
+		es.notifyBlock(chainNum, block, false)
+	}
+}
+
+func (es *eventSystem) notifyBlock(chainNum uint64, block *types.Block, removed bool) {
+	es.notify(headsSubscription, chainNum, &HeadNotification{chainNum, block.HeaderHash, block.Header, removed})
+
+	es.lock.RLock()
+	defer es.lock.RUnlock()
+
+	var receipts []*types.Receipt
+	for sub := range es.subscribers {
+		if sub.typ != logsSubscription || !sub.matchChain(chainNum) {
+			continue
+		}
+
+		if !block.Header.LogBloom.MatchFilter(sub.filter.Addresses, sub.filter.Topics) {
+			continue
+		}
+
+		if receipts == nil {
+			var err error
+			if receipts, err = es.chains[chainNum].GetStore().GetReceiptsByBlockHash(block.HeaderHash); err != nil {
+				es.log.Warn("failed to get receipts of block %s, %s", block.HeaderHash.ToHex(), err)
+				return
+			}
+		}
+
+		for _, log := range filterLogs(chainNum, block.HeaderHash, block.Header.Height, receipts, sub.filter.Addresses, sub.filter.Topics, removed) {
+			es.send(sub, log)
+		}
+	}
+}
+
+func (es *eventSystem) notify(typ subscriptionType, chainNum uint64, data interface{}) {
+	es.lock.RLock()
+	defer es.lock.RUnlock()
+
+	for sub := range es.subscribers {
+		if sub.typ == typ && sub.matchChain(chainNum) {
+			es.send(sub, data)
+		}
+	}
+}
+
+// send sends the notification to subscriber, and drops it if the subscriber is too slow.
+func (es *eventSystem) send(sub *subscriber, data interface{}) {
+	select {
+	case sub.ch <- data:
+	default:
+		es.log.Warn("subscriber is too slow, drop notification %T", data)
+	}
+}
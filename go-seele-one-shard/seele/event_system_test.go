@@ -0,0 +1,235 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package seele
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/crypto"
+	"github.com/seeleteam/go-seele/event"
+	"github.com/seeleteam/go-seele/log"
+	rpc "github.com/seeleteam/go-seele/rpc2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEventSystem(t *testing.T, dbPath string) (*eventSystem, *PublicSeeleAPI, func()) {
+	api := newTestAPI(t, dbPath)
+	es := newEventSystem(api.s.chains, log.GetLogger("seele"))
+
+	return es, api, func() {
+		api.s.Stop()
+		os.RemoveAll(dbPath)
+	}
+}
+
+// newTestChildBlock creates a block on top of the parent block and writes it into the store of chain 0.
+func newTestChildBlock(t *testing.T, api *PublicSeeleAPI, parent *types.Block, receipts []*types.Receipt) *types.Block {
+	block := newTestBlock(parent.Header.Height + 1)
+	block.Header.PreviousBlockHash = parent.HeaderHash
+	block.Header.LogBloom = types.CreateBloom(receipts)
+	block.HeaderHash = block.Header.Hash()
+
+	bcStore := api.s.chains[0].GetStore()
+	assert.Equal(t, bcStore.PutBlock(block, big.NewInt(int64(block.Header.Height)), false), nil)
+	assert.Equal(t, bcStore.PutReceipts(block.HeaderHash, receipts), nil)
+
+	return block
+}
+
+func newTestSubscriber(es *eventSystem, typ subscriptionType, chainNum *uint64) *subscriber {
+	sub := &subscriber{typ: typ, chainNum: chainNum}
+	es.subscribe(sub)
+	return sub
+}
+
+func receiveHeads(sub *subscriber) []*HeadNotification {
+	var heads []*HeadNotification
+	for len(sub.ch) > 0 {
+		heads = append(heads, (<-sub.ch).(*HeadNotification))
+	}
+
+	return heads
+}
+
+func Test_subscriber_matchChain(t *testing.T) {
+	sub := &subscriber{}
+	assert.Equal(t, sub.matchChain(0), true)
+	assert.Equal(t, sub.matchChain(1), true)
+
+	chainNum := uint64(1)
+	sub.chainNum = &chainNum
+	assert.Equal(t, sub.matchChain(0), false)
+	assert.Equal(t, sub.matchChain(1), true)
+}
+
+func Test_eventSystem_Notify(t *testing.T) {
+	es, _, dispose := newTestEventSystem(t, filepath.Join(common.GetTempFolder(), ".eventSystemNotify"))
+	defer dispose()
+
+	chain0, chain1 := uint64(0), uint64(1)
+	allTxs := newTestSubscriber(es, pendingTxsSubscription, nil)
+	chain1Txs := newTestSubscriber(es, pendingTxsSubscription, &chain1)
+	chain0Debts := newTestSubscriber(es, debtsSubscription, &chain0)
+
+	tx := &TxNotification{0, newTestBlock(1).Transactions[0]}
+	es.notify(pendingTxsSubscription, 0, tx)
+	assert.Equal(t, len(allTxs.ch), 1)
+	assert.Equal(t, <-allTxs.ch, tx)
+	assert.Equal(t, len(chain1Txs.ch), 0)
+	assert.Equal(t, len(chain0Debts.ch), 0)
+
+	// no notification after unsubscribed
+	es.unsubscribe(allTxs)
+	es.notify(pendingTxsSubscription, 1, tx)
+	assert.Equal(t, len(allTxs.ch), 0)
+	assert.Equal(t, len(chain1Txs.ch), 1)
+
+	// drop notifications if subscriber is too slow
+	for i := 0; i < subscriberBuffSize+1; i++ {
+		es.notify(debtsSubscription, 0, &DebtNotification{})
+	}
+	assert.Equal(t, len(chain0Debts.ch), subscriberBuffSize)
+}
+
+func Test_eventSystem_ChainHeaderChanged(t *testing.T) {
+	es, api, dispose := newTestEventSystem(t, filepath.Join(common.GetTempFolder(), ".eventSystemHeaderChanged"))
+	defer dispose()
+
+	chain0, chain1 := uint64(0), uint64(1)
+	heads := newTestSubscriber(es, headsSubscription, &chain0)
+	otherHeads := newTestSubscriber(es, headsSubscription, &chain1)
+
+	// genesis <- a1
+	//         <- b1 <- b2
+	genesis := api.s.chains[0].CurrentBlock()
+	a1 := newTestChildBlock(t, api, genesis, nil)
+	b1 := newTestChildBlock(t, api, genesis, nil)
+	b2 := newTestChildBlock(t, api, b1, nil)
+
+	es.handleChainHeaderChanged(0, a1.HeaderHash)
+	notifications := receiveHeads(heads)
+	assert.Equal(t, len(notifications), 1)
+	assert.Equal(t, notifications[0].Hash, a1.HeaderHash)
+	assert.Equal(t, notifications[0].Removed, false)
+
+	// reorg to the longer branch
+	es.handleChainHeaderChanged(0, b2.HeaderHash)
+	notifications = receiveHeads(heads)
+	assert.Equal(t, len(notifications), 3)
+	assert.Equal(t, notifications[0].Hash, a1.HeaderHash)
+	assert.Equal(t, notifications[0].Removed, true)
+	assert.Equal(t, notifications[1].Hash, b1.HeaderHash)
+	assert.Equal(t, notifications[1].Removed, false)
+	assert.Equal(t, notifications[2].Hash, b2.HeaderHash)
+	assert.Equal(t, notifications[2].Removed, false)
+
+	assert.Equal(t, len(otherHeads.ch), 0)
+
+	// unknown block and invalid chain number are ignored
+	es.handleChainHeaderChanged(0, common.StringToHash("unknown"))
+	es.handleChainHeaderChanged(uint64(len(api.s.chains)), b2.HeaderHash)
+	assert.Equal(t, len(heads.ch), 0)
+}
+
+func Test_eventSystem_Logs(t *testing.T) {
+	es, api, dispose := newTestEventSystem(t, filepath.Join(common.GetTempFolder(), ".eventSystemLogs"))
+	defer dispose()
+
+	contract := *crypto.MustGenerateRandomAddress()
+	topic := common.StringToHash("topic")
+	receipts := []*types.Receipt{{
+		TxHash: common.StringToHash("tx"),
+		Logs:   []*types.Log{{Address: contract, Topics: []common.Hash{topic}}},
+	}}
+
+	matched := &subscriber{typ: logsSubscription, chainNum: new(uint64), filter: &LogFilter{Addresses: []common.Address{contract}}}
+	es.subscribe(matched)
+	unmatched := &subscriber{typ: logsSubscription, chainNum: new(uint64), filter: &LogFilter{Addresses: []common.Address{*crypto.MustGenerateRandomAddress()}}}
+	es.subscribe(unmatched)
+
+	genesis := api.s.chains[0].CurrentBlock()
+	a1 := newTestChildBlock(t, api, genesis, receipts)
+	b1 := newTestChildBlock(t, api, genesis, nil)
+	b2 := newTestChildBlock(t, api, b1, nil)
+
+	es.handleChainHeaderChanged(0, a1.HeaderHash)
+	assert.Equal(t, len(matched.ch), 1)
+	log := (<-matched.ch).(GetLogsResponse)
+	assert.Equal(t, log.BlockHash, a1.HeaderHash)
+	assert.Equal(t, log.TxHash, receipts[0].TxHash)
+	assert.Equal(t, log.Removed, false)
+
+	// logs of the removed block are notified again with removed flag
+	es.handleChainHeaderChanged(0, b2.HeaderHash)
+	assert.Equal(t, len(matched.ch), 1)
+	log = (<-matched.ch).(GetLogsResponse)
+	assert.Equal(t, log.BlockHash, a1.HeaderHash)
+	assert.Equal(t, log.Removed, true)
+
+	assert.Equal(t, len(unmatched.ch), 0)
+}
+
+func Test_eventSystem_Loop(t *testing.T) {
+	es, _, dispose := newTestEventSystem(t, filepath.Join(common.GetTempFolder(), ".eventSystemLoop"))
+	defer dispose()
+
+	es.start()
+	defer es.stop()
+
+	txs := newTestSubscriber(es, pendingTxsSubscription, nil)
+	debts := newTestSubscriber(es, debtsSubscription, nil)
+
+	tx := newTestBlock(1).Transactions[0]
+	event.TransactionInsertedEventManager.Fire(event.HandleNewTxMsg{Tx: tx, ChainNum: 1})
+	debt := &types.Debt{Hash: common.StringToHash("debt")}
+	event.DebtInsertedEventManager.Fire(event.HandleNewDebtMsg{Debt: debt, ChainNum: 0})
+
+	select {
+	case data := <-txs.ch:
+		assert.Equal(t, data, &TxNotification{1, tx})
+	case <-time.After(time.Second):
+		t.Fatal("tx notification timeout")
+	}
+
+	select {
+	case data := <-debts.ch:
+		assert.Equal(t, data, &DebtNotification{0, debt})
+	case <-time.After(time.Second):
+		t.Fatal("debt notification timeout")
+	}
+}
+
+func Test_PublicSubscriptionAPI_subscribe(t *testing.T) {
+	dbPath := filepath.Join(common.GetTempFolder(), ".SubscriptionAPI")
+	api := newTestAPI(t, dbPath)
+	defer func() {
+		api.s.Stop()
+		os.RemoveAll(dbPath)
+	}()
+
+	subAPI := NewPublicSubscriptionAPI(api.s)
+
+	invalidChainNum := uint64(len(api.s.chains))
+	_, err := subAPI.NewHeads(context.Background(), &invalidChainNum)
+	assert.Equal(t, err != nil, true)
+
+	_, err = subAPI.Logs(context.Background(), LogFilter{ChainNum: &invalidChainNum})
+	assert.Equal(t, err != nil, true)
+
+	// notifications are not supported without a notifier in context
+	_, err = subAPI.NewPendingTransactions(context.Background(), nil)
+	assert.Equal(t, err, rpc.ErrNotificationsUnsupported)
+
+	_, err = subAPI.NewDebts(context.Background(), nil)
+	assert.Equal(t, err, rpc.ErrNotificationsUnsupported)
+}
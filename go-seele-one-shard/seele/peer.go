@@ -34,6 +34,7 @@ var (
 	errNetworkNotMatch          = errors.New("NetworkID not match")
 	errGenesisNotMatch          = errors.New("Genesis not match")
 	errGenesisDifficultNotMatch = errors.New("Genesis Difficult not match")
+	errNumOfChainsNotMatch      = errors.New("Number of chains not match")
 )
 
 // PeerInfo represents a short summary of a connected peer.
@@ -65,7 +66,7 @@ func idToStr(id common.Address) string {
 	return fmt.Sprintf("%x", id[:8])
 }
 
-func newPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter, log *log.SeeleLog) *peer {
+func newPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter, numOfChains uint64, log *log.SeeleLog) *peer {
 	knownTxsCache, err := lru.New(maxKnownTxs)
 	if err != nil {
 		panic(err)
@@ -81,14 +82,15 @@ func newPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter, log *log.SeeleLog)
 		panic(err)
 	}
 
-	tds := make([]*big.Int, NumOfChains)
- 	for i := 0; i < NumOfChains; i++ {
- 		tds[i] = big.NewInt(0)
+	tds := make([]*big.Int, numOfChains)
+	for i := range tds {
+		tds[i] = big.NewInt(0)
 	}
 	 
 	return &peer{
 		Peer:        p,
 		version:     version,
+		head:        make([]common.Hash, numOfChains),
 		td:          tds,
 		peerID:      p.Node.ID,
 		peerStrID:   idToStr(p.Node.ID),
@@ -284,6 +286,29 @@ func (p *peer) RequestBlocksByHashOrNumber(magic uint32, origin common.Hash, cha
 	return p2p.SendMessage(p.rw, downloader.GetBlocksMsg, buff)
 }
 
+// RequestNodeData fetches a batch of trie nodes of account state by hashes.
+func (p *peer) RequestNodeData(magic uint32, hashes []common.Hash) error {
+	query := &nodeDataQuery{
+		Magic:  magic,
+		Hashes: hashes,
+	}
+	buff := common.SerializePanic(query)
+
+	p.log.Debug("peer send [downloader.GetNodeDataMsg] query with length %d, size %d byte", len(hashes), len(buff))
+	return p2p.SendMessage(p.rw, downloader.GetNodeDataMsg, buff)
+}
+
+func (p *peer) sendNodeData(magic uint32, nodes [][]byte) error {
+	sendMsg := &downloader.NodeDataMsgBody{
+		Magic: magic,
+		Nodes: nodes,
+	}
+	buff := common.SerializePanic(sendMsg)
+
+	p.log.Debug("peer send [downloader.NodeDataMsg] with length: %d, size:%d byte peerid:%s", len(nodes), len(buff), p.peerStrID)
+	return p2p.SendMessage(p.rw, downloader.NodeDataMsg, buff)
+}
+
 func (p *peer) GetPeerRequestInfo() (uint32, common.Hash, uint64, int) {
 	return 0, common.EmptyHash, 0, 0
 }
@@ -310,13 +335,14 @@ func (p *peer) sendHeadStatus(msg *chainHeadStatus) error {
 // handShake exchange networkid td etc between two connected peers.
 func (p *peer) handShake(networkID uint64, td []*big.Int, head []common.Hash, genesis common.Hash, difficult uint64) error {
 	msg := &statusData{
-		ProtocolVersion: uint32(SeeleVersion),
+		ProtocolVersion: uint32(p.version),
 		NetworkID:       networkID,
 		TD:              td,
 		CurrentBlock:    head,
 		GenesisBlock:    genesis,
 		Shard:           common.LocalShardNumber,
 		Difficult:       difficult,
+		NumOfChains:     uint64(len(td)),
 	}
 
 	if err := p2p.SendMessage(p.rw, statusDataMsgCode, common.SerializePanic(msg)); err != nil {
@@ -336,7 +362,7 @@ func (p *peer) handShake(networkID uint64, td []*big.Int, head []common.Hash, ge
 		return err
 	}
 
-	if err = verifyGenesisAndNetworkID(retStatusMsg, genesis, networkID, common.LocalShardNumber, difficult); err != nil {
+	if err = verifyGenesisAndNetworkID(retStatusMsg, genesis, networkID, common.LocalShardNumber, difficult, uint64(len(td))); err != nil {
 		return err
 	}
 
@@ -345,10 +371,15 @@ func (p *peer) handShake(networkID uint64, td []*big.Int, head []common.Hash, ge
 	return nil
 }
 
-func verifyGenesisAndNetworkID(retStatusMsg statusData, genesis common.Hash, networkID uint64, shard uint, difficult uint64) error {
+func verifyGenesisAndNetworkID(retStatusMsg statusData, genesis common.Hash, networkID uint64, shard uint, difficult uint64, numOfChains uint64) error {
 	if retStatusMsg.NetworkID != networkID {
 		return errNetworkNotMatch
 	}
+
+	// all shards should have the same number of chains.
+	if retStatusMsg.NumOfChains != numOfChains || len(retStatusMsg.TD) != int(numOfChains) || len(retStatusMsg.CurrentBlock) != int(numOfChains) {
+		return errNumOfChainsNotMatch
+	}
 	if retStatusMsg.Shard == shard {
 		if retStatusMsg.GenesisBlock != genesis {
 			return errGenesisNotMatch
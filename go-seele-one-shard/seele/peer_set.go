@@ -13,9 +13,10 @@ import (
 )
 
 type peerSet struct {
-	peerMap    map[common.Address]*peer
-	shardPeers [1 + common.ShardCount]map[common.Address]*peer
-	lock       sync.RWMutex
+	peerMap     map[common.Address]*peer
+	shardPeers  [1 + common.ShardCount]map[common.Address]*peer
+	numOfChains uint64
+	lock        sync.RWMutex
 }
 
 type bestPeerForEachChain struct {
@@ -24,10 +25,11 @@ type bestPeerForEachChain struct {
 	chainNum    uint64	        // the peer is the best in this chain
 }
 
-func newPeerSet() *peerSet {
+func newPeerSet(numOfChains uint64) *peerSet {
 	ps := &peerSet{
-		peerMap: make(map[common.Address]*peer),
-		lock:    sync.RWMutex{},
+		peerMap:     make(map[common.Address]*peer),
+		numOfChains: numOfChains,
+		lock:        sync.RWMutex{},
 	}
 
 	for i := 0; i < 1+common.ShardCount; i++ {
@@ -39,9 +41,9 @@ func newPeerSet() *peerSet {
 
 func (p *peerSet) bestPeer(shard uint) []*bestPeerForEachChain {
 	
-	bestPeers := make([]*bestPeerForEachChain, NumOfChains)
+	bestPeers := make([]*bestPeerForEachChain, p.numOfChains)
 	p.ForEach(shard, func(p *peer) bool {
- 		for i := 0; i < NumOfChains; i++ {
+ 		for i := range bestPeers {
 			_, td := p.HeadByChain(uint64(i))   
 			if bestPeers[i] == nil || td.Cmp(bestPeers[i].bestTd) > 0 {
 				 bestPeers[i] = &bestPeerForEachChain{
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/crypto"
 	log2 "github.com/seeleteam/go-seele/log"
 	"github.com/seeleteam/go-seele/p2p"
@@ -21,13 +22,13 @@ func getTestPeer(shard uint) *peer {
 	addr := crypto.MustGenerateRandomAddress()
 	node := discovery.NewNodeWithAddr(*addr, &net.UDPAddr{}, shard)
 	p2pPeer := p2p.NewPeer(nil, nil, nil, node)
-	peer := newPeer(1, p2pPeer, nil, log)
+	peer := newPeer(1, p2pPeer, nil, common.DefaultNumOfChains, log)
 
 	return peer
 }
 
 func Test_PeerSet_Add(t *testing.T) {
-	set := newPeerSet()
+	set := newPeerSet(common.DefaultNumOfChains)
 
 	peer1 := getTestPeer(0)
 	set.Add(peer1)
@@ -45,7 +46,7 @@ func Test_PeerSet_Add(t *testing.T) {
 }
 
 func Test_PeerSet_Find(t *testing.T) {
-	set := newPeerSet()
+	set := newPeerSet(common.DefaultNumOfChains)
 	peer1 := getTestPeer(0)
 	set.Add(peer1)
 	peer2 := getTestPeer(0)
@@ -56,7 +57,7 @@ func Test_PeerSet_Find(t *testing.T) {
 }
 
 func TestPeerSet_ForEach(t *testing.T) {
-	set := newPeerSet()
+	set := newPeerSet(common.DefaultNumOfChains)
 	peer1 := getTestPeer(0)
 	set.Add(peer1)
 	peer2 := getTestPeer(0)
@@ -82,7 +83,7 @@ func TestPeerSet_ForEach(t *testing.T) {
 }
 
 func Test_PeerSet_Remove(t *testing.T) {
-	set := newPeerSet()
+	set := newPeerSet(common.DefaultNumOfChains)
 	peer1 := getTestPeer(0)
 	set.Add(peer1)
 	peer2 := getTestPeer(1)
@@ -6,7 +6,6 @@
 package seele
 
 import (
-	"encoding/json"
 	"math/big"
 	"testing"
 
@@ -28,18 +27,22 @@ func Test_peer_Info(t *testing.T) {
 	var myHash common.Hash
 	copy(myHash[0:20], myAddr[:])
 	bigInt := big.NewInt(100)
-	okStr := "{\"version\":1,\"difficulty\":100,\"head\":\"6b9fd39a9f1273c46fba8951b62de5b95cd3dd84000000000000000000000000\"}"
 
 	// Create peer for test
-	peer := newPeer(SeeleVersion, p2pPeer, nil, log)
-	peer.SetHead(myHash, bigInt)
+	peer := newPeer(SeeleVersion, p2pPeer, nil, common.DefaultNumOfChains, log)
+	peer.SetHead(myHash, bigInt, 0)
 
 	peerInfo := peer.Info()
-	data, _ := json.Marshal(peerInfo)
-	resultStr := string(data)
-	if okStr != resultStr {
-		t.Fail()
-	}
+	assert.Equal(t, peerInfo.Version, uint(SeeleVersion))
+
+	// head is updated by chain
+	hash, td := peer.HeadByChain(0)
+	assert.Equal(t, hash, myHash)
+	assert.Equal(t, td, bigInt)
+
+	hash, td = peer.HeadByChain(1)
+	assert.Equal(t, hash, common.EmptyHash)
+	assert.Equal(t, td, big.NewInt(0))
 }
 
 func Test_verifyGenesis(t *testing.T) {
@@ -47,22 +50,27 @@ func Test_verifyGenesis(t *testing.T) {
 	statusData := statusData{
 		ProtocolVersion: uint32(0),
 		NetworkID:       networkID,
-		TD:              big.NewInt(0),
-		CurrentBlock:    common.EmptyHash,
+		TD:              make([]*big.Int, common.DefaultNumOfChains),
+		CurrentBlock:    make([]common.Hash, common.DefaultNumOfChains),
 		GenesisBlock:    common.EmptyHash,
 		Shard:           1,
 		Difficult:       8000000,
+		NumOfChains:     common.DefaultNumOfChains,
 	}
-	err := verifyGenesisAndNetworkID(statusData, common.EmptyHash, networkID, 1, 8000000)
+	err := verifyGenesisAndNetworkID(statusData, common.EmptyHash, networkID, 1, 8000000, common.DefaultNumOfChains)
 	assert.Equal(t, err, nil)
 
-	err = verifyGenesisAndNetworkID(statusData, common.EmptyHash, networkID, 2, 8000000)
+	err = verifyGenesisAndNetworkID(statusData, common.EmptyHash, networkID, 2, 8000000, common.DefaultNumOfChains)
 	assert.Equal(t, err, nil)
 
-	err = verifyGenesisAndNetworkID(statusData, common.EmptyHash, networkID, 2, 9000000)
+	err = verifyGenesisAndNetworkID(statusData, common.EmptyHash, networkID, 2, 9000000, common.DefaultNumOfChains)
 	assert.Equal(t, err == errGenesisDifficultNotMatch, true)
 
 	errorHash := common.StringToHash("error hash")
-	err = verifyGenesisAndNetworkID(statusData, errorHash, networkID, 1, 8000000)
+	err = verifyGenesisAndNetworkID(statusData, errorHash, networkID, 1, 8000000, common.DefaultNumOfChains)
 	assert.Equal(t, err != nil, true)
+
+	statusData.NumOfChains = common.DefaultNumOfChains + 1
+	err = verifyGenesisAndNetworkID(statusData, common.EmptyHash, networkID, 1, 8000000, common.DefaultNumOfChains)
+	assert.Equal(t, err, errNumOfChainsNotMatch)
 }
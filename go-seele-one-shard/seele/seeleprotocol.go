@@ -13,15 +13,22 @@ import (
 
 	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/core"
+	"github.com/seeleteam/go-seele/core/state"
 	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/database"
 	"github.com/seeleteam/go-seele/event"
 	"github.com/seeleteam/go-seele/log"
+	"github.com/seeleteam/go-seele/miner/pow"
 	"github.com/seeleteam/go-seele/p2p"
 	"github.com/seeleteam/go-seele/seele/download"
 )
 
 var (
 	errSyncFinished = errors.New("Sync Finished!")
+
+	// seeleVersions are the supported versions of Seele protocol, which run side by side
+	// and the highest common version is negotiated with each peer.
+	seeleVersions = []uint{SeeleVersion2, SeeleVersion1}
 )
 
 var (
@@ -37,7 +44,7 @@ var (
 
 	debtMsgCode uint16 = 13
 
-	protocolMsgCodeLength uint16 = 14
+	protocolMsgCodeLength uint16 = 16
 )
 
 func codeToStr(code uint16) string {
@@ -72,9 +79,13 @@ type SeeleProtocol struct {
 
 	networkID  uint64
 	downloader *downloader.Downloader
-	txPool     [NumOfChains]*core.TransactionPool
-	debtPool   [NumOfChains]*core.DebtPool
-	chain      [NumOfChains]*core.Blockchain
+	txPool     []*core.TransactionPool
+	debtPool   []*core.DebtPool
+	chain      []*core.Blockchain
+
+	accountStateDB database.Database // used to serve the trie nodes of account state
+	snapshotSync   bool              // retrieve the state of trusted checkpoint first if local chain is empty
+	p2pServer      *p2p.Server       // scores and bans the peers, set when service started
 
 	wg     sync.WaitGroup
 	quitCh chan struct{}
@@ -102,18 +113,34 @@ func NewSeeleProtocol(seele *SeeleService, log *log.SeeleLog) (s *SeeleProtocol,
 		quitCh:     make(chan struct{}),
 		syncCh:     make(chan struct{}),
 
-		peerSet: newPeerSet(),
+		peerSet:        newPeerSet(seele.numOfChains),
+		accountStateDB: seele.accountStateDB,
+		snapshotSync:   seele.snapshotSync,
 	}
 
-	s.Protocol.AddPeer = s.handleAddPeer
+	s.Protocol.AddPeer = s.addPeerHandler(SeeleVersion)
 	s.Protocol.DeletePeer = s.handleDelPeer
 	s.Protocol.GetPeer = s.handleGetPeer
+	s.downloader.SetHookForPeerEvent(s.handlePeerEvent)
 
 	event.TransactionInsertedEventManager.AddAsyncListener(s.handleNewTx)
 	event.BlockMinedEventManager.AddAsyncListener(s.handleNewMinedBlock)
 	return s, nil
 }
 
+// Protocols returns the p2p protocols of all supported versions of Seele protocol.
+func (sp *SeeleProtocol) Protocols() []p2p.Protocol {
+	protocols := make([]p2p.Protocol, 0, len(seeleVersions))
+	for _, version := range seeleVersions {
+		proto := sp.Protocol
+		proto.Version = version
+		proto.AddPeer = sp.addPeerHandler(version)
+		protocols = append(protocols, proto)
+	}
+
+	return protocols
+}
+
 func (sp *SeeleProtocol) Start() {
 	sp.log.Debug("SeeleProtocol.Start called!")
 	go sp.syncer()
@@ -174,6 +201,18 @@ func (sp *SeeleProtocol) synchronise(bestPeers []*bestPeerForEachChain) {
 
 		// miner stops only when the miner and the new received block are on the same chain 
 		event.BlockDownloaderEventManager.Fire(event.DownloaderStartEvent)
+
+		// retrieve the state of the trusted checkpoint instead of replaying all blocks if local chain is empty.
+		if sp.snapshotSync && block.Header.Height == 0 && bp.bestPeer.version >= SeeleVersion2 && sp.chain[i].GetCheckpoint() != nil {
+			if err = sp.downloader.SynchroniseState(bp.bestPeer.peerStrID, bp.chainNum); err != nil {
+				sp.log.Info("state synchronise end with failed, err %s, chainNum: %d", err, bp.chainNum)
+				event.BlockDownloaderEventManager.Fire(event.DownloaderFailedEvent)
+				return
+			}
+
+			sp.broadcastChainHead(bp.chainNum)
+			continue
+		}
 		// defer func() {
 		//	if err != nil {
 		//		sp.log.Info("download end with failed, err %s, chainNum: %d", err, bp.chainNum)
@@ -239,7 +278,7 @@ func (sp *SeeleProtocol) syncTransactions(p *peer) {
 
 	var pending []*transactionMsg
 	var txMsg 	transactionMsg
- 	for i := 0; i < NumOfChains; i++ {
+ 	for i := range sp.txPool {
 		pendingInOnePool := sp.txPool[i].GetTransactions(false, true)
 		for _, tx := range pendingInOnePool {
 			txMsg.Tx = tx
@@ -354,15 +393,19 @@ func (p *SeeleProtocol) handleNewMinedBlock(e event.Event) {
 		confirmedBlock, err := p.chain[chainNum].GetStore().GetBlockByHeight(confirmedHeight)
 		if err != nil {
 			p.log.Warn("failed to load confirmed block height %d, err %s", confirmedHeight, err)
+		} else {
+			debts, err := types.NewDebtMap(confirmedBlock, uint64(len(p.chain)))
+			if err != nil {
+				p.log.Warn("failed to get debts of confirmed block height %d, err %s", confirmedHeight, err)
+			} else {
+				for _, d := range debts[common.LocalShardNumber] {
+					debtChainNum := d.Data.ChainNum
+					p.log.Debug("Debts from confirmed block, add to debtPool: %d", debtChainNum)
+					p.debtPool[debtChainNum].Add(d)
+				}
+				p.propagateDebtMap(debts)
+			}
 		}
-
-		debts := types.NewDebtMap(confirmedBlock.Transactions)
-		for _, d := range debts[common.LocalShardNumber] {
-			debtChainNum := d.Data.ChainNum
-			p.log.Debug("Debts from confirmed block, add to debtPool: %d", debtChainNum)
-			p.debtPool[debtChainNum].Add(d)
-		}
-		p.propagateDebtMap(debts)
 	}
 
 	p.log.Info("handleNewMinedBlock broadcast chainhead changed. chainNum: %d, new block: %d %s <- %s ",
@@ -371,19 +414,26 @@ func (p *SeeleProtocol) handleNewMinedBlock(e event.Event) {
 	p.broadcastChainHead(chainNum)
 }
 
-func (p *SeeleProtocol) handleAddPeer(p2pPeer *p2p.Peer, rw p2p.MsgReadWriter) {
+// addPeerHandler returns the handler of new peer with the negotiated version.
+func (p *SeeleProtocol) addPeerHandler(version uint) func(p2pPeer *p2p.Peer, rw p2p.MsgReadWriter) {
+	return func(p2pPeer *p2p.Peer, rw p2p.MsgReadWriter) {
+		p.handleAddPeer(p2pPeer, rw, version)
+	}
+}
+
+func (p *SeeleProtocol) handleAddPeer(p2pPeer *p2p.Peer, rw p2p.MsgReadWriter, version uint) {
 	if p.peerSet.Find(p2pPeer.Node.ID) != nil {
 		p.log.Error("handleAddPeer called, but peer of this public-key has already existed, so need quit!")
 		return
 	}
 
-	newPeer := newPeer(SeeleVersion, p2pPeer, rw, p.log)
+	newPeer := newPeer(version, p2pPeer, rw, uint64(len(p.chain)), p.log)
 
-	block := make([]*types.Block,NumOfChains)
- 	head := make([]common.Hash,NumOfChains)
-	localTD := make([]*big.Int,NumOfChains)
+	block := make([]*types.Block, len(p.chain))
+ 	head := make([]common.Hash, len(p.chain))
+	localTD := make([]*big.Int, len(p.chain))
 	var err error 
- 	for i := 0; i < NumOfChains; i++ {
+ 	for i := range p.chain {
  		block[i] = p.chain[i].CurrentBlock()
  		head[i] = block[i].HeaderHash
  		localTD[i], err = p.chain[i].GetStore().GetBlockTotalDifficulty(head[i])
@@ -447,6 +497,68 @@ func (p *SeeleProtocol) SendDifferentShardTx(txMsg *transactionMsg, shard uint)
 	}
 }
 
+// adjustPeerScore updates the score of peer by its behaviour, and the peer is banned if the score is too low.
+func (p *SeeleProtocol) adjustPeerScore(peer *peer, delta int, reason string) {
+	if p.p2pServer != nil {
+		p.p2pServer.AdjustPeerScore(peer.Node.ID, delta, reason)
+	}
+}
+
+// handlePeerEvent scores the peer by the outcome of the requests of downloader.
+func (p *SeeleProtocol) handlePeerEvent(peerID string, e downloader.PeerEvent) {
+	var target *peer
+	p.peerSet.ForEachAll(func(peer *peer) bool {
+		if peer.peerStrID == peerID {
+			target = peer
+			return false
+		}
+
+		return true
+	})
+
+	if target == nil {
+		return
+	}
+
+	switch e {
+	case downloader.PeerEventUsefulData:
+		p.adjustPeerScore(target, scoreUsefulData, "useful data")
+	case downloader.PeerEventTimeout:
+		p.adjustPeerScore(target, scoreTimeout, "request timeout")
+	case downloader.PeerEventInvalidData:
+		p.adjustPeerScore(target, scoreInvalidData, "invalid data")
+	}
+}
+
+// isInvalidTx returns true if the tx is malformed or not signed properly, which is never sent by an honest peer.
+func isInvalidTx(err error) bool {
+	switch err {
+	case types.ErrHashMismatch, types.ErrSigInvalid, types.ErrSigMissing, types.ErrAmountNil, types.ErrAmountNegative,
+		types.ErrFeeNil, types.ErrFeeNegative, types.ErrPayloadOversized, types.ErrNetworkMismatch:
+		return true
+	}
+
+	return false
+}
+
+// isInvalidBlock returns true if the block fails the explicit validation, which is never sent by an
+// honest peer. Other errors, e.g. the block already known, received out of order, ahead of local
+// clock or failed to write into local store, are not the fault of peer.
+func isInvalidBlock(err error) bool {
+	switch err {
+	case core.ErrBlockHeaderNil, core.ErrBlockHashMismatch, core.ErrBlockTxsHashMismatch, core.ErrBlockInvalidHeight,
+		core.ErrBlockStateHashMismatch, core.ErrBlockReceiptHashMismatch, core.ErrBlockLogBloomMismatch,
+		core.ErrBlockDebtHashMismatch, core.ErrBlockTxDebtHashMismatch, core.ErrBlockDebtChainNumMismatch,
+		core.ErrBlockEmptyTxs, core.ErrBlockInvalidToAddress, core.ErrBlockCoinbaseMismatch, core.ErrBlockCreateTimeNull,
+		core.ErrBlockCreateTimeOld, core.ErrBlockDifficultInvalid, core.ErrBlockTooManyTxs, core.ErrBlockExtraDataNotEmpty,
+		core.ErrBlockTxChainNumMismatch, types.ErrDebtSourceInvalid, types.ErrDebtProofMismatch, types.ErrTimestampMismatch,
+		pow.ErrBlockNonceInvalid:
+		return true
+	}
+
+	return isInvalidTx(err)
+}
+
 func (p *SeeleProtocol) handleMsg(peer *peer) {
 handler:
 	for {
@@ -473,6 +585,7 @@ handler:
 			err := common.Deserialize(msg.Payload, &txHashMsg)
 			if err != nil {
 				p.log.Warn("failed to deserialize transaction hash msg, %s", err.Error())
+				p.adjustPeerScore(peer, scoreMalformedMsg, "malformed message")
 				continue
 			}
 
@@ -499,6 +612,7 @@ handler:
 			err := common.Deserialize(msg.Payload, &txHashMsg)
 			if err != nil {
 				p.log.Warn("failed to deserialize transaction request msg %s", err.Error())
+				p.adjustPeerScore(peer, scoreMalformedMsg, "malformed message")
 				continue
 			}
 
@@ -526,6 +640,7 @@ handler:
 			err := common.Deserialize(msg.Payload, &txMsgs)
 			if err != nil {
 				p.log.Warn("failed to deserialize transaction msg %s", err.Error())
+				p.adjustPeerScore(peer, scoreMalformedMsg, "malformed message")
 				break
 			}
 
@@ -541,8 +656,9 @@ handler:
 				if shard != common.LocalShardNumber {
 					go p.SendDifferentShardTx(txMsg, shard)
 					continue
-				} else {
-					p.txPool[chainNum].AddTransaction(tx)
+				} else if err := p.txPool[chainNum].AddTransaction(tx); isInvalidTx(err) {
+					p.log.Debug("received invalid transaction %s, %s", tx.Hash.ToHex(), err)
+					p.adjustPeerScore(peer, scoreInvalidTx, "invalid transaction")
 				}
 			}
 
@@ -551,6 +667,7 @@ handler:
 			err := common.Deserialize(msg.Payload, &blkHashMsg)
 			if err != nil {
 				p.log.Warn("failed to deserialize block hash msg %s", err.Error())
+				p.adjustPeerScore(peer, scoreMalformedMsg, "malformed message")
 				continue
 			}
 
@@ -572,6 +689,7 @@ handler:
 			err := common.Deserialize(msg.Payload, &blkHashMsg)
 			if err != nil {
 				p.log.Warn("failed to deserialize block request msg %s", err.Error())
+				p.adjustPeerScore(peer, scoreMalformedMsg, "malformed message")
 				continue
 			}
 
@@ -599,6 +717,7 @@ handler:
 			err := common.Deserialize(msg.Payload, &blkMsg)
 			if err != nil {
 				p.log.Warn("failed to deserialize block msg %s", err.Error())
+				p.adjustPeerScore(peer, scoreMalformedMsg, "malformed message")
 				continue
 			}
 
@@ -609,7 +728,12 @@ handler:
 			peer.knownBlocks.Add(block.HeaderHash, nil)
 			if block.GetShardNumber() == common.LocalShardNumber {
 				// @todo need to make sure WriteBlock handle block fork
-				p.chain[chainNum].WriteBlock(block)
+				if err := p.chain[chainNum].WriteBlock(block); err == nil {
+					p.adjustPeerScore(peer, scoreUsefulData, "new block")
+				} else if isInvalidBlock(err) {
+					p.log.Warn("received invalid block %s, %s", block.HeaderHash.ToHex(), err)
+					p.adjustPeerScore(peer, scoreInvalidData, "invalid block")
+				}
 			}
 
 		case debtMsgCode:
@@ -617,6 +741,7 @@ handler:
 			err := common.Deserialize(msg.Payload, &debts)
 			if err != nil {
 				p.log.Warn("failed to deserialize debts msg %s", err)
+				p.adjustPeerScore(peer, scoreMalformedMsg, "malformed message")
 				continue
 			}
 
@@ -624,6 +749,11 @@ handler:
 			for _, d := range debts {
 				peer.knownDebts.Add(d.Hash, nil)
 				chainNum := d.Data.ChainNum
+				if chainNum >= uint64(len(p.debtPool)) {
+					p.log.Warn("received debt with invalid chain number %d", chainNum)
+					continue
+				}
+
 				p.log.Debug("received debts message, add to debtPool: %d", chainNum)
 				p.debtPool[chainNum].Add(d)
 			}
@@ -635,6 +765,7 @@ handler:
 			err := common.Deserialize(msg.Payload, &query)
 			if err != nil {
 				p.log.Error("failed to deserialize downloader.GetBlockHeadersMsg, quit! %s", err.Error())
+				p.adjustPeerScore(peer, scoreMalformedMsg, "malformed message")
 				break
 			}
 			var headList []*types.BlockHeader
@@ -689,6 +820,7 @@ handler:
 			err := common.Deserialize(msg.Payload, &query)
 			if err != nil {
 				p.log.Error("failed to deserialize downloader.GetBlocksMsg, quit! %s", err.Error())
+				p.adjustPeerScore(peer, scoreMalformedMsg, "malformed message")
 				break
 			}
 
@@ -744,7 +876,42 @@ handler:
 
 			p.log.Debug("send downloader.sendBlocks")
 
-		case downloader.BlockHeadersMsg, downloader.BlocksPreMsg, downloader.BlocksMsg:
+		case downloader.GetNodeDataMsg:
+			var query nodeDataQuery
+			err := common.Deserialize(msg.Payload, &query)
+			if err != nil {
+				p.log.Error("failed to deserialize downloader.GetNodeDataMsg, quit! %s", err.Error())
+				p.adjustPeerScore(peer, scoreMalformedMsg, "malformed message")
+				break
+			}
+
+			p.log.Debug("Received downloader.GetNodeDataMsg length %d", len(query.Hashes))
+
+			var nodes [][]byte
+			totalLen := 0
+			for _, hash := range query.Hashes {
+				if len(nodes) >= downloader.MaxNodeDataFetch {
+					break
+				}
+
+				value, err := state.GetTrieNode(p.accountStateDB, hash)
+				if err != nil {
+					continue
+				}
+
+				if totalLen > 0 && (totalLen+len(value)) > downloader.MaxMessageLength {
+					break
+				}
+				totalLen += len(value)
+				nodes = append(nodes, value)
+			}
+
+			if err = peer.sendNodeData(query.Magic, nodes); err != nil {
+				p.log.Error("HandleMsg GetNodeDataMsg sendNodeData err. %s", err)
+				break handler
+			}
+
+		case downloader.BlockHeadersMsg, downloader.BlocksPreMsg, downloader.BlocksMsg, downloader.NodeDataMsg:
 			p.log.Debug("Received downloader Msg. %s peerid:%s", codeToStr(msg.Code), peer.peerStrID)
 			p.downloader.DeliverMsg(peer.peerStrID, msg)
 
@@ -753,6 +920,7 @@ handler:
 			err := common.Deserialize(msg.Payload, &status)
 			if err != nil {
 				p.log.Error("failed to deserialize statusChainHeadMsgCode, quit! %s", err.Error())
+				p.adjustPeerScore(peer, scoreMalformedMsg, "malformed message")
 				break
 			}
 
@@ -762,7 +930,8 @@ handler:
 			p.syncCh <- struct{}{}
 
 		default:
-			p.log.Warn("unknown code %s", msg.Code)
+			p.log.Warn("unknown code %d", msg.Code)
+			p.adjustPeerScore(peer, scoreMalformedMsg, "unknown message")
 		}
 	}
 
@@ -0,0 +1,44 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package seele
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/seeleteam/go-seele/core"
+	"github.com/seeleteam/go-seele/core/types"
+	"github.com/seeleteam/go-seele/miner/pow"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isInvalidTx(t *testing.T) {
+	assert.Equal(t, isInvalidTx(nil), false)
+	assert.Equal(t, isInvalidTx(types.ErrSigInvalid), true)
+	assert.Equal(t, isInvalidTx(types.ErrHashMismatch), true)
+
+	// the tx pool errors depend on the local state
+	assert.Equal(t, isInvalidTx(errors.New("tx already exists")), false)
+}
+
+func Test_isInvalidBlock(t *testing.T) {
+	// explicit validation errors
+	assert.Equal(t, isInvalidBlock(core.ErrBlockHashMismatch), true)
+	assert.Equal(t, isInvalidBlock(core.ErrBlockStateHashMismatch), true)
+	assert.Equal(t, isInvalidBlock(types.ErrDebtProofMismatch), true)
+	assert.Equal(t, isInvalidBlock(pow.ErrBlockNonceInvalid), true)
+	assert.Equal(t, isInvalidBlock(types.ErrSigInvalid), true)
+
+	// known, out of order or future blocks
+	assert.Equal(t, isInvalidBlock(nil), false)
+	assert.Equal(t, isInvalidBlock(core.ErrBlockAlreadyExists), false)
+	assert.Equal(t, isInvalidBlock(core.ErrBlockInvalidParentHash), false)
+	assert.Equal(t, isInvalidBlock(core.ErrBlockCreateTimeInFuture), false)
+	assert.Equal(t, isInvalidBlock(core.ErrDebtSourceNotFound), false)
+
+	// local errors, e.g. failed to write into store
+	assert.Equal(t, isInvalidBlock(errors.New("leveldb: closed")), false)
+}
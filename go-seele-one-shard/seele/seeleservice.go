@@ -10,12 +10,11 @@ import (
 	"fmt"
 	"path/filepath"
 	"strconv"
-	"sync"
 
 	"github.com/seeleteam/go-seele/common"
 	"github.com/seeleteam/go-seele/core"
-	"github.com/seeleteam/go-seele/core/store"
 	"github.com/seeleteam/go-seele/core/state"
+	"github.com/seeleteam/go-seele/core/store"
 	"github.com/seeleteam/go-seele/database"
 	"github.com/seeleteam/go-seele/database/leveldb"
 	"github.com/seeleteam/go-seele/event"
@@ -32,22 +31,23 @@ const chainHeaderChangeBuffSize = 100
 // SeeleService implements full node service.
 type SeeleService struct {
 	networkID     uint64
+	numOfChains   uint64
 	p2pServer     *p2p.Server
 	seeleProtocol *SeeleProtocol
 	log           *log.SeeleLog
 
-	txPools         [NumOfChains]*core.TransactionPool
-	debtPools       [NumOfChains]*core.DebtPool
-	chains          [NumOfChains]*core.Blockchain
-	chainDBs        [NumOfChains]database.Database // database used to store blocks.
-	accountStateDB database.Database // database used to store account state info.
-	accountStateDBRootHash common.Hash
-	miner          *miner.Miner
-
-	lastHeaders              [NumOfChains]common.Hash
-	chainHeaderChangeChannels [NumOfChains]chan common.Hash
-
-	lock           sync.RWMutex // lock for update accountstateDB
+	txPools         []*core.TransactionPool
+	debtPools       []*core.DebtPool
+	chains          []*core.Blockchain
+	chainDBs        []database.Database // database used to store blocks.
+	accountStateDB  database.Database   // database used to store account state info of all chains.
+	miner           *miner.Miner
+	pruner          *core.StatePruner // nil if state pruning is disabled
+	snapshotSync    bool              // retrieve the state of trusted checkpoint from peers for empty chain
+	events          *eventSystem // dispatches chain events to rpc subscribers
+
+	lastHeaders               []common.Hash
+	chainHeaderChangeChannels []chan common.Hash
 }
 
 // ServiceContext is a collection of service configuration inherited from node
@@ -55,155 +55,153 @@ type ServiceContext struct {
 	DataDir string
 }
 
-func (s *SeeleService) TxPool() [NumOfChains]*core.TransactionPool { return s.txPools }
-func (s *SeeleService) DebtPool() [NumOfChains]*core.DebtPool      { return s.debtPools }
-func (s *SeeleService) BlockChain() [NumOfChains]*core.Blockchain  { return s.chains }
+func (s *SeeleService) TxPool() []*core.TransactionPool { return s.txPools }
+func (s *SeeleService) DebtPool() []*core.DebtPool      { return s.debtPools }
+func (s *SeeleService) BlockChain() []*core.Blockchain  { return s.chains }
 func (s *SeeleService) NetVersion() uint64            { return s.networkID }
+func (s *SeeleService) NumOfChains() uint64           { return s.numOfChains }
 func (s *SeeleService) Miner() *miner.Miner           { return s.miner }
 func (s *SeeleService) Downloader() *downloader.Downloader {
 	return s.seeleProtocol.Downloader()
 }
-func (s *SeeleService) AccountStateDB() database.Database { return s.accountStateDB }
-// GetCurrentState returns the current state of the accounts
-func (s *SeeleService) GetCurrentState() (*state.Statedb, error) {
-	return state.NewStatedb(s.accountStateDBRootHash, s.accountStateDB)
-}
 
-func (s *SeeleService) UpdateDB(db database.Database) error {
-	s.accountStateDB = db
-	return nil
-}
-
-func (s *SeeleService) UpdateDBRootHash(dbRootHash common.Hash) error {
-	s.accountStateDBRootHash = dbRootHash
-	return nil
-}
-
-func (s *SeeleService) Lock() error {
-	s.lock.Lock()
-	return nil
-}
-
-func (s *SeeleService) Unlock() error {
-	s.lock.Unlock()
-	return nil
+// GetCurrentState returns the current state of the chain that the specified account belongs to.
+func (s *SeeleService) GetCurrentState(account common.Address) (*state.Statedb, error) {
+	return s.chains[account.GetChainNum(s.numOfChains)].GetCurrentState()
 }
 
 // NewSeeleService create SeeleService
 func NewSeeleService(ctx context.Context, conf *node.Config, log *log.SeeleLog) (s *SeeleService, err error) {
 	s = &SeeleService{
-		log:       log,
-		networkID: conf.P2PConfig.NetworkID,
+		log:          log,
+		networkID:    conf.P2PConfig.NetworkID,
+		snapshotSync: conf.BasicConfig.SnapshotSync,
 	}
 
 	serviceContext := ctx.Value("ServiceContext").(ServiceContext)
 
-	// Initialize blockchain DB.
-	for i := 0; i < NumOfChains; i++ {
-		chainNumString := strconv.Itoa(i)
-		chainDBPath := filepath.Join(serviceContext.DataDir, BlockChainDir, chainNumString)
-		log.Info("NewSeeleService BlockChain datadir is %s", chainDBPath)	
-		s.chainDBs[i],err = leveldb.NewLevelDB(chainDBPath)
-		if err != nil {
-			log.Error("NewSeeleService Create BlockChain err. %s", err)
-			return nil, err
-		}
-		leveldb.StartMetrics(s.chainDBs[i], "chaindb"+chainNumString, log)
-	}
-	
+	// The number of chains is defined in genesis, and must be initialized before any chain is created.
+	genesis := core.GetGenesis(conf.SeeleConfig.GenesisConfig)
+	s.numOfChains = genesis.GetNumOfChains()
+	log.Info("NewSeeleService number of chains is %d", s.numOfChains)
+
 	// Initialize account state info DB.
 	accountStateDBPath := filepath.Join(serviceContext.DataDir, AccountStateDir)
 	log.Info("NewSeeleService account state datadir is %s", accountStateDBPath)
 	s.accountStateDB, err = leveldb.NewLevelDB(accountStateDBPath)
 	if err != nil {
-		for i := 0; i < NumOfChains; i++ {
-			s.chainDBs[i].Close()
-		}
 		log.Error("NewSeeleService Create BlockChain err: failed to create account state DB, %s", err)
 		return nil, err
 	}
 
-	// initialize accountStateDB with genesis info
-	genesis := core.GetGenesis(conf.SeeleConfig.GenesisConfig)
-	statedb, err := core.GetStateDB(genesis.Info)
-	if err != nil {
-		return nil, err
-	}
-
-	s.accountStateDBRootHash, err = statedb.Hash()
-	if err != nil {
-		return nil, err
-	}
-
-	batch := s.accountStateDB.NewBatch()
-	statedb.Commit(batch)
-	if err = batch.Commit(); err != nil {
-		return nil, err
-	}
+	// Initialize blockchain DB, and validate genesis of each chain.
+	for i := 0; i < int(s.numOfChains); i++ {
+		chainNumString := strconv.Itoa(i)
+		chainDBPath := filepath.Join(serviceContext.DataDir, BlockChainDir, chainNumString)
+		log.Info("NewSeeleService BlockChain datadir is %s", chainDBPath)
+		chainDB, err := leveldb.NewLevelDB(chainDBPath)
+		if err != nil {
+			s.closeDBs()
+			log.Error("NewSeeleService Create BlockChain err. %s", err)
+			return nil, err
+		}
+		s.chainDBs = append(s.chainDBs, chainDB)
+		leveldb.StartMetrics(chainDB, "chaindb"+chainNumString, log)
 
-	// initialize and validate genesis
-	for i := 0; i < NumOfChains; i++ {
-		bcStore := store.NewCachedStore(store.NewBlockchainDatabase(s.chainDBs[i]))
-		err = genesis.InitializeAndValidate(bcStore)
+		bcStore := store.NewCachedStore(store.NewBlockchainDatabase(chainDB))
+		err = genesis.InitializeAndValidate(bcStore, s.accountStateDB)
 		if err != nil {
-			for i := 0; i < NumOfChains; i++ {
-				s.chainDBs[i].Close()
-			}
-			s.accountStateDB.Close()
+			s.closeDBs()
 			log.Error("NewSeeleService genesis.Initialize err. %s", err)
 			return nil, err
 		}
-	
-		chainNumString := strconv.Itoa(i)
+
 		recoveryPointFile := filepath.Join(serviceContext.DataDir, chainNumString, BlockChainRecoveryPointFile)
-		s.chains[i], err = core.NewBlockchain(bcStore, recoveryPointFile, uint64(i), s)
+		chain, err := core.NewBlockchain(bcStore, s.accountStateDB, recoveryPointFile, uint64(i), s.numOfChains, s.networkID)
 		if err != nil {
-			for i := 0; i < NumOfChains; i++ {
-				s.chainDBs[i].Close()
-			}
-			s.accountStateDB.Close()
+			s.closeDBs()
 			log.Error("failed to init chain in NewSeeleService. %s", err)
 			return nil, err
 		}
+		chain.SetParallelExecution(conf.BasicConfig.ParallelTxWorkers)
+		s.chains = append(s.chains, chain)
 	}
 
-	err = s.initPool(conf)
-	if err != nil {
-		for i := 0; i < NumOfChains; i++ {
-			s.chainDBs[i].Close()
+	for _, chain := range s.chains {
+		chain.SetChains(s.chains)
+	}
+
+	if err = core.SetCheckpoints(s.chains, conf.BasicConfig.SnapshotCheckpoints); err != nil {
+		s.closeDBs()
+		log.Error("failed to set checkpoints in NewSeeleService. %s", err)
+		return nil, err
+	}
+
+	if conf.BasicConfig.StatePruning {
+		retention := conf.BasicConfig.StateRetention
+		if retention == 0 {
+			retention = core.DefaultStateRetention
 		}
-		s.accountStateDB.Close()
+
+		log.Warn("state pruning is enabled, the state of blocks older than %d blocks behind the HEAD block "+
+			"of each chain will be removed and can no longer be queried", retention)
+		s.pruner = core.NewStatePruner(s.accountStateDB, s.chains, retention)
+	}
+
+	err = s.initPool(conf, serviceContext.DataDir)
+	if err != nil {
+		s.closeDBs()
 		log.Error("failed to create transaction pool in NewSeeleService, %s", err)
 		return nil, err
 	}
-	
 
 	s.seeleProtocol, err = NewSeeleProtocol(s, log)
 	if err != nil {
-		for i := 0; i < NumOfChains; i++ {
-			s.chainDBs[i].Close()
-		}
-		s.accountStateDB.Close()
+		s.closeDBs()
 		log.Error("failed to create seeleProtocol in NewSeeleService, %s", err)
 		return nil, err
 	}
 
 	s.miner = miner.NewMiner(conf.SeeleConfig.Coinbase, s)
+	s.events = newEventSystem(s.chains, log)
 
 	return s, nil
 }
 
-func (s *SeeleService) initPool(conf *node.Config) error {
+// closeDBs closes the account state DB and all opened blockchain DBs.
+func (s *SeeleService) closeDBs() {
+	for _, db := range s.chainDBs {
+		db.Close()
+	}
+
+	s.accountStateDB.Close()
+}
+
+func (s *SeeleService) initPool(conf *node.Config, dataDir string) error {
+	numOfChains := len(s.chains)
+	s.lastHeaders = make([]common.Hash, numOfChains)
+	s.chainHeaderChangeChannels = make([]chan common.Hash, numOfChains)
+	s.debtPools = make([]*core.DebtPool, numOfChains)
+	s.txPools = make([]*core.TransactionPool, numOfChains)
+
+	debtVerifier := core.NewDebtVerifier(s.chains)
+
 	var err error
-	for i := 0; i < NumOfChains; i++ {
+	for i := 0; i < numOfChains; i++ {
 		s.lastHeaders[i], err = s.chains[i].GetStore().GetHeadBlockHash()
 		if err != nil {
 			return fmt.Errorf("failed to get chain header, %s", err)
 		}
 
 		s.chainHeaderChangeChannels[i] = make(chan common.Hash, chainHeaderChangeBuffSize)
-		s.debtPools[i] = core.NewDebtPool(s.chains[i], s)
-		s.txPools[i] = core.NewTransactionPool(conf.SeeleConfig.TxConf, s.chains[i], uint64(i), s)
+		s.debtPools[i] = core.NewDebtPool(s.chains[i], debtVerifier)
+
+		// The transaction journal is separated for each chain.
+		txConf := conf.SeeleConfig.TxConf
+		if len(txConf.JournalFile) > 0 {
+			txConf.JournalFile = filepath.Join(dataDir, strconv.Itoa(i), txConf.JournalFile)
+		}
+		s.txPools[i] = core.NewTransactionPool(txConf, s.chains[i], uint64(i), s.numOfChains)
 
 		event.ChainHeaderChangedEventMananger.AddAsyncListener(s.chainHeaderChanged)
 		go s.MonitorChainHeaderChange(uint64(i))
@@ -234,8 +232,15 @@ func (s *SeeleService) MonitorChainHeaderChange(chainNum uint64) {
 				return
 			}
 
-			s.txPools[chainNum].HandleChainHeaderChanged(newHeader, s.lastHeaders[chainNum])
-			s.debtPools[chainNum].HandleChainHeaderChanged(newHeader, s.lastHeaders[chainNum])
+			// the last HEAD block is deleted when the chain rewound, whose txs and debts
+			// are already reinjected into pools along with rewinding.
+			lastHeader := s.lastHeaders[chainNum]
+			if exist, err := s.chains[chainNum].GetStore().HasBlock(lastHeader); err == nil && !exist {
+				lastHeader = common.EmptyHash
+			}
+
+			s.txPools[chainNum].HandleChainHeaderChanged(newHeader, lastHeader)
+			s.debtPools[chainNum].HandleChainHeaderChanged(newHeader, lastHeader)
 
 			s.lastHeaders[chainNum] = newHeader
 		}
@@ -245,7 +250,7 @@ func (s *SeeleService) MonitorChainHeaderChange(chainNum uint64) {
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *SeeleService) Protocols() (protos []p2p.Protocol) {
-	protos = append(protos, s.seeleProtocol.Protocol)
+	protos = append(protos, s.seeleProtocol.Protocols()...)
 	return
 }
 
@@ -253,21 +258,31 @@ func (s *SeeleService) Protocols() (protos []p2p.Protocol) {
 func (s *SeeleService) Start(srvr *p2p.Server) error {
 	s.p2pServer = srvr
 
+	s.seeleProtocol.p2pServer = srvr
 	s.seeleProtocol.Start()
+	s.events.start()
+
+	if s.pruner != nil {
+		s.pruner.Start()
+	}
+
 	return nil
 }
 
 // Stop implements node.Service, terminating all internal goroutines.
 func (s *SeeleService) Stop() error {
 	s.seeleProtocol.Stop()
+	s.events.stop()
 
-	//TODO
-	// s.txPool.Stop() s.chain.Stop()
-	// retries? leave it to future
-	for i := 0; i < NumOfChains; i++ {
-		s.chainDBs[i].Close()
+	for _, pool := range s.txPools {
+		pool.Stop()
 	}
-	s.accountStateDB.Close()
+
+	if s.pruner != nil {
+		s.pruner.Stop()
+	}
+
+	s.closeDBs()
 	return nil
 }
 
@@ -280,6 +295,12 @@ func (s *SeeleService) Stop() error {
  			Service:   NewPublicSeeleAPI(s),
  			Public:    true,
  		},
+		{
+			Namespace: "seele",
+			Version:   "1.0",
+			Service:   NewPublicSubscriptionAPI(s),
+			Public:    true,
+		},
  		{
  			Namespace: "txpool",
  			Version:   "1.0",
@@ -310,5 +331,11 @@ func (s *SeeleService) Stop() error {
  			Service:   NewPrivateMinerAPI(s),
  			Public:    false,
  		},
+		{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   NewPrivateAdminAPI(s),
+			Public:    false,
+		},
  	}...)
  }
@@ -53,10 +53,9 @@ func (t *Trie) GetProof(key []byte) (map[string][]byte, error) {
 				return proof, fmt.Errorf("unhandled trie error: %s", err)
 			}
 		case *LeafNode:
+			// a mismatched leaf proves the absence of the key, so include it anyway.
 			tn = nil
-			if len(key) >= len(n.Key) && bytes.Equal(n.Key, key[:len(n.Key)]) {
-				nodes = append(nodes, n)
-			}
+			nodes = append(nodes, n)
 		default:
 			panic(fmt.Sprintf("%T: invalid node: %v", tn, tn))
 		}
@@ -65,6 +65,31 @@ func TestOneElementProof(t *testing.T) {
 	}
 }
 
+func TestMissingKeyProof(t *testing.T) {
+	_, trie, dispose := newTestTrie()
+	defer dispose()
+
+	trie.Put([]byte("k"), []byte("v"))
+	for _, key := range []string{"a", "j", "l", "z", "kk"} {
+		proofs, err := trie.GetProof([]byte(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(proofs) != 1 {
+			t.Errorf("proof for missing key %q should have one element", key)
+		}
+
+		val, err := VerifyProof(trie.Hash(), []byte(key), proofs)
+		if err != nil {
+			t.Fatalf("VerifyProof error for missing key %q: %v", key, err)
+		}
+		if val != nil {
+			t.Fatalf("VerifyProof returned value for missing key %q: %x", key, val)
+		}
+	}
+}
+
 func TestVerifyBadProof(t *testing.T) {
 	trie, vals, dispose := randomTrie(800)
 	defer dispose()
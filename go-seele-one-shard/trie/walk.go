@@ -0,0 +1,123 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package trie
+
+import (
+	"errors"
+
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/crypto/sha3"
+)
+
+var errNodeHashMismatch = errors.New("node hash mismatch")
+
+// MarkNodes adds the hashes of all nodes that reachable from the specified root into marked.
+// Since the nodes are addressed by content, the sub trie of a marked node is skipped, which
+// makes it cheap to mark the tries of successive blocks that share most of the nodes.
+func MarkNodes(root common.Hash, dbprefix []byte, db Database, marked map[common.Hash]struct{}) error {
+	return WalkNodes(root, dbprefix, db, marked, nil)
+}
+
+// MarkExistingNodes is the same as MarkNodes, except that the missing nodes are skipped,
+// which is used to mark the trie that is being synchronized.
+func MarkExistingNodes(root common.Hash, dbprefix []byte, db Database, marked map[common.Hash]struct{}) error {
+	w := &walker{db, dbprefix, marked, nil, true}
+	return w.walk(root)
+}
+
+// WalkNodes calls fn with the hash and encoded value of every node that reachable from the
+// specified root, and adds the node hash into visited. The sub trie of a visited node is skipped.
+func WalkNodes(root common.Hash, dbprefix []byte, db Database, visited map[common.Hash]struct{},
+	fn func(hash common.Hash, value []byte) error) error {
+	w := &walker{db, dbprefix, visited, fn, false}
+	return w.walk(root)
+}
+
+// DecodeNodeChildren verifies the encoded node against the specified hash, and returns the
+// hashes of its child nodes.
+func DecodeNodeChildren(hash common.Hash, value []byte) ([]common.Hash, error) {
+	sha := sha3.NewKeccak256()
+	sha.Write(value)
+	if !hash.Equal(common.BytesToHash(sha.Sum(nil))) {
+		return nil, errNodeHashMismatch
+	}
+
+	return decodeNodeChildren(hash, value)
+}
+
+func decodeNodeChildren(hash common.Hash, value []byte) ([]common.Hash, error) {
+	node, err := decodeNode(hash.Bytes(), value)
+	if err != nil {
+		return nil, err
+	}
+
+	var children []common.Hash
+	switch n := node.(type) {
+	case *ExtensionNode:
+		children = append(children, common.BytesToHash(n.NextNode.Hash()))
+	case *BranchNode:
+		for _, child := range n.Children {
+			if child != nil {
+				children = append(children, common.BytesToHash(child.Hash()))
+			}
+		}
+	case nil:
+		return nil, errNodeFormat
+	}
+
+	return children, nil
+}
+
+type walker struct {
+	db          Database
+	dbprefix    []byte
+	visited     map[common.Hash]struct{}
+	fn          func(hash common.Hash, value []byte) error
+	skipMissing bool
+}
+
+func (w *walker) walk(hash common.Hash) error {
+	if hash == common.EmptyHash {
+		return nil
+	}
+
+	if _, found := w.visited[hash]; found {
+		return nil
+	}
+
+	key := make([]byte, 0, len(w.dbprefix)+common.HashLength)
+	key = append(append(key, w.dbprefix...), hash.Bytes()...)
+
+	value, err := w.db.Get(key)
+	if err != nil || len(value) == 0 {
+		if w.skipMissing {
+			return nil
+		}
+
+		return errNodeNotExist
+	}
+
+	children, err := decodeNodeChildren(hash, value)
+	if err != nil {
+		return err
+	}
+
+	if w.fn != nil {
+		if err = w.fn(hash, value); err != nil {
+			return err
+		}
+	}
+
+	w.visited[hash] = struct{}{}
+
+	for _, child := range children {
+		if err = w.walk(child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/seeleteam/go-seele/common"
+	"github.com/seeleteam/go-seele/database"
+)
+
+func countTestTrieNodes(db database.Database, dbprefix []byte) int {
+	it := db.NewIterator(dbprefix)
+	defer it.Release()
+
+	count := 0
+	for it.Next() {
+		count++
+	}
+
+	return count
+}
+
+func Test_trie_MarkNodes(t *testing.T) {
+	db, trie, remove := newTestTrie()
+	defer remove()
+
+	prepareData(trie)
+	batch := db.NewBatch()
+	root1 := trie.Commit(batch)
+	batch.Commit()
+
+	trie.Put([]byte("12345678"), []byte("testnew"))
+	batch = db.NewBatch()
+	root2 := trie.Commit(batch)
+	batch.Commit()
+
+	total := countTestTrieNodes(db, trie.dbprefix)
+
+	// the nodes of root1 that not shared with root2 are not marked
+	marked := make(map[common.Hash]struct{})
+	assert.Equal(t, MarkNodes(root2, trie.dbprefix, db, marked), nil)
+	assert.Equal(t, len(marked) < total, true)
+
+	for hash := range marked {
+		found, err := db.Has(append(trie.dbprefix, hash.Bytes()...))
+		assert.Equal(t, err, nil)
+		assert.Equal(t, found, true)
+	}
+
+	assert.Equal(t, MarkNodes(root1, trie.dbprefix, db, marked), nil)
+	assert.Equal(t, len(marked), total)
+
+	// empty trie
+	assert.Equal(t, MarkNodes(common.EmptyHash, trie.dbprefix, db, marked), nil)
+
+	// missing node
+	err := MarkNodes(common.StringToHash("missing"), trie.dbprefix, db, make(map[common.Hash]struct{}))
+	assert.Equal(t, err, errNodeNotExist)
+}
+
+func Test_trie_WalkNodes(t *testing.T) {
+	db, trie, remove := newTestTrie()
+	defer remove()
+
+	prepareData(trie)
+	batch := db.NewBatch()
+	root := trie.Commit(batch)
+	batch.Commit()
+
+	nodes := make(map[common.Hash][]byte)
+	err := WalkNodes(root, trie.dbprefix, db, make(map[common.Hash]struct{}), func(hash common.Hash, value []byte) error {
+		nodes[hash] = value
+		return nil
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(nodes), countTestTrieNodes(db, trie.dbprefix))
+
+	// each node is verified against its hash
+	for hash, value := range nodes {
+		_, err = DecodeNodeChildren(hash, value)
+		assert.Equal(t, err, nil)
+	}
+
+	children, err := DecodeNodeChildren(root, nodes[root])
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(children) > 0, true)
+
+	_, err = DecodeNodeChildren(root, append(nodes[root], 0))
+	assert.Equal(t, err, errNodeHashMismatch)
+}
+
+func Test_trie_MarkExistingNodes(t *testing.T) {
+	db, trie, remove := newTestTrie()
+	defer remove()
+
+	prepareData(trie)
+	batch := db.NewBatch()
+	root := trie.Commit(batch)
+	batch.Commit()
+
+	total := countTestTrieNodes(db, trie.dbprefix)
+	children, err := DecodeNodeChildren(root, mustGetTestTrieNode(db, trie.dbprefix, root))
+	assert.Equal(t, err, nil)
+
+	// remove a child of root
+	assert.Equal(t, db.Delete(append(trie.dbprefix, children[0].Bytes()...)), nil)
+
+	err = MarkNodes(root, trie.dbprefix, db, make(map[common.Hash]struct{}))
+	assert.Equal(t, err, errNodeNotExist)
+
+	marked := make(map[common.Hash]struct{})
+	assert.Equal(t, MarkExistingNodes(root, trie.dbprefix, db, marked), nil)
+	assert.Equal(t, len(marked) > 0, true)
+	assert.Equal(t, len(marked) < total, true)
+}
+
+func mustGetTestTrieNode(db database.Database, dbprefix []byte, hash common.Hash) []byte {
+	value, err := db.Get(append(dbprefix, hash.Bytes()...))
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}